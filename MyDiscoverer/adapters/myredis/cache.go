@@ -2,7 +2,10 @@ package myredis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,16 +14,50 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+// reindexBatch is the COUNT hint of the SCAN used by Reindex and the number of keys read per pipeline.
+const reindexBatch = 500
+
+// pruneBatch is the maximum number of expired members a list removes from a set, so a read after a mass expiry stays
+// bounded; the rest go with the next reads, and their values (already gone) are skipped meanwhile.
+const pruneBatch = 500
+
+// writeRetries is the number of attempts of a write or delete whose key changed between reading the previous value and
+// committing; writeBackoff is the mean pause before the second attempt, doubled for each next one (jittered, so
+// competing writers do not retry in step).
+const (
+	writeRetries = 10
+	writeBackoff = time.Millisecond
+)
+
+// pruneScript removes up to ARGV[2] members of the expiry set KEYS[1] scored below ARGV[1] from it and from the key set
+// KEYS[2] in one step, so a key written meanwhile is never left in one set only. Sent with EVAL: EVALSHA cannot fall
+// back to the source inside a pipeline.
+const pruneScript = `
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', '(' .. ARGV[1], 'LIMIT', 0, ARGV[2])
+if #expired > 0 then
+	redis.call('ZREM', KEYS[1], unpack(expired))
+	redis.call('ZREM', KEYS[2], unpack(expired))
+end
+return #expired
+`
+
 type redisCache[T any] struct {
 	client    redis.UniversalClient
 	prefix    string
 	marshal   func(T) ([]byte, error)
 	unmarshal func([]byte) (T, error)
+	indexOf   func(T) string
+	now       func() time.Time
 	zero      T
 }
 
 // NewCache creates redis implementation of generic cache interface.
-func NewCache[T any](client redis.UniversalClient, prefix string, marshal func(T) ([]byte, error), unmarshal func([]byte) (T, error)) *redisCache[T] {
+// Every key is kept in two sorted sets: "<prefix>_expiry" scored by its expiry (unix ms) and "<prefix>_keys" with score
+// 0, ordered by key; when indexOf is set, "<prefix>_expiry:<value>" and "<prefix>_keys:<value>" hold the keys of one
+// index value (e.g. service type) used by ListValuesByIndex; nil disables the per-value sets. Lists read a range of the
+// key set (ZRANGEBYLEX, so a page costs its size, not the fleet size) and fetch the values in one pipeline; expired
+// members are pruned from both sets on read.
+func NewCache[T any](client redis.UniversalClient, prefix string, marshal func(T) ([]byte, error), unmarshal func([]byte) (T, error), indexOf func(T) string) *redisCache[T] {
 	var zero T
 	return &redisCache[T]{
 		client:    client,
@@ -28,6 +65,8 @@ func NewCache[T any](client redis.UniversalClient, prefix string, marshal func(T
 		zero:      zero,
 		marshal:   marshal,
 		unmarshal: unmarshal,
+		indexOf:   indexOf,
+		now:       time.Now,
 	}
 }
 
@@ -37,7 +76,27 @@ func (r *redisCache[T]) WriteValue(ctx context.Context, key string, item T, ttlM
 		return service.NewInternalServerError("Redis marshal item error", fmt.Errorf("can't marshal item of type %T, err: %w", item, err))
 	}
 
-	err = r.client.Set(ctx, r.generateKey(key), bytes, time.Duration(ttlMs)*time.Millisecond).Err()
+	ttl := time.Duration(ttlMs) * time.Millisecond
+	err = r.watch(ctx, key, func(tx *redis.Tx) error {
+		member := &redis.Z{Score: r.expiryScore(ttl), Member: key}
+		prev, hasPrev := r.readValue(ctx, tx, key)
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, r.generateKey(key), bytes, ttl)
+			pipe.ZAdd(ctx, r.generateAllIndexKey(), member)
+			pipe.ZAdd(ctx, r.generateAllKeysKey(), &redis.Z{Member: key})
+			if r.indexOf != nil {
+				index := r.indexOf(item)
+				if hasPrev && r.indexOf(prev) != index {
+					pipe.ZRem(ctx, r.generateIndexKey(r.indexOf(prev)), key)
+					pipe.ZRem(ctx, r.generateKeysKey(r.indexOf(prev)), key)
+				}
+				pipe.ZAdd(ctx, r.generateIndexKey(index), member)
+				pipe.ZAdd(ctx, r.generateKeysKey(index), &redis.Z{Member: key})
+			}
+			return nil
+		})
+		return err
+	})
 	if err != nil {
 		return service.NewInternalServerError("Redis write key error", fmt.Errorf("can't write item of type %T to redis (key='%s'), err: %w", item, key, err))
 	}
@@ -46,53 +105,266 @@ func (r *redisCache[T]) WriteValue(ctx context.Context, key string, item T, ttlM
 }
 
 func (r *redisCache[T]) DeleteValue(ctx context.Context, key string) error {
-	err := r.client.Del(ctx, r.generateKey(key)).Err()
+	err := r.watch(ctx, key, func(tx *redis.Tx) error {
+		prev, hasPrev := r.readValue(ctx, tx, key)
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, r.generateKey(key))
+			pipe.ZRem(ctx, r.generateAllIndexKey(), key)
+			pipe.ZRem(ctx, r.generateAllKeysKey(), key)
+			if r.indexOf != nil && hasPrev {
+				pipe.ZRem(ctx, r.generateIndexKey(r.indexOf(prev)), key)
+				pipe.ZRem(ctx, r.generateKeysKey(r.indexOf(prev)), key)
+			}
+			return nil
+		})
+		return err
+	})
 	if err != nil {
 		return service.NewInternalServerError("Redis delete key error", fmt.Errorf("can't delete item of type %T from redis (key='%s'), err: %w", r.zero, key, err))
 	}
 	return nil
 }
 
-// ListAllValues lists all keys under the cache prefix then fetches their values.
+// watch runs fn in a transaction watching the value of key, so the index sets of the previous value fn read are the
+// ones it updates; retried after a growing pause when the value changed before fn committed.
+func (r *redisCache[T]) watch(ctx context.Context, key string, fn func(tx *redis.Tx) error) error {
+	var err error
+	for i := 0; i < writeRetries; i++ {
+		if i > 0 {
+			pause := time.Duration(rand.Int64N(int64(writeBackoff<<i))) + 1
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(pause):
+			}
+		}
+		err = r.client.Watch(ctx, fn, r.generateKey(key))
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return err
+}
+
+// ListAllValues reads the keys of the key set then fetches their values, ordered by key.
 func (r *redisCache[T]) ListAllValues(ctx context.Context) ([]T, error) {
-	fullKeys, err := r.client.Keys(ctx, r.prefix+":*").Result()
-	if err != nil {
-		return nil, service.NewInternalServerError("Redis get keys error", fmt.Errorf("redis get keys error, err: %w", err))
+	items, _, err := r.list(ctx, "", "", 0)
+	return items, err
+}
+
+// ListValuesByIndex reads the keys of the key set of index then fetches their values, ordered by key; values that
+// moved to another index value are skipped.
+func (r *redisCache[T]) ListValuesByIndex(ctx context.Context, index string) ([]T, error) {
+	if r.indexOf == nil {
+		return nil, service.NewInternalServerError("Redis cache has no index", nil)
+	}
+	items, _, err := r.list(ctx, index, "", 0)
+	return items, err
+}
+
+// ListValuesPage returns up to limit values with keys after after, ordered by key; only those of index when it is not
+// empty. Keys are compared, not positions, so registrations and expiries between pages neither repeat nor skip the
+// instances that stay.
+func (r *redisCache[T]) ListValuesPage(ctx context.Context, index, after string, limit int) ([]T, string, error) {
+	if index != "" && r.indexOf == nil {
+		return nil, "", service.NewInternalServerError("Redis cache has no index", nil)
+	}
+	if limit < 1 {
+		return nil, "", service.NewInternalServerError("Redis page limit must be positive", nil)
+	}
+	return r.list(ctx, index, after, limit)
+}
+
+// Reindex adds the values written without the expiry and key sets (by versions that listed keys with KEYS or only kept
+// the expiry sets) to them, scored by their remaining TTL. It walks "<prefix>:*" with SCAN, so Redis is not blocked;
+// values already indexed are rescored.
+func (r *redisCache[T]) Reindex(ctx context.Context) (int, error) {
+	var cursor uint64
+	indexed := 0
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, r.prefix+":*", reindexBatch).Result()
+		if err != nil {
+			return indexed, service.NewInternalServerError("Redis scan error", fmt.Errorf("redis scan error, err: %w", err))
+		}
+		n, err := r.reindexKeys(ctx, keys)
+		indexed += n
+		if err != nil {
+			return indexed, err
+		}
+		if next == 0 {
+			return indexed, nil
+		}
+		cursor = next
 	}
+}
 
+// reindexKeys reads the values and TTLs of full keys in one pipeline and adds the live ones to the expiry and key sets.
+func (r *redisCache[T]) reindexKeys(ctx context.Context, fullKeys []string) (int, error) {
 	if len(fullKeys) == 0 {
-		return nil, service.NewEntityNotFoundError("Entity not found", nil)
+		return 0, nil
+	}
+	pipe := r.client.Pipeline()
+	gets := make([]*redis.StringCmd, len(fullKeys))
+	ttls := make([]*redis.DurationCmd, len(fullKeys))
+	for i, k := range fullKeys {
+		gets[i] = pipe.Get(ctx, k)
+		ttls[i] = pipe.PTTL(ctx, k)
+	}
+	_, _ = pipe.Exec(ctx) // per-command errors are checked below
+
+	write := r.client.Pipeline()
+	indexed := 0
+	for i, k := range fullKeys {
+		bytes, err := gets[i].Bytes()
+		if err != nil {
+			continue
+		}
+		ttl, err := ttls[i].Result()
+		if err != nil || ttl <= 0 {
+			continue // gone, or written without TTL
+		}
+		item, err := r.unmarshal(bytes)
+		if err != nil {
+			continue
+		}
+		key := strings.TrimPrefix(k, r.prefix+":")
+		member := &redis.Z{Score: r.expiryScore(ttl), Member: key}
+		write.ZAdd(ctx, r.generateAllIndexKey(), member)
+		write.ZAdd(ctx, r.generateAllKeysKey(), &redis.Z{Member: key})
+		if r.indexOf != nil {
+			write.ZAdd(ctx, r.generateIndexKey(r.indexOf(item)), member)
+			write.ZAdd(ctx, r.generateKeysKey(r.indexOf(item)), &redis.Z{Member: key})
+		}
+		indexed++
 	}
+	if indexed == 0 {
+		return 0, nil
+	}
+	if _, err := write.Exec(ctx); err != nil {
+		return 0, service.NewInternalServerError("Redis write index error", fmt.Errorf("redis write index error, err: %w", err))
+	}
+	return indexed, nil
+}
+
+// list prunes the expired members of the sets (of index when not empty), then reads up to limit keys after after (0 —
+// all of them) from the key set and fetches their values in one pipeline. Keys whose value is missing or unreadable
+// are skipped; they leave the sets once their score passes. next is the last key of the page when more keys follow.
+func (r *redisCache[T]) list(ctx context.Context, index, after string, limit int) ([]T, string, error) {
+	indexKey, keysKey := r.generateAllIndexKey(), r.generateAllKeysKey()
+	if index != "" {
+		indexKey, keysKey = r.generateIndexKey(index), r.generateKeysKey(index)
+	}
+	now := strconv.FormatInt(r.now().UnixMilli(), 10)
 
-	prefixWithColon := r.prefix + ":"
-	keys := make([]string, 0, len(fullKeys))
-	for _, k := range fullKeys {
-		if strings.HasPrefix(k, prefixWithColon) {
-			keys = append(keys, strings.TrimPrefix(k, prefixWithColon))
+	min := "-"
+	if after != "" {
+		min = "(" + after
+	}
+	rangeBy := &redis.ZRangeBy{Min: min, Max: "+"}
+	if limit > 0 {
+		rangeBy.Count = int64(limit) + 1 // one more tells whether a next page follows
+	}
+	pipe := r.client.Pipeline()
+	pipe.Eval(ctx, pruneScript, []string{indexKey, keysKey}, now, pruneBatch)
+	page := pipe.ZRangeByLex(ctx, keysKey, rangeBy)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, "", service.NewInternalServerError("Redis get index error", fmt.Errorf("redis get index error (key='%s'), err: %w", keysKey, err))
+	}
+
+	keys := page.Val()
+	next := ""
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+		next = keys[limit-1]
+	}
+
+	items, err := r.readValues(ctx, keys)
+	if err != nil {
+		return nil, "", err
+	}
+	if index != "" {
+		kept := items[:0]
+		for _, item := range items {
+			if r.indexOf(item) == index {
+				kept = append(kept, item)
+			}
 		}
+		items = kept
+	}
+	if len(items) == 0 && next == "" {
+		return nil, "", service.NewEntityNotFoundError("Entity not found", nil)
+	}
+
+	return items, next, nil
+}
+
+// readValues fetches the values of keys in one pipeline, in order; missing or unreadable values are skipped.
+func (r *redisCache[T]) readValues(ctx context.Context, keys []string) ([]T, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	pipe := r.client.Pipeline()
+	gets := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		gets[i] = pipe.Get(ctx, r.generateKey(key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, service.NewInternalServerError("Redis get values error", fmt.Errorf("redis get values error, err: %w", err))
 	}
 
 	items := make([]T, 0, len(keys))
-	for _, key := range keys {
-		bytes, err := r.client.Get(ctx, r.generateKey(key)).Bytes()
+	for _, get := range gets {
+		bytes, err := get.Bytes()
 		if err != nil {
 			continue
 		}
-
 		item, err := r.unmarshal(bytes)
 		if err != nil {
 			continue
 		}
-
 		items = append(items, item)
 	}
-	if len(items) == 0 {
-		return nil, service.NewEntityNotFoundError("Entity not found", nil)
+	return items, nil
+}
+
+// readValue returns the current value of key read in tx; false when it is missing or unreadable.
+func (r *redisCache[T]) readValue(ctx context.Context, tx *redis.Tx, key string) (T, bool) {
+	bytes, err := tx.Get(ctx, r.generateKey(key)).Bytes()
+	if err != nil {
+		return r.zero, false
+	}
+	item, err := r.unmarshal(bytes)
+	if err != nil {
+		return r.zero, false
 	}
+	return item, true
+}
 
-	return items, nil
+// expiryScore returns the score of a key written now with ttl: its expiry in unix ms.
+func (r *redisCache[T]) expiryScore(ttl time.Duration) float64 {
+	return float64(r.now().Add(ttl).UnixMilli())
 }
 
 func (r *redisCache[T]) generateKey(key string) string {
 	return r.prefix + ":" + key
 }
+
+// generateAllIndexKey returns the key of the expiry set of all keys; outside "<prefix>:*" so Reindex does not see it.
+func (r *redisCache[T]) generateAllIndexKey() string {
+	return r.prefix + "_expiry"
+}
+
+// generateIndexKey returns the key of the expiry set of one index value.
+func (r *redisCache[T]) generateIndexKey(index string) string {
+	return r.prefix + "_expiry:" + index
+}
+
+// generateAllKeysKey returns the key of the key-ordered set of all keys; outside "<prefix>:*" like the expiry set.
+func (r *redisCache[T]) generateAllKeysKey() string {
+	return r.prefix + "_keys"
+}
+
+// generateKeysKey returns the key of the key-ordered set of one index value.
+func (r *redisCache[T]) generateKeysKey(index string) string {
+	return r.prefix + "_keys:" + index
+}
@@ -3,40 +3,29 @@ package myredis
 import (
 	"context"
 	"encoding/json"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	"mydiscoverer/domain"
 	"mydiscoverer/service"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-const testRedisAddr = "redis://localhost:6379"
 const testPrefix = "instance"
 
-func setupTestRedis(t *testing.T) (redis.UniversalClient, func()) {
-	client, err := NewRedisUniversalClient(testRedisAddr)
+// setupTestRedis starts an in-memory Redis (closed with the test) and a client connected to it.
+func setupTestRedis(t *testing.T) (redis.UniversalClient, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client, err := NewRedisUniversalClient("redis://" + mr.Addr())
 	require.NoError(t, err)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
-	keys, err := client.Keys(ctx, testPrefix+":*").Result()
-	if err == nil && len(keys) > 0 {
-		client.Del(ctx, keys...)
-	}
-
-	cleanup := func() {
-		keys, _ := client.Keys(ctx, testPrefix+":*").Result()
-		if len(keys) > 0 {
-			client.Del(ctx, keys...)
-		}
-		client.Close()
-	}
-	return client, cleanup
+	t.Cleanup(func() { client.Close() })
+	return client, mr
 }
 
 func marshalInstance(i domain.Instance) ([]byte, error) { return json.Marshal(i) }
@@ -45,17 +34,17 @@ func unmarshalInstance(b []byte) (domain.Instance, error) {
 	err := json.Unmarshal(b, &i)
 	return i, err
 }
+func serviceTypeOf(i domain.Instance) string { return i.ServiceType }
 
 func TestCache_WriteValue(t *testing.T) {
 	ctx := context.Background()
-	client, cleanup := setupTestRedis(t)
-	defer cleanup()
+	client, mr := setupTestRedis(t)
 
-	cache := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance)
+	cache := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance, serviceTypeOf)
 	inst := domain.Instance{
 		InstanceID:  "inst-1",
 		ServiceType: "grpc",
-		Ipv4:        "127.0.0.1",
+		Address:     "127.0.0.1",
 		Port:        9000,
 		Timestamp:   time.Now(),
 		TTLMs:       300000,
@@ -71,16 +60,16 @@ func TestCache_WriteValue(t *testing.T) {
 		got := items[0]
 		assert.Equal(t, inst.InstanceID, got.InstanceID)
 		assert.Equal(t, inst.ServiceType, got.ServiceType)
-		assert.Equal(t, inst.Ipv4, got.Ipv4)
+		assert.Equal(t, inst.Address, got.Address)
 		assert.Equal(t, inst.Port, got.Port)
 		assert.Equal(t, inst.TTLMs, got.TTLMs)
 	})
 
 	t.Run("when Redis write fails returns internal_server_error", func(t *testing.T) {
-		closedClient, err := NewRedisUniversalClient(testRedisAddr)
+		closedClient, err := NewRedisUniversalClient("redis://" + mr.Addr())
 		require.NoError(t, err)
 		closedClient.Close()
-		cacheClosed := NewCache[domain.Instance](closedClient, testPrefix, marshalInstance, unmarshalInstance)
+		cacheClosed := NewCache[domain.Instance](closedClient, testPrefix, marshalInstance, unmarshalInstance, serviceTypeOf)
 
 		err = cacheClosed.WriteValue(ctx, "x", inst, 60000)
 		require.Error(t, err)
@@ -90,11 +79,10 @@ func TestCache_WriteValue(t *testing.T) {
 
 func TestCache_DeleteValue(t *testing.T) {
 	ctx := context.Background()
-	client, cleanup := setupTestRedis(t)
-	defer cleanup()
+	client, _ := setupTestRedis(t)
 
-	cache := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance)
-	inst := domain.Instance{InstanceID: "inst-del", ServiceType: "grpc", Ipv4: "127.0.0.1", Port: 9000, Timestamp: time.Now(), TTLMs: 300000}
+	cache := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance, serviceTypeOf)
+	inst := domain.Instance{InstanceID: "inst-del", ServiceType: "grpc", Address: "127.0.0.1", Port: 9000, Timestamp: time.Now(), TTLMs: 300000}
 	err := cache.WriteValue(ctx, inst.InstanceID, inst, 60000)
 	require.NoError(t, err)
 
@@ -109,10 +97,9 @@ func TestCache_DeleteValue(t *testing.T) {
 
 func TestCache_ListAllValues(t *testing.T) {
 	ctx := context.Background()
-	client, cleanup := setupTestRedis(t)
-	defer cleanup()
+	client, _ := setupTestRedis(t)
 
-	cache := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance)
+	cache := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance, serviceTypeOf)
 
 	t.Run("empty cache returns entity not found", func(t *testing.T) {
 		items, err := cache.ListAllValues(ctx)
@@ -122,7 +109,7 @@ func TestCache_ListAllValues(t *testing.T) {
 	})
 
 	t.Run("returns all values", func(t *testing.T) {
-		inst := domain.Instance{InstanceID: "list-1", ServiceType: "grpc", Ipv4: "127.0.0.1", Port: 9000, Timestamp: time.Now(), TTLMs: 300000}
+		inst := domain.Instance{InstanceID: "list-1", ServiceType: "grpc", Address: "127.0.0.1", Port: 9000, Timestamp: time.Now(), TTLMs: 300000}
 		err := cache.WriteValue(ctx, inst.InstanceID, inst, 60000)
 		require.NoError(t, err)
 
@@ -134,12 +121,7 @@ func TestCache_ListAllValues(t *testing.T) {
 	})
 
 	t.Run("invalid JSON in redis yields entity not found", func(t *testing.T) {
-		keys, err := client.Keys(ctx, testPrefix+":*").Result()
-		require.NoError(t, err)
-		if len(keys) > 0 {
-			client.Del(ctx, keys...)
-		}
-		err = client.Set(ctx, testPrefix+":badjson", "invalid json", 0).Err()
+		err := client.Set(ctx, testPrefix+":list-1", "invalid json", redis.KeepTTL).Err()
 		require.NoError(t, err)
 
 		items, err := cache.ListAllValues(ctx)
@@ -148,3 +130,214 @@ func TestCache_ListAllValues(t *testing.T) {
 		assert.Nil(t, items)
 	})
 }
+
+func TestCache_ListValuesByIndex(t *testing.T) {
+	ctx := context.Background()
+	client, mr := setupTestRedis(t)
+
+	cache := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance, serviceTypeOf)
+	write := func(id, serviceType string, ttlMs int) {
+		inst := domain.Instance{InstanceID: id, ServiceType: serviceType, Address: "127.0.0.1", Port: 9000, Timestamp: time.Now(), TTLMs: ttlMs}
+		require.NoError(t, cache.WriteValue(ctx, id, inst, ttlMs))
+	}
+	ids := func(serviceType string) []string {
+		items, err := cache.ListValuesByIndex(ctx, serviceType)
+		if service.IsEntityNotFoundError(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		out := make([]string, 0, len(items))
+		for _, i := range items {
+			out = append(out, i.InstanceID)
+		}
+		return out
+	}
+
+	write("orders-1", "orders", 60000)
+	write("orders-2", "orders", 60000)
+	write("payments-1", "payments", 60000)
+	assert.ElementsMatch(t, []string{"orders-1", "orders-2"}, ids("orders"))
+	assert.Equal(t, []string{"payments-1"}, ids("payments"))
+	assert.Nil(t, ids("unknown"), "unknown service type is entity_not_found")
+
+	t.Run("re-register with another type moves the key", func(t *testing.T) {
+		write("orders-2", "payments", 60000)
+		assert.Equal(t, []string{"orders-1"}, ids("orders"))
+		assert.ElementsMatch(t, []string{"payments-1", "orders-2"}, ids("payments"))
+	})
+	t.Run("delete removes the key from the index", func(t *testing.T) {
+		require.NoError(t, cache.DeleteValue(ctx, "orders-1"))
+		assert.Nil(t, ids("orders"))
+		exists, err := client.Exists(ctx, testPrefix+"_expiry:orders").Result()
+		require.NoError(t, err)
+		assert.Zero(t, exists, "empty index set is gone")
+	})
+	t.Run("expired values are pruned on read", func(t *testing.T) {
+		write("short-1", "short", 50)
+		mr.FastForward(100 * time.Millisecond)
+		assert.Nil(t, ids("short"), "value gone, index member skipped")
+		members, err := client.ZRange(ctx, testPrefix+"_expiry:short", 0, -1).Result()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"short-1"}, members, "kept until its score passes")
+
+		cache.now = func() time.Time { return time.Now().Add(time.Second) }
+		defer func() { cache.now = time.Now }()
+		assert.Nil(t, ids("short"))
+		for _, key := range []string{"_expiry:short", "_keys:short"} {
+			members, err = client.ZRange(ctx, testPrefix+key, 0, -1).Result()
+			require.NoError(t, err)
+			assert.Empty(t, members, key)
+		}
+	})
+	t.Run("index keys are not listed as values", func(t *testing.T) {
+		items, err := cache.ListAllValues(ctx)
+		require.NoError(t, err)
+		assert.Len(t, items, 2)
+	})
+	t.Run("concurrent re-registers leave the key in one index", func(t *testing.T) {
+		types := []string{"a", "b", "c", "d"}
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for n := 0; n < 25; n++ {
+					inst := domain.Instance{InstanceID: "moving", ServiceType: types[(w+n)%len(types)], Address: "127.0.0.1", Port: 9000}
+					assert.NoError(t, cache.WriteValue(ctx, "moving", inst, 60000))
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		var stored []string
+		for _, st := range types {
+			if slices.Contains(ids(st), "moving") {
+				stored = append(stored, st)
+			}
+			for _, key := range []string{"_expiry:", "_keys:"} {
+				members, err := client.ZRange(ctx, testPrefix+key+st, 0, -1).Result()
+				require.NoError(t, err)
+				if len(stored) == 1 && stored[0] == st {
+					assert.Equal(t, []string{"moving"}, members, key+st)
+				} else {
+					assert.Empty(t, members, key+st)
+				}
+			}
+		}
+		require.Len(t, stored, 1)
+		require.NoError(t, cache.DeleteValue(ctx, "moving"))
+	})
+	t.Run("cache without index returns internal_server_error", func(t *testing.T) {
+		plain := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance, nil)
+		_, err := plain.ListValuesByIndex(ctx, "orders")
+		assert.True(t, service.IsInternalServerError(err))
+	})
+}
+
+func TestCache_ListValuesPage(t *testing.T) {
+	ctx := context.Background()
+	client, _ := setupTestRedis(t)
+
+	cache := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance, serviceTypeOf)
+	write := func(id, serviceType string) {
+		inst := domain.Instance{InstanceID: id, ServiceType: serviceType, Address: "127.0.0.1", Port: 9000, Timestamp: time.Now(), TTLMs: 60000}
+		require.NoError(t, cache.WriteValue(ctx, id, inst, 60000))
+	}
+	page := func(index, after string, limit int) ([]string, string) {
+		items, next, err := cache.ListValuesPage(ctx, index, after, limit)
+		if service.IsEntityNotFoundError(err) {
+			return nil, next
+		}
+		require.NoError(t, err)
+		out := make([]string, 0, len(items))
+		for _, i := range items {
+			out = append(out, i.InstanceID)
+		}
+		return out, next
+	}
+
+	for _, id := range []string{"i4", "i2", "i5", "i1", "i3"} {
+		write(id, "orders")
+	}
+	write("p1", "payments")
+
+	t.Run("pages are ordered by key", func(t *testing.T) {
+		got, next := page("orders", "", 2)
+		assert.Equal(t, []string{"i1", "i2"}, got)
+		assert.Equal(t, "i2", next)
+		got, next = page("orders", next, 2)
+		assert.Equal(t, []string{"i3", "i4"}, got)
+		got, next = page("orders", next, 2)
+		assert.Equal(t, []string{"i5"}, got)
+		assert.Empty(t, next, "last page")
+	})
+	t.Run("all values without index", func(t *testing.T) {
+		got, next := page("", "i5", 10)
+		assert.Equal(t, []string{"p1"}, got)
+		assert.Empty(t, next)
+	})
+	t.Run("changes between pages do not shift the others", func(t *testing.T) {
+		got, next := page("orders", "", 2)
+		assert.Equal(t, []string{"i1", "i2"}, got)
+		require.NoError(t, cache.DeleteValue(ctx, "i1"))
+		write("i0", "orders")
+		got, _ = page("orders", next, 2)
+		assert.Equal(t, []string{"i3", "i4"}, got)
+	})
+	t.Run("expired keys leave the pages", func(t *testing.T) {
+		inst := domain.Instance{InstanceID: "i6", ServiceType: "orders", Address: "127.0.0.1", Port: 9000, Timestamp: time.Now(), TTLMs: 50}
+		require.NoError(t, cache.WriteValue(ctx, "i6", inst, 50))
+		got, _ := page("orders", "i5", 2)
+		assert.Equal(t, []string{"i6"}, got)
+
+		cache.now = func() time.Time { return time.Now().Add(time.Second) }
+		defer func() { cache.now = time.Now }()
+		got, next := page("orders", "i5", 2)
+		assert.Nil(t, got)
+		assert.Empty(t, next)
+		members, err := client.ZRange(ctx, testPrefix+"_keys:orders", 0, -1).Result()
+		require.NoError(t, err)
+		assert.NotContains(t, members, "i6", "pruned from the key set with the expiry set")
+	})
+	t.Run("page after the last key is entity not found", func(t *testing.T) {
+		got, next := page("orders", "i9", 2)
+		assert.Nil(t, got)
+		assert.Empty(t, next)
+	})
+	t.Run("invalid arguments return internal_server_error", func(t *testing.T) {
+		_, _, err := cache.ListValuesPage(ctx, "", "", 0)
+		assert.True(t, service.IsInternalServerError(err))
+		plain := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance, nil)
+		_, _, err = plain.ListValuesPage(ctx, "orders", "", 10)
+		assert.True(t, service.IsInternalServerError(err))
+	})
+}
+
+func TestCache_Reindex(t *testing.T) {
+	ctx := context.Background()
+	client, mr := setupTestRedis(t)
+
+	cache := NewCache[domain.Instance](client, testPrefix, marshalInstance, unmarshalInstance, serviceTypeOf)
+	for i, id := range []string{"old-1", "old-2"} {
+		b, err := marshalInstance(domain.Instance{InstanceID: id, ServiceType: "orders", Address: "127.0.0.1", Port: 9000 + i})
+		require.NoError(t, err)
+		require.NoError(t, client.Set(ctx, testPrefix+":"+id, b, time.Minute).Err(), "written without index")
+	}
+	require.NoError(t, client.Set(ctx, testPrefix+":no-ttl", "{}", 0).Err())
+	require.NoError(t, client.Set(ctx, testPrefix+":bad", "invalid json", time.Minute).Err())
+	_, err := cache.ListAllValues(ctx)
+	require.True(t, service.IsEntityNotFoundError(err), "not listed before reindex")
+
+	indexed, err := cache.Reindex(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, indexed)
+	items, err := cache.ListValuesByIndex(ctx, "orders")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "old-1", items[0].InstanceID)
+
+	mr.FastForward(2 * time.Minute)
+	cache.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, err = cache.ListAllValues(ctx)
+	assert.True(t, service.IsEntityNotFoundError(err), "scored by the remaining TTL")
+}
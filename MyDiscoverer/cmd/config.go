@@ -4,17 +4,22 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"mydiscoverer/adapters/myredis"
 )
 
+// defaultWatchRefreshInterval is how often the instance watcher re-reads Redis when WATCH_REFRESH_INTERVAL_MS is unset.
+const defaultWatchRefreshInterval = time.Second
+
 type MyDiscovererConfig struct {
-	Redis    myredis.RedisConfig
-	HTTPPort int
+	Redis                myredis.RedisConfig
+	HTTPPort             int
+	WatchRefreshInterval time.Duration
 }
 
 // LoadConfig loads configuration from environment variables.
-// REDIS_ADDR and SERVICE_PORT_HTTP are required.
+// REDIS_ADDR and SERVICE_PORT_HTTP are required; WATCH_REFRESH_INTERVAL_MS is optional (default 1000).
 func LoadConfig() (*MyDiscovererConfig, error) {
 	redisAddr := os.Getenv("REDIS_ADDR")
 	if redisAddr == "" {
@@ -30,10 +35,23 @@ func LoadConfig() (*MyDiscovererConfig, error) {
 		return nil, fmt.Errorf("invalid SERVICE_PORT_HTTP: %w", err)
 	}
 
+	watchRefreshInterval := defaultWatchRefreshInterval
+	if s := os.Getenv("WATCH_REFRESH_INTERVAL_MS"); s != "" {
+		ms, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WATCH_REFRESH_INTERVAL_MS: %w", err)
+		}
+		if ms <= 0 {
+			return nil, fmt.Errorf("WATCH_REFRESH_INTERVAL_MS must be positive")
+		}
+		watchRefreshInterval = time.Duration(ms) * time.Millisecond
+	}
+
 	return &MyDiscovererConfig{
 		Redis: myredis.RedisConfig{
 			Addr: redisAddr,
 		},
-		HTTPPort: httpPort,
+		HTTPPort:             httpPort,
+		WatchRefreshInterval: watchRefreshInterval,
 	}, nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -69,3 +70,26 @@ func TestLoadConfig_InvalidSERVICE_PORT_HTTP(t *testing.T) {
 	assert.Nil(t, cfg)
 	assert.Contains(t, err.Error(), "SERVICE_PORT_HTTP")
 }
+
+func TestLoadConfig_WatchRefreshInterval(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "redis://localhost:6379")
+	t.Setenv("SERVICE_PORT_HTTP", "8080")
+
+	t.Setenv("WATCH_REFRESH_INTERVAL_MS", "")
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, cfg.WatchRefreshInterval)
+
+	t.Setenv("WATCH_REFRESH_INTERVAL_MS", "250")
+	cfg, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, cfg.WatchRefreshInterval)
+
+	for _, v := range []string{"0", "-1", "soon"} {
+		t.Setenv("WATCH_REFRESH_INTERVAL_MS", v)
+		cfg, err = LoadConfig()
+		require.Error(t, err, v)
+		assert.Nil(t, cfg)
+		assert.Contains(t, err.Error(), "WATCH_REFRESH_INTERVAL_MS")
+	}
+}
@@ -39,6 +39,7 @@ func main() {
 		"msg", "Configuration loaded",
 		"service_port_http", config.HTTPPort,
 		"redis_addr", config.Redis.Addr,
+		"watch_refresh_interval", config.WatchRefreshInterval,
 	)
 
 	var cache interfaces.Cache[domain.Instance]
@@ -59,17 +60,39 @@ func main() {
 
 		marshal := func(i domain.Instance) ([]byte, error) { return json.Marshal(i) }
 		unmarshal := func(b []byte) (domain.Instance, error) {
-			var i domain.Instance
-			err := json.Unmarshal(b, &i)
-			return i, err
+			// Ipv4 — field of entries written before Instance.Address replaced it; they live until their TTL expires.
+			var stored struct {
+				domain.Instance
+				Ipv4 string
+			}
+			err := json.Unmarshal(b, &stored)
+			if stored.Address == "" {
+				stored.Address = stored.Ipv4
+			}
+			return stored.Instance, err
 		}
-		cache = myredis.NewCache[domain.Instance](redisClient, "instance", marshal, unmarshal)
+		serviceType := func(i domain.Instance) string { return i.ServiceType }
+		redisCache := myredis.NewCache[domain.Instance](redisClient, "instance", marshal, unmarshal, serviceType)
+		// Entries written before the expiry index are invisible to lists until indexed (or re-registered).
+		indexed, err := redisCache.Reindex(ctx)
+		if err != nil {
+			level.Warn(logger).Log("msg", "Failed to index instances", "indexed", indexed, "err", err)
+		} else {
+			level.Info(logger).Log("msg", "Indexed instances", "indexed", indexed)
+		}
+		cache = redisCache
 	}
 
+	// Create instance watcher (feeds GET /v1/instances/watch)
+	watcherCtx, watcherCancel := context.WithCancel(context.Background())
+	defer watcherCancel()
+	watcher := service.NewInstanceWatcher(cache, config.WatchRefreshInterval, logger)
+	go watcher.Run(watcherCtx)
+
 	// Create HTTPServer
 	var httpServer handlers.ServerInterface
 	{
-		httpServer = handlers.NewHTTPServer(cache, logger)
+		httpServer = handlers.NewHTTPServer(cache, watcher, logger)
 	}
 
 	// Create HTTP server (Echo)
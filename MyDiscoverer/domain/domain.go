@@ -3,12 +3,20 @@ package domain
 import "time"
 
 // Instance represents a registered instance stored by MyDiscoverer.
-// Fields match API: instance_id, service_type, ipv4, port, timestamp, ttl_ms.
+// Fields match API: instance_id, service_type, address, port, timestamp, ttl_ms, labels.
 type Instance struct {
-	InstanceID  string    // unique instance identifier
+	InstanceID  string // unique instance identifier
 	ServiceType string
-	Ipv4        string    // IPv4 address
-	Port        int       // port
-	Timestamp   time.Time // timestamp from request
-	TTLMs       int       // TTL in milliseconds
+	Address     string            // IPv4, IPv6 (without brackets) or DNS hostname, normalized at registration
+	Port        int               // port
+	Timestamp   time.Time         // timestamp from request
+	TTLMs       int               // TTL in milliseconds
+	Labels      map[string]string // arbitrary labels (zone, version, ...); nil when none
+}
+
+// InstanceSet is a snapshot of all registered instances sorted by InstanceID, with Version — an opaque token that
+// changes whenever the set (instance_id, address, port or labels of any instance) changes. Served by GET /v1/instances/watch.
+type InstanceSet struct {
+	Version   string
+	Instances []Instance
 }
@@ -1,10 +1,27 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"net"
+	"strings"
+
 	"mydiscoverer/domain"
 	"mydiscoverer/service"
 )
 
+// fromCursor decodes the cursor of GET /v1/instances into the instance ID the page starts after; empty for an empty
+// cursor. Returns service.BadParameterError when the cursor is not one returned by toCursor.
+func fromCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	after, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(after) == 0 {
+		return "", service.NewBadParameterError("cursor is invalid", err)
+	}
+	return string(after), nil
+}
+
 // fromRegisterRequest converts RegisterRequest to domain.Instance.
 // Returns service.BadParameterError on validation failure.
 func fromRegisterRequest(req RegisterRequest) (domain.Instance, error) {
@@ -14,8 +31,9 @@ func fromRegisterRequest(req RegisterRequest) (domain.Instance, error) {
 	if req.ServiceType == "" {
 		return domain.Instance{}, service.NewBadParameterError("service_type is required", nil)
 	}
-	if req.Ipv4 == "" {
-		return domain.Instance{}, service.NewBadParameterError("ipv4 is required", nil)
+	address, err := registerAddress(req)
+	if err != nil {
+		return domain.Instance{}, err
 	}
 	if req.Port == 0 {
 		return domain.Instance{}, service.NewBadParameterError("port is required", nil)
@@ -23,13 +41,83 @@ func fromRegisterRequest(req RegisterRequest) (domain.Instance, error) {
 	if req.TtlMs <= 0 {
 		return domain.Instance{}, service.NewBadParameterError("ttl_ms is required", nil)
 	}
+	var labels map[string]string
+	if req.Labels != nil && len(*req.Labels) > 0 {
+		labels = make(map[string]string, len(*req.Labels))
+		for k, v := range *req.Labels {
+			k = strings.TrimSpace(k)
+			if k == "" {
+				return domain.Instance{}, service.NewBadParameterError("labels must not have empty keys", nil)
+			}
+			labels[k] = strings.TrimSpace(v)
+		}
+	}
 
 	return domain.Instance{
 		InstanceID:  req.InstanceId,
 		ServiceType: req.ServiceType,
-		Ipv4:        req.Ipv4,
+		Address:     address,
 		Port:        req.Port,
 		Timestamp:   req.Timestamp,
 		TTLMs:       req.TtlMs,
+		Labels:      labels,
 	}, nil
 }
+
+// registerAddress returns the normalized address of a registration: address, or the deprecated ipv4 when address is
+// not set. ipv4 must be an IPv4 address; when both are set they must be the same address.
+// Returns service.BadParameterError when neither is set or a value is invalid.
+func registerAddress(req RegisterRequest) (string, error) {
+	address := strings.TrimSpace(service.Value(req.Address))
+	ipv4 := strings.TrimSpace(service.Value(req.Ipv4))
+	if ipv4 != "" {
+		if ip := net.ParseIP(ipv4); ip == nil || ip.To4() == nil || strings.Contains(ipv4, ":") {
+			return "", service.NewBadParameterError("ipv4 must be an IPv4 address", nil)
+		}
+	}
+	if address == "" {
+		if ipv4 == "" {
+			return "", service.NewBadParameterError("address is required", nil)
+		}
+		address = ipv4
+	}
+	normalized, ok := normalizeAddress(address)
+	if !ok {
+		return "", service.NewBadParameterError("address must be an IPv4 or IPv6 address or a DNS hostname", nil)
+	}
+	if ipv4 != "" && normalized != net.ParseIP(ipv4).String() {
+		return "", service.NewBadParameterError("address and ipv4 must be the same address when both are set", nil)
+	}
+	return normalized, nil
+}
+
+// normalizeAddress validates an instance address and returns its canonical form: an IP address (IPv6 may be in
+// brackets, zones are not allowed) as printed by net.IP.String, or a DNS hostname (RFC 1123 labels of 1–63 letters,
+// digits and hyphens, not starting or ending with a hyphen, at most 253 characters, trailing dot allowed) in lower case.
+func normalizeAddress(s string) (string, bool) {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		ip := net.ParseIP(s[1 : len(s)-1])
+		if ip == nil || ip.To4() != nil && !strings.Contains(s, ":") {
+			return "", false
+		}
+		return ip.String(), true
+	}
+	if ip := net.ParseIP(s); ip != nil {
+		return ip.String(), true
+	}
+	host := strings.ToLower(strings.TrimSuffix(s, "."))
+	if host == "" || len(host) > 253 {
+		return "", false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return "", false
+			}
+		}
+	}
+	return host, true
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -25,7 +26,7 @@ func TestFromRegisterRequest(t *testing.T) {
 			request: RegisterRequest{
 				InstanceId:  "inst-1",
 				ServiceType: "grpc",
-				Ipv4:        "127.0.0.1",
+				Address:     service.Ptr("127.0.0.1"),
 				Port:        9000,
 				Timestamp:   ts,
 				TtlMs:       300000,
@@ -33,7 +34,7 @@ func TestFromRegisterRequest(t *testing.T) {
 			expected: domain.Instance{
 				InstanceID:  "inst-1",
 				ServiceType: "grpc",
-				Ipv4:        "127.0.0.1",
+				Address:     "127.0.0.1",
 				Port:        9000,
 				Timestamp:   ts,
 				TTLMs:       300000,
@@ -44,7 +45,7 @@ func TestFromRegisterRequest(t *testing.T) {
 			request: RegisterRequest{
 				InstanceId:  "",
 				ServiceType: "grpc",
-				Ipv4:        "127.0.0.1",
+				Address:     service.Ptr("127.0.0.1"),
 				Port:        9000,
 				Timestamp:   ts,
 				TtlMs:       300000,
@@ -56,7 +57,7 @@ func TestFromRegisterRequest(t *testing.T) {
 			request: RegisterRequest{
 				InstanceId:  "inst-1",
 				ServiceType: "",
-				Ipv4:        "127.0.0.1",
+				Address:     service.Ptr("127.0.0.1"),
 				Port:        9000,
 				Timestamp:   ts,
 				TtlMs:       300000,
@@ -64,23 +65,23 @@ func TestFromRegisterRequest(t *testing.T) {
 			expectedError: "service_type is required",
 		},
 		{
-			name: "empty ipv4",
+			name: "no address",
 			request: RegisterRequest{
 				InstanceId:  "inst-1",
 				ServiceType: "grpc",
-				Ipv4:        "",
+				Address:     nil,
 				Port:        9000,
 				Timestamp:   ts,
 				TtlMs:       300000,
 			},
-			expectedError: "ipv4 is required",
+			expectedError: "address is required",
 		},
 		{
 			name: "port zero",
 			request: RegisterRequest{
 				InstanceId:  "inst-1",
 				ServiceType: "grpc",
-				Ipv4:        "127.0.0.1",
+				Address:     service.Ptr("127.0.0.1"),
 				Port:        0,
 				Timestamp:   ts,
 				TtlMs:       300000,
@@ -92,13 +93,82 @@ func TestFromRegisterRequest(t *testing.T) {
 			request: RegisterRequest{
 				InstanceId:  "inst-1",
 				ServiceType: "grpc",
-				Ipv4:        "127.0.0.1",
+				Address:     service.Ptr("127.0.0.1"),
 				Port:        9000,
 				Timestamp:   ts,
 				TtlMs:       0,
 			},
 			expectedError: "ttl_ms is required",
 		},
+		{
+			name: "labels trimmed",
+			request: RegisterRequest{
+				InstanceId:  "inst-1",
+				ServiceType: "grpc",
+				Address:     service.Ptr("127.0.0.1"),
+				Port:        9000,
+				Timestamp:   ts,
+				TtlMs:       300000,
+				Labels:      &map[string]string{" zone ": "eu-1a", "version": " v2 "},
+			},
+			expected: domain.Instance{
+				InstanceID:  "inst-1",
+				ServiceType: "grpc",
+				Address:     "127.0.0.1",
+				Port:        9000,
+				Timestamp:   ts,
+				TTLMs:       300000,
+				Labels:      map[string]string{"zone": "eu-1a", "version": "v2"},
+			},
+		},
+		{
+			name: "empty label key",
+			request: RegisterRequest{
+				InstanceId:  "inst-1",
+				ServiceType: "grpc",
+				Address:     service.Ptr("127.0.0.1"),
+				Port:        9000,
+				Timestamp:   ts,
+				TtlMs:       300000,
+				Labels:      &map[string]string{" ": "x"},
+			},
+			expectedError: "labels must not have empty keys",
+		},
+		{
+			name:     "ipv6 in brackets normalized",
+			request:  RegisterRequest{InstanceId: "inst-1", ServiceType: "grpc", Address: service.Ptr(" [FD00:0::10] "), Port: 9000, Timestamp: ts, TtlMs: 300000},
+			expected: domain.Instance{InstanceID: "inst-1", ServiceType: "grpc", Address: "fd00::10", Port: 9000, Timestamp: ts, TTLMs: 300000},
+		},
+		{
+			name:     "hostname lower-cased",
+			request:  RegisterRequest{InstanceId: "inst-1", ServiceType: "grpc", Address: service.Ptr("Orders-1.Svc.Cluster.Local."), Port: 9000, Timestamp: ts, TtlMs: 300000},
+			expected: domain.Instance{InstanceID: "inst-1", ServiceType: "grpc", Address: "orders-1.svc.cluster.local", Port: 9000, Timestamp: ts, TTLMs: 300000},
+		},
+		{
+			name:     "legacy ipv4 only",
+			request:  RegisterRequest{InstanceId: "inst-1", ServiceType: "grpc", Ipv4: service.Ptr("10.0.0.1"), Port: 9000, Timestamp: ts, TtlMs: 300000},
+			expected: domain.Instance{InstanceID: "inst-1", ServiceType: "grpc", Address: "10.0.0.1", Port: 9000, Timestamp: ts, TTLMs: 300000},
+		},
+		{
+			name:     "address and same ipv4",
+			request:  RegisterRequest{InstanceId: "inst-1", ServiceType: "grpc", Address: service.Ptr("10.0.0.1"), Ipv4: service.Ptr("10.0.0.1"), Port: 9000, Timestamp: ts, TtlMs: 300000},
+			expected: domain.Instance{InstanceID: "inst-1", ServiceType: "grpc", Address: "10.0.0.1", Port: 9000, Timestamp: ts, TTLMs: 300000},
+		},
+		{
+			name:          "address and different ipv4",
+			request:       RegisterRequest{InstanceId: "inst-1", ServiceType: "grpc", Address: service.Ptr("orders.local"), Ipv4: service.Ptr("10.0.0.1"), Port: 9000, Timestamp: ts, TtlMs: 300000},
+			expectedError: "address and ipv4 must be the same address when both are set",
+		},
+		{
+			name:          "ipv6 in ipv4",
+			request:       RegisterRequest{InstanceId: "inst-1", ServiceType: "grpc", Ipv4: service.Ptr("fd00::10"), Port: 9000, Timestamp: ts, TtlMs: 300000},
+			expectedError: "ipv4 must be an IPv4 address",
+		},
+		{
+			name:          "address with port",
+			request:       RegisterRequest{InstanceId: "inst-1", ServiceType: "grpc", Address: service.Ptr("10.0.0.1:9000"), Port: 9000, Timestamp: ts, TtlMs: 300000},
+			expectedError: "address must be an IPv4 or IPv6 address or a DNS hostname",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -115,3 +185,40 @@ func TestFromRegisterRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeAddress(t *testing.T) {
+	valid := map[string]string{
+		"10.0.0.1":              "10.0.0.1",
+		"::1":                   "::1",
+		"[fd00::10]":            "fd00::10",
+		"::ffff:10.0.0.1":       "10.0.0.1",
+		"localhost":             "localhost",
+		"a-b.example.COM.":      "a-b.example.com",
+		"1.example":             "1.example",
+		strings.Repeat("a", 63): strings.Repeat("a", 63),
+	}
+	for in, want := range valid {
+		got, ok := normalizeAddress(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", ".", "[10.0.0.1]", "[fd00::10", "fe80::1%eth0", "host:9000", "-host", "host-", "a..b", "under_score", "höst", strings.Repeat("a", 64), strings.Repeat("a.", 127) + "aa"} {
+		_, ok := normalizeAddress(in)
+		assert.False(t, ok, in)
+	}
+}
+
+func TestFromCursor(t *testing.T) {
+	for _, id := range []string{"inst-1", "orders/ü 1"} {
+		got, err := fromCursor(toCursor(id))
+		require.NoError(t, err)
+		assert.Equal(t, id, got, "round trip")
+	}
+	got, err := fromCursor("")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	for _, in := range []string{"!!", "aW5zdC0x=", "a"} {
+		_, err := fromCursor(in)
+		assert.True(t, service.IsBadParameterError(err), in)
+	}
+}
@@ -1,18 +1,46 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"maps"
+	"net"
+	"strings"
+
 	"mydiscoverer/domain"
+	"mydiscoverer/service"
 )
 
 // toInstancesResponse converts domain instances to API response.
 func toInstancesResponse(instances []domain.Instance) InstancesResponse {
 	out := make([]InstanceInfo, 0, len(instances))
 	for _, i := range instances {
-		out = append(out, InstanceInfo{
+		info := InstanceInfo{
 			InstanceId: i.InstanceID,
-			Ipv4:       i.Ipv4,
+			Address:    i.Address,
 			Port:       i.Port,
-		})
+		}
+		if ip := net.ParseIP(i.Address); ip != nil && ip.To4() != nil && !strings.Contains(i.Address, ":") {
+			// Older gateways only read ipv4.
+			info.Ipv4 = service.Ptr(i.Address)
+		}
+		if len(i.Labels) > 0 {
+			labels := maps.Clone(i.Labels)
+			info.Labels = &labels
+		}
+		out = append(out, info)
 	}
 	return InstancesResponse{Instances: out}
 }
+
+// toCursor encodes the instance ID a page ends with as the opaque next_cursor of GET /v1/instances.
+func toCursor(after string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(after))
+}
+
+// toInstancesWatchResponse converts an instance set to the watch API response.
+func toInstancesWatchResponse(set domain.InstanceSet) InstancesWatchResponse {
+	return InstancesWatchResponse{
+		Version:   set.Version,
+		Instances: toInstancesResponse(set.Instances).Instances,
+	}
+}
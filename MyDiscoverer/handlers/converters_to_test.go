@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"mydiscoverer/domain"
+	"mydiscoverer/service"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -32,17 +33,47 @@ func TestToInstancesResponse(t *testing.T) {
 			name: "one",
 			instances: []domain.Instance{{
 				InstanceID: "inst-1",
-				Ipv4:       "10.0.0.1",
+				Address:    "10.0.0.1",
 				Port:       8080,
 				Timestamp:  ts,
 			}},
 			wantLen: 1,
 			wantFirst: &InstanceInfo{
 				InstanceId: "inst-1",
-				Ipv4:       "10.0.0.1",
+				Address:    "10.0.0.1",
+				Ipv4:       service.Ptr("10.0.0.1"),
 				Port:       8080,
 			},
 		},
+		{
+			name:      "ipv6_without_ipv4",
+			instances: []domain.Instance{{InstanceID: "inst-1", Address: "fd00::10", Port: 8080}},
+			wantLen:   1,
+			wantFirst: &InstanceInfo{InstanceId: "inst-1", Address: "fd00::10", Port: 8080},
+		},
+		{
+			name:      "hostname_without_ipv4",
+			instances: []domain.Instance{{InstanceID: "inst-1", Address: "orders-1.svc", Port: 8080}},
+			wantLen:   1,
+			wantFirst: &InstanceInfo{InstanceId: "inst-1", Address: "orders-1.svc", Port: 8080},
+		},
+		{
+			name: "labels",
+			instances: []domain.Instance{{
+				InstanceID: "inst-1",
+				Address:    "10.0.0.1",
+				Port:       8080,
+				Labels:     map[string]string{"version": "v2"},
+			}},
+			wantLen: 1,
+			wantFirst: &InstanceInfo{
+				InstanceId: "inst-1",
+				Address:    "10.0.0.1",
+				Ipv4:       service.Ptr("10.0.0.1"),
+				Port:       8080,
+				Labels:     &map[string]string{"version": "v2"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -54,3 +85,13 @@ func TestToInstancesResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestToInstancesWatchResponse(t *testing.T) {
+	got := toInstancesWatchResponse(domain.InstanceSet{Version: "v1"})
+	assert.Equal(t, "v1", got.Version)
+	assert.NotNil(t, got.Instances, "empty set is [] in JSON, not null")
+	assert.Empty(t, got.Instances)
+
+	got = toInstancesWatchResponse(domain.InstanceSet{Version: "v2", Instances: []domain.Instance{{InstanceID: "inst-1", Address: "10.0.0.1", Port: 8080}}})
+	assert.Equal(t, InstancesWatchResponse{Version: "v2", Instances: []InstanceInfo{{InstanceId: "inst-1", Address: "10.0.0.1", Ipv4: service.Ptr("10.0.0.1"), Port: 8080}}}, got)
+}
@@ -5,8 +5,11 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"mydiscoverer/domain"
 	"mydiscoverer/interfaces"
@@ -16,18 +19,32 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// Long-poll limits of GET /v1/instances/watch (timeout_ms).
+const (
+	defaultWatchTimeoutMs = 30000
+	maxWatchTimeoutMs     = 60000
+)
+
+// Page size limits of GET /v1/instances (limit).
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
 // HTTPServer implements ServerInterface generated from OpenAPI spec.
 type HTTPServer struct {
-	cache  interfaces.Cache[domain.Instance]
-	logger log.Logger
+	cache   interfaces.Cache[domain.Instance]
+	watcher interfaces.InstanceWatcher
+	logger  log.Logger
 }
 
 // NewHTTPServer creates a new HTTPServer.
-func NewHTTPServer(cache interfaces.Cache[domain.Instance], logger log.Logger) *HTTPServer {
+func NewHTTPServer(cache interfaces.Cache[domain.Instance], watcher interfaces.InstanceWatcher, logger log.Logger) *HTTPServer {
 	logger = log.WithPrefix(logger, "component", "HTTPServer")
 	return &HTTPServer{
-		cache:  cache,
-		logger: logger,
+		cache:   cache,
+		watcher: watcher,
+		logger:  logger,
 	}
 }
 
@@ -47,6 +64,7 @@ func (h *HTTPServer) RegisterInstance(ectx echo.Context) error {
 	if err := h.cache.WriteValue(ctx, req.InstanceId, instance, req.TtlMs); err != nil {
 		return fmt.Errorf("registerInstance failed to write instance to cache, err: %w", err)
 	}
+	h.watcher.Notify()
 
 	return ectx.NoContent(http.StatusOK)
 }
@@ -57,17 +75,79 @@ func (h *HTTPServer) UnregisterInstance(ectx echo.Context, instanceId string) er
 	if err := h.cache.DeleteValue(ctx, instanceId); err != nil {
 		return fmt.Errorf("unregisterInstance failed to delete instance from cache, err: %w", err)
 	}
+	h.watcher.Notify()
 
 	return ectx.NoContent(http.StatusOK)
 }
 
-// GetInstances (GET /v1/instances) reads all values from cache and returns instances.
-func (h *HTTPServer) GetInstances(ectx echo.Context) error {
+// GetInstances (GET /v1/instances) reads all values from cache, or only those of service_type via the cache index,
+// and returns instances. With limit or cursor it returns one page (limit default 100, 1–1000) and the cursor of the
+// next. Returns 400 on invalid limit or cursor.
+func (h *HTTPServer) GetInstances(ectx echo.Context, params GetInstancesParams) error {
 	ctx := ectx.Request().Context()
-	instances, err := h.cache.ListAllValues(ctx)
+	serviceType := strings.TrimSpace(service.Value(params.ServiceType))
+	if params.Limit != nil || params.Cursor != nil {
+		return h.getInstancesPage(ectx, serviceType, params)
+	}
+
+	var instances []domain.Instance
+	var err error
+	if serviceType != "" {
+		instances, err = h.cache.ListValuesByIndex(ctx, serviceType)
+	} else {
+		instances, err = h.cache.ListAllValues(ctx)
+	}
 	if err != nil {
-		return fmt.Errorf("getInstances failed to list all instances from cache, err: %w", err)
+		return fmt.Errorf("getInstances failed to list instances from cache, err: %w", err)
 	}
 
 	return ectx.JSON(http.StatusOK, toInstancesResponse(instances))
 }
+
+// getInstancesPage serves GET /v1/instances with limit or cursor.
+func (h *HTTPServer) getInstancesPage(ectx echo.Context, serviceType string, params GetInstancesParams) error {
+	limit := defaultPageLimit
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	if limit < 1 || limit > maxPageLimit {
+		return service.NewBadParameterError(fmt.Sprintf("limit must be between 1 and %d", maxPageLimit), nil)
+	}
+	after, err := fromCursor(service.Value(params.Cursor))
+	if err != nil {
+		return err
+	}
+
+	instances, next, err := h.cache.ListValuesPage(ectx.Request().Context(), serviceType, after, limit)
+	if err != nil {
+		return fmt.Errorf("getInstances failed to list instances page from cache, err: %w", err)
+	}
+
+	resp := toInstancesResponse(instances)
+	if next != "" {
+		resp.NextCursor = service.Ptr(toCursor(next))
+	}
+	return ectx.JSON(http.StatusOK, resp)
+}
+
+// WatchInstances (GET /v1/instances/watch) long-polls the instance set (of service_type when given): returns at once when version is empty or
+// outdated, otherwise when the set changes or timeout_ms (default 30000, 1–60000) passes. Returns 400 on invalid
+// timeout_ms, 500 when the set could not be loaded.
+func (h *HTTPServer) WatchInstances(ectx echo.Context, params WatchInstancesParams) error {
+	timeoutMs := defaultWatchTimeoutMs
+	if params.TimeoutMs != nil {
+		timeoutMs = *params.TimeoutMs
+	}
+	if timeoutMs < 1 || timeoutMs > maxWatchTimeoutMs {
+		return service.NewBadParameterError(fmt.Sprintf("timeout_ms must be between 1 and %d", maxWatchTimeoutMs), nil)
+	}
+
+	ctx, cancel := context.WithTimeout(ectx.Request().Context(), time.Duration(timeoutMs)*time.Millisecond)
+	defer cancel()
+	set, err := h.watcher.Wait(ctx, strings.TrimSpace(service.Value(params.ServiceType)), service.Value(params.Version))
+	if err != nil {
+		return fmt.Errorf("watchInstances failed to wait for instances, err: %w", err)
+	}
+
+	return ectx.JSON(http.StatusOK, toInstancesWatchResponse(set))
+}
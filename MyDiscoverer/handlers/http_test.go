@@ -41,7 +41,7 @@ func TestHTTPServer_RegisterInstance(t *testing.T) {
 				WriteValueFunc: func(ctx context.Context, key string, item domain.Instance, ttlMs int) error {
 					assert.Equal(t, "inst-1", key)
 					assert.Equal(t, "inst-1", item.InstanceID)
-					assert.Equal(t, "127.0.0.1", item.Ipv4)
+					assert.Equal(t, "127.0.0.1", item.Address)
 					assert.Equal(t, 9000, item.Port)
 					assert.Equal(t, 300000, ttlMs)
 					return nil
@@ -64,6 +64,25 @@ func TestHTTPServer_RegisterInstance(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			emptyBody:      false,
 		},
+		{
+			name: "ok ipv6 address",
+			body: `{"instance_id":"inst-1","service_type":"grpc","address":"[fd00::10]","port":9000,"timestamp":"2026-02-19T12:00:00Z","ttl_ms":300000}`,
+			cache: &mock.CacheMock[domain.Instance]{
+				WriteValueFunc: func(ctx context.Context, key string, item domain.Instance, ttlMs int) error {
+					assert.Equal(t, "fd00::10", item.Address)
+					return nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			emptyBody:      true,
+		},
+		{
+			name:           "400 invalid address",
+			body:           `{"instance_id":"inst-1","service_type":"grpc","address":"10.0.0.1:9000","port":9000,"timestamp":"2026-02-19T12:00:00Z","ttl_ms":300000}`,
+			cache:          &mock.CacheMock[domain.Instance]{},
+			expectedStatus: http.StatusBadRequest,
+			emptyBody:      false,
+		},
 		{
 			name: "500 WriteValue error",
 			body: validBody,
@@ -79,7 +98,7 @@ func TestHTTPServer_RegisterInstance(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			e := echo.New()
-			registerHandlers(e, NewHTTPServer(tt.cache, log.NewNopLogger()))
+			registerHandlers(e, NewHTTPServer(tt.cache, &mock.InstanceWatcherMock{}, log.NewNopLogger()))
 			req := httptest.NewRequest(http.MethodPost, "/v1/register", strings.NewReader(tt.body))
 			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
@@ -137,7 +156,7 @@ func TestHTTPServer_UnregisterInstance(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			e := echo.New()
-			registerHandlers(e, NewHTTPServer(tt.cache, log.NewNopLogger()))
+			registerHandlers(e, NewHTTPServer(tt.cache, &mock.InstanceWatcherMock{}, log.NewNopLogger()))
 			req := httptest.NewRequest(http.MethodPost, "/v1/unregister/"+tt.instanceId, nil)
 			rec := httptest.NewRecorder()
 			e.ServeHTTP(rec, req)
@@ -173,7 +192,7 @@ func TestHTTPServer_GetInstances(t *testing.T) {
 				ListAllValuesFunc: func(ctx context.Context) ([]domain.Instance, error) {
 					return []domain.Instance{{
 						InstanceID: "inst-1",
-						Ipv4:       "10.0.0.1",
+						Address:    "10.0.0.1",
 						Port:       8080,
 						Timestamp:  time.Now(),
 					}}, nil
@@ -214,7 +233,7 @@ func TestHTTPServer_GetInstances(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			e := echo.New()
-			registerHandlers(e, NewHTTPServer(tt.cache, log.NewNopLogger()))
+			registerHandlers(e, NewHTTPServer(tt.cache, &mock.InstanceWatcherMock{}, log.NewNopLogger()))
 			req := httptest.NewRequest(http.MethodGet, "/v1/instances", nil)
 			rec := httptest.NewRecorder()
 			e.ServeHTTP(rec, req)
@@ -228,3 +247,178 @@ func TestHTTPServer_GetInstances(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPServer_NotifiesWatcher(t *testing.T) {
+	watcher := &mock.InstanceWatcherMock{NotifyFunc: func() {}}
+	cache := &mock.CacheMock[domain.Instance]{
+		WriteValueFunc:  func(ctx context.Context, key string, item domain.Instance, ttlMs int) error { return nil },
+		DeleteValueFunc: func(ctx context.Context, key string) error { return nil },
+	}
+	e := echo.New()
+	registerHandlers(e, NewHTTPServer(cache, watcher, log.NewNopLogger()))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/register", strings.NewReader(`{"instance_id":"inst-1","service_type":"grpc","ipv4":"127.0.0.1","port":9000,"timestamp":"2026-02-19T12:00:00Z","ttl_ms":300000}`))
+	req.Header.Set("Content-Type", "application/json")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/unregister/inst-1", nil))
+	assert.Len(t, watcher.NotifyCalls(), 2)
+}
+
+func TestHTTPServer_WatchInstances(t *testing.T) {
+	set := domain.InstanceSet{
+		Version:   "v2",
+		Instances: []domain.Instance{{InstanceID: "inst-1", Address: "10.0.0.1", Port: 8080}},
+	}
+	tests := []struct {
+		name            string
+		query           string
+		waitErr         error
+		expectedStatus  int
+		wantServiceType string
+		wantVersion     string
+		wantTimeout     time.Duration
+	}{
+		{name: "ok default timeout", query: "", expectedStatus: http.StatusOK, wantTimeout: 30 * time.Second},
+		{name: "ok with version", query: "?version=v1&timeout_ms=5000", expectedStatus: http.StatusOK, wantVersion: "v1", wantTimeout: 5 * time.Second},
+		{name: "ok with service_type", query: "?service_type=orders&version=v1", expectedStatus: http.StatusOK, wantServiceType: "orders", wantVersion: "v1", wantTimeout: 30 * time.Second},
+		{name: "400 timeout_ms zero", query: "?timeout_ms=0", expectedStatus: http.StatusBadRequest},
+		{name: "400 timeout_ms too large", query: "?timeout_ms=60001", expectedStatus: http.StatusBadRequest},
+		{name: "400 timeout_ms not a number", query: "?timeout_ms=soon", expectedStatus: http.StatusBadRequest},
+		{name: "500 Wait error", query: "", waitErr: service.NewInternalServerError("instance set is not loaded yet", nil), expectedStatus: http.StatusInternalServerError, wantTimeout: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := &mock.InstanceWatcherMock{
+				WaitFunc: func(ctx context.Context, serviceType, version string) (domain.InstanceSet, error) {
+					assert.Equal(t, tt.wantServiceType, serviceType)
+					assert.Equal(t, tt.wantVersion, version)
+					deadline, ok := ctx.Deadline()
+					require.True(t, ok)
+					assert.InDelta(t, tt.wantTimeout, time.Until(deadline), float64(time.Second))
+					if tt.waitErr != nil {
+						return domain.InstanceSet{}, tt.waitErr
+					}
+					return set, nil
+				},
+			}
+			e := echo.New()
+			registerHandlers(e, NewHTTPServer(&mock.CacheMock[domain.Instance]{}, watcher, log.NewNopLogger()))
+			req := httptest.NewRequest(http.MethodGet, "/v1/instances/watch"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var resp InstancesWatchResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				assert.Equal(t, "v2", resp.Version)
+				require.Len(t, resp.Instances, 1)
+				assert.Equal(t, "inst-1", resp.Instances[0].InstanceId)
+			} else if tt.expectedStatus == http.StatusBadRequest {
+				assert.Empty(t, watcher.WaitCalls(), "invalid request does not wait")
+			}
+		})
+	}
+}
+
+func TestHTTPServer_GetInstancesByServiceType(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		listErr        error
+		expectedStatus int
+		wantIndex      string
+	}{
+		{name: "ok", query: "?service_type=orders", expectedStatus: http.StatusOK, wantIndex: "orders"},
+		{name: "blank service_type lists all", query: "?service_type=%20", expectedStatus: http.StatusOK},
+		{name: "404 no instances of the type", query: "?service_type=orders", listErr: service.NewEntityNotFoundError("Entity not found", nil), expectedStatus: http.StatusNotFound, wantIndex: "orders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &mock.CacheMock[domain.Instance]{
+				ListAllValuesFunc: func(ctx context.Context) ([]domain.Instance, error) {
+					return []domain.Instance{{InstanceID: "inst-1"}, {InstanceID: "inst-2"}}, nil
+				},
+				ListValuesByIndexFunc: func(ctx context.Context, index string) ([]domain.Instance, error) {
+					assert.Equal(t, tt.wantIndex, index)
+					if tt.listErr != nil {
+						return nil, tt.listErr
+					}
+					return []domain.Instance{{InstanceID: "inst-1", ServiceType: "orders"}}, nil
+				},
+			}
+			e := echo.New()
+			registerHandlers(e, NewHTTPServer(cache, &mock.InstanceWatcherMock{}, log.NewNopLogger()))
+			req := httptest.NewRequest(http.MethodGet, "/v1/instances"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.wantIndex == "" {
+				assert.Empty(t, cache.ListValuesByIndexCalls())
+				assert.Len(t, cache.ListAllValuesCalls(), 1)
+			} else {
+				assert.Empty(t, cache.ListAllValuesCalls())
+				require.Len(t, cache.ListValuesByIndexCalls(), 1)
+			}
+		})
+	}
+}
+
+func TestHTTPServer_GetInstancesPage(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		next           string
+		listErr        error
+		expectedStatus int
+		wantIndex      string
+		wantAfter      string
+		wantLimit      int
+		wantNextCursor string
+	}{
+		{name: "first page with default limit", query: "?cursor=", expectedStatus: http.StatusOK, wantLimit: 100},
+		{name: "next page", query: "?limit=2&cursor=" + toCursor("inst-2"), next: "inst-4", expectedStatus: http.StatusOK, wantAfter: "inst-2", wantLimit: 2, wantNextCursor: toCursor("inst-4")},
+		{name: "service_type", query: "?service_type=orders&limit=10", expectedStatus: http.StatusOK, wantIndex: "orders", wantLimit: 10},
+		{name: "404 empty last page", query: "?limit=10", listErr: service.NewEntityNotFoundError("Entity not found", nil), expectedStatus: http.StatusNotFound, wantLimit: 10},
+		{name: "400 limit zero", query: "?limit=0", expectedStatus: http.StatusBadRequest},
+		{name: "400 limit too large", query: "?limit=1001", expectedStatus: http.StatusBadRequest},
+		{name: "400 invalid cursor", query: "?cursor=%21%21", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &mock.CacheMock[domain.Instance]{
+				ListValuesPageFunc: func(ctx context.Context, index, after string, limit int) ([]domain.Instance, string, error) {
+					assert.Equal(t, tt.wantIndex, index)
+					assert.Equal(t, tt.wantAfter, after)
+					assert.Equal(t, tt.wantLimit, limit)
+					if tt.listErr != nil {
+						return nil, "", tt.listErr
+					}
+					return []domain.Instance{{InstanceID: "inst-3", Address: "127.0.0.1", Port: 9000}}, tt.next, nil
+				},
+			}
+			e := echo.New()
+			registerHandlers(e, NewHTTPServer(cache, &mock.InstanceWatcherMock{}, log.NewNopLogger()))
+			req := httptest.NewRequest(http.MethodGet, "/v1/instances"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Empty(t, cache.ListAllValuesCalls())
+			assert.Empty(t, cache.ListValuesByIndexCalls())
+			if tt.wantLimit == 0 {
+				assert.Empty(t, cache.ListValuesPageCalls())
+				return
+			}
+			require.Len(t, cache.ListValuesPageCalls(), 1)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp InstancesResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Len(t, resp.Instances, 1)
+			assert.Equal(t, tt.wantNextCursor, service.Value(resp.NextCursor))
+		})
+	}
+}
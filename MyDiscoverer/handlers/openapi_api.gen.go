@@ -25,7 +25,10 @@ import (
 type ServerInterface interface {
 	// List registered instances
 	// (GET /v1/instances)
-	GetInstances(ctx echo.Context) error
+	GetInstances(ctx echo.Context, params GetInstancesParams) error
+	// Watch registered instances
+	// (GET /v1/instances/watch)
+	WatchInstances(ctx echo.Context, params WatchInstancesParams) error
 	// Register or update instance
 	// (POST /v1/register)
 	RegisterInstance(ctx echo.Context) error
@@ -43,8 +46,63 @@ type ServerInterfaceWrapper struct {
 func (w *ServerInterfaceWrapper) GetInstances(ctx echo.Context) error {
 	var err error
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetInstancesParams
+	// ------------- Optional query parameter "service_type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "service_type", ctx.QueryParams(), &params.ServiceType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter service_type: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", ctx.QueryParams(), &params.Cursor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter cursor: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetInstances(ctx, params)
+	return err
+}
+
+// WatchInstances converts echo context to params.
+func (w *ServerInterfaceWrapper) WatchInstances(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params WatchInstancesParams
+	// ------------- Optional query parameter "service_type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "service_type", ctx.QueryParams(), &params.ServiceType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter service_type: %s", err))
+	}
+
+	// ------------- Optional query parameter "version" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "version", ctx.QueryParams(), &params.Version)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter version: %s", err))
+	}
+
+	// ------------- Optional query parameter "timeout_ms" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "timeout_ms", ctx.QueryParams(), &params.TimeoutMs)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter timeout_ms: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetInstances(ctx)
+	err = w.Handler.WatchInstances(ctx, params)
 	return err
 }
 
@@ -102,6 +160,7 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 	}
 
 	router.GET(baseURL+"/v1/instances", wrapper.GetInstances)
+	router.GET(baseURL+"/v1/instances/watch", wrapper.WatchInstances)
 	router.POST(baseURL+"/v1/register", wrapper.RegisterInstance)
 	router.POST(baseURL+"/v1/unregister/:instance_id", wrapper.UnregisterInstance)
 
@@ -112,6 +171,7 @@ type ErrorResponseJSONResponse struct {
 }
 
 type GetInstancesRequestObject struct {
+	Params GetInstancesParams
 }
 
 type GetInstancesResponseObject interface {
@@ -127,7 +187,18 @@ func (response GetInstances200JSONResponse) VisitGetInstancesResponse(w http.Res
 	return json.NewEncoder(w).Encode(response)
 }
 
-type GetInstances500JSONResponse struct{ ErrorResponseJSONResponse }
+type GetInstances400JSONResponse struct{ ErrorResponseJSONResponse }
+
+func (response GetInstances400JSONResponse) VisitGetInstancesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetInstances500JSONResponse struct {
+	Error Err `json:"error"`
+}
 
 func (response GetInstances500JSONResponse) VisitGetInstancesResponse(w http.ResponseWriter) error {
 	w.Header().Set("Content-Type", "application/json")
@@ -136,6 +207,43 @@ func (response GetInstances500JSONResponse) VisitGetInstancesResponse(w http.Res
 	return json.NewEncoder(w).Encode(response)
 }
 
+type WatchInstancesRequestObject struct {
+	Params WatchInstancesParams
+}
+
+type WatchInstancesResponseObject interface {
+	VisitWatchInstancesResponse(w http.ResponseWriter) error
+}
+
+type WatchInstances200JSONResponse InstancesWatchResponse
+
+func (response WatchInstances200JSONResponse) VisitWatchInstancesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type WatchInstances400JSONResponse struct{ ErrorResponseJSONResponse }
+
+func (response WatchInstances400JSONResponse) VisitWatchInstancesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type WatchInstances500JSONResponse struct {
+	Error Err `json:"error"`
+}
+
+func (response WatchInstances500JSONResponse) VisitWatchInstancesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type RegisterInstanceRequestObject struct {
 	Body *RegisterInstanceJSONRequestBody
 }
@@ -202,6 +310,9 @@ type StrictServerInterface interface {
 	// List registered instances
 	// (GET /v1/instances)
 	GetInstances(ctx context.Context, request GetInstancesRequestObject) (GetInstancesResponseObject, error)
+	// Watch registered instances
+	// (GET /v1/instances/watch)
+	WatchInstances(ctx context.Context, request WatchInstancesRequestObject) (WatchInstancesResponseObject, error)
 	// Register or update instance
 	// (POST /v1/register)
 	RegisterInstance(ctx context.Context, request RegisterInstanceRequestObject) (RegisterInstanceResponseObject, error)
@@ -223,9 +334,11 @@ type strictHandler struct {
 }
 
 // GetInstances operation middleware
-func (sh *strictHandler) GetInstances(ctx echo.Context) error {
+func (sh *strictHandler) GetInstances(ctx echo.Context, params GetInstancesParams) error {
 	var request GetInstancesRequestObject
 
+	request.Params = params
+
 	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
 		return sh.ssi.GetInstances(ctx.Request().Context(), request.(GetInstancesRequestObject))
 	}
@@ -245,6 +358,31 @@ func (sh *strictHandler) GetInstances(ctx echo.Context) error {
 	return nil
 }
 
+// WatchInstances operation middleware
+func (sh *strictHandler) WatchInstances(ctx echo.Context, params WatchInstancesParams) error {
+	var request WatchInstancesRequestObject
+
+	request.Params = params
+
+	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.WatchInstances(ctx.Request().Context(), request.(WatchInstancesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "WatchInstances")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		return err
+	} else if validResponse, ok := response.(WatchInstancesResponseObject); ok {
+		return validResponse.VisitWatchInstancesResponse(ctx.Response())
+	} else if response != nil {
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+	return nil
+}
+
 // RegisterInstance operation middleware
 func (sh *strictHandler) RegisterInstance(ctx echo.Context) error {
 	var request RegisterInstanceRequestObject
@@ -302,23 +440,40 @@ func (sh *strictHandler) UnregisterInstance(ctx echo.Context, instanceId string)
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/8RW3W7jNhN9FWK+7yIBVMvbpsBCd9tusDWQIkbWe7UxAkYa29xKJDOkVBiB3r2gRP1Z",
-	"9DZZBOidRHIOZ+bMnOEzpKrQSqK0BpJnIDRaSYPNzzWRoju/4hZSJS1K6z651rlIuRVKxt+Mkm7NpAcs",
-	"uPvSpDSSFS0OOhz38X/CHSTwv3i4NG6NTHxNBHUdAeFTKQgzSL56w20E9qgRElCP3zC1ULtzGZqUhHYO",
-	"QAIfJGsOswM3TKVpSQ7CnfP4Pp65c6nKmtimeE3orNnrbzeWhNyDu5tbPre5bT547j3R/JgrnkEEssxz",
-	"/pgjJJZKnEUTQYHG8P1ZN7rtmSd1BFZYB9yevG0RAzespLFcpriSOzW/xq1S0ZDJdooYZ0bIfY5MeDOI",
-	"TrLWbTyIbA73RYqncjBmIkNpxU4ghZIpdHUVcGldXTGeZYTGhKy0Iju3WrvV/rSQFvc4L6ux8/5+D7j9",
-	"TurMuBOm13Y77G9hDywXxjK16+M3Z7Nn5lA33phwL4xFwmyCIywW5t9aaUJ23YfEifjxbDJMMPg778Yd",
-	"PpVobKh0PMmtw9QWEbXH2aPKjv9B7XTGP1ZEvbkOV1MEBqkSKT60O6f2n9td1uwGbrWiQGN5oeemm34r",
-	"grYlIXFqgz85oyCYzR+KQB1tNjdMSFaIPBcGUyUz89rGmEQ57ZNxEL0PYZ0WZxQnVDb+xsW97OqOVYKz",
-	"9e3nDYurd3HXFNG9LCUFjwzr8fMonDq6l01f9uXemH26bq361cW9hEFU/zx+FCZVFRIS+7BeQQQVkmlD",
-	"eLdYOgKURsm1gAR+WSwXS5chbg8NIxNkt7BHGxIPW5I0zB6wl45Q9y+guaxN1SqDBD6hXY20YTK7f14u",
-	"XzWxX6IngwIGJvDNTPXqCH5tvQiB997G02dGM7PLouB07FDPaKHle+OqdsjB1hmPC6UZ98oEs+6rRxEr",
-	"tWsxxuUgO42QO0b2okLJNCdeoEUKsNAhrYZZ6eXvN6d+b0XCqRLX0+Z1L4s6XAMn6lSmKRqzK/Np711I",
-	"1cj1pePt6gd4ewO2A6SMXiAd33cjtwfKzzX++RL4MkjImPrHIxvZz/kezEaMDwUCyddXzTThTjjJcA9F",
-	"XrTyPJbhKcfRqFxOX4Pbl/DfK++QMMzYhSImlWU7Vcrs8k3IHKX3BST6qRpO4ESH/9hs1t2kYBcZ7niZ",
-	"W/ZRpX8hsd+dlwbZQRl7CRGUlEMCB2t1EsfFMethkvfL906/Z+8vlfKcZVhhrnThuvYEJHcHHLxH2PYx",
-	"vWzGcZn1qffh98RPkhLw7ftvQw8yyGG9rf8JAAD//242VW3gDQAA",
+	"H4sIAAAAAAAC/8xY3Y4btxV+lQO2FytgIsmNawTaK8c2kgWcerHe1BfRYkXNHGkYc8gxyZGsGgL6EH3C",
+	"PklxSM6fZrReG0mTG0HDn8Pzx+98h59YqotSK1TOssUnVnLDC3Ro/NdbNDuR4u2hRPrM0KZGlE5oxRbs",
+	"Bl1lFGglDyCUdVylaMHgVliHBjPYC5eDy4UFG+Tcu0OJl4BF6Q6gDRTCWqG2YLwkC1zKVhJLmKBjPlRo",
+	"DixhihfIFqwriiXMpjkWnJTzAwtmnRFqy47HY8IM2lIri96WV8ZocxNHaCDVyqFy9JeXpRQpJ8Nmv1qy",
+	"7lNHcml0icaJIAdJDv35q8ENW7C/zFoPzsImO3tlDAsafKiEwYwtfokb75JaU73+FVMXNO179rkCvxhy",
+	"bkGnaWVIBK2L8qM9Q+VSnY1EypsOfi459VPCMu74cM8b/4fLqEnJD1LzjOJQScnXEtnCmQoH1iSsQGv5",
+	"9qwa9fRAk2PCnHAS65VvgsSRE65ijlypjR4eQ6Om8MGEjTbAgZJMYpNaLDnxGs8yg9YORT0PE+A0ZIJL",
+	"+O+//wNX17unCf0+gwvKcF05WBuevkdnJ+DPe/mPt5Br6yhlQSiQeo8GUm5H/V+rdS+yoQY/K/GhalUH",
+	"kaFyYiPQjIoqd0+DjNJgyh1mdZT6Um9zhGg07HNUIBwIC1x565qpC10I5zAD7XI0e2FxcgnvsXTer1pm",
+	"ZJUUqJxNoLKNzDHVJF+jrL0tQm5d96IwTMyTsEYXBEmwFTtUwF1EHBMC3qjszVJa4WQsg0pt3NDZ1zTa",
+	"rBbK4RaH97gbr4S1JnuZdw+kq+2izymWhpmAmVJYB3rTw8J+xrYzA1Gv4+YOEHflCIeF/Rx89S7YsTGJ",
+	"G8MP9K3wo7tPK2O1GSrwwo+TCi5HoKVQ8i1eNnCvlZ+R3IYZ4CrGyw+T/sKC0mE2G0WKsYDYh73/jrs0",
+	"Px+Ceh1YdHXpQtihsZRXTkPJAxI0Vu1JIKRcyj9bfKLWY6jOCU5qq2KMRNf0izTnaosBGHCHxi+hmTgx",
+	"+WxA6uOTz4TmJrrgBj9UaN0DMendcROWw1pnh8djeSOLj4D6CfIlceBZC4U1xIOOhXEE6y+h9gJUStI2",
+	"AmTwBMixhOFHXpS+wG2y+XyxeDL/k9SDxjddJzwK6qfwji7uWrscuAl54nI8QFFRhDAkDy+aDb9LbXhu",
+	"1sIZbloiWleJC5xup/AvrTCpsz6BdSVklsAexTZ3EzIng/UBttzhnh/A6Mqhzw+LElMHtlpbdPbxhaTx",
+	"ZzleUZI+jR3sj7wbIskdWO9EgdbxohxuvW2mEhZ4EFsQxcNvaNOoMCfvi5ELc3v7GoSCQkgpLKZaZfZL",
+	"i+MJWa+90VExHj7OisUZfjcGCvGo6VLVqAI7weH6zdtbmO2ezGq4TZaqUmZ0STs++9Sx45gsVShKTZND",
+	"2354FXY1o8lShYowgNTR5bOw+EJqtf2m1FJOpkv1vW7Kf4nBMgs8Tekidp1JyWk6/Vf3SEuwrhV2XDI4",
+	"vGm5qMJa0CbzhWjdXqB7EWuyFIVwhHWh3oOI1Gu6VKxl7D8dXgqb6h0aNPD8+op1ihB7Mp1ToukSFS8F",
+	"W7Bvp/MpYV/JXe4zr6ccDWzRnes4bUsTzpTRpAFpeaj9oy2e+MXfrim8ixx+5Q1dERlZqlUwduWP2uda",
+	"trwkeA6zsBNQuBxN0vE+N9gsogsUXbwB7qDQtjlpqS4y3PBKOngyn0/OBOESVh22tfJyvEjShbRbdWYa",
+	"aqJVYFXCLtU52jWFq7GuXRvAj6UvYmt0e8Tagkx7TmZzsfEYH7oCG/KgSderjC3YD+iuuty186jwyziz",
+	"aZfMuo8Ox+Q0B37iH0VRFaCqYo2mx5LJ2aRXGdrLsdcD7/nes0EMAVs8mc8TVgTp/os+hYqfY8B3qlon",
+	"TnUwSoM7oSsbGfDg5cM6bpyFjdGFX78RpgM0Z4wIRzz4+HF38vjxt/n8i548HkM+23Zm5Anj9aCFOSbs",
+	"6Xx+Tnij7az/TnNM2N+/YhdV2KoouDnUupyh245vKSnbLoHd0eYRpD6LSq9rAPdUqWbPI9R6Cl0A21RS",
+	"tr2GcLZh5Zzub4oefpdqFYdXdOGbBMrEZoOmkzn+kUi5Wshl27PDnpP0SjkhT7n8Ul00hRE6dVEboPLv",
+	"geDgme6Kqrau3H1hV74VQusRxnRM8u1DQ/iiJksVW3GXU8aHg7PJFF4EOhn7qrwDmp2mq0E0arPGoMZ3",
+	"db8X2PwzKtJ4ubnPdebVd7rrhjoU5I4YyzM3uW2Uzl/lgVI/6j0QaSD3UGjjI1dwbDJC2cZObqM5jobf",
+	"zuc9PHw2/zwg/l9Ap9/FjyBPr4/3NbC9Wn8gCHm9vwKF6h10eqntKCtqL21VEtUHrhrx7VNGeDBrc386",
+	"uEu1pKu2/sRe+3tqtX+rWJ62/cd+E0EN6nE8lU66pCpN0dpNJU/eAJX2bwOTPzDcI0HpVPU63jcdtduQ",
+	"n+tDzqfAz82OXuj7THIY73ZbJ+In+PkFzw8eZ4jStzDTbwf7Mf4K/nLmprcOwwwutPE8daMrlU1+k2B2",
+	"3PuIIMbuftyBvT7px9vb66YbaZqBlzp9jwZekJYW/eMSPblVRrIFy50rF7NZccgaMYvv5t/NR4rXa51y",
+	"CRnuUOqyQOVOhUhaQOKjhLvGpse13ASuleoOtYHvOWVEt4cfQaOQFg6Pd8f/DQA0bBqeqhwAAA==",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -21,11 +21,18 @@ type Err struct {
 
 // InstanceInfo Information for a single instance
 type InstanceInfo struct {
+	// Address Address to dial — IPv4, IPv6 (without brackets) or a DNS hostname in lower case
+	Address string `json:"address"`
+
 	// InstanceId Unique instance identifier
 	InstanceId string `json:"instance_id"`
 
-	// Ipv4 IPv4 address
-	Ipv4 string `json:"ipv4"`
+	// Ipv4 The address when it is an IPv4 address (omitted otherwise); kept for older clients, use address
+	// Deprecated:
+	Ipv4 *string `json:"ipv4,omitempty"`
+
+	// Labels Instance labels given at registration (omitted when none)
+	Labels *map[string]string `json:"labels,omitempty"`
 
 	// Port Port
 	Port int `json:"port"`
@@ -35,15 +42,34 @@ type InstanceInfo struct {
 type InstancesResponse struct {
 	// Instances List of registered instances
 	Instances []InstanceInfo `json:"instances"`
+
+	// NextCursor Cursor of the next page; missing on the last page and when the list is not paged
+	NextCursor *string `json:"next_cursor,omitempty"`
+}
+
+// InstancesWatchResponse Instance set with the version to pass to the next watch call
+type InstancesWatchResponse struct {
+	// Instances List of registered instances
+	Instances []InstanceInfo `json:"instances"`
+
+	// Version Opaque version of the instance set (changes whenever the set changes)
+	Version string `json:"version"`
 }
 
 // RegisterRequest Instance registration request body
 type RegisterRequest struct {
+	// Address Instance address to dial — an IPv4 address, an IPv6 address (brackets optional) or a DNS hostname; required unless ipv4 is set
+	Address *string `json:"address,omitempty"`
+
 	// InstanceId Unique instance identifier
 	InstanceId string `json:"instance_id"`
 
-	// Ipv4 Instance IPv4 address
-	Ipv4 string `json:"ipv4"`
+	// Ipv4 Instance IPv4 address; kept for older clients, use address. When both are set they must be the same address
+	// Deprecated:
+	Ipv4 *string `json:"ipv4,omitempty"`
+
+	// Labels Arbitrary instance labels (e.g. zone, version, build, weight) used by gateway routes to select subsets
+	Labels *map[string]string `json:"labels,omitempty"`
 
 	// Port Instance port
 	Port int `json:"port"`
@@ -58,10 +84,37 @@ type RegisterRequest struct {
 	TtlMs int `json:"ttl_ms"`
 }
 
+// ServiceType defines model for ServiceType.
+type ServiceType = string
+
 // ErrorResponse defines model for ErrorResponse.
 type ErrorResponse struct {
 	Error Err `json:"error"`
 }
 
+// GetInstancesParams defines parameters for GetInstances.
+type GetInstancesParams struct {
+	// ServiceType Return only instances registered with this service_type; empty or missing returns all instances
+	ServiceType *ServiceType `form:"service_type,omitempty" json:"service_type,omitempty"`
+
+	// Limit Maximum number of instances in the page
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor next_cursor of the previous page; empty or missing starts from the first instance
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+}
+
+// WatchInstancesParams defines parameters for WatchInstances.
+type WatchInstancesParams struct {
+	// ServiceType Return only instances registered with this service_type; empty or missing returns all instances
+	ServiceType *ServiceType `form:"service_type,omitempty" json:"service_type,omitempty"`
+
+	// Version Version from the previous response; empty returns the current set at once
+	Version *string `form:"version,omitempty" json:"version,omitempty"`
+
+	// TimeoutMs How long to wait for a change, in milliseconds
+	TimeoutMs *int `form:"timeout_ms,omitempty" json:"timeout_ms,omitempty"`
+}
+
 // RegisterInstanceJSONRequestBody defines body for RegisterInstance for application/json ContentType.
 type RegisterInstanceJSONRequestBody = RegisterRequest
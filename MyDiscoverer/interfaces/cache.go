@@ -12,13 +12,29 @@ type Cache[T any] interface {
 	// 2) internal_server_error when marshalling fails or when the storage write fails.
 	WriteValue(ctx context.Context, key string, item T, ttlMs int) error
 
-	// ListAllValues returns all values in the cache (lists keys then fetches values for them).
+	// ListAllValues returns all values in the cache, ordered by key.
 	// Returns:
 	// 1) (items, nil) when there is at least one value;
 	// 2) (nil, entity_not_found) when there are no keys or no values could be read/unmarshalled;
 	// 3) (nil, internal_server_error) when listing keys fails (e.g. Redis error).
 	ListAllValues(ctx context.Context) ([]T, error)
 
+	// ListValuesByIndex returns the values whose index value (e.g. service type) equals index.
+	// Returns:
+	// 1) (items, nil) when there is at least one such value;
+	// 2) (nil, entity_not_found) when there is none;
+	// 3) (nil, internal_server_error) when the cache has no index or reading the index fails.
+	ListValuesByIndex(ctx context.Context, index string) ([]T, error)
+
+	// ListValuesPage returns up to limit values whose key follows after (empty — from the first key), ordered by key;
+	// only those of index when it is not empty.
+	// Returns:
+	// 1) (items, next, nil) — next is the after of the following page, empty on the last page;
+	// 2) (nil, "", entity_not_found) when the page is the last one and empty;
+	// 3) (nil, "", internal_server_error) when limit is not positive, index is set on a cache without index, or
+	// reading fails.
+	ListValuesPage(ctx context.Context, index, after string, limit int) ([]T, string, error)
+
 	// DeleteValue deletes the value for the given key from the cache.
 	// Returns:
 	// 1) nil on success;
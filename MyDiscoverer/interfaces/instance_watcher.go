@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"mydiscoverer/domain"
+)
+
+// InstanceWatcher keeps the current instance set and wakes up long-poll watchers when it changes.
+//
+//go:generate moq -stub -out mock/instance_watcher.go -pkg mock . InstanceWatcher
+type InstanceWatcher interface {
+	// Wait returns the current set — only the instances of serviceType when it is not empty — as soon as its version
+	// differs from version (at once for an empty or outdated version), or when ctx ends.
+	// Returns:
+	// 1) (set, nil) — changed set, or the unchanged set when ctx ended first;
+	// 2) (zero, internal_server_error) when the set has not been loaded from the cache yet and ctx ended.
+	Wait(ctx context.Context, serviceType, version string) (domain.InstanceSet, error)
+
+	// Notify asks for an immediate reload of the set (called after register and unregister).
+	Notify()
+}
@@ -25,6 +25,12 @@ var _ interfaces.Cache[any] = &CacheMock[any]{}
 //			ListAllValuesFunc: func(ctx context.Context) ([]T, error) {
 //				panic("mock out the ListAllValues method")
 //			},
+//			ListValuesByIndexFunc: func(ctx context.Context, index string) ([]T, error) {
+//				panic("mock out the ListValuesByIndex method")
+//			},
+//			ListValuesPageFunc: func(ctx context.Context, index string, after string, limit int) ([]T, string, error) {
+//				panic("mock out the ListValuesPage method")
+//			},
 //			WriteValueFunc: func(ctx context.Context, key string, item T, ttlMs int) error {
 //				panic("mock out the WriteValue method")
 //			},
@@ -41,6 +47,12 @@ type CacheMock[T any] struct {
 	// ListAllValuesFunc mocks the ListAllValues method.
 	ListAllValuesFunc func(ctx context.Context) ([]T, error)
 
+	// ListValuesByIndexFunc mocks the ListValuesByIndex method.
+	ListValuesByIndexFunc func(ctx context.Context, index string) ([]T, error)
+
+	// ListValuesPageFunc mocks the ListValuesPage method.
+	ListValuesPageFunc func(ctx context.Context, index string, after string, limit int) ([]T, string, error)
+
 	// WriteValueFunc mocks the WriteValue method.
 	WriteValueFunc func(ctx context.Context, key string, item T, ttlMs int) error
 
@@ -58,6 +70,24 @@ type CacheMock[T any] struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// ListValuesByIndex holds details about calls to the ListValuesByIndex method.
+		ListValuesByIndex []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Index is the index argument value.
+			Index string
+		}
+		// ListValuesPage holds details about calls to the ListValuesPage method.
+		ListValuesPage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Index is the index argument value.
+			Index string
+			// After is the after argument value.
+			After string
+			// Limit is the limit argument value.
+			Limit int
+		}
 		// WriteValue holds details about calls to the WriteValue method.
 		WriteValue []struct {
 			// Ctx is the ctx argument value.
@@ -70,9 +100,11 @@ type CacheMock[T any] struct {
 			TtlMs int
 		}
 	}
-	lockDeleteValue   sync.RWMutex
-	lockListAllValues sync.RWMutex
-	lockWriteValue    sync.RWMutex
+	lockDeleteValue       sync.RWMutex
+	lockListAllValues     sync.RWMutex
+	lockListValuesByIndex sync.RWMutex
+	lockListValuesPage    sync.RWMutex
+	lockWriteValue        sync.RWMutex
 }
 
 // DeleteValue calls DeleteValueFunc.
@@ -150,6 +182,95 @@ func (mock *CacheMock[T]) ListAllValuesCalls() []struct {
 	return calls
 }
 
+// ListValuesByIndex calls ListValuesByIndexFunc.
+func (mock *CacheMock[T]) ListValuesByIndex(ctx context.Context, index string) ([]T, error) {
+	callInfo := struct {
+		Ctx   context.Context
+		Index string
+	}{
+		Ctx:   ctx,
+		Index: index,
+	}
+	mock.lockListValuesByIndex.Lock()
+	mock.calls.ListValuesByIndex = append(mock.calls.ListValuesByIndex, callInfo)
+	mock.lockListValuesByIndex.Unlock()
+	if mock.ListValuesByIndexFunc == nil {
+		var (
+			vsOut  []T
+			errOut error
+		)
+		return vsOut, errOut
+	}
+	return mock.ListValuesByIndexFunc(ctx, index)
+}
+
+// ListValuesByIndexCalls gets all the calls that were made to ListValuesByIndex.
+// Check the length with:
+//
+//	len(mockedCache.ListValuesByIndexCalls())
+func (mock *CacheMock[T]) ListValuesByIndexCalls() []struct {
+	Ctx   context.Context
+	Index string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Index string
+	}
+	mock.lockListValuesByIndex.RLock()
+	calls = mock.calls.ListValuesByIndex
+	mock.lockListValuesByIndex.RUnlock()
+	return calls
+}
+
+// ListValuesPage calls ListValuesPageFunc.
+func (mock *CacheMock[T]) ListValuesPage(ctx context.Context, index string, after string, limit int) ([]T, string, error) {
+	callInfo := struct {
+		Ctx   context.Context
+		Index string
+		After string
+		Limit int
+	}{
+		Ctx:   ctx,
+		Index: index,
+		After: after,
+		Limit: limit,
+	}
+	mock.lockListValuesPage.Lock()
+	mock.calls.ListValuesPage = append(mock.calls.ListValuesPage, callInfo)
+	mock.lockListValuesPage.Unlock()
+	if mock.ListValuesPageFunc == nil {
+		var (
+			vsOut  []T
+			sOut   string
+			errOut error
+		)
+		return vsOut, sOut, errOut
+	}
+	return mock.ListValuesPageFunc(ctx, index, after, limit)
+}
+
+// ListValuesPageCalls gets all the calls that were made to ListValuesPage.
+// Check the length with:
+//
+//	len(mockedCache.ListValuesPageCalls())
+func (mock *CacheMock[T]) ListValuesPageCalls() []struct {
+	Ctx   context.Context
+	Index string
+	After string
+	Limit int
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Index string
+		After string
+		Limit int
+	}
+	mock.lockListValuesPage.RLock()
+	calls = mock.calls.ListValuesPage
+	mock.lockListValuesPage.RUnlock()
+	return calls
+}
+
 // WriteValue calls WriteValueFunc.
 func (mock *CacheMock[T]) WriteValue(ctx context.Context, key string, item T, ttlMs int) error {
 	callInfo := struct {
@@ -0,0 +1,130 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"mydiscoverer/domain"
+	"mydiscoverer/interfaces"
+	"sync"
+)
+
+// Ensure, that InstanceWatcherMock does implement interfaces.InstanceWatcher.
+// If this is not the case, regenerate this file with moq.
+var _ interfaces.InstanceWatcher = &InstanceWatcherMock{}
+
+// InstanceWatcherMock is a mock implementation of interfaces.InstanceWatcher.
+//
+//	func TestSomethingThatUsesInstanceWatcher(t *testing.T) {
+//
+//		// make and configure a mocked interfaces.InstanceWatcher
+//		mockedInstanceWatcher := &InstanceWatcherMock{
+//			NotifyFunc: func()  {
+//				panic("mock out the Notify method")
+//			},
+//			WaitFunc: func(ctx context.Context, serviceType string, version string) (domain.InstanceSet, error) {
+//				panic("mock out the Wait method")
+//			},
+//		}
+//
+//		// use mockedInstanceWatcher in code that requires interfaces.InstanceWatcher
+//		// and then make assertions.
+//
+//	}
+type InstanceWatcherMock struct {
+	// NotifyFunc mocks the Notify method.
+	NotifyFunc func()
+
+	// WaitFunc mocks the Wait method.
+	WaitFunc func(ctx context.Context, serviceType string, version string) (domain.InstanceSet, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Notify holds details about calls to the Notify method.
+		Notify []struct {
+		}
+		// Wait holds details about calls to the Wait method.
+		Wait []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ServiceType is the serviceType argument value.
+			ServiceType string
+			// Version is the version argument value.
+			Version string
+		}
+	}
+	lockNotify sync.RWMutex
+	lockWait   sync.RWMutex
+}
+
+// Notify calls NotifyFunc.
+func (mock *InstanceWatcherMock) Notify() {
+	callInfo := struct {
+	}{}
+	mock.lockNotify.Lock()
+	mock.calls.Notify = append(mock.calls.Notify, callInfo)
+	mock.lockNotify.Unlock()
+	if mock.NotifyFunc == nil {
+		return
+	}
+	mock.NotifyFunc()
+}
+
+// NotifyCalls gets all the calls that were made to Notify.
+// Check the length with:
+//
+//	len(mockedInstanceWatcher.NotifyCalls())
+func (mock *InstanceWatcherMock) NotifyCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockNotify.RLock()
+	calls = mock.calls.Notify
+	mock.lockNotify.RUnlock()
+	return calls
+}
+
+// Wait calls WaitFunc.
+func (mock *InstanceWatcherMock) Wait(ctx context.Context, serviceType string, version string) (domain.InstanceSet, error) {
+	callInfo := struct {
+		Ctx         context.Context
+		ServiceType string
+		Version     string
+	}{
+		Ctx:         ctx,
+		ServiceType: serviceType,
+		Version:     version,
+	}
+	mock.lockWait.Lock()
+	mock.calls.Wait = append(mock.calls.Wait, callInfo)
+	mock.lockWait.Unlock()
+	if mock.WaitFunc == nil {
+		var (
+			instanceSetOut domain.InstanceSet
+			errOut         error
+		)
+		return instanceSetOut, errOut
+	}
+	return mock.WaitFunc(ctx, serviceType, version)
+}
+
+// WaitCalls gets all the calls that were made to Wait.
+// Check the length with:
+//
+//	len(mockedInstanceWatcher.WaitCalls())
+func (mock *InstanceWatcherMock) WaitCalls() []struct {
+	Ctx         context.Context
+	ServiceType string
+	Version     string
+} {
+	var calls []struct {
+		Ctx         context.Context
+		ServiceType string
+		Version     string
+	}
+	mock.lockWait.RLock()
+	calls = mock.calls.Wait
+	mock.lockWait.RUnlock()
+	return calls
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mydiscoverer/domain"
+	"mydiscoverer/interfaces"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// instanceWatcher implements interfaces.InstanceWatcher. It reloads the instance set from the cache every
+// refreshInterval (this is how TTL expiry and changes made through other replicas are seen) and right after Notify.
+// When the version changes it closes changed, waking every waiting long-poll request.
+type instanceWatcher struct {
+	cache           interfaces.Cache[domain.Instance]
+	refreshInterval time.Duration
+	logger          log.Logger
+	notify          chan struct{}
+
+	mu      sync.Mutex
+	current domain.InstanceSet
+	loaded  bool
+	changed chan struct{}
+}
+
+// NewInstanceWatcher creates an instance watcher over cache. Run must be started for the set to be loaded.
+func NewInstanceWatcher(cache interfaces.Cache[domain.Instance], refreshInterval time.Duration, logger log.Logger) *instanceWatcher {
+	return &instanceWatcher{
+		cache:           cache,
+		refreshInterval: refreshInterval,
+		logger:          log.WithPrefix(logger, "component", "InstanceWatcher"),
+		notify:          make(chan struct{}, 1),
+		changed:         make(chan struct{}),
+	}
+}
+
+// Run loads the set at once, then on every tick of refreshInterval and after Notify, until ctx ends.
+func (w *instanceWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.refreshInterval)
+	defer ticker.Stop()
+	for {
+		w.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.notify:
+		}
+	}
+}
+
+// Notify asks Run for an immediate reload; never blocks (a pending request is enough).
+func (w *instanceWatcher) Notify() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Wait returns the current set (filtered by serviceType when not empty) once its version differs from version, or the
+// current set when ctx ends. Returns internal_server_error when ctx ends before the set was ever loaded.
+func (w *instanceWatcher) Wait(ctx context.Context, serviceType, version string) (domain.InstanceSet, error) {
+	for {
+		w.mu.Lock()
+		current, loaded, changed := w.current, w.loaded, w.changed
+		w.mu.Unlock()
+		if serviceType != "" {
+			current = filterInstanceSet(current, serviceType)
+		}
+		if loaded && current.Version != version {
+			return current, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			if !loaded {
+				return domain.InstanceSet{}, NewInternalServerError("instance set is not loaded yet", ctx.Err())
+			}
+			return current, nil
+		}
+	}
+}
+
+// refresh reads all instances; an empty registry (entity_not_found) is an empty set, other errors keep the previous
+// set. Publishes the set and wakes waiters only when the version changed.
+func (w *instanceWatcher) refresh(ctx context.Context) {
+	instances, err := w.cache.ListAllValues(ctx)
+	if err != nil {
+		if myErr := ToMyError(err); myErr == nil || myErr.Code != ErrEntityNotFound {
+			level.Warn(w.logger).Log("msg", "failed to reload instances", "err", err)
+			return
+		}
+		instances = nil
+	}
+	instances = slices.Clone(instances)
+	slices.SortFunc(instances, func(a, b domain.Instance) int { return strings.Compare(a.InstanceID, b.InstanceID) })
+	set := domain.InstanceSet{Version: instanceSetVersion(instances), Instances: instances}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.loaded && w.current.Version == set.Version {
+		return
+	}
+	w.current = set
+	w.loaded = true
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+// filterInstanceSet returns the instances of set with the given service type and their own version.
+func filterInstanceSet(set domain.InstanceSet, serviceType string) domain.InstanceSet {
+	instances := make([]domain.Instance, 0, len(set.Instances))
+	for _, i := range set.Instances {
+		if i.ServiceType == serviceType {
+			instances = append(instances, i)
+		}
+	}
+	return domain.InstanceSet{Version: instanceSetVersion(instances), Instances: instances}
+}
+
+// instanceSetVersion hashes instance_id, service_type, address, port and labels (sorted by key) of the sorted instances,
+// so heartbeat re-registrations do not change the version and every replica computes the same token.
+func instanceSetVersion(instances []domain.Instance) string {
+	h := sha256.New()
+	for _, i := range instances {
+		h.Write([]byte(i.InstanceID + "\x00" + i.ServiceType + "\x00" + i.Address + "\x00" + strconv.Itoa(i.Port)))
+		for _, k := range slices.Sorted(maps.Keys(i.Labels)) {
+			h.Write([]byte("\x00" + k + "=" + i.Labels[k]))
+		}
+		h.Write([]byte("\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mydiscoverer/domain"
+	"mydiscoverer/interfaces/mock"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceWatcher_Wait(t *testing.T) {
+	var instances atomic.Value
+	instances.Store([]domain.Instance{{InstanceID: "b", Address: "10.0.0.2", Port: 80}, {InstanceID: "a", Address: "10.0.0.1", Port: 80}})
+	cache := &mock.CacheMock[domain.Instance]{
+		ListAllValuesFunc: func(ctx context.Context) ([]domain.Instance, error) {
+			return instances.Load().([]domain.Instance), nil
+		},
+	}
+	w := NewInstanceWatcher(cache, time.Hour, log.NewNopLogger())
+
+	shortCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	_, err := w.Wait(shortCtx, "", "")
+	cancel()
+	myErr := ToMyError(err)
+	require.NotNil(t, myErr, "not loaded before Run")
+	assert.Equal(t, ErrInternalServerError, myErr.Code)
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go w.Run(ctx)
+
+	first, err := w.Wait(ctx, "", "")
+	require.NoError(t, err)
+	require.Len(t, first.Instances, 2)
+	assert.Equal(t, "a", first.Instances[0].InstanceID, "sorted by ID")
+	assert.NotEmpty(t, first.Version)
+
+	t.Run("same_version_returns_current_on_timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		got, err := w.Wait(ctx, "", first.Version)
+		require.NoError(t, err)
+		assert.Equal(t, first, got)
+	})
+	t.Run("heartbeat_does_not_change_version", func(t *testing.T) {
+		instances.Store([]domain.Instance{{InstanceID: "a", Address: "10.0.0.1", Port: 80, Timestamp: time.Now()}, {InstanceID: "b", Address: "10.0.0.2", Port: 80}})
+		w.Notify()
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		got, err := w.Wait(ctx, "", first.Version)
+		require.NoError(t, err)
+		assert.Equal(t, first.Version, got.Version)
+	})
+	t.Run("notify_wakes_waiter", func(t *testing.T) {
+		done := make(chan domain.InstanceSet)
+		go func() {
+			got, _ := w.Wait(ctx, "", first.Version)
+			done <- got
+		}()
+		instances.Store([]domain.Instance{{InstanceID: "a", Address: "10.0.0.1", Port: 80}})
+		w.Notify()
+		select {
+		case got := <-done:
+			assert.NotEqual(t, first.Version, got.Version)
+			assert.Len(t, got.Instances, 1)
+		case <-time.After(5 * time.Second):
+			t.Fatal("waiter not woken")
+		}
+	})
+}
+
+func TestInstanceWatcher_ServiceType(t *testing.T) {
+	var instances atomic.Value
+	instances.Store([]domain.Instance{
+		{InstanceID: "o1", ServiceType: "orders", Address: "10.0.0.1", Port: 80},
+		{InstanceID: "p1", ServiceType: "payments", Address: "10.0.0.2", Port: 80},
+	})
+	cache := &mock.CacheMock[domain.Instance]{
+		ListAllValuesFunc: func(ctx context.Context) ([]domain.Instance, error) {
+			return instances.Load().([]domain.Instance), nil
+		},
+	}
+	w := NewInstanceWatcher(cache, time.Hour, log.NewNopLogger())
+	w.refresh(context.Background())
+
+	orders, err := w.Wait(context.Background(), "orders", "")
+	require.NoError(t, err)
+	require.Len(t, orders.Instances, 1)
+	assert.Equal(t, "o1", orders.Instances[0].InstanceID)
+	all, err := w.Wait(context.Background(), "", "")
+	require.NoError(t, err)
+	assert.Len(t, all.Instances, 2)
+	assert.NotEqual(t, all.Version, orders.Version)
+
+	instances.Store([]domain.Instance{
+		{InstanceID: "o1", ServiceType: "orders", Address: "10.0.0.1", Port: 80},
+		{InstanceID: "p2", ServiceType: "payments", Address: "10.0.0.3", Port: 80},
+	})
+	w.refresh(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	got, err := w.Wait(ctx, "orders", orders.Version)
+	require.NoError(t, err)
+	assert.Equal(t, orders, got, "a change of another service type does not wake the watcher")
+}
+
+func TestInstanceWatcher_EmptyAndFailingCache(t *testing.T) {
+	var fail atomic.Bool
+	cache := &mock.CacheMock[domain.Instance]{
+		ListAllValuesFunc: func(ctx context.Context) ([]domain.Instance, error) {
+			if fail.Load() {
+				return nil, assert.AnError
+			}
+			return nil, NewEntityNotFoundError("no instances", nil)
+		},
+	}
+	w := NewInstanceWatcher(cache, time.Hour, log.NewNopLogger())
+	w.refresh(context.Background())
+	empty, err := w.Wait(context.Background(), "", "")
+	require.NoError(t, err, "entity_not_found is an empty registry")
+	assert.Empty(t, empty.Instances)
+
+	fail.Store(true)
+	w.refresh(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	got, err := w.Wait(ctx, "", empty.Version)
+	require.NoError(t, err)
+	assert.Equal(t, empty, got, "a failed reload keeps the previous set")
+}
+
+func TestInstanceSetVersion_Labels(t *testing.T) {
+	base := []domain.Instance{{InstanceID: "a", Address: "10.0.0.1", Port: 80, Labels: map[string]string{"zone": "eu-1a", "version": "v1"}}}
+	same := []domain.Instance{{InstanceID: "a", Address: "10.0.0.1", Port: 80, Labels: map[string]string{"version": "v1", "zone": "eu-1a"}}}
+	changed := []domain.Instance{{InstanceID: "a", Address: "10.0.0.1", Port: 80, Labels: map[string]string{"zone": "eu-1a", "version": "v2"}}}
+	assert.Equal(t, instanceSetVersion(base), instanceSetVersion(same), "label order does not matter")
+	assert.NotEqual(t, instanceSetVersion(base), instanceSetVersion(changed), "label change changes the version")
+}
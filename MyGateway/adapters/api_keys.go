@@ -0,0 +1,82 @@
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"mygateway/auth"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+)
+
+// APIKeyFile creates an interfaces.APIKeyStore backed by a local key file (auth.ParseAPIKeys: YAML/JSON list of name,
+// sha256, allowed_prefixes, expires_at). The file is parsed on first use and re-parsed whenever its modification time
+// changes, so keys can be added or revoked without a restart. Panics on empty path.
+//
+// Parameter path — path to the API key file.
+//
+// Returns: interfaces.APIKeyStore (*apiKeyFile).
+//
+// Called from cmd/main when auth.api_key.keys_file is set.
+func APIKeyFile(path string) interfaces.APIKeyStore {
+	return &apiKeyFile{path: helpers.StrPanic(path, "adapters.api_keys.go: path is required")}
+}
+
+// apiKeyFile implements interfaces.APIKeyStore over a local file. Under mu: byHash (last parsed keys indexed by hash) and modTime (file mtime at last parse).
+type apiKeyFile struct {
+	path string
+
+	mu      sync.Mutex
+	byHash  map[string]auth.APIKey
+	modTime time.Time
+}
+
+// Lookup hashes rawKey (auth.HashAPIKey) and returns the matching key, re-reading the file when its mtime changed.
+// A broken rewrite keeps serving the previous keys.
+//
+// Parameter rawKey — value of the API key header.
+//
+// Returns: (key, true, nil) when found; (zero, false, nil) when unknown; (zero, false, error) when the file cannot be read/parsed and nothing is cached.
+//
+// Called from helpers.APIKeyProcessor.Process.
+func (f *apiKeyFile) Lookup(rawKey string) (auth.APIKey, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.reload(); err != nil && f.byHash == nil {
+		return auth.APIKey{}, false, err
+	}
+	key, ok := f.byHash[auth.HashAPIKey(rawKey)]
+	return key, ok, nil
+}
+
+// reload re-parses the file when its mtime differs from the last successful parse. Caller must hold mu.
+//
+// Returns: nil when the cache is current; error on stat/read/parse failure (the previous cache is left untouched).
+//
+// Called only from Lookup.
+func (f *apiKeyFile) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("stat api key file: %w", err)
+	}
+	if f.byHash != nil && info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("read api key file: %w", err)
+	}
+	keys, err := auth.ParseAPIKeys(data)
+	if err != nil {
+		return err
+	}
+	byHash := make(map[string]auth.APIKey, len(keys))
+	for _, k := range keys {
+		byHash[k.Hash] = k
+	}
+	f.byHash = byHash
+	f.modTime = info.ModTime()
+	return nil
+}
@@ -0,0 +1,56 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mygateway/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyFile_Panics(t *testing.T) {
+	assert.PanicsWithValue(t, "adapters.api_keys.go: path is required", func() {
+		APIKeyFile("")
+	})
+}
+
+func TestAPIKeyFile_Lookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_keys.yaml")
+
+	t.Run("missing_file_is_error", func(t *testing.T) {
+		_, _, err := APIKeyFile(path).Lookup("k1")
+		require.Error(t, err)
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte("keys:\n  - name: billing\n    sha256: "+auth.HashAPIKey("k1")+"\n"), 0o600))
+	store := APIKeyFile(path)
+	key, ok, err := store.Lookup("k1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "billing", key.Name)
+	_, ok, err = store.Lookup("k2")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Rewrite with a new mtime: k1 revoked, k2 added.
+	require.NoError(t, os.WriteFile(path, []byte("keys:\n  - name: ops\n    sha256: "+auth.HashAPIKey("k2")+"\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	_, ok, err = store.Lookup("k1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	key, ok, err = store.Lookup("k2")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "ops", key.Name)
+
+	// A broken rewrite keeps the previous keys.
+	require.NoError(t, os.WriteFile(path, []byte("keys: ["), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute)))
+	_, ok, err = store.Lookup("k2")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
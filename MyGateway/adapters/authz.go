@@ -0,0 +1,91 @@
+package adapters
+
+// The Authorizer client (mygateway/api/authzpb) is generated from api/authz.proto.
+//go:generate protoc --proto_path=.. --go_out=.. --go-grpc_out=.. --go_opt=module=mygateway --go-grpc_opt=module=mygateway ../api/authz.proto
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"mygateway/api/authzpb"
+	"mygateway/domain"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+)
+
+// AuthzGRPC creates an interfaces.Authorizer that calls mygateway.authz.Authorizer/Check over gRPC. Panics on nil client.
+//
+// Parameters: client — generated Authorizer client (authzpb.NewAuthorizerClient over a grpc.ClientConn to auth.external.address);
+// timeout — deadline per Check call (zero — only the request context deadline applies).
+//
+// Returns: interfaces.Authorizer (*authzGRPC).
+//
+// Called from cmd/main when auth.external.address is set.
+func AuthzGRPC(client authzpb.AuthorizerClient, timeout time.Duration) interfaces.Authorizer {
+	return &authzGRPC{
+		client:  helpers.NilPanic(client, "adapters.authz.go: authorizer client is required"),
+		timeout: timeout,
+	}
+}
+
+// authzGRPC implements interfaces.Authorizer over the generated gRPC client; converts domain.AuthzRequest/AuthzDecision to and from protobuf.
+type authzGRPC struct {
+	client  authzpb.AuthorizerClient
+	timeout time.Duration
+}
+
+// Check sends the request to the authz service and converts the response.
+//
+// Parameters: ctx — request context; req — method, metadata, peer, route.
+//
+// Returns: (decision, nil) when the service answered; (zero, error) on transport error, timeout or a non-OK status from the service.
+//
+// Called from helpers.ExternalAuthProcessor.Process on a cache miss.
+func (a *authzGRPC) Check(ctx context.Context, req domain.AuthzRequest) (domain.AuthzDecision, error) {
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+	resp, err := a.client.Check(ctx, &authzpb.CheckRequest{
+		Method:   req.Method,
+		Metadata: toHeaderValues(req.Metadata),
+		Peer:     req.Peer,
+		Route:    req.Route,
+	})
+	if err != nil {
+		return domain.AuthzDecision{}, fmt.Errorf("authz check: %w", err)
+	}
+	return domain.AuthzDecision{
+		Allow:           resp.GetAllow(),
+		HeadersToAdd:    fromHeaderValues(resp.GetHeadersToAdd()),
+		HeadersToRemove: resp.GetHeadersToRemove(),
+		DenyCode:        resp.GetDenyCode(),
+		DenyMessage:     resp.GetDenyMessage(),
+	}, nil
+}
+
+// toHeaderValues converts metadata to the protobuf map form.
+func toHeaderValues(md map[string][]string) map[string]*authzpb.HeaderValues {
+	if len(md) == 0 {
+		return nil
+	}
+	out := make(map[string]*authzpb.HeaderValues, len(md))
+	for k, v := range md {
+		out[k] = &authzpb.HeaderValues{Values: v}
+	}
+	return out
+}
+
+// fromHeaderValues converts the protobuf map form back to metadata.
+func fromHeaderValues(in map[string]*authzpb.HeaderValues) map[string][]string {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string][]string, len(in))
+	for k, v := range in {
+		out[k] = v.GetValues()
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mygateway/api/authzpb"
+	"mygateway/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// authorizerClientFunc adapts a function to authzpb.AuthorizerClient.
+type authorizerClientFunc func(ctx context.Context, in *authzpb.CheckRequest) (*authzpb.CheckResponse, error)
+
+func (f authorizerClientFunc) Check(ctx context.Context, in *authzpb.CheckRequest, _ ...grpc.CallOption) (*authzpb.CheckResponse, error) {
+	return f(ctx, in)
+}
+
+func TestAuthzGRPC_Panics(t *testing.T) {
+	assert.PanicsWithValue(t, "adapters.authz.go: authorizer client is required", func() {
+		AuthzGRPC(nil, time.Second)
+	})
+}
+
+func TestAuthzGRPC_Check(t *testing.T) {
+	var got *authzpb.CheckRequest
+	var hadDeadline bool
+	client := authorizerClientFunc(func(ctx context.Context, in *authzpb.CheckRequest) (*authzpb.CheckResponse, error) {
+		got = in
+		_, hadDeadline = ctx.Deadline()
+		return &authzpb.CheckResponse{
+			Allow:           true,
+			HeadersToAdd:    map[string]*authzpb.HeaderValues{"x-tenant": {Values: []string{"t1"}}},
+			HeadersToRemove: []string{"authorization"},
+		}, nil
+	})
+
+	decision, err := AuthzGRPC(client, time.Second).Check(context.Background(), domain.AuthzRequest{
+		Method:   "/svc.Svc/Call",
+		Metadata: map[string][]string{"authorization": {"tok"}},
+		Peer:     "10.0.0.1:1234",
+		Route:    "/svc.Svc/",
+	})
+	require.NoError(t, err)
+	assert.True(t, hadDeadline, "timeout applied")
+	assert.Equal(t, "/svc.Svc/Call", got.GetMethod())
+	assert.Equal(t, []string{"tok"}, got.GetMetadata()["authorization"].GetValues())
+	assert.Equal(t, "10.0.0.1:1234", got.GetPeer())
+	assert.Equal(t, "/svc.Svc/", got.GetRoute())
+	assert.Equal(t, domain.AuthzDecision{
+		Allow:           true,
+		HeadersToAdd:    map[string][]string{"x-tenant": {"t1"}},
+		HeadersToRemove: []string{"authorization"},
+	}, decision)
+}
+
+func TestAuthzGRPC_CheckDenyAndError(t *testing.T) {
+	deny := authorizerClientFunc(func(context.Context, *authzpb.CheckRequest) (*authzpb.CheckResponse, error) {
+		return &authzpb.CheckResponse{DenyCode: 7, DenyMessage: "tenant suspended"}, nil
+	})
+	decision, err := AuthzGRPC(deny, 0).Check(context.Background(), domain.AuthzRequest{Method: "/a/b"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.AuthzDecision{DenyCode: 7, DenyMessage: "tenant suspended"}, decision)
+
+	failing := authorizerClientFunc(func(context.Context, *authzpb.CheckRequest) (*authzpb.CheckResponse, error) {
+		return nil, errors.New("connection refused")
+	})
+	_, err = AuthzGRPC(failing, 0).Check(context.Background(), domain.AuthzRequest{Method: "/a/b"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authz check: connection refused")
+}
@@ -3,10 +3,14 @@ package adapters
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"mygateway/domain"
@@ -14,25 +18,84 @@ import (
 	"mygateway/interfaces"
 )
 
-// DiscovererHTTP creates an interfaces.Discoverer that talks to MyDiscoverer over HTTP: GET baseURL/v1/instances and POST baseURL/v1/unregister/{instance_id}. Panics on empty baseURL or nil client.
+// defaultDiscovererTimeout bounds one request to one MyDiscoverer replica when HTTPDiscovery.Timeout is not set.
+const defaultDiscovererTimeout = 5 * time.Second
+
+// Backoff of a failed MyDiscoverer replica: it is skipped for endpointBackoffBase after the first failure, doubling up
+// to maxEndpointBackoff (with jitter, see helpers.Backoff) while it keeps failing.
+const (
+	endpointBackoffBase = time.Second
+	maxEndpointBackoff  = 30 * time.Second
+)
+
+// DiscovererHTTP creates an interfaces.Discoverer that talks to MyDiscoverer over HTTP: GET {url}/v1/instances and
+// POST {url}/v1/unregister/{instance_id}. Requests go to the replica that answered last; when it fails the call fails
+// over to the next replica whose backoff is over, and a failed replica is skipped for an exponentially growing, jittered
+// time. When every replica is backing off, the one that recovers first is tried. With cfg.Watch > 0 the result also
+// implements interfaces.InstanceWatcher (see discovererHTTPWatch). Panics on no URLs, an empty URL, nil client or time
+// provider.
 //
-// Parameters: baseURL — discoverer base URL (e.g. http://mydiscoverer:8080), no trailing slash; client — HTTP client (timeout recommended; main uses 10s).
+// Parameters: cfg — validated HTTP settings of the cluster (URLs without trailing slash, e.g. http://mydiscoverer:8080;
+// ServiceType sent as ?service_type=, "" — all instances; Timeout per request, 0 — defaultDiscovererTimeout); client —
+// HTTP client (requests are bounded by contexts, no client timeout needed); timeProvider — clock for the backoff.
 //
-// Returns: interfaces.Discoverer (*discovererHTTP).
+// Returns: interfaces.Discoverer (*discovererHTTP, or *discovererHTTPWatch with cfg.Watch).
 //
-// Called from cmd/main for each dynamic cluster.
-func DiscovererHTTP(baseURL string, client *http.Client) interfaces.Discoverer {
-	return &discovererHTTP{
-		baseURL: helpers.StrPanic(baseURL, "adapters.discoverer.go: baseURL is required"),
-		client:  helpers.NilPanic(client, "adapters.discoverer.go: http client is required"),
+// Called from cmd/main for each dynamic cluster with discoverer http.
+func DiscovererHTTP(cfg domain.HTTPDiscovery, client *http.Client, timeProvider interfaces.TimeProvider) interfaces.Discoverer {
+	if len(cfg.URLs) == 0 {
+		panic("adapters.discoverer.go: at least one URL is required")
+	}
+	endpoints := make([]*discovererEndpoint, 0, len(cfg.URLs))
+	for _, u := range cfg.URLs {
+		endpoints = append(endpoints, &discovererEndpoint{baseURL: helpers.StrPanic(u, "adapters.discoverer.go: baseURL is required")})
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultDiscovererTimeout
 	}
+	d := &discovererHTTP{
+		cfg:          cfg,
+		client:       helpers.NilPanic(client, "adapters.discoverer.go: http client is required"),
+		timeProvider: helpers.NilPanic(timeProvider, "adapters.discoverer.go: time provider is required"),
+		endpoints:    endpoints,
+	}
+	if cfg.Watch > 0 {
+		return &discovererHTTPWatch{discovererHTTP: d}
+	}
+	return d
 }
 
 // discovererHTTP implements interfaces.Discoverer. Used by service.connectionPool to fetch the instance
-// list (refresh) and to unregister an instance on backend failure. Holds baseURL and http.Client.
+// list (refresh) and to unregister an instance on backend failure. Holds cfg (URLs, ServiceType — filter, "" — none,
+// Timeout), http.Client, timeProvider and, under mu, endpoints (one per URL, in config order) and current (index of the
+// replica that answered last).
 type discovererHTTP struct {
-	baseURL string
-	client  *http.Client
+	cfg          domain.HTTPDiscovery
+	client       *http.Client
+	timeProvider interfaces.TimeProvider
+
+	mu        sync.Mutex
+	endpoints []*discovererEndpoint
+	current   int
+}
+
+// discovererEndpoint is the health of one MyDiscoverer replica: failures (consecutive failed requests) and retryAt
+// (the replica is skipped until then; zero — healthy).
+type discovererEndpoint struct {
+	baseURL  string
+	failures int
+	retryAt  time.Time
+}
+
+// statusError is a non-200 answer of MyDiscoverer; only 5xx and 429 make the call fail over to another replica — any
+// other status would be the same on every replica.
+type statusError struct {
+	op   string
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned %d", e.op, e.code)
 }
 
 // instancesResponse is the JSON shape of GET /v1/instances response: { "instances": [ InstanceInfo ] }.
@@ -40,24 +103,54 @@ type instancesResponse struct {
 	Instances []instanceInfo `json:"instances"`
 }
 
-// instanceInfo is one element of the instances array in the discoverer JSON (instance_id, ipv4, port).
+// instancesWatchResponse is the JSON shape of GET /v1/instances/watch response: { "version": string, "instances": [ InstanceInfo ] }.
+type instancesWatchResponse struct {
+	Version   string         `json:"version"`
+	Instances []instanceInfo `json:"instances"`
+}
+
+// instanceInfo is one element of the instances array in the discoverer JSON (instance_id, address, port, optional
+// labels); ipv4 is the field of MyDiscoverer versions before address and is used only when address is missing.
 type instanceInfo struct {
-	InstanceID string `json:"instance_id"`
-	Ipv4       string `json:"ipv4"`
-	Port       int    `json:"port"`
+	InstanceID string            `json:"instance_id"`
+	Address    string            `json:"address"`
+	Ipv4       string            `json:"ipv4"`
+	Port       int               `json:"port"`
+	Labels     map[string]string `json:"labels"`
 }
 
-// GetInstances performs GET baseURL/v1/instances with 5s timeout. On 404 (MyDiscoverer entity_not_found when no instances) returns empty slice; on 200 parses JSON and maps to domain.ServiceInstance (AssignedClientSessionID is not set by the adapter).
+// GetInstances performs GET {url}/v1/instances (?service_type= when set), failing over between the replicas (see
+// DiscovererHTTP). On 404 (MyDiscoverer entity_not_found when no instances) returns empty slice; on 200 parses JSON and
+// maps to domain.ServiceInstance (AssignedClientSessionID is not set by the adapter).
 //
-// Parameters: none.
+// Parameter ctx — cancels the call; every request is also bounded by cfg.Timeout.
 //
-// Returns: ([]domain.ServiceInstance, nil) on 200 (possibly empty slice) or 404 (empty slice); (nil, error) on other status, network error or JSON parse error (e.g. missing "instances" field).
+// Returns: ([]domain.ServiceInstance, nil) on 200 (possibly empty slice) or 404 (empty slice); (nil, error) when no
+// replica answered (the error of the last one), on a non-retryable status or when ctx is done.
 //
 // Called from service.connectionPool.refresh (on timer and at startup).
-func (d *discovererHTTP) GetInstances() ([]domain.ServiceInstance, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	reqURL := d.baseURL + "/v1/instances"
+func (d *discovererHTTP) GetInstances(ctx context.Context) ([]domain.ServiceInstance, error) {
+	path := "/v1/instances"
+	if d.cfg.ServiceType != "" {
+		path += "?" + url.Values{"service_type": {d.cfg.ServiceType}}.Encode()
+	}
+	var instances []domain.ServiceInstance
+	err := d.do(ctx, func(baseURL string) error {
+		ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+		defer cancel()
+		var err error
+		instances, err = d.getInstances(ctx, baseURL+path)
+		return err
+	})
+	return instances, err
+}
+
+// getInstances performs one GET of reqURL and parses the instance list.
+//
+// Returns: as GetInstances; a non-200, non-404 status is a *statusError.
+//
+// Called only from GetInstances for each tried replica.
+func (d *discovererHTTP) getInstances(ctx context.Context, reqURL string) ([]domain.ServiceInstance, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return nil, err
@@ -72,7 +165,7 @@ func (d *discovererHTTP) GetInstances() ([]domain.ServiceInstance, error) {
 		return []domain.ServiceInstance{}, nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("discoverer returned %d", resp.StatusCode)
+		return nil, &statusError{op: "discoverer", code: resp.StatusCode}
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -85,43 +178,215 @@ func (d *discovererHTTP) GetInstances() ([]domain.ServiceInstance, error) {
 	if raw.Instances == nil {
 		return nil, fmt.Errorf("discoverer response missing instances field")
 	}
-	out := make([]domain.ServiceInstance, 0, len(raw.Instances))
-	for _, r := range raw.Instances {
-		addr := r.Ipv4
+	return toServiceInstances(raw.Instances), nil
+}
+
+// toServiceInstances maps the discoverer JSON instances to domain.ServiceInstance (AssignedClientSessionID is not set).
+func toServiceInstances(raw []instanceInfo) []domain.ServiceInstance {
+	out := make([]domain.ServiceInstance, 0, len(raw))
+	for _, r := range raw {
 		out = append(out, domain.ServiceInstance{
 			InstanceID:              r.InstanceID,
-			Ipv4:                    addr,
+			Address:                 instanceAddress(r.Address, r.Ipv4),
 			Port:                    r.Port,
 			AssignedClientSessionID: "",
+			Labels:                  r.Labels,
 		})
 	}
-	return out, nil
+	return out
 }
 
-// UnregisterInstance performs POST baseURL/v1/unregister/{instance_id} with 5s timeout so the discoverer can remove or mark the instance as unavailable.
+// instanceAddress returns the address to store in domain.ServiceInstance: address, or the legacy ipv4 when address is
+// empty, trimmed and with the brackets of an IPv6 literal removed (net.JoinHostPort adds them when dialing).
 //
-// Parameter instanceID — instance identifier to unregister; substituted in URL via url.PathEscape (special chars escaped).
+// Called from toServiceInstances, ParseInstanceFile and the instance snapshot file.
+func instanceAddress(address, ipv4 string) string {
+	addr := strings.TrimSpace(address)
+	if addr == "" {
+		addr = strings.TrimSpace(ipv4)
+	}
+	if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		addr = addr[1 : len(addr)-1]
+	}
+	return addr
+}
+
+// UnregisterInstance performs POST {url}/v1/unregister/{instance_id} so the discoverer can remove or mark the instance
+// as unavailable; the replicas share the registry, so the first one that answers is enough.
 //
-// Returns: nil on 200; error on non-200 or request error (network, timeout).
+// Parameters: ctx — cancels the call, every request is also bounded by cfg.Timeout; instanceID — instance identifier to
+// unregister, substituted in URL via url.PathEscape (special chars escaped).
 //
-// Called from service.connectionPool.OnBackendFailure after closing the connection to the instance.
-func (d *discovererHTTP) UnregisterInstance(instanceID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// Returns: nil on 200; error when no replica answered 200 (non-200 status, network error, timeout).
+//
+// Called from service.connectionPool.unregisterLoop after OnBackendFailure closed the connection to the instance.
+func (d *discovererHTTP) UnregisterInstance(ctx context.Context, instanceID string) error {
 	path := "/v1/unregister/" + url.PathEscape(instanceID)
-	reqURL := d.baseURL + path
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, nil)
+	return d.do(ctx, func(baseURL string) error {
+		ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+path, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			return &statusError{op: "discoverer unregister", code: resp.StatusCode}
+		}
+		return nil
+	})
+}
+
+// do runs request against the replicas in the order of candidates until one succeeds, recording the outcome of every
+// attempt (succeeded, failed). A non-retryable error or a done ctx ends the call at once.
+//
+// Parameters: ctx — the call context; request — one request to the replica at baseURL.
+//
+// Returns: nil on the first success; the last error otherwise.
+//
+// Called from GetInstances and UnregisterInstance.
+func (d *discovererHTTP) do(ctx context.Context, request func(baseURL string) error) error {
+	var err error
+	for _, i := range d.candidates() {
+		err = request(d.endpoints[i].baseURL)
+		if err == nil {
+			d.succeeded(i)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		if !retryable(err) {
+			// The replica answered; the request itself is wrong.
+			d.succeeded(i)
+			return err
+		}
+		d.failed(i)
+	}
+	return err
+}
+
+// candidates returns the replica indices to try for one call: the replicas whose backoff is over, starting from
+// current and in config order after it; when all are backing off — only the one whose backoff ends first.
+//
+// Called from do and WatchInstances.
+func (d *discovererHTTP) candidates() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.timeProvider.Now()
+	var ready []int
+	soonest := d.current
+	for k := range d.endpoints {
+		i := (d.current + k) % len(d.endpoints)
+		if !now.Before(d.endpoints[i].retryAt) {
+			ready = append(ready, i)
+		} else if d.endpoints[i].retryAt.Before(d.endpoints[soonest].retryAt) {
+			soonest = i
+		}
+	}
+	if len(ready) == 0 {
+		return []int{soonest}
+	}
+	return ready
+}
+
+// succeeded marks replica i healthy and makes it the current one.
+func (d *discovererHTTP) succeeded(i int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.endpoints[i].failures, d.endpoints[i].retryAt = 0, time.Time{}
+	d.current = i
+}
+
+// failed counts a failure of replica i and skips it for the next backoff delay.
+func (d *discovererHTTP) failed(i int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.endpoints[i]
+	e.failures++
+	e.retryAt = d.timeProvider.Now().Add(helpers.Backoff(e.failures, endpointBackoffBase, maxEndpointBackoff))
+}
+
+// retryable reports whether err is worth trying on another replica: network errors, timeouts, unreadable answers,
+// 5xx and 429.
+func retryable(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code >= http.StatusInternalServerError || status.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// discovererHTTPWatch is a discovererHTTP with the long-poll watch of MyDiscoverer (cfg.Watch is timeout_ms), so
+// service.connectionPool learns about register, unregister and TTL expiry as soon as MyDiscoverer sees them instead of
+// on the next refresh.
+type discovererHTTPWatch struct {
+	*discovererHTTP
+}
+
+// WatchInstances performs GET {url}/v1/instances/watch?service_type=...&version=...&timeout_ms=... on the first
+// candidate replica and waits for the answer: at once when version is outdated (or empty), otherwise when the list
+// changes or after cfg.Watch. The request is bounded by ctx and by cfg.Watch plus cfg.Timeout. A failed watch counts
+// against the replica, so the next call (the pool retries with backoff) goes to another one; versions are content
+// hashes, so they stay valid across replicas.
+//
+// Parameters: ctx — cancels the wait; version — version from the previous call ("" — return the current list).
+//
+// Returns: (instances, version, nil) on 200; (nil, "", error) on non-200, network error or JSON without version or
+// instances.
+//
+// Called from service.connectionPool.watchLoop.
+func (d *discovererHTTPWatch) WatchInstances(ctx context.Context, version string) ([]domain.ServiceInstance, string, error) {
+	i := d.candidates()[0]
+	instances, next, err := d.watch(ctx, d.endpoints[i].baseURL, version)
+	switch {
+	case err == nil:
+		d.succeeded(i)
+	case ctx.Err() == nil && retryable(err):
+		d.failed(i)
+	}
+	return instances, next, err
+}
+
+// watch performs one watch request to the replica at baseURL.
+//
+// Called only from WatchInstances.
+func (d *discovererHTTPWatch) watch(ctx context.Context, baseURL, version string) ([]domain.ServiceInstance, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Watch+d.cfg.Timeout)
+	defer cancel()
+	query := url.Values{}
+	if d.cfg.ServiceType != "" {
+		query.Set("service_type", d.cfg.ServiceType)
+	}
+	if version != "" {
+		query.Set("version", version)
+	}
+	query.Set("timeout_ms", strconv.FormatInt(d.cfg.Watch.Milliseconds(), 10))
+	reqURL := baseURL + "/v1/instances/watch?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 	resp, err := d.client.Do(req)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
-	_, _ = io.Copy(io.Discard, resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("discoverer unregister returned %d", resp.StatusCode)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, "", &statusError{op: "discoverer watch", code: resp.StatusCode}
+	}
+	var raw instancesWatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, "", err
+	}
+	if raw.Version == "" || raw.Instances == nil {
+		return nil, "", fmt.Errorf("discoverer watch response missing version or instances field")
 	}
-	return nil
+	return toServiceInstances(raw.Instances), raw.Version, nil
 }
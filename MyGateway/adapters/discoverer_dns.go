@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+)
+
+// DiscovererDNS creates an interfaces.Discoverer that resolves instances from DNS on every GetInstances: SRV records of
+// cfg.Name when cfg.Port is 0 (each target resolved to its A/AAAA addresses, with the record port), otherwise the
+// A/AAAA addresses of cfg.Name with cfg.Port. The instance ID is the dial address ("10.0.0.1:50051", "[fd00::1]:50051").
+// UnregisterInstance does not touch DNS: the instance is left out of the results for cfg.ExclusionTTL. Panics on empty
+// name, nil resolver or time provider.
+//
+// Parameters: cfg — validated DNS settings of the cluster; resolver — DNS resolver (net.DefaultResolver in main);
+// timeProvider — clock for exclusions.
+//
+// Returns: interfaces.Discoverer (*discovererDNS).
+//
+// Called from cmd/main for each dynamic cluster with discoverer dns.
+func DiscovererDNS(cfg domain.DNSDiscovery, resolver interfaces.DNSResolver, timeProvider interfaces.TimeProvider) interfaces.Discoverer {
+	helpers.StrPanic(cfg.Name, "adapters.discoverer_dns.go: name is required")
+	return &discovererDNS{
+		cfg:          cfg,
+		resolver:     helpers.NilPanic(resolver, "adapters.discoverer_dns.go: resolver is required"),
+		timeProvider: helpers.NilPanic(timeProvider, "adapters.discoverer_dns.go: time provider is required"),
+		excluded:     make(map[string]time.Time),
+	}
+}
+
+// discovererDNS implements interfaces.Discoverer over DNS. Holds cfg, resolver, timeProvider and, under mu, excluded
+// (instance ID → end of exclusion).
+type discovererDNS struct {
+	cfg          domain.DNSDiscovery
+	resolver     interfaces.DNSResolver
+	timeProvider interfaces.TimeProvider
+
+	mu       sync.Mutex
+	excluded map[string]time.Time
+}
+
+// GetInstances resolves the records with a 5s timeout (within ctx) and returns one instance per address and port, sorted by ID and
+// without the excluded ones. A name that does not exist (NXDOMAIN) gives an empty list, like 404 from MyDiscoverer.
+// An SRV target that fails to resolve is skipped unless every target fails.
+//
+// Returns: ([]domain.ServiceInstance, nil) (possibly empty); (nil, error) on lookup failure.
+//
+// Called from service.connectionPool.refresh (on timer and at startup).
+func (d *discovererDNS) GetInstances(ctx context.Context) ([]domain.ServiceInstance, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var instances []domain.ServiceInstance
+	var err error
+	if d.cfg.Port > 0 {
+		instances, err = d.lookupHost(ctx, d.cfg.Name, d.cfg.Port)
+	} else {
+		instances, err = d.lookupSRV(ctx)
+	}
+	if isNotFound(err) {
+		return []domain.ServiceInstance{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(instances, func(a, b domain.ServiceInstance) int { return strings.Compare(a.InstanceID, b.InstanceID) })
+	instances = slices.CompactFunc(instances, func(a, b domain.ServiceInstance) bool { return a.InstanceID == b.InstanceID })
+
+	now := d.timeProvider.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]domain.ServiceInstance, 0, len(instances))
+	for _, inst := range instances {
+		if until, ok := d.excluded[inst.InstanceID]; ok {
+			if now.Before(until) {
+				continue
+			}
+			delete(d.excluded, inst.InstanceID)
+		}
+		out = append(out, inst)
+	}
+	return out, nil
+}
+
+// UnregisterInstance excludes the instance from GetInstances for cfg.ExclusionTTL; DNS itself is not changed.
+//
+// Parameter instanceID — instance ID (dial address) reported as failed.
+//
+// Returns: nil.
+//
+// Called from service.connectionPool.unregisterLoop after OnBackendFailure closed the connection to the instance.
+func (d *discovererDNS) UnregisterInstance(_ context.Context, instanceID string) error {
+	now := d.timeProvider.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, until := range d.excluded {
+		if !until.After(now) {
+			delete(d.excluded, id)
+		}
+	}
+	d.excluded[instanceID] = now.Add(d.cfg.ExclusionTTL)
+	return nil
+}
+
+// lookupSRV resolves cfg.Name as a full SRV record name and each target to its addresses.
+func (d *discovererDNS) lookupSRV(ctx context.Context) ([]domain.ServiceInstance, error) {
+	_, records, err := d.resolver.LookupSRV(ctx, "", "", d.cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	var out []domain.ServiceInstance
+	var errs []error
+	for _, srv := range records {
+		instances, err := d.lookupHost(ctx, strings.TrimSuffix(srv.Target, "."), int(srv.Port))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out = append(out, instances...)
+	}
+	if len(errs) > 0 && len(errs) == len(records) {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// lookupHost resolves host to its A/AAAA addresses, one instance per address (IPv6 addresses without brackets;
+// cmd/main dials with net.JoinHostPort).
+func (d *discovererDNS) lookupHost(ctx context.Context, host string, port int) ([]domain.ServiceInstance, error) {
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.ServiceInstance, 0, len(addrs))
+	for _, addr := range addrs {
+		ip := addr.IP.String()
+		out = append(out, domain.ServiceInstance{
+			InstanceID: net.JoinHostPort(ip, strconv.Itoa(port)),
+			Address:    ip,
+			Port:       port,
+		})
+	}
+	return out, nil
+}
+
+// isNotFound reports whether err is a DNS "no such host" answer.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
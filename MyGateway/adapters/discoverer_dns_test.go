@@ -0,0 +1,162 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/interfaces/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubDNS returns a resolver answering SRV for "_grpc._tcp.svc.test" and A/AAAA for the hosts in addrs.
+func stubDNS(srv []*net.SRV, addrs map[string][]string) *mock.DNSResolverMock {
+	notFound := func(name string) error { return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true} }
+	return &mock.DNSResolverMock{
+		LookupSRVFunc: func(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+			if service != "" || proto != "" || name != "_grpc._tcp.svc.test" || srv == nil {
+				return "", nil, notFound(name)
+			}
+			return name, srv, nil
+		},
+		LookupIPAddrFunc: func(_ context.Context, host string) ([]net.IPAddr, error) {
+			ips, ok := addrs[host]
+			if !ok {
+				return nil, notFound(host)
+			}
+			out := make([]net.IPAddr, 0, len(ips))
+			for _, ip := range ips {
+				out = append(out, net.IPAddr{IP: net.ParseIP(ip)})
+			}
+			return out, nil
+		},
+	}
+}
+
+func TestDiscovererDNS_Panics(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	resolver := &mock.DNSResolverMock{}
+	assert.PanicsWithValue(t, "adapters.discoverer_dns.go: name is required", func() {
+		DiscovererDNS(domain.DNSDiscovery{}, resolver, tp)
+	})
+	assert.PanicsWithValue(t, "adapters.discoverer_dns.go: resolver is required", func() {
+		DiscovererDNS(domain.DNSDiscovery{Name: "svc"}, nil, tp)
+	})
+	assert.PanicsWithValue(t, "adapters.discoverer_dns.go: time provider is required", func() {
+		DiscovererDNS(domain.DNSDiscovery{Name: "svc"}, resolver, nil)
+	})
+}
+
+func TestDiscovererDNS_GetInstances(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	tests := []struct {
+		name    string
+		cfg     domain.DNSDiscovery
+		srv     []*net.SRV
+		addrs   map[string][]string
+		want    []domain.ServiceInstance
+		wantErr bool
+	}{
+		{
+			name:  "address_records_with_port",
+			cfg:   domain.DNSDiscovery{Name: "svc.test", Port: 50051},
+			addrs: map[string][]string{"svc.test": {"10.0.0.2", "fd00::1", "10.0.0.1", "10.0.0.1"}},
+			want: []domain.ServiceInstance{
+				{InstanceID: "10.0.0.1:50051", Address: "10.0.0.1", Port: 50051},
+				{InstanceID: "10.0.0.2:50051", Address: "10.0.0.2", Port: 50051},
+				{InstanceID: "[fd00::1]:50051", Address: "fd00::1", Port: 50051},
+			},
+		},
+		{
+			name: "srv_records",
+			cfg:  domain.DNSDiscovery{Name: "_grpc._tcp.svc.test"},
+			srv: []*net.SRV{
+				{Target: "pod-b.svc.test.", Port: 9001},
+				{Target: "pod-a.svc.test.", Port: 9000},
+			},
+			addrs: map[string][]string{"pod-a.svc.test": {"10.0.0.1"}, "pod-b.svc.test": {"10.0.0.2"}},
+			want: []domain.ServiceInstance{
+				{InstanceID: "10.0.0.1:9000", Address: "10.0.0.1", Port: 9000},
+				{InstanceID: "10.0.0.2:9001", Address: "10.0.0.2", Port: 9001},
+			},
+		},
+		{
+			name: "srv_unresolvable_target_skipped",
+			cfg:  domain.DNSDiscovery{Name: "_grpc._tcp.svc.test"},
+			srv: []*net.SRV{
+				{Target: "gone.svc.test.", Port: 9000},
+				{Target: "pod-a.svc.test.", Port: 9000},
+			},
+			addrs: map[string][]string{"pod-a.svc.test": {"10.0.0.1"}},
+			want:  []domain.ServiceInstance{{InstanceID: "10.0.0.1:9000", Address: "10.0.0.1", Port: 9000}},
+		},
+		{
+			name: "nxdomain_is_empty_list",
+			cfg:  domain.DNSDiscovery{Name: "missing.test", Port: 50051},
+			want: []domain.ServiceInstance{},
+		},
+		{
+			name: "srv_nxdomain_is_empty_list",
+			cfg:  domain.DNSDiscovery{Name: "_grpc._tcp.svc.test"},
+			want: []domain.ServiceInstance{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := DiscovererDNS(tc.cfg, stubDNS(tc.srv, tc.addrs), tp)
+			got, err := d.GetInstances(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("lookup_error", func(t *testing.T) {
+		resolver := &mock.DNSResolverMock{
+			LookupIPAddrFunc: func(context.Context, string) ([]net.IPAddr, error) {
+				return nil, &net.DNSError{Err: "server misbehaving", Name: "svc.test", IsTemporary: true}
+			},
+		}
+		d := DiscovererDNS(domain.DNSDiscovery{Name: "svc.test", Port: 50051}, resolver, tp)
+		_, err := d.GetInstances(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "server misbehaving")
+	})
+	t.Run("srv_every_target_fails", func(t *testing.T) {
+		resolver := stubDNS([]*net.SRV{{Target: "pod-a.svc.test.", Port: 9000}}, nil)
+		resolver.LookupIPAddrFunc = func(context.Context, string) ([]net.IPAddr, error) {
+			return nil, errors.New("timeout")
+		}
+		d := DiscovererDNS(domain.DNSDiscovery{Name: "_grpc._tcp.svc.test"}, resolver, tp)
+		_, err := d.GetInstances(context.Background())
+		assert.ErrorContains(t, err, "timeout")
+	})
+}
+
+func TestDiscovererDNS_UnregisterExcludesTemporarily(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
+	resolver := stubDNS(nil, map[string][]string{"svc.test": {"10.0.0.1", "10.0.0.2"}})
+	d := DiscovererDNS(domain.DNSDiscovery{Name: "svc.test", Port: 50051, ExclusionTTL: 30 * time.Second}, resolver, tp)
+
+	require.NoError(t, d.UnregisterInstance(context.Background(), "10.0.0.1:50051"))
+	got, err := d.GetInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "10.0.0.2:50051", got[0].InstanceID)
+
+	now = now.Add(29 * time.Second)
+	got, err = d.GetInstances(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, got, 1, "still excluded before the TTL")
+
+	now = now.Add(time.Second)
+	got, err = d.GetInstances(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, got, 2, "back after the TTL")
+	assert.Len(t, resolver.LookupIPAddrCalls(), 3, "DNS is queried on every refresh")
+}
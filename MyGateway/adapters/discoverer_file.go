@@ -0,0 +1,352 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"gopkg.in/yaml.v3"
+)
+
+// fileWatchTimeout bounds one WatchInstances wait; the unchanged list is returned after it, so the pool still sees a
+// fresh answer and a change missed by the watcher is caught by the stat check of the next call.
+const fileWatchTimeout = 30 * time.Second
+
+// instanceFile is the shape of the instance file of discoverer file: the GET /v1/instances response of MyDiscoverer
+// ({ "instances": [ { instance_id, address, port, labels } ] }) written as JSON or YAML.
+type instanceFile struct {
+	Instances []instanceFileEntry `yaml:"instances"`
+}
+
+// instanceFileEntry is one element of the instances list of the instance file.
+type instanceFileEntry struct {
+	InstanceID string            `yaml:"instance_id"`
+	Address    string            `yaml:"address"`
+	Ipv4       string            `yaml:"ipv4"`
+	Port       int               `yaml:"port"`
+	Labels     map[string]string `yaml:"labels"`
+}
+
+// ParseInstanceFile parses an instance file (JSON or YAML, same shape as the MyDiscoverer GET /v1/instances response).
+// Every entry needs a unique instance_id, an address (IPv4, IPv6 with or without brackets, or a host name; the older
+// ipv4 field is read when address is missing) and a port in 1–65535; labels are optional (no empty names); an empty list
+// is valid.
+//
+// Parameter data — file contents.
+//
+// Returns: (instances sorted by ID, nil); (nil, error) on syntax error, missing instances field or invalid entry.
+//
+// Called from DiscovererFile on every reload and from cmd.LoadConfig to validate clusters.<name>.path.
+func ParseInstanceFile(data []byte) ([]domain.ServiceInstance, error) {
+	var raw instanceFile
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse instance file: %w", err)
+	}
+	if raw.Instances == nil {
+		return nil, errors.New("instance file: missing instances field")
+	}
+	out := make([]domain.ServiceInstance, 0, len(raw.Instances))
+	seen := make(map[string]bool, len(raw.Instances))
+	for i, r := range raw.Instances {
+		id := strings.TrimSpace(r.InstanceID)
+		address := instanceAddress(r.Address, r.Ipv4)
+		switch {
+		case id == "":
+			return nil, fmt.Errorf("instance file: instances[%d]: instance_id is required", i)
+		case seen[id]:
+			return nil, fmt.Errorf("instance file: instances[%d]: duplicate instance_id %q", i, id)
+		case address == "":
+			return nil, fmt.Errorf("instance file: instances[%d]: address is required", i)
+		case strings.Contains(address, ":") && net.ParseIP(address) == nil:
+			return nil, fmt.Errorf("instance file: instances[%d]: address must be an IP address or a host name without port", i)
+		case r.Port < 1 || r.Port > 65535:
+			return nil, fmt.Errorf("instance file: instances[%d]: port must be between 1 and 65535", i)
+		}
+		var labels map[string]string
+		for k, v := range r.Labels {
+			if strings.TrimSpace(k) == "" {
+				return nil, fmt.Errorf("instance file: instances[%d]: labels must not have empty names", i)
+			}
+			if labels == nil {
+				labels = make(map[string]string, len(r.Labels))
+			}
+			labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		seen[id] = true
+		out = append(out, domain.ServiceInstance{InstanceID: id, Address: address, Port: r.Port, Labels: labels})
+	}
+	slices.SortFunc(out, func(a, b domain.ServiceInstance) int { return strings.Compare(a.InstanceID, b.InstanceID) })
+	return out, nil
+}
+
+// DiscovererFile creates an interfaces.Discoverer that serves the instance list from a local file (ParseInstanceFile).
+// The file is watched with fsnotify (its directory, so atomic renames and ConfigMap symlink swaps are seen) and, as a
+// fallback for file systems without change events, GetInstances re-reads it whenever its mtime or size changed. The
+// first load happens here; a broken rewrite keeps serving the previous list. UnregisterInstance does not touch the file:
+// the instance is left out of the results until the file changes or, when cfg.ExclusionTTL is set, for at most that
+// long. The watcher lives until process exit, like connectionPool.refreshLoop. When it starts the result also
+// implements interfaces.InstanceWatcher, so the pool applies a change as soon as the watcher reloads the file instead of
+// on its next refresh. Panics on empty path, nil time provider or logger.
+//
+// Parameters: cfg — validated file settings of the cluster; timeProvider — clock for exclusions; logger — logs reloads,
+// rejected rewrites and watcher errors.
+//
+// Returns: interfaces.Discoverer (*discovererFileWatch; *discovererFile when the watcher cannot be created).
+//
+// Called from cmd/main for each dynamic cluster with discoverer file.
+func DiscovererFile(cfg domain.FileDiscovery, timeProvider interfaces.TimeProvider, logger log.Logger) interfaces.Discoverer {
+	helpers.StrPanic(cfg.Path, "adapters.discoverer_file.go: path is required")
+	cfg.Path = filepath.Clean(cfg.Path)
+	d := &discovererFile{
+		cfg:          cfg,
+		timeProvider: helpers.NilPanic(timeProvider, "adapters.discoverer_file.go: time provider is required"),
+		logger:       log.With(helpers.NilPanic(logger, "adapters.discoverer_file.go: logger is required"), "component", "discoverer_file", "path", cfg.Path),
+		excluded:     make(map[string]time.Time),
+		changed:      make(chan struct{}),
+	}
+	d.mu.Lock()
+	_ = d.load(false) // a missing or broken file is reported by GetInstances
+	d.mu.Unlock()
+	if err := d.watch(); err != nil {
+		level.Warn(d.logger).Log("msg", "file watch unavailable, polling on refresh only", "err", err)
+		return d
+	}
+	return &discovererFileWatch{discovererFile: d}
+}
+
+// discovererFile implements interfaces.Discoverer over a local file. Holds cfg, timeProvider, logger and, under mu,
+// instances (last parsed list; nil until the first successful load), modTime and size (file state at that load),
+// excluded (instance ID → end of exclusion; zero time — until the file changes), generation (bumped whenever the
+// served list changes) and changed (closed and replaced on every bump, wakes WatchInstances).
+type discovererFile struct {
+	cfg          domain.FileDiscovery
+	timeProvider interfaces.TimeProvider
+	logger       log.Logger
+
+	mu         sync.Mutex
+	instances  []domain.ServiceInstance
+	modTime    time.Time
+	size       int64
+	excluded   map[string]time.Time
+	generation uint64
+	changed    chan struct{}
+}
+
+// GetInstances returns the instances from the file without the excluded ones, re-reading the file first when its mtime
+// or size changed since the last load.
+//
+// Returns: ([]domain.ServiceInstance, nil) (possibly empty); (nil, error) when the file cannot be read or parsed and no
+// list was loaded yet.
+//
+// Called from service.connectionPool.refresh (on timer and at startup).
+func (d *discovererFile) GetInstances(_ context.Context) ([]domain.ServiceInstance, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.load(false); err != nil {
+		return nil, err
+	}
+	out, _ := d.current()
+	return out, nil
+}
+
+// current returns the loaded instances without the excluded ones and the earliest end of a running exclusion (zero —
+// none); exclusions that ended are dropped (a change of the list). Must be called under mu.
+func (d *discovererFile) current() ([]domain.ServiceInstance, time.Time) {
+	now := d.timeProvider.Now()
+	out := make([]domain.ServiceInstance, 0, len(d.instances))
+	var next time.Time
+	for _, inst := range d.instances {
+		if until, ok := d.excluded[inst.InstanceID]; ok {
+			if until.IsZero() {
+				continue
+			}
+			if now.Before(until) {
+				if next.IsZero() || until.Before(next) {
+					next = until
+				}
+				continue
+			}
+			delete(d.excluded, inst.InstanceID)
+			d.bump()
+		}
+		out = append(out, inst)
+	}
+	return out, next
+}
+
+// bump marks a change of the served list: advances generation and wakes the waiting WatchInstances calls. Must be
+// called under mu.
+func (d *discovererFile) bump() {
+	d.generation++
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// UnregisterInstance excludes the instance from GetInstances until the file changes (or for cfg.ExclusionTTL when set);
+// the file itself is not changed.
+//
+// Parameter instanceID — instance ID reported as failed.
+//
+// Returns: nil.
+//
+// Called from service.connectionPool.unregisterLoop after OnBackendFailure closed the connection to the instance.
+func (d *discovererFile) UnregisterInstance(_ context.Context, instanceID string) error {
+	var until time.Time
+	if d.cfg.ExclusionTTL > 0 {
+		until = d.timeProvider.Now().Add(d.cfg.ExclusionTTL)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.excluded[instanceID] = until
+	d.bump()
+	return nil
+}
+
+// load re-reads the file when force is set, nothing was loaded yet, or its mtime or size changed. A successful reload
+// replaces the list and clears the exclusions; a failed one keeps the previous list (logged). Must be called under mu.
+//
+// Returns: nil when a list is available; error when the file cannot be read or parsed and nothing was loaded yet.
+func (d *discovererFile) load(force bool) error {
+	info, err := os.Stat(d.cfg.Path)
+	if err != nil {
+		return d.keepPrevious(fmt.Errorf("stat instance file: %w", err))
+	}
+	if !force && d.instances != nil && info.ModTime().Equal(d.modTime) && info.Size() == d.size {
+		return nil
+	}
+	data, err := os.ReadFile(d.cfg.Path)
+	if err != nil {
+		return d.keepPrevious(fmt.Errorf("read instance file: %w", err))
+	}
+	instances, err := ParseInstanceFile(data)
+	if err != nil {
+		return d.keepPrevious(err)
+	}
+	d.instances = instances
+	d.modTime = info.ModTime()
+	d.size = info.Size()
+	clear(d.excluded)
+	d.bump()
+	level.Info(d.logger).Log("msg", "instance file loaded", "instances", len(instances))
+	return nil
+}
+
+// keepPrevious returns err when nothing was loaded yet; otherwise logs it and returns nil so the previous list is served.
+func (d *discovererFile) keepPrevious(err error) error {
+	if d.instances == nil {
+		return err
+	}
+	level.Warn(d.logger).Log("msg", "instance file not reloaded, keeping previous list", "err", err)
+	return nil
+}
+
+// watch starts an fsnotify watcher on the directory of the file and a goroutine that reloads on its events: a forced
+// reload for events on the file itself, an mtime/size check for other entries of the directory (ConfigMap volumes swap
+// a symlinked ..data directory instead of writing the file).
+//
+// Returns: nil when the watcher is running; error when it cannot be created (GetInstances polling still works).
+func (d *discovererFile) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(d.cfg.Path)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				d.mu.Lock()
+				_ = d.load(filepath.Clean(event.Name) == d.cfg.Path)
+				d.mu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				level.Warn(d.logger).Log("msg", "file watch error", "err", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// discovererFileWatch is a discovererFile whose fsnotify watcher is running, so service.connectionPool applies a
+// rewrite of the file as soon as the watcher reloads it instead of on the next refresh.
+type discovererFileWatch struct {
+	*discovererFile
+}
+
+// WatchInstances returns the instances (as GetInstances) once the served list differs from version: at once for an
+// empty or outdated version, otherwise when the watcher reloads the file, an exclusion is added or ends, or, with the
+// unchanged list, after fileWatchTimeout. Every wake-up also runs the mtime/size check of GetInstances.
+//
+// Parameters: ctx — cancels the wait; version — version from the previous call ("" — return the current list).
+//
+// Returns: (instances, version, nil); (nil, "", error) when no list was loaded yet or ctx ended.
+//
+// Called from service.connectionPool.watchLoop in a loop.
+func (d *discovererFileWatch) WatchInstances(ctx context.Context, version string) ([]domain.ServiceInstance, string, error) {
+	deadline := time.NewTimer(fileWatchTimeout)
+	defer deadline.Stop()
+	for {
+		d.mu.Lock()
+		if err := d.load(false); err != nil {
+			d.mu.Unlock()
+			return nil, "", err
+		}
+		out, nextExpiry := d.current()
+		current := strconv.FormatUint(d.generation, 10)
+		changed := d.changed
+		d.mu.Unlock()
+		if current != version {
+			return out, current, nil
+		}
+
+		var expiry *time.Timer
+		var expired <-chan time.Time
+		if !nextExpiry.IsZero() {
+			expiry = time.NewTimer(nextExpiry.Sub(d.timeProvider.Now()))
+			expired = expiry.C
+		}
+		timedOut := false
+		select {
+		case <-ctx.Done():
+		case <-deadline.C:
+			timedOut = true
+		case <-changed:
+		case <-expired:
+		}
+		if expiry != nil {
+			expiry.Stop()
+		}
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		if timedOut {
+			return out, current, nil
+		}
+	}
+}
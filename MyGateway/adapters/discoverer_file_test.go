@@ -0,0 +1,298 @@
+package adapters
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/interfaces"
+	"mygateway/interfaces/mock"
+	"mygateway/service"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const twoInstancesYAML = `instances:
+  - instance_id: i2
+    ipv4: 10.0.0.2
+    port: 50051
+  - instance_id: i1
+    ipv4: 10.0.0.1
+    port: 50051
+`
+
+func writeInstanceFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func instanceIDs(t *testing.T, d interfaces.Discoverer) []string {
+	t.Helper()
+	instances, err := d.GetInstances(context.Background())
+	require.NoError(t, err)
+	ids := make([]string, 0, len(instances))
+	for _, inst := range instances {
+		ids = append(ids, inst.InstanceID)
+	}
+	return ids
+}
+
+func TestDiscovererFile_Panics(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	assert.PanicsWithValue(t, "adapters.discoverer_file.go: path is required", func() {
+		DiscovererFile(domain.FileDiscovery{}, tp, log.NewNopLogger())
+	})
+	assert.PanicsWithValue(t, "adapters.discoverer_file.go: time provider is required", func() {
+		DiscovererFile(domain.FileDiscovery{Path: "instances.yaml"}, nil, log.NewNopLogger())
+	})
+	assert.PanicsWithValue(t, "adapters.discoverer_file.go: logger is required", func() {
+		DiscovererFile(domain.FileDiscovery{Path: "instances.yaml"}, tp, nil)
+	})
+}
+
+func TestParseInstanceFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		want        []domain.ServiceInstance
+		wantContain string
+	}{
+		{
+			name: "yaml_sorted",
+			data: twoInstancesYAML,
+			want: []domain.ServiceInstance{
+				{InstanceID: "i1", Address: "10.0.0.1", Port: 50051},
+				{InstanceID: "i2", Address: "10.0.0.2", Port: 50051},
+			},
+		},
+		{
+			name: "json",
+			data: `{"instances": [{"instance_id": "i1", "ipv4": "10.0.0.1", "port": 50051}]}`,
+			want: []domain.ServiceInstance{{InstanceID: "i1", Address: "10.0.0.1", Port: 50051}},
+		},
+		{
+			name: "labels",
+			data: "instances:\n  - {instance_id: i1, ipv4: 10.0.0.1, port: 1, labels: {version: v2, ' zone ': eu-1a}}\n",
+			want: []domain.ServiceInstance{{InstanceID: "i1", Address: "10.0.0.1", Port: 1, Labels: map[string]string{"version": "v2", "zone": "eu-1a"}}},
+		},
+		{name: "empty_list", data: `{"instances": []}`, want: []domain.ServiceInstance{}},
+		{name: "missing_instances", data: `{}`, wantContain: "missing instances field"},
+		{name: "syntax_error", data: `{"instances": [`, wantContain: "parse instance file"},
+		{name: "missing_id", data: "instances:\n  - ipv4: 10.0.0.1\n    port: 1\n", wantContain: "instances[0]: instance_id is required"},
+		{name: "duplicate_id", data: "instances:\n  - {instance_id: i1, ipv4: 10.0.0.1, port: 1}\n  - {instance_id: i1, ipv4: 10.0.0.2, port: 1}\n", wantContain: `instances[1]: duplicate instance_id "i1"`},
+		{
+			name: "address",
+			data: "instances:\n  - {instance_id: i1, address: '2001:db8::1', port: 1}\n  - {instance_id: i2, address: '[2001:db8::2]', port: 1}\n  - {instance_id: i3, address: backend-0.backend.svc, ipv4: 10.0.0.3, port: 1}\n",
+			want: []domain.ServiceInstance{
+				{InstanceID: "i1", Address: "2001:db8::1", Port: 1},
+				{InstanceID: "i2", Address: "2001:db8::2", Port: 1},
+				{InstanceID: "i3", Address: "backend-0.backend.svc", Port: 1},
+			},
+		},
+		{name: "missing_address", data: "instances:\n  - {instance_id: i1, port: 1}\n", wantContain: "instances[0]: address is required"},
+		{name: "address_with_port", data: "instances:\n  - {instance_id: i1, address: 'backend:50051', port: 1}\n", wantContain: "instances[0]: address must be an IP address or a host name without port"},
+		{name: "empty_label_name", data: "instances:\n  - {instance_id: i1, ipv4: 10.0.0.1, port: 1, labels: {'': v2}}\n", wantContain: "instances[0]: labels must not have empty names"},
+		{name: "port_range", data: "instances:\n  - {instance_id: i1, ipv4: 10.0.0.1, port: 65536}\n", wantContain: "instances[0]: port must be between 1 and 65535"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseInstanceFile([]byte(tc.data))
+			if tc.wantContain != "" {
+				assert.ErrorContains(t, err, tc.wantContain)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDiscovererFile_GetInstances(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+
+	t.Run("missing_file", func(t *testing.T) {
+		d := DiscovererFile(domain.FileDiscovery{Path: filepath.Join(t.TempDir(), "instances.yaml")}, tp, log.NewNopLogger())
+		_, err := d.GetInstances(context.Background())
+		assert.ErrorContains(t, err, "stat instance file")
+	})
+	t.Run("reloads_on_change_and_keeps_list_on_broken_rewrite", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "instances.yaml")
+		writeInstanceFile(t, path, twoInstancesYAML)
+		d := DiscovererFile(domain.FileDiscovery{Path: path}, tp, log.NewNopLogger())
+		assert.Equal(t, []string{"i1", "i2"}, instanceIDs(t, d))
+
+		writeInstanceFile(t, path, `{"instances": [{"instance_id": "i3", "ipv4": "10.0.0.3", "port": 50051}]}`)
+		assert.Equal(t, []string{"i3"}, instanceIDs(t, d), "size changed: picked up on the next refresh")
+
+		writeInstanceFile(t, path, `{"instances": [`)
+		assert.Equal(t, []string{"i3"}, instanceIDs(t, d), "broken rewrite keeps the previous list")
+		require.NoError(t, os.Remove(path))
+		assert.Equal(t, []string{"i3"}, instanceIDs(t, d), "removed file keeps the previous list")
+	})
+}
+
+func TestDiscovererFile_WatchesFile(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "instances.yaml")
+	writeInstanceFile(t, path, twoInstancesYAML)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	d := DiscovererFile(domain.FileDiscovery{Path: path}, tp, log.NewNopLogger())
+	assert.Equal(t, []string{"i1", "i2"}, instanceIDs(t, d))
+
+	// Same size and mtime: the stat check on refresh cannot see this rewrite, only the watcher can.
+	writeInstanceFile(t, path, strings.ReplaceAll(twoInstancesYAML, "i2", "i9"))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	assert.Eventually(t, func() bool {
+		ids := instanceIDs(t, d)
+		return len(ids) == 2 && ids[1] == "i9"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Atomic replace (write elsewhere, rename over the file), as config-management tools do.
+	tmp := filepath.Join(dir, ".instances.tmp")
+	writeInstanceFile(t, tmp, strings.ReplaceAll(twoInstancesYAML, "i1", "i0"))
+	require.NoError(t, os.Chtimes(tmp, info.ModTime(), info.ModTime()))
+	require.NoError(t, os.Rename(tmp, path))
+	assert.Eventually(t, func() bool {
+		ids := instanceIDs(t, d)
+		return len(ids) == 2 && ids[0] == "i0"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDiscovererFile_UnregisterExcludes(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
+
+	t.Run("until_file_changes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "instances.yaml")
+		writeInstanceFile(t, path, twoInstancesYAML)
+		d := DiscovererFile(domain.FileDiscovery{Path: path}, tp, log.NewNopLogger())
+		require.NoError(t, d.UnregisterInstance(context.Background(), "i1"))
+		assert.Equal(t, []string{"i2"}, instanceIDs(t, d))
+		now = now.Add(time.Hour)
+		assert.Equal(t, []string{"i2"}, instanceIDs(t, d), "no TTL: excluded while the file is unchanged")
+
+		writeInstanceFile(t, path, twoInstancesYAML+"\n")
+		assert.Equal(t, []string{"i1", "i2"}, instanceIDs(t, d), "rewritten file clears exclusions")
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, twoInstancesYAML+"\n", string(data), "file itself is not changed")
+	})
+	t.Run("ttl", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "instances.yaml")
+		writeInstanceFile(t, path, twoInstancesYAML)
+		d := DiscovererFile(domain.FileDiscovery{Path: path, ExclusionTTL: 30 * time.Second}, tp, log.NewNopLogger())
+		require.NoError(t, d.UnregisterInstance(context.Background(), "i2"))
+		assert.Equal(t, []string{"i1"}, instanceIDs(t, d))
+		now = now.Add(29 * time.Second)
+		assert.Equal(t, []string{"i1"}, instanceIDs(t, d), "still excluded before the TTL")
+		now = now.Add(time.Second)
+		assert.Equal(t, []string{"i1", "i2"}, instanceIDs(t, d), "back after the TTL")
+	})
+}
+
+func TestDiscovererFile_WatchInstances(t *testing.T) {
+	var offset atomic.Int64 // read by the watch goroutines
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return time.Now().Add(time.Duration(offset.Load())) }}
+	path := filepath.Join(t.TempDir(), "instances.yaml")
+	writeInstanceFile(t, path, twoInstancesYAML)
+	d := DiscovererFile(domain.FileDiscovery{Path: path, ExclusionTTL: 50 * time.Millisecond}, tp, log.NewNopLogger())
+	watcher, ok := d.(interfaces.InstanceWatcher)
+	require.True(t, ok, "watcher running: the pool watches instead of polling")
+
+	type answer struct {
+		ids     []string
+		version string
+		err     error
+	}
+	watch := func(ctx context.Context, version string) <-chan answer {
+		out := make(chan answer, 1)
+		go func() {
+			instances, next, err := watcher.WatchInstances(ctx, version)
+			ids := make([]string, 0, len(instances))
+			for _, inst := range instances {
+				ids = append(ids, inst.InstanceID)
+			}
+			out <- answer{ids: ids, version: next, err: err}
+		}()
+		return out
+	}
+	ctx := context.Background()
+
+	first := <-watch(ctx, "")
+	require.NoError(t, first.err)
+	assert.Equal(t, []string{"i1", "i2"}, first.ids, "empty version answers at once")
+
+	pending := watch(ctx, first.version)
+	select {
+	case a := <-pending:
+		t.Fatalf("answered without a change: %+v", a)
+	case <-time.After(50 * time.Millisecond):
+	}
+	writeInstanceFile(t, path, strings.ReplaceAll(twoInstancesYAML, "i2", "i9"))
+	var edited answer
+	select {
+	case edited = <-pending:
+	case <-time.After(5 * time.Second):
+		t.Fatal("file edit did not wake the watch")
+	}
+	require.NoError(t, edited.err)
+	assert.Equal(t, []string{"i1", "i9"}, edited.ids)
+	assert.NotEqual(t, first.version, edited.version)
+
+	pending = watch(ctx, edited.version)
+	require.NoError(t, d.UnregisterInstance(ctx, "i9"))
+	excluded := <-pending
+	assert.Equal(t, []string{"i1"}, excluded.ids, "exclusion is a change")
+
+	pending = watch(ctx, excluded.version)
+	offset.Add(int64(50 * time.Millisecond))
+	select {
+	case back := <-pending:
+		assert.Equal(t, []string{"i1", "i9"}, back.ids, "end of the exclusion wakes the watch")
+	case <-time.After(5 * time.Second):
+		t.Fatal("end of the exclusion did not wake the watch")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	pending = watch(cancelled, excluded.version+"-unchanged")
+	<-pending // outdated version answers at once
+	current := <-watch(ctx, "")
+	pending = watch(cancelled, current.version)
+	cancel()
+	assert.ErrorIs(t, (<-pending).err, context.Canceled)
+}
+
+func TestDiscovererFile_EditReachesPool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instances.yaml")
+	writeInstanceFile(t, path, "instances:\n  - {instance_id: i1, address: 127.0.0.1, port: 50051}\n")
+	d := DiscovererFile(domain.FileDiscovery{Path: path}, &mock.TimeProviderMock{NowFunc: time.Now}, log.NewNopLogger())
+	factory := func(_ context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
+		return grpc.NewClient(net.JoinHostPort(inst.Address, strconv.Itoa(inst.Port)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	pool := service.NewConnectionPool(d, factory, time.Hour, log.NewNopLogger(), service.NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
+	defer pool.Close()
+	pick := func() string {
+		_, id, err := pool.GetConnectionRoundRobin(context.Background(), nil)
+		require.NoError(t, err)
+		pool.ReleaseStream(id)
+		return id
+	}
+	require.Equal(t, "i1", pick())
+
+	writeInstanceFile(t, path, "instances:\n  - {instance_id: i2, address: 127.0.0.1, port: 50052}\n")
+	assert.Eventually(t, func() bool { return pick() == "i2" }, 5*time.Second, 10*time.Millisecond,
+		"edit applied long before the one-hour refresh")
+}
@@ -0,0 +1,193 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DiscovererKubernetes creates an interfaces.Discoverer over the EndpointSlices of a Kubernetes Service. The slices
+// (label kubernetes.io/service-name=cfg.Service in cfg.Namespace) are watched by an informer, so GetInstances reads a
+// local cache instead of calling the API server. Ready endpoints become instances with the pod name as ID; terminating
+// endpoints that are still serving are returned with Draining set. UnregisterInstance does not touch the cluster: the
+// instance is left out of the results for cfg.ExclusionTTL. The informer runs until process exit, like
+// connectionPool.refreshLoop. Panics on empty service or namespace, nil client or time provider.
+//
+// Parameters: cfg — validated Kubernetes settings of the cluster; client — clientset (in-cluster or kubeconfig in
+// cmd/main, k8s.io/client-go/kubernetes/fake in tests); timeProvider — clock for exclusions.
+//
+// Returns: interfaces.Discoverer (*discovererKubernetes).
+//
+// Called from cmd/main for each dynamic cluster with discoverer kubernetes.
+func DiscovererKubernetes(cfg domain.KubernetesDiscovery, client kubernetes.Interface, timeProvider interfaces.TimeProvider) interfaces.Discoverer {
+	helpers.StrPanic(cfg.Service, "adapters.discoverer_k8s.go: service is required")
+	helpers.StrPanic(cfg.Namespace, "adapters.discoverer_k8s.go: namespace is required")
+	helpers.NilPanic(client, "adapters.discoverer_k8s.go: kubernetes client is required")
+	d := &discovererKubernetes{
+		cfg:          cfg,
+		timeProvider: helpers.NilPanic(timeProvider, "adapters.discoverer_k8s.go: time provider is required"),
+		excluded:     make(map[string]time.Time),
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+		informers.WithNamespace(cfg.Namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = discoveryv1.LabelServiceName + "=" + cfg.Service
+		}),
+	)
+	informer := factory.Discovery().V1().EndpointSlices()
+	d.lister = informer.Lister().EndpointSlices(cfg.Namespace)
+	d.synced = informer.Informer().HasSynced
+	factory.Start(wait.NeverStop)
+	return d
+}
+
+// discovererKubernetes implements interfaces.Discoverer over an EndpointSlice informer. Holds cfg, timeProvider, lister
+// (slices of the service), synced (informer cache filled) and, under mu, excluded (instance ID → end of exclusion).
+type discovererKubernetes struct {
+	cfg          domain.KubernetesDiscovery
+	timeProvider interfaces.TimeProvider
+	lister       discoverylisters.EndpointSliceNamespaceLister
+	synced       cache.InformerSynced
+
+	mu       sync.Mutex
+	excluded map[string]time.Time
+}
+
+// GetInstances returns one instance per ready or terminating-but-serving endpoint of the service, sorted by ID and
+// without the excluded ones. Waits up to 5s (within ctx) for the first sync of the informer. Slices without the configured port
+// and FQDN slices are skipped; a pod listed in two slices (dual-stack) is returned once, preferring a ready endpoint.
+//
+// Returns: ([]domain.ServiceInstance, nil) (possibly empty); (nil, error) when the cache is not synced in time.
+//
+// Called from service.connectionPool.refresh (on timer and at startup).
+func (d *discovererKubernetes) GetInstances(ctx context.Context) ([]domain.ServiceInstance, error) {
+	if !d.synced() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if !cache.WaitForCacheSync(ctx.Done(), d.synced) {
+			return nil, fmt.Errorf("endpoint slices of %s/%s not synced", d.cfg.Namespace, d.cfg.Service)
+		}
+	}
+	endpointSlices, err := d.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(endpointSlices, func(a, b *discoveryv1.EndpointSlice) int { return strings.Compare(a.Name, b.Name) })
+	byID := make(map[string]domain.ServiceInstance)
+	for _, slice := range endpointSlices {
+		port, ok := d.slicePort(slice)
+		if !ok || slice.AddressType == discoveryv1.AddressTypeFQDN {
+			continue
+		}
+		for _, ep := range slice.Endpoints {
+			inst, ok := endpointInstance(ep, port)
+			if !ok {
+				continue
+			}
+			if prev, dup := byID[inst.InstanceID]; dup && !prev.Draining {
+				continue
+			}
+			byID[inst.InstanceID] = inst
+		}
+	}
+	instances := make([]domain.ServiceInstance, 0, len(byID))
+	for _, inst := range byID {
+		instances = append(instances, inst)
+	}
+	slices.SortFunc(instances, func(a, b domain.ServiceInstance) int { return strings.Compare(a.InstanceID, b.InstanceID) })
+
+	now := d.timeProvider.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]domain.ServiceInstance, 0, len(instances))
+	for _, inst := range instances {
+		if until, ok := d.excluded[inst.InstanceID]; ok {
+			if now.Before(until) {
+				continue
+			}
+			delete(d.excluded, inst.InstanceID)
+		}
+		out = append(out, inst)
+	}
+	return out, nil
+}
+
+// UnregisterInstance excludes the instance from GetInstances for cfg.ExclusionTTL; the EndpointSlices are not changed
+// (readiness is owned by the kubelet).
+//
+// Parameter instanceID — instance ID (pod name) reported as failed.
+//
+// Returns: nil.
+//
+// Called from service.connectionPool.unregisterLoop after OnBackendFailure closed the connection to the instance.
+func (d *discovererKubernetes) UnregisterInstance(_ context.Context, instanceID string) error {
+	now := d.timeProvider.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, until := range d.excluded {
+		if !until.After(now) {
+			delete(d.excluded, id)
+		}
+	}
+	d.excluded[instanceID] = now.Add(d.cfg.ExclusionTTL)
+	return nil
+}
+
+// slicePort returns the port of the slice named cfg.PortName (an unnamed port matches "").
+func (d *discovererKubernetes) slicePort(slice *discoveryv1.EndpointSlice) (int, bool) {
+	for _, p := range slice.Ports {
+		name := ""
+		if p.Name != nil {
+			name = *p.Name
+		}
+		if name == d.cfg.PortName && p.Port != nil {
+			return int(*p.Port), true
+		}
+	}
+	return 0, false
+}
+
+// endpointInstance converts an endpoint to an instance: ready (not terminating) — active; terminating but serving —
+// Draining; anything else is skipped. The ID is the pod name (targetRef), or the address for endpoints without a pod;
+// the endpoint zone, when set, becomes the "zone" label.
+// Unset conditions follow the EndpointSlice API: ready and serving default to true, terminating to false.
+func endpointInstance(ep discoveryv1.Endpoint, port int) (domain.ServiceInstance, bool) {
+	if len(ep.Addresses) == 0 {
+		return domain.ServiceInstance{}, false
+	}
+	ready := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+	serving := ep.Conditions.Serving == nil || *ep.Conditions.Serving
+	terminating := ep.Conditions.Terminating != nil && *ep.Conditions.Terminating
+	var draining bool
+	switch {
+	case terminating && serving:
+		draining = true
+	case terminating, !ready:
+		return domain.ServiceInstance{}, false
+	}
+	id := ep.Addresses[0]
+	if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" && ep.TargetRef.Name != "" {
+		id = ep.TargetRef.Name
+	}
+	var labels map[string]string
+	if ep.Zone != nil && *ep.Zone != "" {
+		labels = map[string]string{domain.ZoneLabel: *ep.Zone}
+	}
+	return domain.ServiceInstance{InstanceID: id, Address: ep.Addresses[0], Port: port, Draining: draining, Labels: labels}, true
+}
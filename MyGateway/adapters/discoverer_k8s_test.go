@@ -0,0 +1,144 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/interfaces/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+// testEndpoint builds an endpoint of pod name at addr with the given conditions (nil — unset).
+func testEndpoint(name, addr string, ready, serving, terminating *bool) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses:  []string{addr},
+		Conditions: discoveryv1.EndpointConditions{Ready: ready, Serving: serving, Terminating: terminating},
+		TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: name, Namespace: "shop"},
+	}
+}
+
+// withZone sets the zone of ep.
+func withZone(ep discoveryv1.Endpoint, zone string) discoveryv1.Endpoint {
+	ep.Zone = ptr.To(zone)
+	return ep
+}
+
+// testEndpointSlice builds a slice of service in namespace shop with ports "grpc" (50051) and "metrics" (9090).
+func testEndpointSlice(name, service string, addressType discoveryv1.AddressType, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "shop",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+		},
+		AddressType: addressType,
+		Endpoints:   endpoints,
+		Ports: []discoveryv1.EndpointPort{
+			{Name: ptr.To("grpc"), Port: ptr.To[int32](50051)},
+			{Name: ptr.To("metrics"), Port: ptr.To[int32](9090)},
+		},
+	}
+}
+
+func TestDiscovererKubernetes_Panics(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	client := fake.NewClientset()
+	cfg := domain.KubernetesDiscovery{Service: "orders", Namespace: "shop"}
+	assert.PanicsWithValue(t, "adapters.discoverer_k8s.go: service is required", func() {
+		DiscovererKubernetes(domain.KubernetesDiscovery{Namespace: "shop"}, client, tp)
+	})
+	assert.PanicsWithValue(t, "adapters.discoverer_k8s.go: namespace is required", func() {
+		DiscovererKubernetes(domain.KubernetesDiscovery{Service: "orders"}, client, tp)
+	})
+	assert.PanicsWithValue(t, "adapters.discoverer_k8s.go: kubernetes client is required", func() {
+		DiscovererKubernetes(cfg, nil, tp)
+	})
+	assert.PanicsWithValue(t, "adapters.discoverer_k8s.go: time provider is required", func() {
+		DiscovererKubernetes(cfg, client, nil)
+	})
+}
+
+func TestDiscovererKubernetes_GetInstances(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	client := fake.NewClientset(
+		testEndpointSlice("orders-ipv4", "orders", discoveryv1.AddressTypeIPv4,
+			testEndpoint("orders-b", "10.0.0.2", nil, nil, nil),
+			withZone(testEndpoint("orders-a", "10.0.0.1", ptr.To(true), ptr.To(true), ptr.To(false)), "eu-1a"),
+			testEndpoint("orders-c", "10.0.0.3", ptr.To(false), ptr.To(true), ptr.To(true)),
+			testEndpoint("orders-d", "10.0.0.4", ptr.To(false), ptr.To(false), ptr.To(false)),
+			testEndpoint("orders-e", "10.0.0.5", ptr.To(false), ptr.To(false), ptr.To(true)),
+		),
+		testEndpointSlice("orders-ipv6", "orders", discoveryv1.AddressTypeIPv6,
+			testEndpoint("orders-a", "fd00::1", ptr.To(true), nil, nil),
+		),
+		testEndpointSlice("payments-ipv4", "payments", discoveryv1.AddressTypeIPv4,
+			testEndpoint("payments-a", "10.0.1.1", nil, nil, nil),
+		),
+	)
+	d := DiscovererKubernetes(domain.KubernetesDiscovery{Service: "orders", Namespace: "shop", PortName: "grpc"}, client, tp)
+
+	got, err := d.GetInstances(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ServiceInstance{
+		{InstanceID: "orders-a", Address: "10.0.0.1", Port: 50051, Labels: map[string]string{"zone": "eu-1a"}},
+		{InstanceID: "orders-b", Address: "10.0.0.2", Port: 50051},
+		{InstanceID: "orders-c", Address: "10.0.0.3", Port: 50051, Draining: true},
+	}, got, "ready pods by name, zone as label, terminating-but-serving pod draining, not-ready and other services left out")
+
+	t.Run("follows_slice_updates", func(t *testing.T) {
+		slice := testEndpointSlice("orders-ipv4", "orders", discoveryv1.AddressTypeIPv4,
+			testEndpoint("orders-b", "10.0.0.2", ptr.To(false), ptr.To(true), ptr.To(true)),
+			testEndpoint("orders-f", "10.0.0.6", nil, nil, nil),
+		)
+		_, err := client.DiscoveryV1().EndpointSlices("shop").Update(context.Background(), slice, metav1.UpdateOptions{})
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			got, err := d.GetInstances(context.Background())
+			return err == nil && assert.ObjectsAreEqual([]domain.ServiceInstance{
+				{InstanceID: "orders-a", Address: "fd00::1", Port: 50051},
+				{InstanceID: "orders-b", Address: "10.0.0.2", Port: 50051, Draining: true},
+				{InstanceID: "orders-f", Address: "10.0.0.6", Port: 50051},
+			}, got)
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+	t.Run("port_name_not_in_slice", func(t *testing.T) {
+		d := DiscovererKubernetes(domain.KubernetesDiscovery{Service: "orders", Namespace: "shop", PortName: "http"}, client, tp)
+		got, err := d.GetInstances(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestDiscovererKubernetes_UnregisterExcludesTemporarily(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
+	client := fake.NewClientset(testEndpointSlice("orders-ipv4", "orders", discoveryv1.AddressTypeIPv4,
+		testEndpoint("orders-a", "10.0.0.1", nil, nil, nil),
+		testEndpoint("orders-b", "10.0.0.2", nil, nil, nil),
+	))
+	d := DiscovererKubernetes(domain.KubernetesDiscovery{Service: "orders", Namespace: "shop", PortName: "grpc", ExclusionTTL: 30 * time.Second}, client, tp)
+
+	require.NoError(t, d.UnregisterInstance(context.Background(), "orders-a"))
+	got, err := d.GetInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "orders-b", got[0].InstanceID)
+
+	now = now.Add(30 * time.Second)
+	got, err = d.GetInstances(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, got, 2, "back after the TTL")
+
+	slice, err := client.DiscoveryV1().EndpointSlices("shop").Get(context.Background(), "orders-ipv4", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, slice.Endpoints, 2, "the cluster is not modified")
+}
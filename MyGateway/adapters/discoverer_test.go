@@ -1,25 +1,47 @@
 package adapters
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"mygateway/domain"
+	"mygateway/interfaces"
+	"mygateway/interfaces/mock"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// httpDiscovery returns HTTP discovery settings over urls with a short request timeout.
+func httpDiscovery(urls ...string) domain.HTTPDiscovery {
+	return domain.HTTPDiscovery{URLs: urls, Timeout: time.Second}
+}
+
 func TestDiscovererHTTP_Panics(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	t.Run("no_urls", func(t *testing.T) {
+		assert.PanicsWithValue(t, "adapters.discoverer.go: at least one URL is required", func() {
+			DiscovererHTTP(domain.HTTPDiscovery{}, &http.Client{}, tp)
+		})
+	})
 	t.Run("baseURL_empty", func(t *testing.T) {
 		assert.PanicsWithValue(t, "adapters.discoverer.go: baseURL is required", func() {
-			DiscovererHTTP("", &http.Client{})
+			DiscovererHTTP(httpDiscovery("http://localhost:8080", ""), &http.Client{}, tp)
 		})
 	})
 	t.Run("client_nil", func(t *testing.T) {
 		assert.PanicsWithValue(t, "adapters.discoverer.go: http client is required", func() {
-			DiscovererHTTP("http://localhost:8080", nil)
+			DiscovererHTTP(httpDiscovery("http://localhost:8080"), nil, tp)
+		})
+	})
+	t.Run("time_provider_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "adapters.discoverer.go: time provider is required", func() {
+			DiscovererHTTP(httpDiscovery("http://localhost:8080"), &http.Client{}, nil)
 		})
 	})
 }
@@ -38,7 +60,29 @@ func TestDiscovererHTTP_GetInstances(t *testing.T) {
 			statusCode: http.StatusOK,
 			body:       `{"instances":[{"instance_id":"i1","ipv4":"127.0.0.1","port":9000}]}`,
 			wantInstances: []domain.ServiceInstance{
-				{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9000, AssignedClientSessionID: ""},
+				{InstanceID: "i1", Address: "127.0.0.1", Port: 9000, AssignedClientSessionID: ""},
+			},
+		},
+		{
+			name:       "success_with_labels",
+			statusCode: http.StatusOK,
+			body:       `{"instances":[{"instance_id":"i1","ipv4":"127.0.0.1","port":9000,"labels":{"version":"v2","zone":"eu-1a"}}]}`,
+			wantInstances: []domain.ServiceInstance{
+				{InstanceID: "i1", Address: "127.0.0.1", Port: 9000, Labels: map[string]string{"version": "v2", "zone": "eu-1a"}},
+			},
+		},
+		{
+			name:       "success_address_preferred_over_ipv4",
+			statusCode: http.StatusOK,
+			body: `{"instances":[{"instance_id":"i1","address":"10.0.0.5","ipv4":"10.0.0.5","port":9000},` +
+				`{"instance_id":"i2","address":"2001:db8::1","port":9000},` +
+				`{"instance_id":"i3","address":"[2001:db8::2]","port":9000},` +
+				`{"instance_id":"i4","address":"backend-0.backend.svc","port":9000}]}`,
+			wantInstances: []domain.ServiceInstance{
+				{InstanceID: "i1", Address: "10.0.0.5", Port: 9000},
+				{InstanceID: "i2", Address: "2001:db8::1", Port: 9000},
+				{InstanceID: "i3", Address: "2001:db8::2", Port: 9000},
+				{InstanceID: "i4", Address: "backend-0.backend.svc", Port: 9000},
 			},
 		},
 		{
@@ -52,7 +96,7 @@ func TestDiscovererHTTP_GetInstances(t *testing.T) {
 			statusCode: http.StatusOK,
 			body:       `{"instances":[{"instance_id":"i2","ipv4":"10.0.0.1","port":9001,"resolved_address":"10.0.0.2","assigned_client_session_id":"sess-1"}]}`,
 			wantInstances: []domain.ServiceInstance{
-				{InstanceID: "i2", Ipv4: "10.0.0.1", Port: 9001, AssignedClientSessionID: ""},
+				{InstanceID: "i2", Address: "10.0.0.1", Port: 9001, AssignedClientSessionID: ""},
 			},
 		},
 		{
@@ -95,8 +139,8 @@ func TestDiscovererHTTP_GetInstances(t *testing.T) {
 			}))
 			defer server.Close()
 
-			disc := DiscovererHTTP(server.URL, server.Client())
-			got, err := disc.GetInstances()
+			disc := DiscovererHTTP(httpDiscovery(server.URL), server.Client(), &mock.TimeProviderMock{NowFunc: time.Now})
+			got, err := disc.GetInstances(context.Background())
 			if tt.wantErr {
 				require.Error(t, err)
 				if tt.wantErrContain != "" {
@@ -156,8 +200,8 @@ func TestDiscovererHTTP_UnregisterInstance(t *testing.T) {
 			}))
 			defer server.Close()
 
-			disc := DiscovererHTTP(server.URL, server.Client())
-			err := disc.UnregisterInstance(tt.instanceID)
+			disc := DiscovererHTTP(httpDiscovery(server.URL), server.Client(), &mock.TimeProviderMock{NowFunc: time.Now})
+			err := disc.UnregisterInstance(context.Background(), tt.instanceID)
 			if tt.wantErr {
 				require.Error(t, err)
 				if tt.wantErrContain != "" {
@@ -171,3 +215,198 @@ func TestDiscovererHTTP_UnregisterInstance(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscovererHTTP_IsNotWatcher(t *testing.T) {
+	_, ok := DiscovererHTTP(httpDiscovery("http://localhost:8080"), &http.Client{}, &mock.TimeProviderMock{NowFunc: time.Now}).(interfaces.InstanceWatcher)
+	assert.False(t, ok, "the watch is opt-in")
+}
+
+func TestDiscovererHTTPWatch_WatchInstances(t *testing.T) {
+	tests := []struct {
+		name           string
+		serviceType    string
+		version        string
+		statusCode     int
+		body           string
+		wantQuery      url.Values
+		wantInstances  []domain.ServiceInstance
+		wantVersion    string
+		wantErrContain string
+	}{
+		{
+			name:          "first_call_without_version",
+			statusCode:    http.StatusOK,
+			body:          `{"version":"v1","instances":[{"instance_id":"i1","ipv4":"127.0.0.1","port":9000}]}`,
+			wantQuery:     url.Values{"timeout_ms": {"25000"}},
+			wantInstances: []domain.ServiceInstance{{InstanceID: "i1", Address: "127.0.0.1", Port: 9000}},
+			wantVersion:   "v1",
+		},
+		{
+			name:          "with_version_and_service_type_empty_list",
+			serviceType:   "orders",
+			version:       "v1",
+			statusCode:    http.StatusOK,
+			body:          `{"version":"v2","instances":[]}`,
+			wantQuery:     url.Values{"service_type": {"orders"}, "version": {"v1"}, "timeout_ms": {"25000"}},
+			wantInstances: []domain.ServiceInstance{},
+			wantVersion:   "v2",
+		},
+		{name: "non_200_returns_error", statusCode: http.StatusNotFound, body: `{}`, wantErrContain: "404"},
+		{name: "missing_version_returns_error", statusCode: http.StatusOK, body: `{"instances":[]}`, wantErrContain: "missing version"},
+		{name: "invalid_json_returns_error", statusCode: http.StatusOK, body: `not json`, wantErrContain: "invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+			var gotQuery url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath, gotQuery = r.URL.Path, r.URL.Query()
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			disc := DiscovererHTTP(domain.HTTPDiscovery{URLs: []string{server.URL}, ServiceType: tt.serviceType, Watch: 25 * time.Second}, server.Client(), &mock.TimeProviderMock{NowFunc: time.Now})
+			got, version, err := disc.(interfaces.InstanceWatcher).WatchInstances(context.Background(), tt.version)
+			if tt.wantErrContain != "" {
+				assert.ErrorContains(t, err, tt.wantErrContain)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "/v1/instances/watch", gotPath)
+			assert.Equal(t, tt.wantQuery, gotQuery)
+			assert.Equal(t, tt.wantInstances, got)
+			assert.Equal(t, tt.wantVersion, version)
+		})
+	}
+	t.Run("ctx_cancel_ends_wait", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+		disc := DiscovererHTTP(domain.HTTPDiscovery{URLs: []string{server.URL}, Watch: 25 * time.Second}, server.Client(), &mock.TimeProviderMock{NowFunc: time.Now})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, _, err := disc.(interfaces.InstanceWatcher).WatchInstances(ctx, "v1")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestDiscovererHTTP_GetInstancesServiceType(t *testing.T) {
+	var gotQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		_, _ = w.Write([]byte(`{"instances":[]}`))
+	}))
+	defer server.Close()
+
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	_, err := DiscovererHTTP(domain.HTTPDiscovery{URLs: []string{server.URL}, ServiceType: "orders service"}, server.Client(), tp).GetInstances(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"service_type": {"orders service"}}, gotQuery)
+
+	_, err = DiscovererHTTP(httpDiscovery(server.URL), server.Client(), tp).GetInstances(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, gotQuery, "no filter without a service type")
+}
+
+// replica is a test MyDiscoverer replica answering with status and counting its requests.
+type replica struct {
+	*httptest.Server
+	status atomic.Int32
+	hits   atomic.Int32
+}
+
+func newReplica(t *testing.T) *replica {
+	r := &replica{}
+	r.status.Store(http.StatusOK)
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.hits.Add(1)
+		w.WriteHeader(int(r.status.Load()))
+		_, _ = w.Write([]byte(`{"version":"v1","instances":[]}`))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestDiscovererHTTP_Failover(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
+	hits := func(rs ...*replica) []int32 {
+		var out []int32
+		for _, r := range rs {
+			out = append(out, r.hits.Swap(0))
+		}
+		return out
+	}
+
+	t.Run("fails_over_and_stays", func(t *testing.T) {
+		a, b := newReplica(t), newReplica(t)
+		disc := DiscovererHTTP(httpDiscovery(a.URL, b.URL), &http.Client{}, tp)
+		a.status.Store(http.StatusServiceUnavailable)
+		_, err := disc.GetInstances(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1, 1}, hits(a, b))
+
+		a.status.Store(http.StatusOK)
+		require.NoError(t, disc.UnregisterInstance(ctx, "i1"))
+		assert.Equal(t, []int32{0, 1}, hits(a, b), "the replica that answered stays current")
+
+		b.status.Store(http.StatusBadGateway)
+		now = now.Add(maxEndpointBackoff)
+		_, err = disc.GetInstances(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1, 1}, hits(a, b), "backoff of a is over — now back to a")
+	})
+	t.Run("failed_replica_is_skipped_during_backoff", func(t *testing.T) {
+		a, b := newReplica(t), newReplica(t)
+		disc := DiscovererHTTP(httpDiscovery(a.URL, b.URL), &http.Client{}, tp)
+		a.status.Store(http.StatusServiceUnavailable)
+		b.status.Store(http.StatusServiceUnavailable)
+		_, err := disc.GetInstances(ctx)
+		assert.ErrorContains(t, err, "503")
+		assert.Equal(t, []int32{1, 1}, hits(a, b))
+
+		a.status.Store(http.StatusOK)
+		_, _ = disc.GetInstances(ctx)
+		assert.Equal(t, int32(1), a.hits.Load()+b.hits.Load(), "both replicas back off; only the one that recovers first is tried")
+		hits(a, b)
+
+		now = now.Add(maxEndpointBackoff)
+		_, err = disc.GetInstances(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1, 0}, hits(a, b))
+	})
+	t.Run("client_error_does_not_fail_over", func(t *testing.T) {
+		a, b := newReplica(t), newReplica(t)
+		disc := DiscovererHTTP(httpDiscovery(a.URL, b.URL), &http.Client{}, tp)
+		a.status.Store(http.StatusBadRequest)
+		_, err := disc.GetInstances(ctx)
+		assert.ErrorContains(t, err, "400")
+		assert.Equal(t, []int32{1, 0}, hits(a, b))
+	})
+	t.Run("request_timeout", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer slow.Close()
+		b := newReplica(t)
+		disc := DiscovererHTTP(domain.HTTPDiscovery{URLs: []string{slow.URL, b.URL}, Timeout: 50 * time.Millisecond}, &http.Client{}, tp)
+		_, err := disc.GetInstances(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), b.hits.Load())
+	})
+	t.Run("failed_watch_moves_to_next_replica", func(t *testing.T) {
+		a, b := newReplica(t), newReplica(t)
+		disc := DiscovererHTTP(domain.HTTPDiscovery{URLs: []string{a.URL, b.URL}, Watch: time.Second, Timeout: time.Second}, &http.Client{}, tp)
+		watcher := disc.(interfaces.InstanceWatcher)
+		a.status.Store(http.StatusServiceUnavailable)
+		_, _, err := watcher.WatchInstances(ctx, "")
+		assert.ErrorContains(t, err, "503")
+		_, version, err := watcher.WatchInstances(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, "v1", version)
+		assert.Equal(t, []int32{1, 1}, hits(a, b))
+	})
+}
@@ -0,0 +1,133 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+)
+
+// snapshotFile is the JSON shape of the last-known-good snapshot: { "saved_at": RFC 3339, "instances": [ ... ] }.
+type snapshotFile struct {
+	SavedAt   time.Time          `json:"saved_at"`
+	Instances []snapshotInstance `json:"instances"`
+}
+
+// snapshotInstance is one element of the instances list of the snapshot file; Ipv4 is only read (snapshots saved before
+// address was added).
+type snapshotInstance struct {
+	InstanceID string            `json:"instance_id"`
+	Address    string            `json:"address"`
+	Ipv4       string            `json:"ipv4,omitempty"`
+	Port       int               `json:"port"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Draining   bool              `json:"draining,omitempty"`
+}
+
+// InstanceSnapshotFile creates an interfaces.InstanceSnapshotStore over a JSON file. Save writes a temporary file in
+// the same directory (created when missing), syncs it to disk and renames it over path, so a crash or a full disk never
+// leaves a half-written snapshot. Panics on empty path.
+//
+// Parameter path — snapshot file of one cluster (clusters.<name>.snapshot_path).
+//
+// Returns: interfaces.InstanceSnapshotStore (*instanceSnapshotFile).
+//
+// Called from cmd/main for each dynamic cluster with snapshot_path.
+func InstanceSnapshotFile(path string) interfaces.InstanceSnapshotStore {
+	return &instanceSnapshotFile{path: filepath.Clean(helpers.StrPanic(path, "adapters.instance_snapshot_file.go: path is required"))}
+}
+
+// instanceSnapshotFile implements interfaces.InstanceSnapshotStore over the file at path.
+type instanceSnapshotFile struct {
+	path string
+}
+
+// Load reads and parses the snapshot file.
+//
+// Returns: (snapshot, nil); (zero, error wrapping fs.ErrNotExist) when the file does not exist; (zero, error) on read
+// or parse error or when saved_at or instances is missing.
+//
+// Called from service.NewConnectionPool when the first refresh fails.
+func (s *instanceSnapshotFile) Load() (domain.InstanceSnapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return domain.InstanceSnapshot{}, fmt.Errorf("read instance snapshot: %w", err)
+	}
+	var raw snapshotFile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return domain.InstanceSnapshot{}, fmt.Errorf("parse instance snapshot: %w", err)
+	}
+	if raw.SavedAt.IsZero() || raw.Instances == nil {
+		return domain.InstanceSnapshot{}, errors.New("instance snapshot: missing saved_at or instances field")
+	}
+	instances := make([]domain.ServiceInstance, 0, len(raw.Instances))
+	for _, r := range raw.Instances {
+		instances = append(instances, domain.ServiceInstance{
+			InstanceID: r.InstanceID,
+			Address:    instanceAddress(r.Address, r.Ipv4),
+			Port:       r.Port,
+			Labels:     r.Labels,
+			Draining:   r.Draining,
+		})
+	}
+	return domain.InstanceSnapshot{SavedAt: raw.SavedAt, Instances: instances}, nil
+}
+
+// Save writes snapshot to a temporary file next to path, syncs it and renames it over path; the directory is then synced
+// so the rename survives a crash (best effort — not every platform can sync a directory).
+//
+// Parameter snapshot — instance list and the time it was obtained.
+//
+// Returns: nil on success; error when the directory, the temporary file or the rename fails (the previous snapshot is
+// kept).
+//
+// Called from service.connectionPool after a successful discovery answer that changed the list.
+func (s *instanceSnapshotFile) Save(snapshot domain.InstanceSnapshot) error {
+	raw := snapshotFile{SavedAt: snapshot.SavedAt.UTC(), Instances: make([]snapshotInstance, 0, len(snapshot.Instances))}
+	for _, inst := range snapshot.Instances {
+		raw.Instances = append(raw.Instances, snapshotInstance{
+			InstanceID: inst.InstanceID,
+			Address:    inst.Address,
+			Port:       inst.Port,
+			Labels:     inst.Labels,
+			Draining:   inst.Draining,
+		})
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("encode instance snapshot: %w", err)
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create instance snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("create instance snapshot: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write instance snapshot: %w", err)
+	}
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
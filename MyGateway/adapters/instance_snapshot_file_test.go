@@ -0,0 +1,65 @@
+package adapters
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceSnapshotFile_Panics(t *testing.T) {
+	assert.PanicsWithValue(t, "adapters.instance_snapshot_file.go: path is required", func() {
+		InstanceSnapshotFile("")
+	})
+}
+
+func TestInstanceSnapshotFile_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snapshots", "orders.json")
+	s := InstanceSnapshotFile(path)
+
+	_, err := s.Load()
+	assert.ErrorIs(t, err, fs.ErrNotExist, "nothing saved yet")
+
+	want := domain.InstanceSnapshot{
+		SavedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		Instances: []domain.ServiceInstance{
+			{InstanceID: "i1", Address: "10.0.0.1", Port: 50051, Labels: map[string]string{"zone": "eu-1a"}},
+			{InstanceID: "i2", Address: "10.0.0.2", Port: 50051, Draining: true},
+		},
+	}
+	require.NoError(t, s.Save(want), "missing directory is created")
+	got, err := s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	require.NoError(t, s.Save(domain.InstanceSnapshot{SavedAt: want.SavedAt.Add(time.Minute)}))
+	got, err = s.Load()
+	require.NoError(t, err)
+	assert.Empty(t, got.Instances, "empty list is a valid snapshot")
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files left")
+
+	t.Run("legacy_ipv4", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(`{"saved_at": "2026-01-01T12:00:00Z", "instances": [{"instance_id": "i1", "ipv4": "10.0.0.1", "port": 50051}]}`), 0o644))
+		got, err := s.Load()
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ServiceInstance{{InstanceID: "i1", Address: "10.0.0.1", Port: 50051}}, got.Instances)
+	})
+
+	t.Run("invalid_file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(`{"instances": []}`), 0o644))
+		_, err := s.Load()
+		assert.ErrorContains(t, err, "missing saved_at or instances field")
+		require.NoError(t, os.WriteFile(path, []byte(`{"saved_at": `), 0o644))
+		_, err = s.Load()
+		assert.ErrorContains(t, err, "parse instance snapshot")
+	})
+}
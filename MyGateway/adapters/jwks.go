@@ -0,0 +1,173 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"mygateway/auth"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+
+	"github.com/go-kit/log"
+)
+
+// JWKSFile creates an interfaces.KeySource that reads a JWKS document from a local file. The file is parsed on first
+// use and re-parsed whenever its modification time changes. Panics on empty path.
+//
+// Parameter path — path to the JWKS JSON file.
+//
+// Returns: interfaces.KeySource (*fileKeys).
+//
+// Called from cmd/main when auth.jwt.jwks_file is set.
+func JWKSFile(path string) interfaces.KeySource {
+	return &fileKeys{path: helpers.StrPanic(path, "adapters.jwks.go: path is required"), parse: auth.ParseJWKS}
+}
+
+// SecretKeysFile creates an interfaces.KeySource that reads a rotation key file (auth.ParseSecretKeys: YAML/JSON list of
+// id, secret, not_after). Like JWKSFile, the file is re-parsed whenever its modification time changes, so keys can be
+// rotated without a restart. Panics on empty path.
+//
+// Parameter path — path to the key file.
+//
+// Returns: interfaces.KeySource (*fileKeys).
+//
+// Called from cmd/main when JWT_KEYS_FILE is set.
+func SecretKeysFile(path string) interfaces.KeySource {
+	return &fileKeys{path: helpers.StrPanic(path, "adapters.jwks.go: path is required"), parse: auth.ParseSecretKeys}
+}
+
+// fileKeys implements interfaces.KeySource over a local key file decoded by parse. Under mu: keys (last parsed set) and modTime (file mtime at last parse).
+type fileKeys struct {
+	path  string
+	parse func(data []byte) ([]auth.VerificationKey, error)
+
+	mu      sync.Mutex
+	keys    []auth.VerificationKey
+	modTime time.Time
+}
+
+// Keys returns the keys from the file, re-reading it when the mtime changed. A broken rewrite keeps serving the previous keys.
+//
+// Returns: (keys, nil) on success or when a cached set exists; (nil, error) when the file cannot be read/parsed and nothing is cached.
+//
+// Called from service.jwtValidator and service.standardJWTValidator on each token validation.
+func (f *fileKeys) Keys() ([]auth.VerificationKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if f.keys != nil {
+			return f.keys, nil
+		}
+		return nil, fmt.Errorf("stat key file: %w", err)
+	}
+	if f.keys != nil && info.ModTime().Equal(f.modTime) {
+		return f.keys, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if f.keys != nil {
+			return f.keys, nil
+		}
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	keys, err := f.parse(data)
+	if err != nil {
+		if f.keys != nil {
+			return f.keys, nil
+		}
+		return nil, err
+	}
+	f.keys = keys
+	f.modTime = info.ModTime()
+	return f.keys, nil
+}
+
+// JWKSHTTP creates an interfaces.KeySource that fetches a JWKS document from url and caches it for refreshInterval.
+// When a refresh fails the previous keys keep being served (the error is logged). Panics on empty url, nil client or nil logger.
+//
+// Parameters: url — JWKS endpoint (e.g. https://idp/.well-known/jwks.json); client — HTTP client (timeout recommended);
+// refreshInterval — cache lifetime of a fetched set; logger — logger for refresh failures.
+//
+// Returns: interfaces.KeySource (*jwksHTTP).
+//
+// Called from cmd/main when auth.jwt.jwks_url is set.
+func JWKSHTTP(url string, client *http.Client, refreshInterval time.Duration, logger log.Logger) interfaces.KeySource {
+	return &jwksHTTP{
+		url:             helpers.StrPanic(url, "adapters.jwks.go: url is required"),
+		client:          helpers.NilPanic(client, "adapters.jwks.go: http client is required"),
+		refreshInterval: refreshInterval,
+		logger:          log.With(helpers.NilPanic(logger, "adapters.jwks.go: logger is required"), "component", "jwks_http"),
+		now:             time.Now,
+	}
+}
+
+// jwksHTTP implements interfaces.KeySource over a JWKS URL. Under mu: keys (last fetched set) and fetchedAt (time of the last fetch attempt).
+type jwksHTTP struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	logger          log.Logger
+	now             func() time.Time
+
+	mu        sync.Mutex
+	keys      []auth.VerificationKey
+	fetchedAt time.Time
+}
+
+// Keys returns the cached keys, fetching the document when the cache is older than refreshInterval.
+//
+// Returns: (keys, nil) on success or when a stale cached set exists; (nil, error) when the first fetch fails.
+//
+// Called from service.standardJWTValidator.ValidateToken.
+func (j *jwksHTTP) Keys() ([]auth.VerificationKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now()
+	if j.keys != nil && now.Sub(j.fetchedAt) < j.refreshInterval {
+		return j.keys, nil
+	}
+	keys, err := j.fetch()
+	j.fetchedAt = now
+	if err != nil {
+		if j.keys != nil {
+			_ = log.With(j.logger, "err", err).Log("msg", "jwks refresh failed, serving cached keys")
+			return j.keys, nil
+		}
+		return nil, err
+	}
+	j.keys = keys
+	return j.keys, nil
+}
+
+// fetch performs GET url with a 5s timeout and parses the body as JWKS.
+//
+// Returns: (keys, nil) on 200 with a usable document; (nil, error) on request error, non-200 status or parse error.
+//
+// Called only from Keys under lock.
+func (j *jwksHTTP) fetch() ([]auth.VerificationKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return auth.ParseJWKS(body)
+}
@@ -0,0 +1,139 @@
+package adapters
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mygateway/auth"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func octJWKS(kid, secret string) string {
+	return `{"keys":[{"kty":"oct","kid":"` + kid + `","k":"` + base64.RawURLEncoding.EncodeToString([]byte(secret)) + `"}]}`
+}
+
+func TestJWKS_Panics(t *testing.T) {
+	t.Run("file_path_empty", func(t *testing.T) {
+		assert.PanicsWithValue(t, "adapters.jwks.go: path is required", func() {
+			JWKSFile("")
+		})
+	})
+	t.Run("http_url_empty", func(t *testing.T) {
+		assert.PanicsWithValue(t, "adapters.jwks.go: url is required", func() {
+			JWKSHTTP("", &http.Client{}, time.Minute, log.NewNopLogger())
+		})
+	})
+	t.Run("http_client_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "adapters.jwks.go: http client is required", func() {
+			JWKSHTTP("http://idp/jwks", nil, time.Minute, log.NewNopLogger())
+		})
+	})
+	t.Run("http_logger_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "adapters.jwks.go: logger is required", func() {
+			JWKSHTTP("http://idp/jwks", &http.Client{}, time.Minute, nil)
+		})
+	})
+}
+
+func TestJWKSFile_Keys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+
+	t.Run("missing_file_is_error", func(t *testing.T) {
+		_, err := JWKSFile(path).Keys()
+		require.Error(t, err)
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte(octJWKS("k1", "one")), 0o600))
+	src := JWKSFile(path)
+	keys, err := src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, []auth.VerificationKey{{ID: "k1", Key: []byte("one")}}, keys)
+
+	// Rewrite with a new mtime: keys are re-read.
+	require.NoError(t, os.WriteFile(path, []byte(octJWKS("k2", "two")), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	keys, err = src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, "k2", keys[0].ID)
+
+	// A broken rewrite keeps the previous keys.
+	require.NoError(t, os.WriteFile(path, []byte(`{broken`), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute)))
+	keys, err = src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, "k2", keys[0].ID)
+}
+
+func TestSecretKeysFile_Keys(t *testing.T) {
+	assert.PanicsWithValue(t, "adapters.jwks.go: path is required", func() {
+		SecretKeysFile("")
+	})
+
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("keys:\n  - id: k1\n    secret: one\n"), 0o600))
+	src := SecretKeysFile(path)
+	keys, err := src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, []auth.VerificationKey{{ID: "k1", Algorithm: auth.AlgHS256, Key: []byte("one")}}, keys)
+
+	// Rotation: the new key is added in front, the old one gets not_after.
+	require.NoError(t, os.WriteFile(path, []byte("keys:\n  - id: k2\n    secret: two\n  - id: k1\n    secret: one\n    not_after: 2026-03-01T00:00:00Z\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	keys, err = src.Keys()
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "k2", keys[0].ID)
+	assert.False(t, keys[1].NotAfter.IsZero())
+}
+
+func TestJWKSHTTP_Keys(t *testing.T) {
+	var calls atomic.Int32
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(octJWKS("k1", "one")))
+	}))
+	defer srv.Close()
+
+	now := time.Date(2026, 2, 11, 12, 0, 0, 0, time.UTC)
+	src := JWKSHTTP(srv.URL, srv.Client(), time.Minute, log.NewNopLogger()).(*jwksHTTP)
+	src.now = func() time.Time { return now }
+
+	keys, err := src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, "k1", keys[0].ID)
+	_, err = src.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "cached within refresh interval")
+
+	now = now.Add(2 * time.Minute)
+	fail.Store(true)
+	keys, err = src.Keys()
+	require.NoError(t, err, "stale keys served when refresh fails")
+	assert.Equal(t, "k1", keys[0].ID)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestJWKSHTTP_FirstFetchFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	_, err := JWKSHTTP(srv.URL, srv.Client(), time.Minute, log.NewNopLogger()).Keys()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
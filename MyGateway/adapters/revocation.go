@@ -0,0 +1,112 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"mygateway/auth"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultRevocationKeyPrefix is the Redis key prefix used when auth.revocation.key_prefix is not set.
+const DefaultRevocationKeyPrefix = "gateway:revoked:"
+
+// RevocationFile creates an interfaces.RevocationSource backed by a local file (auth.ParseRevocations: YAML/JSON with
+// sessions, issued_before and logins). The file is read on every Load; caching is done by the caller. Panics on empty path.
+//
+// Parameter path — path to the revocation file.
+//
+// Returns: interfaces.RevocationSource (*revocationFile).
+//
+// Called from cmd/main when auth.revocation.file is set (local setups and tests).
+func RevocationFile(path string) interfaces.RevocationSource {
+	return &revocationFile{path: helpers.StrPanic(path, "adapters.revocation.go: path is required")}
+}
+
+// revocationFile implements interfaces.RevocationSource over a local file.
+type revocationFile struct {
+	path string
+}
+
+// Load reads and parses the file.
+//
+// Returns: (revocations, nil); (zero, error) when the file cannot be read or parsed.
+//
+// Called from service.revocationCache on refresh.
+func (f *revocationFile) Load(context.Context) (auth.Revocations, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return auth.Revocations{}, fmt.Errorf("read revocation file: %w", err)
+	}
+	return auth.ParseRevocations(data)
+}
+
+// RevocationRedis creates an interfaces.RevocationSource backed by Redis. Layout under keyPrefix: "<prefix>sessions" —
+// SET of revoked session IDs; "<prefix>issued_before" — STRING, RFC3339 global cutoff; "<prefix>logins" — HASH
+// login → RFC3339 cutoff. Missing keys mean nothing is revoked. Panics on nil client.
+//
+// Parameters: client — Redis client; keyPrefix — key prefix (empty — DefaultRevocationKeyPrefix).
+//
+// Returns: interfaces.RevocationSource (*revocationRedis).
+//
+// Called from cmd/main when auth.revocation.redis_url is set.
+func RevocationRedis(client redis.UniversalClient, keyPrefix string) interfaces.RevocationSource {
+	if keyPrefix == "" {
+		keyPrefix = DefaultRevocationKeyPrefix
+	}
+	return &revocationRedis{
+		client: helpers.NilPanic(client, "adapters.revocation.go: redis client is required"),
+		prefix: keyPrefix,
+	}
+}
+
+// revocationRedis implements interfaces.RevocationSource over Redis; the three keys are read in one pipeline.
+type revocationRedis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// Load reads the sessions set, the global cutoff and the per-login cutoffs in one round trip.
+//
+// Parameters: ctx — refresh context (deadline bounds the pipeline).
+//
+// Returns: (revocations, nil); (zero, error) on Redis error or a cutoff that is not RFC3339.
+//
+// Called from service.revocationCache on refresh.
+func (r *revocationRedis) Load(ctx context.Context) (auth.Revocations, error) {
+	pipe := r.client.Pipeline()
+	sessions := pipe.SMembers(ctx, r.prefix+"sessions")
+	issuedBefore := pipe.Get(ctx, r.prefix+"issued_before")
+	logins := pipe.HGetAll(ctx, r.prefix+"logins")
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return auth.Revocations{}, fmt.Errorf("load revocations: %w", err)
+	}
+	out := auth.Revocations{Sessions: make(map[string]struct{}, len(sessions.Val()))}
+	for _, s := range sessions.Val() {
+		out.Sessions[s] = struct{}{}
+	}
+	if v := issuedBefore.Val(); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return auth.Revocations{}, fmt.Errorf("%sissued_before must be RFC3339: %w", r.prefix, err)
+		}
+		out.IssuedBefore = t
+	}
+	if len(logins.Val()) > 0 {
+		out.LoginsIssuedBefore = make(map[string]time.Time, len(logins.Val()))
+	}
+	for login, v := range logins.Val() {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return auth.Revocations{}, fmt.Errorf("%slogins[%s] must be RFC3339: %w", r.prefix, login, err)
+		}
+		out.LoginsIssuedBefore[login] = t
+	}
+	return out, nil
+}
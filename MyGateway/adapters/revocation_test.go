@@ -0,0 +1,66 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationFile_Load(t *testing.T) {
+	assert.PanicsWithValue(t, "adapters.revocation.go: path is required", func() {
+		RevocationFile("")
+	})
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "revoked.yaml")
+	_, err := RevocationFile(path).Load(ctx)
+	require.Error(t, err, "missing file")
+
+	require.NoError(t, os.WriteFile(path, []byte("sessions: [s1]\nissued_before: \"2026-01-01T00:00:00Z\"\n"), 0o600))
+	r, err := RevocationFile(path).Load(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, r.Sessions, "s1")
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), r.IssuedBefore)
+}
+
+func TestRevocationRedis_Load(t *testing.T) {
+	assert.PanicsWithValue(t, "adapters.revocation.go: redis client is required", func() {
+		RevocationRedis(nil, "")
+	})
+
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	source := RevocationRedis(client, "")
+
+	r, err := source.Load(ctx)
+	require.NoError(t, err, "missing keys mean nothing is revoked")
+	assert.Empty(t, r.Sessions)
+	assert.True(t, r.IssuedBefore.IsZero())
+
+	_, err = mr.SAdd(DefaultRevocationKeyPrefix+"sessions", "s1", "s2")
+	require.NoError(t, err)
+	require.NoError(t, mr.Set(DefaultRevocationKeyPrefix+"issued_before", "2026-01-01T00:00:00Z"))
+	mr.HSet(DefaultRevocationKeyPrefix+"logins", "alice", "2026-02-01T00:00:00Z")
+	r, err = source.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"s1": {}, "s2": {}}, r.Sessions)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), r.IssuedBefore)
+	assert.Equal(t, map[string]time.Time{"alice": time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)}, r.LoginsIssuedBefore)
+
+	require.NoError(t, mr.Set(DefaultRevocationKeyPrefix+"issued_before", "yesterday"))
+	_, err = source.Load(ctx)
+	require.Error(t, err)
+
+	mr.Close()
+	_, err = RevocationRedis(client, "other:").Load(ctx)
+	require.Error(t, err, "redis unavailable")
+}
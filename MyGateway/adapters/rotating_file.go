@@ -0,0 +1,118 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"mygateway/helpers"
+)
+
+// RotatingFile opens (appending) a log file that is rotated by size: when a write would grow it beyond maxBytes the file
+// is renamed to "<path>.1" (older backups shift to .2 … .maxBackups, the oldest is removed) and a new file is started.
+// Panics on empty path.
+//
+// Parameters: path — log file path; maxBytes — rotation size (≤ 0 — never rotate); maxBackups — rotated files kept
+// (0 — the current file is truncated on rotation).
+//
+// Returns: (io.WriteCloser safe for concurrent use, nil); (nil, error) when the file cannot be opened.
+//
+// Called from cmd/main when access_log.output is a file path.
+func RotatingFile(path string, maxBytes int64, maxBackups int) (io.WriteCloser, error) {
+	f := &rotatingFile{
+		path:       helpers.StrPanic(path, "adapters.rotating_file.go: path is required"),
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// rotatingFile implements RotatingFile. Under mu: file and size.
+type rotatingFile struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// open opens the log file for appending and records its current size. Caller must hold mu (or be the constructor).
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first when the file would exceed maxBytes (a single entry larger than maxBytes is still written whole).
+//
+// Returns: (len(p), nil); (n, error) on rotation or write failure.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.maxBytes > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file, shifts the backups and opens a new file. Caller must hold mu.
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	f.file = nil
+	if f.maxBackups <= 0 {
+		if err := os.Truncate(f.path, 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("truncate log file: %w", err)
+		}
+		return f.open()
+	}
+	backup := func(i int) string { return f.path + "." + strconv.Itoa(i) }
+	if err := os.Remove(backup(f.maxBackups)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove oldest log backup: %w", err)
+	}
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backup(i), backup(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("shift log backup: %w", err)
+		}
+	}
+	if err := os.Rename(f.path, backup(1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	return f.open()
+}
+
+// Close closes the current file; later writes fail with os.ErrClosed.
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,72 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile_Panics(t *testing.T) {
+	assert.PanicsWithValue(t, "adapters.rotating_file.go: path is required", func() {
+		_, _ = RotatingFile("", 10, 1)
+	})
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestRotatingFile_RotatesAndKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := RotatingFile(path, 8, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		n, err := f.Write([]byte(line))
+		require.NoError(t, err)
+		assert.Equal(t, len(line), n)
+	}
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, "dddddd\n", readFile(t, path))
+	assert.Equal(t, "cccccc\n", readFile(t, path+".1"))
+	assert.Equal(t, "bbbbbb\n", readFile(t, path+".2"))
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = f.Write([]byte("x"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotatingFile_NoBackupsTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := RotatingFile(path, 8, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("aaaaaa\n"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("bbbbbb\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "bbbbbb\n", readFile(t, path))
+	_, err = os.Stat(path + ".1")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRotatingFile_AppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+	f, err := RotatingFile(path, 0, 1)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("new\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "old\nnew\n", readFile(t, path))
+}
@@ -0,0 +1,296 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.2
+// source: api/authz.proto
+
+package authzpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HeaderValues struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeaderValues) Reset() {
+	*x = HeaderValues{}
+	mi := &file_api_authz_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeaderValues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeaderValues) ProtoMessage() {}
+
+func (x *HeaderValues) ProtoReflect() protoreflect.Message {
+	mi := &file_api_authz_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeaderValues.ProtoReflect.Descriptor instead.
+func (*HeaderValues) Descriptor() ([]byte, []int) {
+	return file_api_authz_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HeaderValues) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type CheckRequest struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Method        string                   `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`                                                                               // full gRPC method, e.g. /my_service.MyServiceAPI/MyServiceEcho
+	Metadata      map[string]*HeaderValues `protobuf:"bytes,2,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // incoming client metadata (keys lowercase)
+	Peer          string                   `protobuf:"bytes,3,opt,name=peer,proto3" json:"peer,omitempty"`                                                                                   // client address (host:port), empty when unknown
+	Route         string                   `protobuf:"bytes,4,opt,name=route,proto3" json:"route,omitempty"`                                                                                 // matched route prefix, e.g. /my_service.MyServiceAPI/
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckRequest) Reset() {
+	*x = CheckRequest{}
+	mi := &file_api_authz_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRequest) ProtoMessage() {}
+
+func (x *CheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_authz_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRequest.ProtoReflect.Descriptor instead.
+func (*CheckRequest) Descriptor() ([]byte, []int) {
+	return file_api_authz_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetMetadata() map[string]*HeaderValues {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *CheckRequest) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetRoute() string {
+	if x != nil {
+		return x.Route
+	}
+	return ""
+}
+
+type CheckResponse struct {
+	state           protoimpl.MessageState   `protogen:"open.v1"`
+	Allow           bool                     `protobuf:"varint,1,opt,name=allow,proto3" json:"allow,omitempty"`                                                                                                              // true — forward the call, false — reject it
+	HeadersToAdd    map[string]*HeaderValues `protobuf:"bytes,2,rep,name=headers_to_add,json=headersToAdd,proto3" json:"headers_to_add,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // set on the forwarded metadata (replaces existing values); allow only
+	HeadersToRemove []string                 `protobuf:"bytes,3,rep,name=headers_to_remove,json=headersToRemove,proto3" json:"headers_to_remove,omitempty"`                                                                  // removed from the forwarded metadata; allow only
+	DenyCode        uint32                   `protobuf:"varint,4,opt,name=deny_code,json=denyCode,proto3" json:"deny_code,omitempty"`                                                                                        // gRPC status code when denied; 0 (or OK) — PERMISSION_DENIED
+	DenyMessage     string                   `protobuf:"bytes,5,opt,name=deny_message,json=denyMessage,proto3" json:"deny_message,omitempty"`                                                                                // status message when denied; empty — default message
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CheckResponse) Reset() {
+	*x = CheckResponse{}
+	mi := &file_api_authz_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResponse) ProtoMessage() {}
+
+func (x *CheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_authz_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResponse.ProtoReflect.Descriptor instead.
+func (*CheckResponse) Descriptor() ([]byte, []int) {
+	return file_api_authz_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CheckResponse) GetAllow() bool {
+	if x != nil {
+		return x.Allow
+	}
+	return false
+}
+
+func (x *CheckResponse) GetHeadersToAdd() map[string]*HeaderValues {
+	if x != nil {
+		return x.HeadersToAdd
+	}
+	return nil
+}
+
+func (x *CheckResponse) GetHeadersToRemove() []string {
+	if x != nil {
+		return x.HeadersToRemove
+	}
+	return nil
+}
+
+func (x *CheckResponse) GetDenyCode() uint32 {
+	if x != nil {
+		return x.DenyCode
+	}
+	return 0
+}
+
+func (x *CheckResponse) GetDenyMessage() string {
+	if x != nil {
+		return x.DenyMessage
+	}
+	return ""
+}
+
+var File_api_authz_proto protoreflect.FileDescriptor
+
+const file_api_authz_proto_rawDesc = "" +
+	"\n" +
+	"\x0fapi/authz.proto\x12\x0fmygateway.authz\"&\n" +
+	"\fHeaderValues\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"\xf5\x01\n" +
+	"\fCheckRequest\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12G\n" +
+	"\bmetadata\x18\x02 \x03(\v2+.mygateway.authz.CheckRequest.MetadataEntryR\bmetadata\x12\x12\n" +
+	"\x04peer\x18\x03 \x01(\tR\x04peer\x12\x14\n" +
+	"\x05route\x18\x04 \x01(\tR\x05route\x1aZ\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x123\n" +
+	"\x05value\x18\x02 \x01(\v2\x1d.mygateway.authz.HeaderValuesR\x05value:\x028\x01\"\xc9\x02\n" +
+	"\rCheckResponse\x12\x14\n" +
+	"\x05allow\x18\x01 \x01(\bR\x05allow\x12V\n" +
+	"\x0eheaders_to_add\x18\x02 \x03(\v20.mygateway.authz.CheckResponse.HeadersToAddEntryR\fheadersToAdd\x12*\n" +
+	"\x11headers_to_remove\x18\x03 \x03(\tR\x0fheadersToRemove\x12\x1b\n" +
+	"\tdeny_code\x18\x04 \x01(\rR\bdenyCode\x12!\n" +
+	"\fdeny_message\x18\x05 \x01(\tR\vdenyMessage\x1a^\n" +
+	"\x11HeadersToAddEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x123\n" +
+	"\x05value\x18\x02 \x01(\v2\x1d.mygateway.authz.HeaderValuesR\x05value:\x028\x012T\n" +
+	"\n" +
+	"Authorizer\x12F\n" +
+	"\x05Check\x12\x1d.mygateway.authz.CheckRequest\x1a\x1e.mygateway.authz.CheckResponseB\x17Z\x15mygateway/api/authzpbb\x06proto3"
+
+var (
+	file_api_authz_proto_rawDescOnce sync.Once
+	file_api_authz_proto_rawDescData []byte
+)
+
+func file_api_authz_proto_rawDescGZIP() []byte {
+	file_api_authz_proto_rawDescOnce.Do(func() {
+		file_api_authz_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_authz_proto_rawDesc), len(file_api_authz_proto_rawDesc)))
+	})
+	return file_api_authz_proto_rawDescData
+}
+
+var file_api_authz_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_api_authz_proto_goTypes = []any{
+	(*HeaderValues)(nil),  // 0: mygateway.authz.HeaderValues
+	(*CheckRequest)(nil),  // 1: mygateway.authz.CheckRequest
+	(*CheckResponse)(nil), // 2: mygateway.authz.CheckResponse
+	nil,                   // 3: mygateway.authz.CheckRequest.MetadataEntry
+	nil,                   // 4: mygateway.authz.CheckResponse.HeadersToAddEntry
+}
+var file_api_authz_proto_depIdxs = []int32{
+	3, // 0: mygateway.authz.CheckRequest.metadata:type_name -> mygateway.authz.CheckRequest.MetadataEntry
+	4, // 1: mygateway.authz.CheckResponse.headers_to_add:type_name -> mygateway.authz.CheckResponse.HeadersToAddEntry
+	0, // 2: mygateway.authz.CheckRequest.MetadataEntry.value:type_name -> mygateway.authz.HeaderValues
+	0, // 3: mygateway.authz.CheckResponse.HeadersToAddEntry.value:type_name -> mygateway.authz.HeaderValues
+	1, // 4: mygateway.authz.Authorizer.Check:input_type -> mygateway.authz.CheckRequest
+	2, // 5: mygateway.authz.Authorizer.Check:output_type -> mygateway.authz.CheckResponse
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_api_authz_proto_init() }
+func file_api_authz_proto_init() {
+	if File_api_authz_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_authz_proto_rawDesc), len(file_api_authz_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_authz_proto_goTypes,
+		DependencyIndexes: file_api_authz_proto_depIdxs,
+		MessageInfos:      file_api_authz_proto_msgTypes,
+	}.Build()
+	File_api_authz_proto = out.File
+	file_api_authz_proto_goTypes = nil
+	file_api_authz_proto_depIdxs = nil
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIKey is one entry of the API key store: Name (forwarded to backends for auditing), Hash (hex SHA-256 of the raw key;
+// the raw key is never stored), AllowedPrefixes (method prefixes the key may call; empty — any api_key route) and
+// ExpiresAt (zero — never expires).
+type APIKey struct {
+	Name            string
+	Hash            string
+	AllowedPrefixes []string
+	ExpiresAt       time.Time
+}
+
+// Active reports whether the key has not expired at now.
+//
+// Called from helpers.APIKeyProcessor.Process.
+func (k APIKey) Active(now time.Time) bool {
+	return k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt)
+}
+
+// Allows reports whether the key may call method: AllowedPrefixes is empty or one of them is a prefix of method.
+//
+// Called from helpers.APIKeyProcessor.Process.
+func (k APIKey) Allows(method string) bool {
+	if len(k.AllowedPrefixes) == 0 {
+		return true
+	}
+	for _, p := range k.AllowedPrefixes {
+		if strings.HasPrefix(method, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// HashAPIKey returns the lowercase hex SHA-256 of a raw API key, the form stored in the key file.
+//
+// Called from adapters.APIKeyFile on lookup and from tests/tools that produce key files.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeysFile is the YAML (or JSON) shape of the API key store: { keys: [ { name, sha256, allowed_prefixes, expires_at } ] }.
+type apiKeysFile struct {
+	Keys []apiKeyEntry `yaml:"keys"`
+}
+
+// apiKeyEntry is one key of the store file; ExpiresAt is RFC3339, empty — the key never expires.
+type apiKeyEntry struct {
+	Name            string   `yaml:"name"`
+	SHA256          string   `yaml:"sha256"`
+	AllowedPrefixes []string `yaml:"allowed_prefixes"`
+	ExpiresAt       string   `yaml:"expires_at"`
+}
+
+// ParseAPIKeys decodes the API key store file (YAML or JSON). Hashes are lowercased; allowed_prefixes must start with
+// "/" (a trailing "*" is dropped, as in route prefixes).
+//
+// Parameter data — file content.
+//
+// Returns: ([]APIKey, nil) in file order (may be empty — no key is accepted); (nil, error) on decode error, empty or
+// duplicate name, hash that is not 64 hex chars or duplicate, invalid prefix or expires_at.
+//
+// Called from adapters.APIKeyFile when (re)loading and from cmd.LoadConfig to validate the file at start.
+func ParseAPIKeys(data []byte) ([]APIKey, error) {
+	var file apiKeysFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unmarshal api key file: %w", err)
+	}
+	out := make([]APIKey, 0, len(file.Keys))
+	names := make(map[string]bool, len(file.Keys))
+	hashes := make(map[string]bool, len(file.Keys))
+	for i, e := range file.Keys {
+		name := strings.TrimSpace(e.Name)
+		if name == "" {
+			return nil, fmt.Errorf("api key %d: name is required", i)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("api key %q: duplicate name", name)
+		}
+		names[name] = true
+		hash := strings.ToLower(strings.TrimSpace(e.SHA256))
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("api key %q: sha256 must be 64 hex characters", name)
+		}
+		if hashes[hash] {
+			return nil, fmt.Errorf("api key %q: duplicate sha256", name)
+		}
+		hashes[hash] = true
+		key := APIKey{Name: name, Hash: hash}
+		for _, p := range e.AllowedPrefixes {
+			p = strings.TrimSuffix(strings.TrimSpace(p), "*")
+			if !strings.HasPrefix(p, "/") {
+				return nil, fmt.Errorf("api key %q: allowed prefix %q must start with /", name, p)
+			}
+			key.AllowedPrefixes = append(key.AllowedPrefixes, p)
+		}
+		if e.ExpiresAt != "" {
+			t, err := time.Parse(time.RFC3339, e.ExpiresAt)
+			if err != nil {
+				return nil, fmt.Errorf("api key %q: expires_at must be RFC3339: %w", name, err)
+			}
+			key.ExpiresAt = t
+		}
+		out = append(out, key)
+	}
+	return out, nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashAPIKey(t *testing.T) {
+	assert.Equal(t, "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", HashAPIKey("secret"))
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys([]byte(`
+keys:
+  - name: billing
+    sha256: ` + strings.ToUpper(HashAPIKey("k1")) + `
+    allowed_prefixes: [/billing.Billing/*, " /reports.Reports/GetReport "]
+    expires_at: "2026-12-31T00:00:00Z"
+  - name: ops
+    sha256: ` + HashAPIKey("k2") + `
+`))
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, APIKey{
+		Name:            "billing",
+		Hash:            HashAPIKey("k1"),
+		AllowedPrefixes: []string{"/billing.Billing/", "/reports.Reports/GetReport"},
+		ExpiresAt:       time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+	}, keys[0])
+	assert.True(t, keys[0].Allows("/billing.Billing/Charge"))
+	assert.False(t, keys[0].Allows("/reports.Reports/List"))
+	assert.True(t, keys[0].Active(testNow()))
+	assert.False(t, keys[0].Active(keys[0].ExpiresAt))
+	assert.True(t, keys[1].Allows("/any.Service/Method"))
+	assert.True(t, keys[1].Active(testNow().AddDate(100, 0, 0)))
+
+	empty, err := ParseAPIKeys([]byte("keys: []\n"))
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestParseAPIKeys_Errors(t *testing.T) {
+	h1, h2 := HashAPIKey("k1"), HashAPIKey("k2")
+	tests := []struct {
+		name        string
+		doc         string
+		wantContain string
+	}{
+		{name: "invalid_yaml", doc: "keys: [", wantContain: "unmarshal api key file"},
+		{name: "missing_name", doc: "keys:\n  - sha256: " + h1, wantContain: "name is required"},
+		{name: "duplicate_name", doc: "keys:\n  - {name: a, sha256: " + h1 + "}\n  - {name: a, sha256: " + h2 + "}", wantContain: "duplicate name"},
+		{name: "raw_key_instead_of_hash", doc: "keys:\n  - {name: a, sha256: k1}", wantContain: "64 hex characters"},
+		{name: "duplicate_hash", doc: "keys:\n  - {name: a, sha256: " + h1 + "}\n  - {name: b, sha256: " + h1 + "}", wantContain: "duplicate sha256"},
+		{name: "prefix_without_slash", doc: "keys:\n  - {name: a, sha256: " + h1 + ", allowed_prefixes: [svc]}", wantContain: "must start with /"},
+		{name: "invalid_expires_at", doc: "keys:\n  - {name: a, sha256: " + h1 + ", expires_at: soon}", wantContain: "expires_at must be RFC3339"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAPIKeys([]byte(tt.doc))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantContain)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ErrEmptyJWKS is returned by ParseJWKS when the document contains no usable key.
+var ErrEmptyJWKS = errors.New("jwks contains no usable keys")
+
+// VerificationKey is one key the gateway may verify token signatures with: ID (JWK "kid" or rotation key ID, empty for the
+// JWT_SECRET key), Algorithm (JWK "alg", empty — any algorithm fitting the key type), Key ([]byte for HMAC, *rsa.PublicKey
+// or *ecdsa.PublicKey) and NotAfter (zero — no expiry; after it the key is no longer used, e.g. a retired rotation key).
+type VerificationKey struct {
+	ID        string
+	Algorithm string
+	Key       any
+	NotAfter  time.Time
+}
+
+// Active reports whether the key may still be used at now (NotAfter is zero or not yet passed).
+//
+// Called from service.jwtValidator and service.standardJWTValidator when selecting candidate keys.
+func (k VerificationKey) Active(now time.Time) bool {
+	return k.NotAfter.IsZero() || !now.After(k.NotAfter)
+}
+
+// Accepts reports whether the key may be used to verify a token with the given JWS alg (key type and optional pinned alg must fit).
+//
+// Parameter alg — token header "alg".
+//
+// Returns: true when the key type fits alg and Algorithm is empty or equal to alg.
+//
+// Called from service.jwtValidator and service.standardJWTValidator when selecting candidate keys.
+func (k VerificationKey) Accepts(alg string) bool {
+	if k.Algorithm != "" && k.Algorithm != alg {
+		return false
+	}
+	switch k.Key.(type) {
+	case []byte:
+		return alg == AlgHS256
+	case *rsa.PublicKey:
+		return alg == AlgRS256
+	case *ecdsa.PublicKey:
+		return alg == AlgES256
+	default:
+		return false
+	}
+}
+
+// StaticKeys is a fixed key list implementing interfaces.KeySource (e.g. the JWT_SECRET HMAC key or the JWT_SECRETS rotation list).
+type StaticKeys []VerificationKey
+
+// Keys returns the fixed key list; never fails.
+func (k StaticKeys) Keys() ([]VerificationKey, error) {
+	return k, nil
+}
+
+// jwkSet is the JSON shape of a JWKS document (RFC 7517): { "keys": [ jwk ] }.
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwk is one JSON Web Key; only the members needed for oct, RSA and EC (P-256) verification keys are decoded.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS decodes a JWKS document into verification keys. Keys with "use" other than "sig", unknown kty or unsupported
+// curve are skipped; malformed members of a supported key are an error.
+//
+// Parameter data — raw JSON of the JWKS document (file content or HTTP body).
+//
+// Returns: ([]VerificationKey, nil) with at least one key; (nil, ErrEmptyJWKS) when nothing usable; (nil, error) on JSON or key decode error.
+//
+// Called from adapters.JWKSFile and adapters.JWKSHTTP when (re)loading keys.
+func ParseJWKS(data []byte) ([]VerificationKey, error) {
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("unmarshal jwks: %w", err)
+	}
+	out := make([]VerificationKey, 0, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key any
+		switch k.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("jwks key %d (%s): invalid k", i, k.Kid)
+			}
+			key = secret
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("jwks key %d (%s): invalid n: %w", i, k.Kid, err)
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+				return nil, fmt.Errorf("jwks key %d (%s): invalid e", i, k.Kid)
+			}
+			key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			x, err := decodeBigInt(k.X)
+			if err != nil {
+				return nil, fmt.Errorf("jwks key %d (%s): invalid x: %w", i, k.Kid, err)
+			}
+			y, err := decodeBigInt(k.Y)
+			if err != nil {
+				return nil, fmt.Errorf("jwks key %d (%s): invalid y: %w", i, k.Kid, err)
+			}
+			pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+			if _, err := pub.ECDH(); err != nil {
+				return nil, fmt.Errorf("jwks key %d (%s): point is not on P-256", i, k.Kid)
+			}
+			key = pub
+		default:
+			continue
+		}
+		out = append(out, VerificationKey{ID: k.Kid, Algorithm: k.Alg, Key: key})
+	}
+	if len(out) == 0 {
+		return nil, ErrEmptyJWKS
+	}
+	return out, nil
+}
+
+// decodeBigInt decodes a base64url (no padding) unsigned big-endian integer as used by JWK members n, e, x, y.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	doc := `{"keys":[
+		{"kty":"oct","kid":"h1","alg":"HS256","k":"` + b64([]byte("secret")) + `"},
+		{"kty":"RSA","kid":"r1","use":"sig","n":"` + b64(rsaKey.N.Bytes()) + `","e":"` + b64(big.NewInt(int64(rsaKey.E)).Bytes()) + `"},
+		{"kty":"EC","kid":"e1","crv":"P-256","x":"` + b64(ecKey.X.Bytes()) + `","y":"` + b64(ecKey.Y.Bytes()) + `"},
+		{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"},
+		{"kty":"OKP","kid":"ed","crv":"Ed25519","x":"AA"},
+		{"kty":"EC","kid":"p384","crv":"P-384","x":"AA","y":"AA"}
+	]}`
+
+	keys, err := ParseJWKS([]byte(doc))
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+
+	assert.Equal(t, VerificationKey{ID: "h1", Algorithm: AlgHS256, Key: []byte("secret")}, keys[0])
+	assert.Equal(t, "r1", keys[1].ID)
+	assert.True(t, rsaKey.PublicKey.Equal(keys[1].Key))
+	assert.Equal(t, "e1", keys[2].ID)
+	assert.True(t, ecKey.PublicKey.Equal(keys[2].Key))
+
+	assert.True(t, keys[0].Accepts(AlgHS256))
+	assert.False(t, keys[0].Accepts(AlgRS256))
+	assert.True(t, keys[1].Accepts(AlgRS256))
+	assert.False(t, keys[1].Accepts(AlgES256))
+	assert.True(t, keys[2].Accepts(AlgES256))
+}
+
+func TestParseJWKS_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr error
+	}{
+		{name: "invalid_json", doc: `{`},
+		{name: "no_keys", doc: `{"keys":[]}`, wantErr: ErrEmptyJWKS},
+		{name: "only_unsupported", doc: `{"keys":[{"kty":"OKP","x":"AA"}]}`, wantErr: ErrEmptyJWKS},
+		{name: "oct_empty_k", doc: `{"keys":[{"kty":"oct","kid":"h"}]}`},
+		{name: "rsa_bad_n", doc: `{"keys":[{"kty":"RSA","n":"!!","e":"AQAB"}]}`},
+		{name: "ec_point_not_on_curve", doc: `{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJWKS([]byte(tt.doc))
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerificationKey_Accepts_PinnedAlgorithm(t *testing.T) {
+	k := VerificationKey{ID: "h", Algorithm: AlgRS256, Key: []byte("s")}
+	assert.False(t, k.Accepts(AlgHS256), "pinned alg must match the key type and header")
+	assert.False(t, VerificationKey{Key: "not-a-key"}.Accepts(AlgHS256))
+}
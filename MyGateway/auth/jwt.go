@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Supported JWS algorithms for standard (RFC 7519) tokens.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// SupportedAlgorithms lists the JWS "alg" values accepted by VerifySignature, in the order used as config default.
+var SupportedAlgorithms = []string{AlgHS256, AlgRS256, AlgES256}
+
+// ErrInvalidJWTFormat is returned when the token is not three base64url segments "header.payload.signature" or a segment does not decode.
+var ErrInvalidJWTFormat = errors.New("invalid token format: expected header.payload.signature")
+
+// ErrUnsupportedAlgorithm is returned when the JWS "alg" is not one of SupportedAlgorithms (including "none").
+var ErrUnsupportedAlgorithm = errors.New("unsupported token algorithm")
+
+// ErrKeyTypeMismatch is returned when the verification key type does not fit the algorithm (e.g. RSA key for HS256).
+var ErrKeyTypeMismatch = errors.New("verification key does not match token algorithm")
+
+// JWTHeader is the JOSE header of a standard token: alg (signature algorithm), kid (key ID, optional), typ (optional).
+type JWTHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// NumericDate is an RFC 7519 NumericDate: seconds since the Unix epoch, decoded from a JSON number (fractions are truncated).
+type NumericDate int64
+
+// Time returns the date as time.Time in UTC.
+func (d NumericDate) Time() time.Time {
+	return time.Unix(int64(d), 0).UTC()
+}
+
+// UnmarshalJSON accepts integer and fractional JSON numbers; anything else (e.g. RFC3339 string) is an error.
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("numeric date: %w", err)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("numeric date: invalid value")
+	}
+	*d = NumericDate(int64(f))
+	return nil
+}
+
+// NewNumericDate converts t to a NumericDate (seconds precision).
+func NewNumericDate(t time.Time) *NumericDate {
+	d := NumericDate(t.Unix())
+	return &d
+}
+
+// Audience is the "aud" claim, which RFC 7519 allows as a single string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON accepts a string or an array of strings.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("aud: %w", err)
+	}
+	*a = many
+	return nil
+}
+
+// Contains reports whether aud is one of the audience values.
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTClaims is the payload of a standard token: registered claims (iss, sub, aud, exp, nbf, iat) plus the MyAuth
+// claims login, role and session_id (sid is accepted as an alias of session_id, sub as a fallback for login).
+type JWTClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	Login     string       `json:"login,omitempty"`
+	Role      string       `json:"role,omitempty"`
+	SessionID string       `json:"session_id,omitempty"`
+	SID       string       `json:"sid,omitempty"`
+}
+
+// TokenClaims converts standard claims to the gateway-wide TokenClaims: login (or sub), role, session_id (or sid), exp and iat as RFC3339.
+//
+// Returns: TokenClaims; ExpiresAt/IssuedAt are empty when the claim is absent.
+//
+// Called from service.standardJWTValidator.ValidateToken after all checks passed.
+func (c JWTClaims) TokenClaims() TokenClaims {
+	out := TokenClaims{Login: c.Login, Role: c.Role, SessionID: c.SessionID}
+	if out.Login == "" {
+		out.Login = c.Subject
+	}
+	if out.SessionID == "" {
+		out.SessionID = c.SID
+	}
+	if c.ExpiresAt != nil {
+		out.ExpiresAt = c.ExpiresAt.Time().Format(time.RFC3339)
+	}
+	if c.IssuedAt != nil {
+		out.IssuedAt = c.IssuedAt.Time().Format(time.RFC3339)
+	}
+	return out
+}
+
+// ParsedJWT is a decoded but not yet verified token: header, claims, the signing input ("header.payload" as sent) and the raw signature.
+type ParsedJWT struct {
+	Header       JWTHeader
+	Claims       JWTClaims
+	SigningInput string
+	Signature    []byte
+}
+
+// ParseJWT splits a compact JWS into header, payload and signature (base64url without padding) and decodes header and claims. The signature is NOT verified.
+//
+// Parameter token — raw token string from authorization metadata.
+//
+// Returns: (*ParsedJWT, nil) on success; (nil, ErrInvalidJWTFormat) when the token does not have three segments or a segment does not decode; (nil, wrapped error) on JSON decode error.
+//
+// Called from service.standardJWTValidator.ValidateToken before key selection and VerifySignature.
+func ParseJWT(token string) (*ParsedJWT, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidJWTFormat
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidJWTFormat
+	}
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidJWTFormat
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidJWTFormat
+	}
+	var out ParsedJWT
+	if err := json.Unmarshal(headerBytes, &out.Header); err != nil {
+		return nil, fmt.Errorf("unmarshal header: %w", err)
+	}
+	if err := json.Unmarshal(payloadBytes, &out.Claims); err != nil {
+		return nil, fmt.Errorf("unmarshal claims: %w", err)
+	}
+	out.SigningInput = parts[0] + "." + parts[1]
+	out.Signature = signature
+	return &out, nil
+}
+
+// VerifySignature checks the JWS signature of signingInput with key for alg: HS256 — key []byte (HMAC-SHA256); RS256 — *rsa.PublicKey (PKCS#1 v1.5);
+// ES256 — *ecdsa.PublicKey on P-256 with the 64-byte r||s signature of RFC 7518.
+//
+// Parameters: alg — header "alg"; key — verification key material; signingInput — "header.payload"; signature — decoded signature bytes.
+//
+// Returns: nil when the signature is valid; ErrUnsupportedAlgorithm, ErrKeyTypeMismatch or ErrInvalidSignature otherwise.
+//
+// Called from service.standardJWTValidator.ValidateToken for each candidate key.
+func VerifySignature(alg string, key any, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case AlgHS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrKeyTypeMismatch
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case AlgRS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrKeyTypeMismatch
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case AlgES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return ErrKeyTypeMismatch
+		}
+		if len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+}
+
+// CreateJWT builds a compact RFC 7519 token signed with alg: HS256 — key []byte; RS256 — *rsa.PrivateKey; ES256 — *ecdsa.PrivateKey (P-256).
+// The gateway only verifies tokens; creation is used in tests and by external issuers.
+//
+// Parameters: alg — signature algorithm; kid — key ID put into the header (empty — omitted); claims — payload; key — signing key.
+//
+// Returns: (token, nil) on success; ("", error) on unsupported alg, key type mismatch or signing error.
+//
+// Called from tests.
+func CreateJWT(alg, kid string, claims JWTClaims, key any) (string, error) {
+	headerBytes, err := json.Marshal(JWTHeader{Algorithm: alg, KeyID: kid, Type: "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("marshal header: %w", err)
+	}
+	payloadBytes, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal claims: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(payloadBytes)
+	digest := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	switch alg {
+	case AlgHS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return "", ErrKeyTypeMismatch
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case AlgRS256:
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", ErrKeyTypeMismatch
+		}
+		signature, err = rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("sign: %w", err)
+		}
+	case AlgES256:
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve != elliptic.P256() {
+			return "", ErrKeyTypeMismatch
+		}
+		r, s, signErr := ecdsa.Sign(rand.Reader, priv, digest[:])
+		if signErr != nil {
+			return "", fmt.Errorf("sign: %w", signErr)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	default:
+		return "", ErrUnsupportedAlgorithm
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
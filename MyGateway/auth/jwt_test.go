@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testJWTClaims() JWTClaims {
+	now := testNow()
+	return JWTClaims{
+		Issuer:    "myauth",
+		Audience:  Audience{"mygateway"},
+		ExpiresAt: NewNumericDate(now.Add(time.Hour)),
+		IssuedAt:  NewNumericDate(now),
+		Login:     "u1",
+		Role:      "admin",
+		SessionID: "sess1",
+	}
+}
+
+func TestCreateJWT_ParseJWT_VerifySignature(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	secret := []byte("secret")
+
+	tests := []struct {
+		name      string
+		alg       string
+		signKey   any
+		verifyKey any
+	}{
+		{name: "hs256", alg: AlgHS256, signKey: secret, verifyKey: secret},
+		{name: "rs256", alg: AlgRS256, signKey: rsaKey, verifyKey: &rsaKey.PublicKey},
+		{name: "es256", alg: AlgES256, signKey: ecKey, verifyKey: &ecKey.PublicKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := CreateJWT(tt.alg, "kid-1", testJWTClaims(), tt.signKey)
+			require.NoError(t, err)
+			require.Len(t, strings.Split(token, "."), 3)
+			assert.NotContains(t, token, "=", "base64url without padding")
+
+			parsed, err := ParseJWT(token)
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, parsed.Header.Algorithm)
+			assert.Equal(t, "kid-1", parsed.Header.KeyID)
+			assert.Equal(t, testJWTClaims(), parsed.Claims)
+			require.NoError(t, VerifySignature(tt.alg, tt.verifyKey, parsed.SigningInput, parsed.Signature))
+
+			tampered := append([]byte{}, parsed.Signature...)
+			tampered[0] ^= 0xff
+			assert.ErrorIs(t, VerifySignature(tt.alg, tt.verifyKey, parsed.SigningInput, tampered), ErrInvalidSignature)
+			assert.ErrorIs(t, VerifySignature(tt.alg, tt.verifyKey, parsed.SigningInput+"x", parsed.Signature), ErrInvalidSignature)
+		})
+	}
+}
+
+func TestVerifySignature_Errors(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	t.Run("alg_none_unsupported", func(t *testing.T) {
+		assert.ErrorIs(t, VerifySignature("none", []byte("s"), "a.b", nil), ErrUnsupportedAlgorithm)
+	})
+	t.Run("rsa_key_for_hs256", func(t *testing.T) {
+		assert.ErrorIs(t, VerifySignature(AlgHS256, &rsaKey.PublicKey, "a.b", nil), ErrKeyTypeMismatch)
+	})
+	t.Run("secret_for_rs256", func(t *testing.T) {
+		assert.ErrorIs(t, VerifySignature(AlgRS256, []byte("s"), "a.b", nil), ErrKeyTypeMismatch)
+	})
+	t.Run("es256_wrong_signature_length", func(t *testing.T) {
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		assert.ErrorIs(t, VerifySignature(AlgES256, &ecKey.PublicKey, "a.b", make([]byte, 70)), ErrInvalidSignature)
+	})
+}
+
+func TestParseJWT_InvalidFormat(t *testing.T) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "legacy_two_parts", token: header + "." + payload},
+		{name: "four_parts", token: header + "." + payload + ".c2ln.x"},
+		{name: "std_base64_padding", token: header + "." + payload + "==.c2ln"},
+		{name: "bad_signature_encoding", token: header + "." + payload + ".!!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJWT(tt.token)
+			assert.ErrorIs(t, err, ErrInvalidJWTFormat)
+		})
+	}
+
+	t.Run("rfc3339_exp_rejected", func(t *testing.T) {
+		p := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":"2026-02-11T12:00:00Z"}`))
+		_, err := ParseJWT(header + "." + p + ".c2ln")
+		require.Error(t, err)
+	})
+}
+
+func TestNumericDate_UnmarshalJSON(t *testing.T) {
+	var c JWTClaims
+	require.NoError(t, json.Unmarshal([]byte(`{"exp":1770811200.75,"iat":1770807600}`), &c))
+	assert.Equal(t, time.Date(2026, 2, 11, 12, 0, 0, 0, time.UTC), c.ExpiresAt.Time())
+	assert.Equal(t, time.Date(2026, 2, 11, 11, 0, 0, 0, time.UTC), c.IssuedAt.Time())
+}
+
+func TestAudience_UnmarshalJSON(t *testing.T) {
+	var single, many JWTClaims
+	require.NoError(t, json.Unmarshal([]byte(`{"aud":"a"}`), &single))
+	require.NoError(t, json.Unmarshal([]byte(`{"aud":["a","b"]}`), &many))
+	assert.True(t, single.Audience.Contains("a"))
+	assert.True(t, many.Audience.Contains("b"))
+	assert.False(t, many.Audience.Contains("c"))
+}
+
+func TestJWTClaims_TokenClaims(t *testing.T) {
+	c := JWTClaims{
+		Subject:   "subject-login",
+		Role:      "admin",
+		SID:       "sid-1",
+		ExpiresAt: NewNumericDate(testNow().Add(time.Hour)),
+		IssuedAt:  NewNumericDate(testNow()),
+	}
+	got := c.TokenClaims()
+	assert.Equal(t, TokenClaims{
+		Login:     "subject-login",
+		Role:      "admin",
+		SessionID: "sid-1",
+		ExpiresAt: "2026-02-11T13:00:00Z",
+		IssuedAt:  "2026-02-11T12:00:00Z",
+	}, got)
+
+	c.Login = "login"
+	c.SessionID = "session"
+	got = c.TokenClaims()
+	assert.Equal(t, "login", got.Login)
+	assert.Equal(t, "session", got.SessionID)
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Revocations is a snapshot of the revocation store: Sessions (revoked session IDs — every token of the session is
+// rejected), IssuedBefore (tokens issued before it are rejected; zero — no global cutoff) and LoginsIssuedBefore
+// (per-login cutoff, e.g. "log out everywhere" after a password change).
+type Revocations struct {
+	Sessions           map[string]struct{}
+	IssuedBefore       time.Time
+	LoginsIssuedBefore map[string]time.Time
+}
+
+// Revoked reports whether a token with claims is revoked: its session_id is listed, or its issued_at is before the
+// global or per-login cutoff. A token without a valid issued_at is revoked by any cutoff that applies to it.
+//
+// Called from service.revocationCache.IsRevoked.
+func (r Revocations) Revoked(claims TokenClaims) bool {
+	if _, ok := r.Sessions[claims.SessionID]; ok && claims.SessionID != "" {
+		return true
+	}
+	cutoff := r.IssuedBefore
+	if t, ok := r.LoginsIssuedBefore[claims.Login]; ok && t.After(cutoff) {
+		cutoff = t
+	}
+	if cutoff.IsZero() {
+		return false
+	}
+	issuedAt, err := time.Parse(time.RFC3339, claims.IssuedAt)
+	if err != nil {
+		return true
+	}
+	return issuedAt.Before(cutoff)
+}
+
+// revocationsFile is the YAML (or JSON) shape of the revocation file: { sessions: [id], issued_before: RFC3339, logins: { login: RFC3339 } }.
+type revocationsFile struct {
+	Sessions     []string          `yaml:"sessions"`
+	IssuedBefore string            `yaml:"issued_before"`
+	Logins       map[string]string `yaml:"logins"`
+}
+
+// ParseRevocations decodes the revocation file (YAML or JSON).
+//
+// Parameter data — file content (empty — nothing is revoked).
+//
+// Returns: (Revocations, nil); (zero, error) on decode error, empty session ID or login, or a cutoff that is not RFC3339.
+//
+// Called from adapters.RevocationFile when loading and from cmd.LoadConfig to validate the file at start.
+func ParseRevocations(data []byte) (Revocations, error) {
+	var file revocationsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return Revocations{}, fmt.Errorf("unmarshal revocation file: %w", err)
+	}
+	out := Revocations{Sessions: make(map[string]struct{}, len(file.Sessions))}
+	for _, s := range file.Sessions {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return Revocations{}, fmt.Errorf("revoked session ID must not be empty")
+		}
+		out.Sessions[s] = struct{}{}
+	}
+	if file.IssuedBefore != "" {
+		t, err := time.Parse(time.RFC3339, file.IssuedBefore)
+		if err != nil {
+			return Revocations{}, fmt.Errorf("issued_before must be RFC3339: %w", err)
+		}
+		out.IssuedBefore = t
+	}
+	if len(file.Logins) > 0 {
+		out.LoginsIssuedBefore = make(map[string]time.Time, len(file.Logins))
+	}
+	for login, v := range file.Logins {
+		if strings.TrimSpace(login) == "" {
+			return Revocations{}, fmt.Errorf("revoked login must not be empty")
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return Revocations{}, fmt.Errorf("login %q: issued_before must be RFC3339: %w", login, err)
+		}
+		out.LoginsIssuedBefore[login] = t
+	}
+	return out, nil
+}
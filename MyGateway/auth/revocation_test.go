@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRevocations(t *testing.T) {
+	r, err := ParseRevocations([]byte(`
+sessions: [s1, " s2 "]
+issued_before: "2026-01-01T00:00:00Z"
+logins:
+  alice: "2026-02-01T00:00:00Z"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"s1": {}, "s2": {}}, r.Sessions)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), r.IssuedBefore)
+	assert.Equal(t, map[string]time.Time{"alice": time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)}, r.LoginsIssuedBefore)
+
+	empty, err := ParseRevocations(nil)
+	require.NoError(t, err)
+	assert.False(t, empty.Revoked(TokenClaims{SessionID: "s1"}))
+
+	for name, data := range map[string]string{
+		"bad_yaml":          "sessions: [",
+		"empty_session":     "sessions: [\"\"]",
+		"bad_issued_before": "issued_before: yesterday",
+		"bad_login_cutoff":  "logins: {alice: yesterday}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRevocations([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRevocations_Revoked(t *testing.T) {
+	r := Revocations{
+		Sessions:           map[string]struct{}{"revoked": {}},
+		LoginsIssuedBefore: map[string]time.Time{"alice": time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	tests := []struct {
+		name   string
+		claims TokenClaims
+		want   bool
+	}{
+		{name: "revoked_session", claims: TokenClaims{SessionID: "revoked", IssuedAt: "2026-03-01T00:00:00Z"}, want: true},
+		{name: "other_session", claims: TokenClaims{SessionID: "s1", Login: "bob", IssuedAt: "2025-01-01T00:00:00Z"}, want: false},
+		{name: "login_issued_before_cutoff", claims: TokenClaims{SessionID: "s1", Login: "alice", IssuedAt: "2026-01-31T23:59:59Z"}, want: true},
+		{name: "login_issued_after_cutoff", claims: TokenClaims{SessionID: "s1", Login: "alice", IssuedAt: "2026-02-01T00:00:00Z"}, want: false},
+		{name: "login_missing_issued_at", claims: TokenClaims{SessionID: "s1", Login: "alice"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.Revoked(tt.claims))
+		})
+	}
+
+	r.IssuedBefore = time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	assert.True(t, r.Revoked(TokenClaims{SessionID: "s1", Login: "bob", IssuedAt: "2026-01-14T00:00:00Z"}), "global cutoff")
+	assert.False(t, r.Revoked(TokenClaims{SessionID: "s1", Login: "bob", IssuedAt: "2026-01-16T00:00:00Z"}))
+	assert.True(t, r.Revoked(TokenClaims{SessionID: "s1", Login: "alice", IssuedAt: "2026-01-16T00:00:00Z"}), "later login cutoff wins")
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrEmptyKeyList is returned by ParseSecretList and ParseSecretKeys when no key is defined.
+var ErrEmptyKeyList = errors.New("key list contains no keys")
+
+// secretKeysFile is the YAML (or JSON) shape of a rotation key file: { keys: [ { id, secret, not_after } ] }.
+type secretKeysFile struct {
+	Keys []secretKeyEntry `yaml:"keys"`
+}
+
+// secretKeyEntry is one HMAC key of a rotation key file; NotAfter is RFC3339, empty — the key never expires.
+type secretKeyEntry struct {
+	ID       string `yaml:"id"`
+	Secret   string `yaml:"secret"`
+	NotAfter string `yaml:"not_after"`
+}
+
+// ParseSecretList parses the JWT_SECRETS value: comma-separated entries "id=secret" or "id=secret@not_after"
+// (not_after in RFC3339). The first "=" separates the ID, the last "@" followed by a valid RFC3339 time separates the
+// expiry, so secrets may contain "=" and "@" but not ",".
+//
+// Parameter s — raw env value.
+//
+// Returns: ([]VerificationKey, nil) in the given order (current key first by convention); (nil, ErrEmptyKeyList) when s
+// has no entries; (nil, error) on a malformed entry, empty ID/secret or duplicate ID.
+//
+// Called from cmd.LoadConfig.
+func ParseSecretList(s string) ([]VerificationKey, error) {
+	var entries []secretKeyEntry
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("key entry %q: expected id=secret[@not_after]", part)
+		}
+		entry := secretKeyEntry{ID: strings.TrimSpace(id), Secret: secret}
+		if i := strings.LastIndex(secret, "@"); i >= 0 {
+			if _, err := time.Parse(time.RFC3339, secret[i+1:]); err == nil {
+				entry.Secret = secret[:i]
+				entry.NotAfter = secret[i+1:]
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return secretKeys(entries)
+}
+
+// ParseSecretKeys decodes a rotation key file (YAML or JSON): a "keys" list of { id, secret, not_after }.
+//
+// Parameter data — file content.
+//
+// Returns: ([]VerificationKey, nil) in file order; (nil, ErrEmptyKeyList) when the list is empty; (nil, error) on decode
+// error, empty ID/secret, duplicate ID or invalid not_after.
+//
+// Called from adapters.SecretKeysFile when (re)loading keys and from cmd.LoadConfig to validate the file at start.
+func ParseSecretKeys(data []byte) ([]VerificationKey, error) {
+	var file secretKeysFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unmarshal key file: %w", err)
+	}
+	return secretKeys(file.Keys)
+}
+
+// secretKeys validates entries and converts them to HMAC verification keys.
+func secretKeys(entries []secretKeyEntry) ([]VerificationKey, error) {
+	if len(entries) == 0 {
+		return nil, ErrEmptyKeyList
+	}
+	out := make([]VerificationKey, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for i, e := range entries {
+		if e.ID == "" {
+			return nil, fmt.Errorf("key %d: id is required", i)
+		}
+		if seen[e.ID] {
+			return nil, fmt.Errorf("key %q: duplicate id", e.ID)
+		}
+		seen[e.ID] = true
+		if e.Secret == "" {
+			return nil, fmt.Errorf("key %q: secret is required", e.ID)
+		}
+		key := VerificationKey{ID: e.ID, Algorithm: AlgHS256, Key: []byte(e.Secret)}
+		if e.NotAfter != "" {
+			t, err := time.Parse(time.RFC3339, e.NotAfter)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: not_after must be RFC3339: %w", e.ID, err)
+			}
+			key.NotAfter = t
+		}
+		out = append(out, key)
+	}
+	return out, nil
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecretList(t *testing.T) {
+	keys, err := ParseSecretList("k2=new=value, k1=old@pass@2026-03-01T00:00:00Z,")
+	require.NoError(t, err)
+	assert.Equal(t, []VerificationKey{
+		{ID: "k2", Algorithm: AlgHS256, Key: []byte("new=value")},
+		{ID: "k1", Algorithm: AlgHS256, Key: []byte("old@pass"), NotAfter: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}, keys)
+
+	t.Run("at_without_time_is_part_of_secret", func(t *testing.T) {
+		keys, err := ParseSecretList("k1=user@host")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("user@host"), keys[0].Key)
+		assert.True(t, keys[0].NotAfter.IsZero())
+	})
+
+	errorCases := []struct {
+		name    string
+		value   string
+		wantErr error
+	}{
+		{name: "empty", value: " , ", wantErr: ErrEmptyKeyList},
+		{name: "no_separator", value: "secret"},
+		{name: "empty_id", value: "=secret"},
+		{name: "empty_secret", value: "k1="},
+		{name: "duplicate_id", value: "k1=a,k1=b"},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSecretList(tt.value)
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSecretKeys(t *testing.T) {
+	keys, err := ParseSecretKeys([]byte(`
+keys:
+  - id: k2
+    secret: new
+  - id: k1
+    secret: old
+    not_after: "2026-03-01T00:00:00Z"
+`))
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "k2", keys[0].ID)
+	assert.True(t, keys[0].Active(testNow().AddDate(10, 0, 0)))
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), keys[1].NotAfter)
+	assert.True(t, keys[1].Active(keys[1].NotAfter))
+	assert.False(t, keys[1].Active(keys[1].NotAfter.Add(time.Second)))
+
+	t.Run("json", func(t *testing.T) {
+		keys, err := ParseSecretKeys([]byte(`{"keys":[{"id":"k1","secret":"s"}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("s"), keys[0].Key)
+	})
+	t.Run("invalid_not_after", func(t *testing.T) {
+		_, err := ParseSecretKeys([]byte("keys:\n  - id: k1\n    secret: s\n    not_after: tomorrow\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not_after must be RFC3339")
+	})
+	t.Run("empty", func(t *testing.T) {
+		_, err := ParseSecretKeys([]byte("keys: []\n"))
+		assert.ErrorIs(t, err, ErrEmptyKeyList)
+	})
+}
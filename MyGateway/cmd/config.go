@@ -2,57 +2,313 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"mygateway/adapters"
+	"mygateway/auth"
 	"mygateway/domain"
+	"mygateway/service"
 
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc/codes"
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // Env variable names.
 const (
 	envGRPCPort       = "SERVICE_PORT_GRPC"
 	envJWTSecret      = "JWT_SECRET"
+	envJWTSecrets     = "JWT_SECRETS"
+	envJWTKeysFile    = "JWT_KEYS_FILE"
 	envConfigPath     = "CONFIG_PATH"
 	envRetryCount     = "RETRY_COUNT"
 	envRetryTimeoutMs = "RETRY_TIMEOUT_MS"
+	envZone           = "GATEWAY_ZONE"
 )
 
 // Config holds the full gateway configuration loaded by LoadConfig from environment variables and the YAML file.
-// GRPCPort is the listening port (from SERVICE_PORT_GRPC); JWTSecret from JWT_SECRET; Routes and Clusters from YAML;
-// RetryCount and RetryTimeout for FR-MGW-4 retry on dynamic clusters (from RETRY_COUNT, RETRY_TIMEOUT_MS).
+// GRPCPort is the listening port (from SERVICE_PORT_GRPC); JWTKeys are the static HMAC verification keys (JWT_SECRET
+// or the JWT_SECRETS rotation list); JWTKeysFile is the rotation key file re-read on change (JWT_KEYS_FILE); Routes and Clusters from YAML;
+// RetryCount and RetryTimeout for FR-MGW-4 retry on dynamic clusters (from RETRY_COUNT, RETRY_TIMEOUT_MS);
+// Auth holds gateway-wide auth options from the YAML "auth" section; GRPCWeb — the optional gRPC-Web frontend (YAML "grpc_web");
+// HTTPJSON — the optional HTTP/JSON transcoding frontend (YAML "http_json") with HTTPRules parsed from its descriptor set;
+// AccessLog — the per-RPC access log (YAML "access_log" plus per-route access_log_sample_rate);
+// LoadShedding — gateway-wide adaptive admission control (YAML "load_shedding" plus per-route priority);
+// Shutdown — the graceful drain on SIGINT/SIGTERM (YAML "shutdown"); Server — keepalive and HTTP/2 settings of the
+// listeners (YAML "server").
 type Config struct {
 	GRPCPort     int
-	JWTSecret    []byte
+	JWTKeys      []auth.VerificationKey
+	JWTKeysFile  string
 	Routes       domain.RouteConfig
 	Clusters     map[domain.ClusterID]domain.ClusterConfig
 	RetryCount   int
 	RetryTimeout time.Duration
+	Auth         domain.AuthConfig
+	GRPCWeb      domain.GRPCWebConfig
+	HTTPJSON     domain.HTTPJSONConfig
+	HTTPRules    service.HTTPRules
+	AccessLog    domain.AccessLogConfig
+	LoadShedding domain.LoadSheddingConfig
+	Shutdown     domain.ShutdownConfig
+	Server       domain.ServerTransport
 }
 
-// yamlConfig is the root struct for YAML unmarshalling; contains default, routes, and clusters.
+// yamlConfig is the root struct for YAML unmarshalling; contains default, routes, clusters, auth, grpc_web, http_json, access_log, load_shedding, shutdown and server.
 type yamlConfig struct {
-	Default  yamlDefault            `yaml:"default"`
-	Routes   []yamlRoute            `yaml:"routes"`
-	Clusters map[string]yamlCluster `yaml:"clusters"`
+	Default      yamlDefault            `yaml:"default"`
+	Routes       []yamlRoute            `yaml:"routes"`
+	Clusters     map[string]yamlCluster `yaml:"clusters"`
+	Auth         yamlAuth               `yaml:"auth"`
+	GRPCWeb      yamlGRPCWeb            `yaml:"grpc_web"`
+	HTTPJSON     yamlHTTPJSON           `yaml:"http_json"`
+	AccessLog    yamlAccessLog          `yaml:"access_log"`
+	LoadShedding yamlLoadShedding       `yaml:"load_shedding"`
+	Shutdown     yamlShutdown           `yaml:"shutdown"`
+	Server       yamlServer             `yaml:"server"`
 }
 
+// yamlServer holds the listener transport: keepalive, max_recv_msg_size_bytes/max_send_msg_size_bytes,
+// max_concurrent_streams (per connection) and initial_window_size/initial_conn_window_size (HTTP/2 flow control).
+type yamlServer struct {
+	Keepalive             yamlServerKeepalive `yaml:"keepalive"`
+	MaxRecvMsgSizeBytes   int                 `yaml:"max_recv_msg_size_bytes"`
+	MaxSendMsgSizeBytes   int                 `yaml:"max_send_msg_size_bytes"`
+	MaxConcurrentStreams  int                 `yaml:"max_concurrent_streams"`
+	InitialWindowSize     int                 `yaml:"initial_window_size"`
+	InitialConnWindowSize int                 `yaml:"initial_conn_window_size"`
+}
+
+// yamlServerKeepalive holds server pings (time_ms, timeout_ms), the client ping policy (min_time_ms,
+// permit_without_stream) and connection lifetime (max_connection_idle_ms, max_connection_age_ms, max_connection_age_grace_ms).
+type yamlServerKeepalive struct {
+	TimeMs                  int   `yaml:"time_ms"`
+	TimeoutMs               int   `yaml:"timeout_ms"`
+	MinTimeMs               int   `yaml:"min_time_ms"`
+	PermitWithoutStream     *bool `yaml:"permit_without_stream"`
+	MaxConnectionIdleMs     int   `yaml:"max_connection_idle_ms"`
+	MaxConnectionAgeMs      int   `yaml:"max_connection_age_ms"`
+	MaxConnectionAgeGraceMs int   `yaml:"max_connection_age_grace_ms"`
+}
+
+// yamlClientTransport holds the dial settings of one cluster: keepalive (time_ms, timeout_ms, permit_without_stream),
+// max_recv_msg_size_bytes/max_send_msg_size_bytes and initial_window_size/initial_conn_window_size.
+type yamlClientTransport struct {
+	Keepalive struct {
+		TimeMs              int  `yaml:"time_ms"`
+		TimeoutMs           int  `yaml:"timeout_ms"`
+		PermitWithoutStream bool `yaml:"permit_without_stream"`
+	} `yaml:"keepalive"`
+	MaxRecvMsgSizeBytes   int `yaml:"max_recv_msg_size_bytes"`
+	MaxSendMsgSizeBytes   int `yaml:"max_send_msg_size_bytes"`
+	InitialWindowSize     int `yaml:"initial_window_size"`
+	InitialConnWindowSize int `yaml:"initial_conn_window_size"`
+}
+
+// yamlShutdown holds the graceful drain: drain_timeout_ms, notify_before_ms (end open streams that long before the
+// deadline), status_code/status_message (gRPC status of drained calls) and trailers sent with it.
+type yamlShutdown struct {
+	DrainTimeoutMs int               `yaml:"drain_timeout_ms"`
+	NotifyBeforeMs int               `yaml:"notify_before_ms"`
+	StatusCode     string            `yaml:"status_code"`
+	StatusMessage  string            `yaml:"status_message"`
+	Trailers       map[string]string `yaml:"trailers"`
+}
+
+// yamlLoadShedding holds adaptive admission control: enabled, initial_limit/min_limit/max_limit (concurrent calls),
+// latency_tolerance, backoff, baseline_window_ms, retry_after_ms (hint for shed calls) and priority_header.
+type yamlLoadShedding struct {
+	Enabled          bool    `yaml:"enabled"`
+	InitialLimit     int     `yaml:"initial_limit"`
+	MinLimit         int     `yaml:"min_limit"`
+	MaxLimit         int     `yaml:"max_limit"`
+	LatencyTolerance float64 `yaml:"latency_tolerance"`
+	Backoff          float64 `yaml:"backoff"`
+	BaselineWindowMs int     `yaml:"baseline_window_ms"`
+	RetryAfterMs     *int    `yaml:"retry_after_ms"`
+	PriorityHeader   string  `yaml:"priority_header"`
+}
+
+// yamlAccessLog holds the access log: enabled, format (json|logfmt), output (stderr or file path), max_size_mb and
+// max_backups (file rotation), hash_sticky_key, sample_rate (default for routes) and headers (request headers to log).
+type yamlAccessLog struct {
+	Enabled       bool     `yaml:"enabled"`
+	Format        string   `yaml:"format"`
+	Output        string   `yaml:"output"`
+	MaxSizeMB     int      `yaml:"max_size_mb"`
+	MaxBackups    *int     `yaml:"max_backups"`
+	HashStickyKey bool     `yaml:"hash_sticky_key"`
+	SampleRate    *float64 `yaml:"sample_rate"`
+	Headers       []string `yaml:"headers"`
+}
+
+// yamlHTTPJSON holds the HTTP/JSON transcoding frontend: enabled, port and descriptor_set (FileDescriptorSet path).
+type yamlHTTPJSON struct {
+	Enabled       bool   `yaml:"enabled"`
+	Port          int    `yaml:"port"`
+	DescriptorSet string `yaml:"descriptor_set"`
+}
+
+// yamlGRPCWeb holds the gRPC-Web frontend: enabled, port (0 — same port as SERVICE_PORT_GRPC) and cors.
+type yamlGRPCWeb struct {
+	Enabled bool     `yaml:"enabled"`
+	Port    int      `yaml:"port"`
+	CORS    yamlCORS `yaml:"cors"`
+}
+
+// yamlCORS holds the CORS policy for browser clients: allowed_origins ("*" — any), extra allowed_headers and exposed_headers, allow_credentials and max_age_ms of a preflight answer.
+type yamlCORS struct {
+	AllowedOrigins   []string `yaml:"allowed_origins"`
+	AllowedHeaders   []string `yaml:"allowed_headers"`
+	ExposedHeaders   []string `yaml:"exposed_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials"`
+	MaxAgeMs         int      `yaml:"max_age_ms"`
+}
+
+// yamlAuth holds gateway-wide auth options: forward_claims (inject x-auth-* headers from validated JWT), strip_authorization (drop the authorization header when forwarding claims), jwt (token format and verification), api_key (authorization=api_key routes), external (authorization=external routes) and revocation (revoked sessions and tokens).
+type yamlAuth struct {
+	ForwardClaims      bool           `yaml:"forward_claims"`
+	StripAuthorization bool           `yaml:"strip_authorization"`
+	JWT                yamlJWT        `yaml:"jwt"`
+	APIKey             yamlAPIKey     `yaml:"api_key"`
+	External           yamlExternal   `yaml:"external"`
+	Revocation         yamlRevocation `yaml:"revocation"`
+}
+
+// yamlRevocation holds the token revocation store: file (local YAML/JSON) or redis_url (with key_prefix), and refresh_interval_ms for the local copy.
+type yamlRevocation struct {
+	File              string `yaml:"file"`
+	RedisURL          string `yaml:"redis_url"`
+	KeyPrefix         string `yaml:"key_prefix"`
+	RefreshIntervalMs int    `yaml:"refresh_interval_ms"`
+}
+
+// yamlExternal holds the external authz service settings: address (gRPC host:port), timeout_ms per Check, and the decision cache — cache_key (metadata keys, ":peer" for the client address), cache_ttl_ms (0 — no caching) and cache_max_entries.
+type yamlExternal struct {
+	Address         string   `yaml:"address"`
+	TimeoutMs       int      `yaml:"timeout_ms"`
+	CacheKey        []string `yaml:"cache_key"`
+	CacheTTLMs      int      `yaml:"cache_ttl_ms"`
+	CacheMaxEntries int      `yaml:"cache_max_entries"`
+}
+
+// yamlAPIKey holds settings for authorization=api_key routes: header (metadata key with the raw key, default x-api-key) and keys_file (hashed key store).
+type yamlAPIKey struct {
+	Header   string `yaml:"header"`
+	KeysFile string `yaml:"keys_file"`
+}
+
+// yamlJWT holds token verification settings: format (legacy|standard) and, for standard, algorithms, issuer, audience, leeway_ms and the JWKS source (jwks_file or jwks_url with jwks_refresh_interval_ms).
+type yamlJWT struct {
+	Format              string   `yaml:"format"`
+	Algorithms          []string `yaml:"algorithms"`
+	Issuer              string   `yaml:"issuer"`
+	Audience            string   `yaml:"audience"`
+	LeewayMs            int      `yaml:"leeway_ms"`
+	JWKSFile            string   `yaml:"jwks_file"`
+	JWKSURL             string   `yaml:"jwks_url"`
+	JWKSRefreshInterval int      `yaml:"jwks_refresh_interval_ms"`
+}
+
+// defaultExternalAuthTimeout is used when auth.external.timeout_ms is not set.
+const defaultExternalAuthTimeout = time.Second
+
+// defaultRevocationRefreshInterval is used when auth.revocation is set without refresh_interval_ms.
+const defaultRevocationRefreshInterval = 5 * time.Second
+
+// defaultDNSExclusionTTL is how long a DNS-discovered instance reported as failed is left out when dns_exclusion_ms is not set.
+const defaultDNSExclusionTTL = 30 * time.Second
+
+// maxDiscovererWatchMs is the largest discoverer_watch_ms (MyDiscoverer accepts timeout_ms up to 60000).
+const maxDiscovererWatchMs = 60000
+
+// defaultDiscovererTimeout bounds one request to one MyDiscoverer replica when discoverer_timeout_ms is not set.
+const defaultDiscovererTimeout = 5 * time.Second
+
+// Kubernetes discovery defaults: namespace when k8s_namespace is not set and how long a failed pod is left out when
+// k8s_exclusion_ms is not set.
+const (
+	defaultKubernetesNamespace    = "default"
+	defaultKubernetesExclusionTTL = 30 * time.Second
+)
+
+// defaultStreamQueueTimeout is used when max_concurrent_streams_per_instance is set without stream_queue_timeout_ms.
+const defaultStreamQueueTimeout = 100 * time.Millisecond
+
+// defaultZoneSpillThreshold is used when GATEWAY_ZONE is set and a dynamic cluster has no zone_spill_threshold.
+const defaultZoneSpillThreshold = 1
+
+// Load shedding defaults (load_shedding section).
+const (
+	defaultShedInitialLimit     = 100
+	defaultShedMinLimit         = 10
+	defaultShedMaxLimit         = 1000
+	defaultShedLatencyTolerance = 2.0
+	defaultShedBackoff          = 0.9
+	defaultShedBaselineWindow   = 30 * time.Second
+	defaultShedRetryAfter       = time.Second
+)
+
+// Transport defaults (server section and clusters.<name>.transport). Server pings keep idle client connections alive
+// behind NAT; backend keepalive is off by default because servers reject pings more frequent than their own policy
+// allows (gRPC default: 5 minutes).
+const (
+	defaultServerKeepaliveTime    = time.Minute
+	defaultKeepaliveTimeout       = 20 * time.Second
+	defaultServerKeepaliveMinTime = 10 * time.Second
+	defaultMaxMsgSize             = 16 << 20
+	minServerKeepaliveTime        = time.Second
+	minClientKeepaliveTime        = 10 * time.Second
+	minWindowSize                 = 65535
+)
+
+// Shutdown defaults (shutdown section).
+const (
+	defaultDrainTimeout = 5 * time.Second
+	defaultDrainCode    = codes.Unavailable
+	defaultDrainMessage = "gateway is shutting down, reconnect"
+)
+
+// defaultAccessLogMaxSizeMB and defaultAccessLogMaxBackups are used when access_log.max_size_mb / max_backups are not set.
+const (
+	defaultAccessLogMaxSizeMB  = 100
+	defaultAccessLogMaxBackups = 5
+)
+
+// defaultAPIKeyHeader is used when auth.api_key.header is not set.
+const defaultAPIKeyHeader = "x-api-key"
+
+// defaultJWKSRefreshInterval is used when auth.jwt.jwks_url is set without jwks_refresh_interval_ms.
+const defaultJWKSRefreshInterval = 5 * time.Minute
+
 // yamlDefault holds the default route action (error|use_cluster) and optional use_cluster name.
 type yamlDefault struct {
 	Action     string `yaml:"action"`
 	UseCluster string `yaml:"use_cluster"`
 }
 
-// yamlRoute is one route entry: prefix, cluster name, authorization (none|required), balancer (type and header).
+// yamlRoute is one route entry: prefix, cluster name, authorization (none|required|api_key), allowed_roles (for required), balancer (type and header), priority (load shedding), subset (label → value) and subset_headers (label → request header) for dynamic clusters.
 type yamlRoute struct {
 	Prefix        string       `yaml:"prefix"`
 	Cluster       string       `yaml:"cluster"`
 	Authorization string       `yaml:"authorization"`
+	AllowedRoles  []string     `yaml:"allowed_roles"`
+	FailOpen      bool         `yaml:"fail_open"`
 	Balancer      yamlBalancer `yaml:"balancer"`
+	Priority      string       `yaml:"priority"`
+	// Subset selects instances by labels (label → value); SubsetHeaders takes a label value from a request header (label → header name).
+	Subset        map[string]string `yaml:"subset"`
+	SubsetHeaders map[string]string `yaml:"subset_headers"`
+	// AccessLogSampleRate overrides access_log.sample_rate for this route (nil — not set).
+	AccessLogSampleRate *float64 `yaml:"access_log_sample_rate"`
 }
 
 // yamlBalancer holds balancer type (round_robin|sticky_sessions) and optional header for sticky.
@@ -61,12 +317,36 @@ type yamlBalancer struct {
 	Header string `yaml:"header"`
 }
 
-// yamlCluster is one cluster entry: type (static|dynamic), address (static), discoverer_url and discoverer_interval_ms (dynamic).
+// yamlCluster is one cluster entry: type (static|dynamic), address (static), discoverer (http|dns|file|kubernetes),
+// discoverer_url or discoverer_urls, service_type, discoverer_watch_ms and discoverer_timeout_ms (http), dns_name, dns_port and dns_exclusion_ms (dns), path and file_exclusion_ms (file), k8s_service,
+// k8s_namespace, k8s_port_name and k8s_exclusion_ms (kubernetes), discoverer_interval_ms,
+// max_concurrent_streams_per_instance, stream_queue_timeout_ms, zone_spill_threshold, snapshot_path and
+// snapshot_max_age_ms (dynamic), transport (dial settings).
 type yamlCluster struct {
-	Type               string `yaml:"type"`
-	Address            string `yaml:"address"`
-	DiscovererURL      string `yaml:"discoverer_url"`
-	DiscovererInterval int    `yaml:"discoverer_interval_ms"`
+	Type                            string              `yaml:"type"`
+	Address                         string              `yaml:"address"`
+	Discoverer                      string              `yaml:"discoverer"`
+	DiscovererURL                   string              `yaml:"discoverer_url"`
+	DiscovererURLs                  []string            `yaml:"discoverer_urls"`
+	DiscovererTimeoutMs             *int                `yaml:"discoverer_timeout_ms"`
+	ServiceType                     string              `yaml:"service_type"`
+	DiscovererWatchMs               *int                `yaml:"discoverer_watch_ms"`
+	DNSName                         string              `yaml:"dns_name"`
+	DNSPort                         int                 `yaml:"dns_port"`
+	DNSExclusionMs                  *int                `yaml:"dns_exclusion_ms"`
+	Path                            string              `yaml:"path"`
+	FileExclusionMs                 *int                `yaml:"file_exclusion_ms"`
+	K8sService                      string              `yaml:"k8s_service"`
+	K8sNamespace                    string              `yaml:"k8s_namespace"`
+	K8sPortName                     string              `yaml:"k8s_port_name"`
+	K8sExclusionMs                  *int                `yaml:"k8s_exclusion_ms"`
+	DiscovererInterval              int                 `yaml:"discoverer_interval_ms"`
+	MaxConcurrentStreamsPerInstance int                 `yaml:"max_concurrent_streams_per_instance"`
+	StreamQueueTimeoutMs            *int                `yaml:"stream_queue_timeout_ms"`
+	ZoneSpillThreshold              *int                `yaml:"zone_spill_threshold"`
+	SnapshotPath                    string              `yaml:"snapshot_path"`
+	SnapshotMaxAgeMs                *int                `yaml:"snapshot_max_age_ms"`
+	Transport                       yamlClientTransport `yaml:"transport"`
 }
 
 // loadYAMLConfig reads the YAML file at path and unmarshals it into yamlConfig (default, routes, clusters).
@@ -88,7 +368,7 @@ func loadYAMLConfig(path string) (*yamlConfig, error) {
 	return &out, nil
 }
 
-// LoadConfig builds gateway config from environment variables and YAML at CONFIG_PATH. Reads SERVICE_PORT_GRPC (required, 1–65535), CONFIG_PATH (required), JWT_SECRET (required if any route has authorization=required), RETRY_COUNT and RETRY_TIMEOUT_MS (required, positive). CONFIG_PATH is converted to absolute; YAML is loaded via loadYAMLConfig; routes are normalized (normalizePrefix, authorization, balancer); ValidateRouteConfig is run; clusters are validated for static (address) and dynamic (discoverer_url, discoverer_interval_ms); all route.cluster and default.cluster must exist in clusters.
+// LoadConfig builds gateway config from environment variables and YAML at CONFIG_PATH. Reads SERVICE_PORT_GRPC (required, 1–65535), CONFIG_PATH (required), JWT_SECRET (required if any route has authorization=required), RETRY_COUNT and RETRY_TIMEOUT_MS (required, positive), GATEWAY_ZONE (optional zone of the gateway for zone-aware balancing of dynamic clusters, see parseLocality). CONFIG_PATH is converted to absolute; YAML is loaded via loadYAMLConfig; routes are normalized (normalizePrefix, authorization, balancer); ValidateRouteConfig is run; clusters are validated for static (address) and dynamic (discoverer_url, discoverer_interval_ms); all route.cluster and default.cluster must exist in clusters; auth.strip_authorization is only allowed together with auth.forward_claims; auth.jwt is validated by parseJWTConfig (standard format accepts JWKS instead of JWT_SECRET).
 //
 // Parameters: none (source — os.Getenv and file at CONFIG_PATH).
 //
@@ -122,6 +402,8 @@ func LoadConfig() (*Config, error) {
 
 	routes := make([]domain.Route, 0, len(raw.Routes))
 	needsJWT := false
+	needsAPIKey := false
+	needsExternal := false
 	for _, route := range raw.Routes {
 		prefix := normalizePrefix(route.Prefix)
 		balancerType := domain.BalancerType(strings.TrimSpace(route.Balancer.Type))
@@ -135,14 +417,31 @@ func LoadConfig() (*Config, error) {
 		if auth == domain.AuthorizationRequired {
 			needsJWT = true
 		}
+		if auth == domain.AuthorizationAPIKey {
+			needsAPIKey = true
+		}
+		if auth == domain.AuthorizationExternal {
+			needsExternal = true
+		}
+		var allowedRoles []string
+		for _, role := range route.AllowedRoles {
+			allowedRoles = append(allowedRoles, strings.TrimSpace(role))
+		}
 		routes = append(routes, domain.Route{
 			Prefix:        prefix,
 			Cluster:       domain.ClusterID(strings.TrimSpace(route.Cluster)),
 			Authorization: auth,
+			AllowedRoles:  allowedRoles,
+			FailOpen:      route.FailOpen,
 			Balancer: domain.BalancerConfig{
 				Type:   balancerType,
 				Header: strings.TrimSpace(route.Balancer.Header),
 			},
+			Priority: domain.Priority(strings.ToLower(strings.TrimSpace(route.Priority))),
+			Subset: domain.SubsetConfig{
+				Labels:  trimStringMap(route.Subset, false),
+				Headers: trimStringMap(route.SubsetHeaders, true),
+			},
 		})
 	}
 	defaultCfg := domain.DefaultRoute{
@@ -161,44 +460,75 @@ func LoadConfig() (*Config, error) {
 	if err := domain.ValidateRouteConfig(routeCfg); err != nil {
 		return nil, err
 	}
+	zone := strings.TrimSpace(os.Getenv(envZone))
 	clusters := make(map[domain.ClusterID]domain.ClusterConfig, len(raw.Clusters))
 	for name, cluster := range raw.Clusters {
 		clusterType := domain.ClusterType(strings.TrimSpace(cluster.Type))
 		cfg := domain.ClusterConfig{
 			Type:               clusterType,
 			Address:            strings.TrimSpace(cluster.Address),
-			DiscovererURL:      strings.TrimSpace(cluster.DiscovererURL),
 			DiscovererInterval: time.Duration(cluster.DiscovererInterval) * time.Millisecond,
 		}
 		if cfg.Type == domain.ClusterTypeStatic && cfg.Address == "" {
 			return nil, fmt.Errorf("cluster %s: address is required for static cluster", name)
 		}
 		if cfg.Type == domain.ClusterTypeDynamic {
-			if cfg.DiscovererURL == "" {
-				return nil, fmt.Errorf("cluster %s: discoverer_url is required for dynamic cluster", name)
-			}
 			if cfg.DiscovererInterval <= 0 {
 				return nil, fmt.Errorf("cluster %s: discoverer_interval_ms must be positive", name)
 			}
 		}
+		if err := parseDiscoverer(name, cluster, &cfg); err != nil {
+			return nil, err
+		}
+		cfg.StreamLimit, err = parseStreamLimit(name, cluster)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Locality, err = parseLocality(name, cluster, zone)
+		if err != nil {
+			return nil, err
+		}
+		if err := parseSnapshot(name, cluster, &cfg); err != nil {
+			return nil, err
+		}
+		cfg.Transport, err = parseClientTransport(name, cluster.Transport)
+		if err != nil {
+			return nil, err
+		}
 		if cfg.Type != domain.ClusterTypeStatic && cfg.Type != domain.ClusterTypeDynamic {
 			return nil, fmt.Errorf("cluster %s: type must be static|dynamic", name)
 		}
 		clusters[domain.ClusterID(name)] = cfg
 	}
 	for _, route := range routeCfg.Routes {
-		if _, ok := clusters[route.Cluster]; !ok {
+		cluster, ok := clusters[route.Cluster]
+		if !ok {
 			return nil, fmt.Errorf("route prefix %q references unknown cluster %q", route.Prefix, route.Cluster)
 		}
+		if !route.Subset.IsZero() && cluster.Type != domain.ClusterTypeDynamic {
+			return nil, fmt.Errorf("route prefix %q: subset and subset_headers require a dynamic cluster", route.Prefix)
+		}
 	}
 	if routeCfg.Default.Action == domain.DefaultRouteUseCluster {
 		if _, ok := clusters[routeCfg.Default.Cluster]; !ok {
 			return nil, fmt.Errorf("default cluster %q is not defined", routeCfg.Default.Cluster)
 		}
 	}
-	jwtSecret := []byte(strings.TrimSpace(os.Getenv(envJWTSecret)))
-	if needsJWT && len(jwtSecret) == 0 {
-		return nil, fmt.Errorf("%s is required when at least one route has authorization=required", envJWTSecret)
+	jwtCfg, err := parseJWTConfig(raw.Auth.JWT)
+	if err != nil {
+		return nil, err
+	}
+	jwtKeys, jwtKeysFile, err := loadJWTKeys()
+	if err != nil {
+		return nil, err
+	}
+	if needsJWT && len(jwtKeys) == 0 && jwtKeysFile == "" {
+		if jwtCfg.Format == domain.JWTFormatLegacy {
+			return nil, fmt.Errorf("%s, %s or %s is required when at least one route has authorization=required", envJWTSecret, envJWTSecrets, envJWTKeysFile)
+		}
+		if jwtCfg.JWKSFile == "" && jwtCfg.JWKSURL == "" {
+			return nil, fmt.Errorf("%s, %s, %s, auth.jwt.jwks_file or auth.jwt.jwks_url is required when at least one route has authorization=required", envJWTSecret, envJWTSecrets, envJWTKeysFile)
+		}
 	}
 	retryCountStr := strings.TrimSpace(os.Getenv(envRetryCount))
 	if retryCountStr == "" {
@@ -217,16 +547,942 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("%s must be a positive integer (ms), got %q", envRetryTimeoutMs, retryTimeoutMsStr)
 	}
 	retryTimeout := time.Duration(retryTimeoutMs) * time.Millisecond
+	if raw.Auth.StripAuthorization && !raw.Auth.ForwardClaims {
+		return nil, fmt.Errorf("auth.strip_authorization requires auth.forward_claims")
+	}
+	apiKeyCfg, err := parseAPIKeyConfig(raw.Auth.APIKey, needsAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	externalCfg, err := parseExternalAuthConfig(raw.Auth.External, needsExternal)
+	if err != nil {
+		return nil, err
+	}
+	revocationCfg, err := parseRevocationConfig(raw.Auth.Revocation)
+	if err != nil {
+		return nil, err
+	}
+	grpcWebCfg, err := parseGRPCWebConfig(raw.GRPCWeb, grpcPort)
+	if err != nil {
+		return nil, err
+	}
+	httpJSONCfg, httpRules, err := parseHTTPJSONConfig(raw.HTTPJSON, grpcPort, grpcWebCfg.Port)
+	if err != nil {
+		return nil, err
+	}
+	accessLogCfg, err := parseAccessLogConfig(raw.AccessLog, raw.Routes)
+	if err != nil {
+		return nil, err
+	}
+	loadSheddingCfg, err := parseLoadSheddingConfig(raw.LoadShedding)
+	if err != nil {
+		return nil, err
+	}
+	shutdownCfg, err := parseShutdownConfig(raw.Shutdown)
+	if err != nil {
+		return nil, err
+	}
+	serverTransport, err := parseServerTransport(raw.Server)
+	if err != nil {
+		return nil, err
+	}
 	return &Config{
 		GRPCPort:     grpcPort,
-		JWTSecret:    jwtSecret,
+		JWTKeys:      jwtKeys,
+		JWTKeysFile:  jwtKeysFile,
 		Routes:       routeCfg,
 		Clusters:     clusters,
 		RetryCount:   retryCount,
 		RetryTimeout: retryTimeout,
+		Auth: domain.AuthConfig{
+			ForwardClaims:      raw.Auth.ForwardClaims,
+			StripAuthorization: raw.Auth.StripAuthorization,
+			JWT:                jwtCfg,
+			APIKey:             apiKeyCfg,
+			External:           externalCfg,
+			Revocation:         revocationCfg,
+		},
+		GRPCWeb:      grpcWebCfg,
+		HTTPJSON:     httpJSONCfg,
+		HTTPRules:    httpRules,
+		AccessLog:    accessLogCfg,
+		LoadShedding: loadSheddingCfg,
+		Shutdown:     shutdownCfg,
+		Server:       serverTransport,
 	}, nil
 }
 
+// parseExternalAuthConfig validates the auth.external section: address is required when a route has authorization=external;
+// timeout_ms, cache_ttl_ms and cache_max_entries must not be negative (timeout 0 — 1s); cache_key entries are lowercased and
+// must not be empty, and a positive cache_ttl_ms requires a cache_key (otherwise all callers of a method would share one decision).
+//
+// Parameters: raw — YAML section; needed — at least one route has authorization=external.
+//
+// Returns: (domain.ExternalAuthConfig, nil); (zero, error) on the first invalid value.
+//
+// Called only from LoadConfig.
+func parseExternalAuthConfig(raw yamlExternal, needed bool) (domain.ExternalAuthConfig, error) {
+	cfg := domain.ExternalAuthConfig{
+		Address:         strings.TrimSpace(raw.Address),
+		Timeout:         time.Duration(raw.TimeoutMs) * time.Millisecond,
+		CacheTTL:        time.Duration(raw.CacheTTLMs) * time.Millisecond,
+		CacheMaxEntries: raw.CacheMaxEntries,
+	}
+	if cfg.Address == "" && needed {
+		return domain.ExternalAuthConfig{}, fmt.Errorf("auth.external.address is required when at least one route has authorization=external")
+	}
+	if raw.TimeoutMs < 0 {
+		return domain.ExternalAuthConfig{}, fmt.Errorf("auth.external.timeout_ms must not be negative, got %d", raw.TimeoutMs)
+	}
+	if raw.TimeoutMs == 0 {
+		cfg.Timeout = defaultExternalAuthTimeout
+	}
+	if raw.CacheTTLMs < 0 {
+		return domain.ExternalAuthConfig{}, fmt.Errorf("auth.external.cache_ttl_ms must not be negative, got %d", raw.CacheTTLMs)
+	}
+	if raw.CacheMaxEntries < 0 {
+		return domain.ExternalAuthConfig{}, fmt.Errorf("auth.external.cache_max_entries must not be negative, got %d", raw.CacheMaxEntries)
+	}
+	for _, k := range raw.CacheKey {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			return domain.ExternalAuthConfig{}, fmt.Errorf("auth.external.cache_key must not contain empty values")
+		}
+		cfg.CacheKey = append(cfg.CacheKey, k)
+	}
+	if cfg.CacheTTL > 0 && len(cfg.CacheKey) == 0 {
+		return domain.ExternalAuthConfig{}, fmt.Errorf("auth.external.cache_key is required when auth.external.cache_ttl_ms > 0")
+	}
+	return cfg, nil
+}
+
+// parseStreamLimit validates max_concurrent_streams_per_instance (≥ 0, dynamic clusters only; 0 — unlimited) and
+// stream_queue_timeout_ms (≥ 0, default 100 ms; only with a limit).
+//
+// Parameters: name — cluster name for error messages; raw — YAML cluster entry.
+//
+// Returns: (domain.StreamLimit, nil) (zero limit when not set); (zero, error) on an invalid value.
+//
+// Called only from LoadConfig for each cluster.
+func parseStreamLimit(name string, raw yamlCluster) (domain.StreamLimit, error) {
+	if raw.MaxConcurrentStreamsPerInstance < 0 {
+		return domain.StreamLimit{}, fmt.Errorf("cluster %s: max_concurrent_streams_per_instance must not be negative", name)
+	}
+	if raw.MaxConcurrentStreamsPerInstance == 0 {
+		if raw.StreamQueueTimeoutMs != nil {
+			return domain.StreamLimit{}, fmt.Errorf("cluster %s: stream_queue_timeout_ms requires max_concurrent_streams_per_instance", name)
+		}
+		return domain.StreamLimit{}, nil
+	}
+	if domain.ClusterType(strings.TrimSpace(raw.Type)) != domain.ClusterTypeDynamic {
+		return domain.StreamLimit{}, fmt.Errorf("cluster %s: max_concurrent_streams_per_instance requires type dynamic", name)
+	}
+	limit := domain.StreamLimit{MaxPerInstance: raw.MaxConcurrentStreamsPerInstance, QueueTimeout: defaultStreamQueueTimeout}
+	if raw.StreamQueueTimeoutMs != nil {
+		if *raw.StreamQueueTimeoutMs < 0 {
+			return domain.StreamLimit{}, fmt.Errorf("cluster %s: stream_queue_timeout_ms must not be negative", name)
+		}
+		limit.QueueTimeout = time.Duration(*raw.StreamQueueTimeoutMs) * time.Millisecond
+	}
+	return limit, nil
+}
+
+// parseLocality builds the zone preference of a dynamic cluster: zone from GATEWAY_ZONE and zone_spill_threshold (≥ 1,
+// default 1; dynamic clusters only). Without a gateway zone the result is zero (no preference), and the threshold is
+// still validated.
+//
+// Parameters: name — cluster name for error messages; raw — YAML cluster entry; zone — trimmed GATEWAY_ZONE ("" — unset).
+//
+// Returns: (domain.Locality, nil); (zero, error) on an invalid threshold.
+//
+// Called only from LoadConfig for each cluster.
+func parseLocality(name string, raw yamlCluster, zone string) (domain.Locality, error) {
+	dynamic := domain.ClusterType(strings.TrimSpace(raw.Type)) == domain.ClusterTypeDynamic
+	threshold := defaultZoneSpillThreshold
+	if raw.ZoneSpillThreshold != nil {
+		if !dynamic {
+			return domain.Locality{}, fmt.Errorf("cluster %s: zone_spill_threshold requires type dynamic", name)
+		}
+		if *raw.ZoneSpillThreshold < 1 {
+			return domain.Locality{}, fmt.Errorf("cluster %s: zone_spill_threshold must be at least 1", name)
+		}
+		threshold = *raw.ZoneSpillThreshold
+	}
+	if !dynamic || zone == "" {
+		return domain.Locality{}, nil
+	}
+	return domain.Locality{Zone: zone, SpillThreshold: threshold}, nil
+}
+
+// parseSnapshot validates the last-known-good settings of a cluster and stores them in cfg: snapshot_path (file of the
+// instance snapshot, used as given) and snapshot_max_age_ms (≥ 0, default 0 — no limit) are allowed only for dynamic
+// clusters; snapshot_max_age_ms works without snapshot_path too (the in-memory list is still dropped when stale).
+//
+// Parameters: name — cluster name for error messages; raw — YAML cluster entry; cfg — cluster being built.
+//
+// Returns: nil; error on a non-dynamic cluster or a negative age.
+//
+// Called only from LoadConfig for each cluster.
+func parseSnapshot(name string, raw yamlCluster, cfg *domain.ClusterConfig) error {
+	path := strings.TrimSpace(raw.SnapshotPath)
+	if path == "" && raw.SnapshotMaxAgeMs == nil {
+		return nil
+	}
+	if cfg.Type != domain.ClusterTypeDynamic {
+		return fmt.Errorf("cluster %s: snapshot_path and snapshot_max_age_ms require type dynamic", name)
+	}
+	cfg.SnapshotPath = path
+	if raw.SnapshotMaxAgeMs != nil {
+		if *raw.SnapshotMaxAgeMs < 0 {
+			return fmt.Errorf("cluster %s: snapshot_max_age_ms must not be negative", name)
+		}
+		cfg.SnapshotMaxAge = time.Duration(*raw.SnapshotMaxAgeMs) * time.Millisecond
+	}
+	return nil
+}
+
+// parseDiscovererURLs returns the MyDiscoverer replicas of an http cluster from discoverer_url or discoverer_urls (not
+// both): trimmed, without trailing slash, each an absolute http or https URL, no duplicates.
+//
+// Parameters: name — cluster name for error messages; raw — YAML cluster entry.
+//
+// Returns: (urls in config order, nil); (nil, error) when none is set, both are set or a URL is invalid or repeated.
+//
+// Called only from parseDiscoverer for discoverer http.
+func parseDiscovererURLs(name string, raw yamlCluster) ([]string, error) {
+	list := raw.DiscovererURLs
+	if single := strings.TrimSpace(raw.DiscovererURL); single != "" {
+		if len(list) > 0 {
+			return nil, fmt.Errorf("cluster %s: discoverer_url and discoverer_urls are mutually exclusive", name)
+		}
+		list = []string{single}
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("cluster %s: discoverer_url is required for dynamic cluster", name)
+	}
+	urls := make([]string, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSuffix(strings.TrimSpace(s), "/")
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("cluster %s: discoverer URL %q must be an absolute http or https URL", name, s)
+		}
+		if slices.Contains(urls, s) {
+			return nil, fmt.Errorf("cluster %s: discoverer_urls must not repeat %q", name, s)
+		}
+		urls = append(urls, s)
+	}
+	return urls, nil
+}
+
+// parseDiscoverer validates the discovery settings of a cluster and stores them in cfg: only dynamic clusters may set
+// discoverer (http — default, needs discoverer_url or a list of replicas in discoverer_urls (absolute http(s) URLs, no
+// duplicates, trailing slash removed), service_type filters the instances of a shared MyDiscoverer, discoverer_watch_ms
+// 1–60000 enables the long-poll watch, discoverer_timeout_ms ≥ 1 bounds one request, default 5000; dns — needs dns_name, dns_port 0 for SRV or 1–65535 for A/AAAA,
+// dns_exclusion_ms ≥ 0, default 30000; file — needs path to a readable instance file (adapters.ParseInstanceFile),
+// file_exclusion_ms ≥ 0, default 0 — until the file changes; kubernetes — needs k8s_service (DNS-1035 label),
+// k8s_namespace (DNS-1123 label, default "default"), k8s_port_name (IANA service name, default "" — unnamed port),
+// k8s_exclusion_ms ≥ 0, default 30000) or the discoverer_urls, service_type, discoverer_watch_ms, discoverer_timeout_ms,
+// dns_*, file and k8s_* fields. discoverer_url on a non-http cluster is ignored, as before.
+//
+// Parameters: name — cluster name for error messages; raw — YAML cluster entry; cfg — cluster being built (Discoverer,
+// HTTP, DNS, File and Kubernetes are set; left empty for static clusters).
+//
+// Returns: nil; error on the first invalid value.
+//
+// Called only from LoadConfig for each cluster.
+func parseDiscoverer(name string, raw yamlCluster, cfg *domain.ClusterConfig) error {
+	discoverer := domain.DiscovererType(strings.ToLower(strings.TrimSpace(raw.Discoverer)))
+	dnsSet := raw.DNSName != "" || raw.DNSPort != 0 || raw.DNSExclusionMs != nil
+	fileSet := raw.Path != "" || raw.FileExclusionMs != nil
+	k8sSet := raw.K8sService != "" || raw.K8sNamespace != "" || raw.K8sPortName != "" || raw.K8sExclusionMs != nil
+	httpSet := raw.DiscovererURLs != nil || raw.ServiceType != "" || raw.DiscovererWatchMs != nil || raw.DiscovererTimeoutMs != nil
+	if domain.ClusterType(strings.TrimSpace(raw.Type)) != domain.ClusterTypeDynamic {
+		if discoverer != "" || httpSet || dnsSet || fileSet || k8sSet {
+			return fmt.Errorf("cluster %s: discoverer requires type dynamic", name)
+		}
+		return nil
+	}
+	if httpSet && discoverer != "" && discoverer != domain.DiscovererHTTP {
+		return fmt.Errorf("cluster %s: discoverer_urls, service_type, discoverer_watch_ms and discoverer_timeout_ms require discoverer http", name)
+	}
+	if dnsSet && discoverer != domain.DiscovererDNS {
+		return fmt.Errorf("cluster %s: dns_name, dns_port and dns_exclusion_ms require discoverer dns", name)
+	}
+	if fileSet && discoverer != domain.DiscovererFile {
+		return fmt.Errorf("cluster %s: path and file_exclusion_ms require discoverer file", name)
+	}
+	if k8sSet && discoverer != domain.DiscovererKubernetes {
+		return fmt.Errorf("cluster %s: k8s_service, k8s_namespace, k8s_port_name and k8s_exclusion_ms require discoverer kubernetes", name)
+	}
+	switch discoverer {
+	case "", domain.DiscovererHTTP:
+		http := domain.HTTPDiscovery{ServiceType: strings.TrimSpace(raw.ServiceType), Timeout: defaultDiscovererTimeout}
+		var err error
+		if http.URLs, err = parseDiscovererURLs(name, raw); err != nil {
+			return err
+		}
+		if raw.DiscovererWatchMs != nil {
+			if *raw.DiscovererWatchMs < 1 || *raw.DiscovererWatchMs > maxDiscovererWatchMs {
+				return fmt.Errorf("cluster %s: discoverer_watch_ms must be between 1 and %d", name, maxDiscovererWatchMs)
+			}
+			http.Watch = time.Duration(*raw.DiscovererWatchMs) * time.Millisecond
+		}
+		if raw.DiscovererTimeoutMs != nil {
+			if *raw.DiscovererTimeoutMs < 1 {
+				return fmt.Errorf("cluster %s: discoverer_timeout_ms must be positive", name)
+			}
+			http.Timeout = time.Duration(*raw.DiscovererTimeoutMs) * time.Millisecond
+		}
+		cfg.Discoverer, cfg.HTTP = domain.DiscovererHTTP, http
+	case domain.DiscovererDNS:
+		dns := domain.DNSDiscovery{Name: strings.TrimSuffix(strings.TrimSpace(raw.DNSName), "."), Port: raw.DNSPort, ExclusionTTL: defaultDNSExclusionTTL}
+		if dns.Name == "" {
+			return fmt.Errorf("cluster %s: dns_name is required for discoverer dns", name)
+		}
+		if dns.Port < 0 || dns.Port > 65535 {
+			return fmt.Errorf("cluster %s: dns_port must be between 0 (SRV) and 65535", name)
+		}
+		if raw.DNSExclusionMs != nil {
+			if *raw.DNSExclusionMs < 0 {
+				return fmt.Errorf("cluster %s: dns_exclusion_ms must not be negative", name)
+			}
+			dns.ExclusionTTL = time.Duration(*raw.DNSExclusionMs) * time.Millisecond
+		}
+		cfg.Discoverer, cfg.DNS = domain.DiscovererDNS, dns
+	case domain.DiscovererFile:
+		file := domain.FileDiscovery{Path: strings.TrimSpace(raw.Path)}
+		if file.Path == "" {
+			return fmt.Errorf("cluster %s: path is required for discoverer file", name)
+		}
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			return fmt.Errorf("cluster %s: path: %w", name, err)
+		}
+		if _, err := adapters.ParseInstanceFile(data); err != nil {
+			return fmt.Errorf("cluster %s: path: %w", name, err)
+		}
+		if raw.FileExclusionMs != nil {
+			if *raw.FileExclusionMs < 0 {
+				return fmt.Errorf("cluster %s: file_exclusion_ms must not be negative", name)
+			}
+			file.ExclusionTTL = time.Duration(*raw.FileExclusionMs) * time.Millisecond
+		}
+		cfg.Discoverer, cfg.File = domain.DiscovererFile, file
+	case domain.DiscovererKubernetes:
+		k8s := domain.KubernetesDiscovery{
+			Service:      strings.TrimSpace(raw.K8sService),
+			Namespace:    strings.TrimSpace(raw.K8sNamespace),
+			PortName:     strings.TrimSpace(raw.K8sPortName),
+			ExclusionTTL: defaultKubernetesExclusionTTL,
+		}
+		if k8s.Service == "" {
+			return fmt.Errorf("cluster %s: k8s_service is required for discoverer kubernetes", name)
+		}
+		if errs := validation.IsDNS1035Label(k8s.Service); len(errs) > 0 {
+			return fmt.Errorf("cluster %s: k8s_service %q: %s", name, k8s.Service, strings.Join(errs, "; "))
+		}
+		if k8s.Namespace == "" {
+			k8s.Namespace = defaultKubernetesNamespace
+		}
+		if errs := validation.IsDNS1123Label(k8s.Namespace); len(errs) > 0 {
+			return fmt.Errorf("cluster %s: k8s_namespace %q: %s", name, k8s.Namespace, strings.Join(errs, "; "))
+		}
+		if k8s.PortName != "" {
+			if errs := validation.IsValidPortName(k8s.PortName); len(errs) > 0 {
+				return fmt.Errorf("cluster %s: k8s_port_name %q: %s", name, k8s.PortName, strings.Join(errs, "; "))
+			}
+		}
+		if raw.K8sExclusionMs != nil {
+			if *raw.K8sExclusionMs < 0 {
+				return fmt.Errorf("cluster %s: k8s_exclusion_ms must not be negative", name)
+			}
+			k8s.ExclusionTTL = time.Duration(*raw.K8sExclusionMs) * time.Millisecond
+		}
+		cfg.Discoverer, cfg.Kubernetes = domain.DiscovererKubernetes, k8s
+	default:
+		return fmt.Errorf("cluster %s: discoverer must be http|dns|file|kubernetes", name)
+	}
+	return nil
+}
+
+// parseServerTransport validates the server section and applies defaults: keepalive time_ms 60000 (0 — default,
+// otherwise at least 1000), timeout_ms 20000, min_time_ms 10000, permit_without_stream true; connection idle/age limits
+// 0 (infinite); message sizes 16 MiB; max_concurrent_streams 0 (unlimited); window sizes 0 (gRPC default) or at least 65535.
+// No value may be negative.
+//
+// Parameter raw — YAML section.
+//
+// Returns: (domain.ServerTransport, nil); (zero, error) on the first invalid value.
+//
+// Called only from LoadConfig.
+func parseServerTransport(raw yamlServer) (domain.ServerTransport, error) {
+	ka := raw.Keepalive
+	for _, f := range []struct {
+		name  string
+		value int
+	}{
+		{"keepalive.time_ms", ka.TimeMs}, {"keepalive.timeout_ms", ka.TimeoutMs}, {"keepalive.min_time_ms", ka.MinTimeMs},
+		{"keepalive.max_connection_idle_ms", ka.MaxConnectionIdleMs}, {"keepalive.max_connection_age_ms", ka.MaxConnectionAgeMs},
+		{"keepalive.max_connection_age_grace_ms", ka.MaxConnectionAgeGraceMs}, {"max_recv_msg_size_bytes", raw.MaxRecvMsgSizeBytes},
+		{"max_send_msg_size_bytes", raw.MaxSendMsgSizeBytes}, {"max_concurrent_streams", raw.MaxConcurrentStreams},
+	} {
+		if f.value < 0 {
+			return domain.ServerTransport{}, fmt.Errorf("server.%s must not be negative", f.name)
+		}
+	}
+	cfg := domain.ServerTransport{
+		Keepalive: domain.ServerKeepalive{
+			Time:                  msOrDefault(ka.TimeMs, defaultServerKeepaliveTime),
+			Timeout:               msOrDefault(ka.TimeoutMs, defaultKeepaliveTimeout),
+			MinTime:               msOrDefault(ka.MinTimeMs, defaultServerKeepaliveMinTime),
+			PermitWithoutStream:   ka.PermitWithoutStream == nil || *ka.PermitWithoutStream,
+			MaxConnectionIdle:     time.Duration(ka.MaxConnectionIdleMs) * time.Millisecond,
+			MaxConnectionAge:      time.Duration(ka.MaxConnectionAgeMs) * time.Millisecond,
+			MaxConnectionAgeGrace: time.Duration(ka.MaxConnectionAgeGraceMs) * time.Millisecond,
+		},
+		MaxRecvMsgSize:       orDefaultInt(raw.MaxRecvMsgSizeBytes, defaultMaxMsgSize),
+		MaxSendMsgSize:       orDefaultInt(raw.MaxSendMsgSizeBytes, defaultMaxMsgSize),
+		MaxConcurrentStreams: uint32(min(raw.MaxConcurrentStreams, math.MaxUint32)),
+	}
+	if cfg.Keepalive.Time < minServerKeepaliveTime {
+		return domain.ServerTransport{}, fmt.Errorf("server.keepalive.time_ms must be at least %d", minServerKeepaliveTime.Milliseconds())
+	}
+	var err error
+	if cfg.InitialWindowSize, err = parseWindowSize("server.initial_window_size", raw.InitialWindowSize); err != nil {
+		return domain.ServerTransport{}, err
+	}
+	if cfg.InitialConnWindowSize, err = parseWindowSize("server.initial_conn_window_size", raw.InitialConnWindowSize); err != nil {
+		return domain.ServerTransport{}, err
+	}
+	return cfg, nil
+}
+
+// parseClientTransport validates clusters.<name>.transport and applies defaults: keepalive time_ms 0 (no pings;
+// otherwise at least 10000 — gRPC's minimum), timeout_ms 20000; message sizes 16 MiB; window sizes 0 (gRPC default) or
+// at least 65535. No value may be negative.
+//
+// Parameters: name — cluster name for error messages; raw — YAML section.
+//
+// Returns: (domain.ClientTransport, nil); (zero, error) on the first invalid value.
+//
+// Called only from LoadConfig for each cluster.
+func parseClientTransport(name string, raw yamlClientTransport) (domain.ClientTransport, error) {
+	ka := raw.Keepalive
+	for _, f := range []struct {
+		name  string
+		value int
+	}{
+		{"keepalive.time_ms", ka.TimeMs}, {"keepalive.timeout_ms", ka.TimeoutMs},
+		{"max_recv_msg_size_bytes", raw.MaxRecvMsgSizeBytes}, {"max_send_msg_size_bytes", raw.MaxSendMsgSizeBytes},
+	} {
+		if f.value < 0 {
+			return domain.ClientTransport{}, fmt.Errorf("cluster %s: transport.%s must not be negative", name, f.name)
+		}
+	}
+	cfg := domain.ClientTransport{
+		KeepaliveTime:       time.Duration(ka.TimeMs) * time.Millisecond,
+		KeepaliveTimeout:    msOrDefault(ka.TimeoutMs, defaultKeepaliveTimeout),
+		PermitWithoutStream: ka.PermitWithoutStream,
+		MaxRecvMsgSize:      orDefaultInt(raw.MaxRecvMsgSizeBytes, defaultMaxMsgSize),
+		MaxSendMsgSize:      orDefaultInt(raw.MaxSendMsgSizeBytes, defaultMaxMsgSize),
+	}
+	if cfg.KeepaliveTime != 0 && cfg.KeepaliveTime < minClientKeepaliveTime {
+		return domain.ClientTransport{}, fmt.Errorf("cluster %s: transport.keepalive.time_ms must be 0 or at least %d", name, minClientKeepaliveTime.Milliseconds())
+	}
+	if cfg.PermitWithoutStream && cfg.KeepaliveTime == 0 {
+		return domain.ClientTransport{}, fmt.Errorf("cluster %s: transport.keepalive.permit_without_stream requires keepalive.time_ms", name)
+	}
+	var err error
+	if cfg.InitialWindowSize, err = parseWindowSize("cluster "+name+": transport.initial_window_size", raw.InitialWindowSize); err != nil {
+		return domain.ClientTransport{}, err
+	}
+	if cfg.InitialConnWindowSize, err = parseWindowSize("cluster "+name+": transport.initial_conn_window_size", raw.InitialConnWindowSize); err != nil {
+		return domain.ClientTransport{}, err
+	}
+	return cfg, nil
+}
+
+// parseWindowSize checks an HTTP/2 flow-control window: 0 (gRPC default) or 65535..2147483647 (gRPC ignores smaller windows).
+func parseWindowSize(field string, v int) (int32, error) {
+	if v != 0 && (v < minWindowSize || v > math.MaxInt32) {
+		return 0, fmt.Errorf("%s must be 0 or between %d and %d", field, minWindowSize, math.MaxInt32)
+	}
+	return int32(v), nil
+}
+
+// msOrDefault converts milliseconds to a duration, def when ms is 0.
+func msOrDefault(ms int, def time.Duration) time.Duration {
+	if ms == 0 {
+		return def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// orDefaultInt returns def when v is 0.
+func orDefaultInt(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// parseLoadSheddingConfig validates the load_shedding section when enabled and applies defaults: initial_limit 100,
+// min_limit 10, max_limit 1000 (1 ≤ min ≤ initial ≤ max), latency_tolerance 2 (> 1), backoff 0.9 (0 < backoff < 1),
+// baseline_window_ms 30000 (> 0), retry_after_ms 1000 (≥ 0), priority_header x-priority.
+//
+// Parameter raw — YAML section.
+//
+// Returns: (domain.LoadSheddingConfig, nil) (zero config when disabled); (zero, error) on the first invalid value.
+//
+// Called only from LoadConfig.
+func parseLoadSheddingConfig(raw yamlLoadShedding) (domain.LoadSheddingConfig, error) {
+	if !raw.Enabled {
+		return domain.LoadSheddingConfig{}, nil
+	}
+	cfg := domain.LoadSheddingConfig{
+		Enabled:          true,
+		InitialLimit:     orDefaultInt(raw.InitialLimit, defaultShedInitialLimit),
+		MinLimit:         orDefaultInt(raw.MinLimit, defaultShedMinLimit),
+		MaxLimit:         orDefaultInt(raw.MaxLimit, defaultShedMaxLimit),
+		LatencyTolerance: raw.LatencyTolerance,
+		Backoff:          raw.Backoff,
+		BaselineWindow:   msOrDefault(raw.BaselineWindowMs, defaultShedBaselineWindow),
+		RetryAfter:       defaultShedRetryAfter,
+		PriorityHeader:   strings.ToLower(strings.TrimSpace(raw.PriorityHeader)),
+	}
+	if cfg.MinLimit < 1 || cfg.MinLimit > cfg.InitialLimit || cfg.InitialLimit > cfg.MaxLimit {
+		return domain.LoadSheddingConfig{}, fmt.Errorf("load_shedding limits must satisfy 1 <= min_limit <= initial_limit <= max_limit, got %d/%d/%d", cfg.MinLimit, cfg.InitialLimit, cfg.MaxLimit)
+	}
+	if cfg.LatencyTolerance == 0 {
+		cfg.LatencyTolerance = defaultShedLatencyTolerance
+	}
+	if cfg.LatencyTolerance <= 1 {
+		return domain.LoadSheddingConfig{}, fmt.Errorf("load_shedding.latency_tolerance must be greater than 1, got %v", cfg.LatencyTolerance)
+	}
+	if cfg.Backoff == 0 {
+		cfg.Backoff = defaultShedBackoff
+	}
+	if cfg.Backoff <= 0 || cfg.Backoff >= 1 {
+		return domain.LoadSheddingConfig{}, fmt.Errorf("load_shedding.backoff must be between 0 and 1 (exclusive), got %v", cfg.Backoff)
+	}
+	if cfg.BaselineWindow <= 0 {
+		return domain.LoadSheddingConfig{}, fmt.Errorf("load_shedding.baseline_window_ms must be positive")
+	}
+	if raw.RetryAfterMs != nil {
+		if *raw.RetryAfterMs < 0 {
+			return domain.LoadSheddingConfig{}, fmt.Errorf("load_shedding.retry_after_ms must not be negative")
+		}
+		cfg.RetryAfter = time.Duration(*raw.RetryAfterMs) * time.Millisecond
+	}
+	if cfg.PriorityHeader == "" {
+		cfg.PriorityHeader = domain.DefaultPriorityHeader
+	}
+	return cfg, nil
+}
+
+// parseShutdownConfig validates the shutdown section and applies defaults: drain_timeout_ms 5000 (> 0);
+// notify_before_ms 0 (never; otherwise less than drain_timeout_ms); status_code UNAVAILABLE (a gRPC code name other
+// than OK, case-insensitive, e.g. UNAVAILABLE or Unavailable); status_message "gateway is shutting down, reconnect";
+// trailer keys are lowercased and must be valid metadata keys outside the reserved grpc- prefix.
+//
+// Parameter raw — YAML section.
+//
+// Returns: (domain.ShutdownConfig, nil); (zero, error) on the first invalid value.
+//
+// Called only from LoadConfig.
+func parseShutdownConfig(raw yamlShutdown) (domain.ShutdownConfig, error) {
+	cfg := domain.ShutdownConfig{
+		DrainTimeout:  defaultDrainTimeout,
+		NotifyBefore:  time.Duration(raw.NotifyBeforeMs) * time.Millisecond,
+		StatusCode:    uint32(defaultDrainCode),
+		StatusMessage: defaultDrainMessage,
+	}
+	if raw.DrainTimeoutMs < 0 {
+		return domain.ShutdownConfig{}, fmt.Errorf("shutdown.drain_timeout_ms must not be negative")
+	}
+	if raw.DrainTimeoutMs > 0 {
+		cfg.DrainTimeout = time.Duration(raw.DrainTimeoutMs) * time.Millisecond
+	}
+	if cfg.NotifyBefore < 0 || cfg.NotifyBefore >= cfg.DrainTimeout {
+		return domain.ShutdownConfig{}, fmt.Errorf("shutdown.notify_before_ms must be between 0 and drain_timeout_ms (exclusive), got %d", raw.NotifyBeforeMs)
+	}
+	if name := strings.TrimSpace(raw.StatusCode); name != "" {
+		code, ok := parseGRPCCode(name)
+		if !ok || code == codes.OK {
+			return domain.ShutdownConfig{}, fmt.Errorf("shutdown.status_code %q is not a gRPC error code", raw.StatusCode)
+		}
+		cfg.StatusCode = uint32(code)
+	}
+	if msg := strings.TrimSpace(raw.StatusMessage); msg != "" {
+		cfg.StatusMessage = msg
+	}
+	if len(raw.Trailers) > 0 {
+		cfg.Trailers = make(map[string]string, len(raw.Trailers))
+		for key, value := range raw.Trailers {
+			k := strings.ToLower(strings.TrimSpace(key))
+			if !validMetadataKey(k) || strings.HasPrefix(k, "grpc-") {
+				return domain.ShutdownConfig{}, fmt.Errorf("shutdown.trailers: invalid key %q", key)
+			}
+			cfg.Trailers[k] = value
+		}
+	}
+	return cfg, nil
+}
+
+// parseGRPCCode resolves a gRPC code name: UPPER_SNAKE (UNAVAILABLE, DEADLINE_EXCEEDED) or Go style (Unavailable), case-insensitive.
+func parseGRPCCode(name string) (codes.Code, bool) {
+	normalized := strings.ReplaceAll(name, "_", "")
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if strings.EqualFold(normalized, c.String()) {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// validMetadataKey reports whether key is a non-empty lowercase gRPC metadata key (0-9 a-z - _ .).
+func validMetadataKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseAccessLogConfig validates the access_log section when enabled: format json (default) or logfmt; output stderr
+// (default) or a file path; max_size_mb (default 100) and max_backups (default 5) must not be negative; sample_rate
+// (default 1) and every route access_log_sample_rate must be within 0..1; header names are lowercased and must not be empty.
+//
+// Parameters: raw — YAML section; routes — YAML routes (for access_log_sample_rate, keyed by normalized prefix).
+//
+// Returns: (domain.AccessLogConfig, nil) (zero config when disabled); (zero, error) on the first invalid value.
+//
+// Called only from LoadConfig.
+func parseAccessLogConfig(raw yamlAccessLog, routes []yamlRoute) (domain.AccessLogConfig, error) {
+	if !raw.Enabled {
+		return domain.AccessLogConfig{}, nil
+	}
+	cfg := domain.AccessLogConfig{
+		Enabled:       true,
+		Format:        strings.ToLower(strings.TrimSpace(raw.Format)),
+		Output:        strings.TrimSpace(raw.Output),
+		MaxSizeBytes:  defaultAccessLogMaxSizeMB << 20,
+		MaxBackups:    defaultAccessLogMaxBackups,
+		HashStickyKey: raw.HashStickyKey,
+		SampleRate:    1,
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = domain.AccessLogFormatJSON
+	case domain.AccessLogFormatJSON, domain.AccessLogFormatLogfmt:
+	default:
+		return domain.AccessLogConfig{}, fmt.Errorf("access_log.format must be json|logfmt, got %q", raw.Format)
+	}
+	if cfg.Output == "" {
+		cfg.Output = domain.AccessLogOutputStderr
+	}
+	if raw.MaxSizeMB < 0 {
+		return domain.AccessLogConfig{}, fmt.Errorf("access_log.max_size_mb must not be negative, got %d", raw.MaxSizeMB)
+	}
+	if raw.MaxSizeMB > 0 {
+		cfg.MaxSizeBytes = int64(raw.MaxSizeMB) << 20
+	}
+	if raw.MaxBackups != nil {
+		if *raw.MaxBackups < 0 {
+			return domain.AccessLogConfig{}, fmt.Errorf("access_log.max_backups must not be negative, got %d", *raw.MaxBackups)
+		}
+		cfg.MaxBackups = *raw.MaxBackups
+	}
+	if raw.SampleRate != nil {
+		if *raw.SampleRate < 0 || *raw.SampleRate > 1 {
+			return domain.AccessLogConfig{}, fmt.Errorf("access_log.sample_rate must be between 0 and 1, got %v", *raw.SampleRate)
+		}
+		cfg.SampleRate = *raw.SampleRate
+	}
+	for _, route := range routes {
+		if route.AccessLogSampleRate == nil {
+			continue
+		}
+		rate := *route.AccessLogSampleRate
+		if rate < 0 || rate > 1 {
+			return domain.AccessLogConfig{}, fmt.Errorf("route prefix %s: access_log_sample_rate must be between 0 and 1, got %v", route.Prefix, rate)
+		}
+		if cfg.RouteSampleRates == nil {
+			cfg.RouteSampleRates = map[string]float64{}
+		}
+		cfg.RouteSampleRates[normalizePrefix(route.Prefix)] = rate
+	}
+	for _, h := range raw.Headers {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" {
+			return domain.AccessLogConfig{}, fmt.Errorf("access_log.headers must not contain empty values")
+		}
+		cfg.Headers = append(cfg.Headers, h)
+	}
+	return cfg, nil
+}
+
+// parseHTTPJSONConfig validates the http_json section when enabled: port is required (1-65535) and must differ from the
+// gRPC and gRPC-Web ports; descriptor_set is required and must hold a FileDescriptorSet with at least one valid
+// google.api.http rule (service.ParseHTTPRules).
+//
+// Parameters: raw — YAML section; grpcPort — SERVICE_PORT_GRPC; grpcWebPort — grpc_web.port (0 — none).
+//
+// Returns: (domain.HTTPJSONConfig, rules, nil) (zero values when disabled); (zero, zero, error) on the first invalid value.
+//
+// Called only from LoadConfig.
+func parseHTTPJSONConfig(raw yamlHTTPJSON, grpcPort, grpcWebPort int) (domain.HTTPJSONConfig, service.HTTPRules, error) {
+	if !raw.Enabled {
+		return domain.HTTPJSONConfig{}, service.HTTPRules{}, nil
+	}
+	if raw.Port <= 0 || raw.Port > 65535 {
+		return domain.HTTPJSONConfig{}, service.HTTPRules{}, fmt.Errorf("http_json.port must be 1-65535, got %d", raw.Port)
+	}
+	if raw.Port == grpcPort || raw.Port == grpcWebPort {
+		return domain.HTTPJSONConfig{}, service.HTTPRules{}, fmt.Errorf("http_json.port must differ from %s and grpc_web.port", envGRPCPort)
+	}
+	cfg := domain.HTTPJSONConfig{Enabled: true, Port: raw.Port, DescriptorSet: strings.TrimSpace(raw.DescriptorSet)}
+	if cfg.DescriptorSet == "" {
+		return domain.HTTPJSONConfig{}, service.HTTPRules{}, fmt.Errorf("http_json.descriptor_set is required when http_json is enabled")
+	}
+	data, err := os.ReadFile(cfg.DescriptorSet)
+	if err != nil {
+		return domain.HTTPJSONConfig{}, service.HTTPRules{}, fmt.Errorf("read http_json.descriptor_set: %w", err)
+	}
+	rules, err := service.ParseHTTPRules(data)
+	if err != nil {
+		return domain.HTTPJSONConfig{}, service.HTTPRules{}, fmt.Errorf("http_json.descriptor_set: %w", err)
+	}
+	return cfg, rules, nil
+}
+
+// parseGRPCWebConfig validates the grpc_web section when enabled: port must be 0 (share the gRPC port) or 1-65535 and
+// differ from the gRPC port; allowed_origins must not contain empty values and "*" cannot be combined with
+// allow_credentials (browsers reject it); header names are lowercased and must not be empty; max_age_ms must not be negative.
+//
+// Parameters: raw — YAML section; grpcPort — SERVICE_PORT_GRPC.
+//
+// Returns: (domain.GRPCWebConfig, nil) (zero config when disabled); (zero, error) on the first invalid value.
+//
+// Called only from LoadConfig.
+func parseGRPCWebConfig(raw yamlGRPCWeb, grpcPort int) (domain.GRPCWebConfig, error) {
+	if !raw.Enabled {
+		return domain.GRPCWebConfig{}, nil
+	}
+	if raw.Port < 0 || raw.Port > 65535 {
+		return domain.GRPCWebConfig{}, fmt.Errorf("grpc_web.port must be 0-65535, got %d", raw.Port)
+	}
+	if raw.Port == grpcPort {
+		return domain.GRPCWebConfig{}, fmt.Errorf("grpc_web.port must differ from %s (omit it to share the port)", envGRPCPort)
+	}
+	if raw.CORS.MaxAgeMs < 0 {
+		return domain.GRPCWebConfig{}, fmt.Errorf("grpc_web.cors.max_age_ms must not be negative, got %d", raw.CORS.MaxAgeMs)
+	}
+	cors := domain.CORSConfig{
+		AllowCredentials: raw.CORS.AllowCredentials,
+		MaxAge:           time.Duration(raw.CORS.MaxAgeMs) * time.Millisecond,
+	}
+	for _, origin := range raw.CORS.AllowedOrigins {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			return domain.GRPCWebConfig{}, fmt.Errorf("grpc_web.cors.allowed_origins must not contain empty values")
+		}
+		if origin == domain.AnyOrigin && cors.AllowCredentials {
+			return domain.GRPCWebConfig{}, fmt.Errorf("grpc_web.cors.allowed_origins \"*\" cannot be combined with allow_credentials")
+		}
+		cors.AllowedOrigins = append(cors.AllowedOrigins, origin)
+	}
+	for _, list := range []struct {
+		name string
+		in   []string
+		out  *[]string
+	}{
+		{name: "allowed_headers", in: raw.CORS.AllowedHeaders, out: &cors.AllowedHeaders},
+		{name: "exposed_headers", in: raw.CORS.ExposedHeaders, out: &cors.ExposedHeaders},
+	} {
+		for _, h := range list.in {
+			h = strings.ToLower(strings.TrimSpace(h))
+			if h == "" {
+				return domain.GRPCWebConfig{}, fmt.Errorf("grpc_web.cors.%s must not contain empty values", list.name)
+			}
+			*list.out = append(*list.out, h)
+		}
+	}
+	return domain.GRPCWebConfig{Enabled: true, Port: raw.Port, CORS: cors}, nil
+}
+
+// parseRevocationConfig validates the auth.revocation section: file and redis_url are mutually exclusive; redis_url must
+// parse as a Redis URL; key_prefix requires redis_url; the file is parsed once here so a broken file fails the start
+// (the refresh loop keeps the previous copy on later failures); refresh_interval_ms must not be negative (0 — 5s).
+//
+// Parameter raw — YAML section.
+//
+// Returns: (domain.RevocationConfig, nil) (zero config when neither file nor redis_url is set); (zero, error) on the first invalid value.
+//
+// Called only from LoadConfig.
+func parseRevocationConfig(raw yamlRevocation) (domain.RevocationConfig, error) {
+	cfg := domain.RevocationConfig{
+		File:            strings.TrimSpace(raw.File),
+		RedisURL:        strings.TrimSpace(raw.RedisURL),
+		KeyPrefix:       strings.TrimSpace(raw.KeyPrefix),
+		RefreshInterval: time.Duration(raw.RefreshIntervalMs) * time.Millisecond,
+	}
+	if cfg.File != "" && cfg.RedisURL != "" {
+		return domain.RevocationConfig{}, fmt.Errorf("auth.revocation.file and auth.revocation.redis_url are mutually exclusive")
+	}
+	if cfg.KeyPrefix != "" && cfg.RedisURL == "" {
+		return domain.RevocationConfig{}, fmt.Errorf("auth.revocation.key_prefix requires auth.revocation.redis_url")
+	}
+	if raw.RefreshIntervalMs < 0 {
+		return domain.RevocationConfig{}, fmt.Errorf("auth.revocation.refresh_interval_ms must not be negative, got %d", raw.RefreshIntervalMs)
+	}
+	if !cfg.Enabled() {
+		return domain.RevocationConfig{}, nil
+	}
+	if raw.RefreshIntervalMs == 0 {
+		cfg.RefreshInterval = defaultRevocationRefreshInterval
+	}
+	if cfg.RedisURL != "" {
+		if _, err := redis.ParseURL(cfg.RedisURL); err != nil {
+			return domain.RevocationConfig{}, fmt.Errorf("auth.revocation.redis_url: %w", err)
+		}
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		return domain.RevocationConfig{}, fmt.Errorf("read auth.revocation.file: %w", err)
+	}
+	if _, err := auth.ParseRevocations(data); err != nil {
+		return domain.RevocationConfig{}, fmt.Errorf("auth.revocation.file: %w", err)
+	}
+	return cfg, nil
+}
+
+// parseAPIKeyConfig validates the auth.api_key section: keys_file is required when a route has authorization=api_key and
+// is parsed once here so a broken store fails the start (cmd/main re-reads it on change); header is lowercased, default x-api-key.
+//
+// Parameters: raw — YAML section; needed — at least one route has authorization=api_key.
+//
+// Returns: (domain.APIKeyConfig, nil); (zero, error) when keys_file is missing while needed or cannot be read/parsed.
+//
+// Called only from LoadConfig.
+func parseAPIKeyConfig(raw yamlAPIKey, needed bool) (domain.APIKeyConfig, error) {
+	cfg := domain.APIKeyConfig{
+		Header:   strings.ToLower(strings.TrimSpace(raw.Header)),
+		KeysFile: strings.TrimSpace(raw.KeysFile),
+	}
+	if cfg.Header == "" {
+		cfg.Header = defaultAPIKeyHeader
+	}
+	if cfg.KeysFile == "" {
+		if needed {
+			return domain.APIKeyConfig{}, fmt.Errorf("auth.api_key.keys_file is required when at least one route has authorization=api_key")
+		}
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfg.KeysFile)
+	if err != nil {
+		return domain.APIKeyConfig{}, fmt.Errorf("read auth.api_key.keys_file: %w", err)
+	}
+	if _, err := auth.ParseAPIKeys(data); err != nil {
+		return domain.APIKeyConfig{}, fmt.Errorf("auth.api_key.keys_file: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadJWTKeys reads the HMAC verification keys from env: JWT_SECRET (single key without ID) or JWT_SECRETS (rotation
+// list "id=secret[@not_after],..."; mutually exclusive with JWT_SECRET), and JWT_KEYS_FILE (rotation key file, parsed
+// once here so a broken file fails the start; cmd/main re-reads it on change).
+//
+// Returns: (static keys, key file path, nil); (nil, "", error) when both JWT_SECRET and JWT_SECRETS are set, the list is malformed or the key file cannot be read or parsed.
+//
+// Called only from LoadConfig.
+func loadJWTKeys() ([]auth.VerificationKey, string, error) {
+	secret := strings.TrimSpace(os.Getenv(envJWTSecret))
+	secrets := strings.TrimSpace(os.Getenv(envJWTSecrets))
+	keysFile := strings.TrimSpace(os.Getenv(envJWTKeysFile))
+	var keys []auth.VerificationKey
+	switch {
+	case secret != "" && secrets != "":
+		return nil, "", fmt.Errorf("%s and %s are mutually exclusive", envJWTSecret, envJWTSecrets)
+	case secret != "":
+		keys = []auth.VerificationKey{{Key: []byte(secret)}}
+	case secrets != "":
+		list, err := auth.ParseSecretList(secrets)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", envJWTSecrets, err)
+		}
+		keys = list
+	}
+	if keysFile != "" {
+		data, err := os.ReadFile(keysFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("read %s: %w", envJWTKeysFile, err)
+		}
+		if _, err := auth.ParseSecretKeys(data); err != nil {
+			return nil, "", fmt.Errorf("%s: %w", envJWTKeysFile, err)
+		}
+	}
+	return keys, keysFile, nil
+}
+
+// parseJWTConfig validates the auth.jwt section and converts it to domain.JWTConfig: format defaults to legacy; algorithms must be supported (empty — all supported);
+// leeway_ms must not be negative; jwks_file and jwks_url are mutually exclusive; jwks_refresh_interval_ms defaults to 5 minutes and must be positive when set.
+//
+// Parameter raw — auth.jwt from YAML.
+//
+// Returns: (domain.JWTConfig, nil) on success; (zero, error) with the offending key in the message.
+//
+// Called only from LoadConfig.
+func parseJWTConfig(raw yamlJWT) (domain.JWTConfig, error) {
+	format := domain.JWTFormat(strings.TrimSpace(raw.Format))
+	switch format {
+	case "":
+		format = domain.JWTFormatLegacy
+	case domain.JWTFormatLegacy, domain.JWTFormatStandard:
+	default:
+		return domain.JWTConfig{}, fmt.Errorf("auth.jwt.format must be legacy|standard")
+	}
+	var algorithms []string
+	for _, alg := range raw.Algorithms {
+		alg = strings.TrimSpace(alg)
+		if !slices.Contains(auth.SupportedAlgorithms, alg) {
+			return domain.JWTConfig{}, fmt.Errorf("auth.jwt.algorithms: unsupported algorithm %q (supported: %s)", alg, strings.Join(auth.SupportedAlgorithms, ", "))
+		}
+		algorithms = append(algorithms, alg)
+	}
+	if raw.LeewayMs < 0 {
+		return domain.JWTConfig{}, fmt.Errorf("auth.jwt.leeway_ms must not be negative")
+	}
+	cfg := domain.JWTConfig{
+		Format:     format,
+		Algorithms: algorithms,
+		Issuer:     strings.TrimSpace(raw.Issuer),
+		Audience:   strings.TrimSpace(raw.Audience),
+		Leeway:     time.Duration(raw.LeewayMs) * time.Millisecond,
+		JWKSFile:   strings.TrimSpace(raw.JWKSFile),
+		JWKSURL:    strings.TrimSpace(raw.JWKSURL),
+	}
+	if cfg.JWKSFile != "" && cfg.JWKSURL != "" {
+		return domain.JWTConfig{}, fmt.Errorf("auth.jwt.jwks_file and auth.jwt.jwks_url are mutually exclusive")
+	}
+	if raw.JWKSRefreshInterval < 0 {
+		return domain.JWTConfig{}, fmt.Errorf("auth.jwt.jwks_refresh_interval_ms must be positive")
+	}
+	if cfg.JWKSURL != "" {
+		cfg.JWKSRefreshInterval = defaultJWKSRefreshInterval
+		if raw.JWKSRefreshInterval > 0 {
+			cfg.JWKSRefreshInterval = time.Duration(raw.JWKSRefreshInterval) * time.Millisecond
+		}
+	}
+	return cfg, nil
+}
+
 // normalizePrefix trims spaces, removes trailing "*" if present and adds leading "/" if needed so route matching (strings.HasPrefix) works correctly.
 //
 // Parameter prefix — prefix string from YAML (may lack leading "/" or have trailing "*").
@@ -244,3 +1500,25 @@ func normalizePrefix(prefix string) string {
 	}
 	return p
 }
+
+// trimStringMap returns m with keys and values trimmed (values also lowercased when lowerValues, e.g. header names).
+//
+// Parameters: m — map from YAML (nil allowed); lowerValues — lowercase the values.
+//
+// Returns: new map, or nil for an empty m. Empty keys are kept for domain.ValidateRouteConfig to reject.
+//
+// Called only from LoadConfig for route subset and subset_headers.
+func trimStringMap(m map[string]string, lowerValues bool) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		v = strings.TrimSpace(v)
+		if lowerValues {
+			v = strings.ToLower(v)
+		}
+		out[strings.TrimSpace(k)] = v
+	}
+	return out
+}
@@ -3,13 +3,20 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"mygateway/auth"
 	"mygateway/domain"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 func TestLoadConfig_YAML(t *testing.T) {
@@ -170,6 +177,1261 @@ clusters:
 	})
 }
 
+func TestLoadConfig_Auth(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envJWTSecret, "secret")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /svc/*
+    cluster: c1
+    authorization: required
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("defaults_off", func(t *testing.T) {
+		writeConfig(t, base)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.Auth.ForwardClaims)
+		assert.False(t, cfg.Auth.StripAuthorization)
+		assert.Equal(t, domain.JWTFormatLegacy, cfg.Auth.JWT.Format)
+	})
+	t.Run("forward_claims_and_strip", func(t *testing.T) {
+		writeConfig(t, base+`
+auth:
+  forward_claims: true
+  strip_authorization: true
+`)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.Auth.ForwardClaims)
+		assert.True(t, cfg.Auth.StripAuthorization)
+	})
+	t.Run("strip_without_forward_is_error", func(t *testing.T) {
+		writeConfig(t, base+`
+auth:
+  strip_authorization: true
+`)
+		_, err := LoadConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "auth.strip_authorization requires auth.forward_claims")
+	})
+}
+
+func TestLoadConfig_JWT(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /svc/*
+    cluster: c1
+    authorization: required
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("standard_with_jwks_url", func(t *testing.T) {
+		t.Setenv(envJWTSecret, "")
+		writeConfig(t, base+`
+auth:
+  jwt:
+    format: standard
+    algorithms: [RS256, ES256]
+    issuer: https://idp.example
+    audience: mygateway
+    leeway_ms: 30000
+    jwks_url: https://idp.example/jwks.json
+`)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.JWTConfig{
+			Format:              domain.JWTFormatStandard,
+			Algorithms:          []string{"RS256", "ES256"},
+			Issuer:              "https://idp.example",
+			Audience:            "mygateway",
+			Leeway:              30 * time.Second,
+			JWKSURL:             "https://idp.example/jwks.json",
+			JWKSRefreshInterval: 5 * time.Minute,
+		}, cfg.Auth.JWT)
+	})
+	t.Run("standard_with_jwt_secret_only", func(t *testing.T) {
+		t.Setenv(envJWTSecret, "secret")
+		writeConfig(t, base+`
+auth:
+  jwt:
+    format: standard
+`)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.JWTFormatStandard, cfg.Auth.JWT.Format)
+		assert.Nil(t, cfg.Auth.JWT.Algorithms)
+	})
+
+	errorCases := []struct {
+		name        string
+		secret      string
+		jwt         string
+		wantContain string
+	}{
+		{name: "legacy_needs_secret", jwt: "format: legacy", wantContain: "JWT_SECRET, JWT_SECRETS or JWT_KEYS_FILE is required"},
+		{name: "standard_needs_key_source", jwt: "format: standard", wantContain: "auth.jwt.jwks_file or auth.jwt.jwks_url is required"},
+		{name: "invalid_format", secret: "s", jwt: "format: jws", wantContain: "auth.jwt.format must be legacy|standard"},
+		{name: "unsupported_algorithm", secret: "s", jwt: "format: standard\n    algorithms: [none]", wantContain: "unsupported algorithm \"none\""},
+		{name: "negative_leeway", secret: "s", jwt: "format: standard\n    leeway_ms: -1", wantContain: "auth.jwt.leeway_ms"},
+		{name: "file_and_url", secret: "s", jwt: "format: standard\n    jwks_file: /a\n    jwks_url: http://b", wantContain: "mutually exclusive"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envJWTSecret, tc.secret)
+			writeConfig(t, base+"auth:\n  jwt:\n    "+tc.jwt+"\n")
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_AllowedRoles(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envJWTSecret, "secret")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+	content := `
+default:
+  action: error
+routes:
+  - prefix: /svc/Admin*
+    cluster: c1
+    authorization: required
+    allowed_roles: [admin, " operator "]
+  - prefix: /svc/Public*
+    cluster: c1
+    authorization: none
+    allowed_roles: [admin]
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+	t.Setenv(envConfigPath, cfgPath)
+
+	_, err := LoadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "route[1]: allowed_roles requires authorization=required")
+
+	content = strings.Replace(content, "    authorization: none\n    allowed_roles: [admin]\n", "    authorization: none\n", 1)
+	require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin", "operator"}, cfg.Routes.Routes[0].AllowedRoles)
+	assert.Nil(t, cfg.Routes.Routes[1].AllowedRoles)
+}
+
+func TestLoadConfig_JWTKeys(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	t.Setenv(envJWTSecret, "")
+	t.Setenv(envJWTSecrets, "")
+	t.Setenv(envJWTKeysFile, "")
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "gateway.yaml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`
+default:
+  action: error
+routes:
+  - prefix: /svc/*
+    cluster: c1
+    authorization: required
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`), 0o644))
+	t.Setenv(envConfigPath, cfgPath)
+
+	t.Run("jwt_secret", func(t *testing.T) {
+		t.Setenv(envJWTSecret, "secret")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, []auth.VerificationKey{{Key: []byte("secret")}}, cfg.JWTKeys)
+		assert.Empty(t, cfg.JWTKeysFile)
+	})
+	t.Run("jwt_secrets", func(t *testing.T) {
+		t.Setenv(envJWTSecrets, "k2=new, k1=old@2026-03-01T00:00:00Z")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		require.Len(t, cfg.JWTKeys, 2)
+		assert.Equal(t, "k2", cfg.JWTKeys[0].ID)
+		assert.Equal(t, []byte("old"), cfg.JWTKeys[1].Key)
+		assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), cfg.JWTKeys[1].NotAfter)
+	})
+	t.Run("keys_file", func(t *testing.T) {
+		path := filepath.Join(dir, "keys.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("keys:\n  - id: k1\n    secret: s1\n"), 0o600))
+		t.Setenv(envJWTKeysFile, path)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Nil(t, cfg.JWTKeys)
+		assert.Equal(t, path, cfg.JWTKeysFile)
+	})
+
+	errorCases := []struct {
+		name        string
+		secret      string
+		secrets     string
+		keysFile    string
+		wantContain string
+	}{
+		{name: "secret_and_secrets", secret: "s", secrets: "k1=s", wantContain: "JWT_SECRET and JWT_SECRETS are mutually exclusive"},
+		{name: "malformed_secrets", secrets: "k1", wantContain: "JWT_SECRETS: key entry \"k1\""},
+		{name: "duplicate_id", secrets: "k1=a,k1=b", wantContain: "duplicate id"},
+		{name: "missing_keys_file", keysFile: filepath.Join(dir, "missing.yaml"), wantContain: "read JWT_KEYS_FILE"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envJWTSecret, tc.secret)
+			t.Setenv(envJWTSecrets, tc.secrets)
+			t.Setenv(envJWTKeysFile, tc.keysFile)
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_APIKey(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envJWTSecret, "")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	dir := t.TempDir()
+	keysPath := filepath.Join(dir, "api_keys.yaml")
+	require.NoError(t, os.WriteFile(keysPath, []byte("keys:\n  - name: billing\n    sha256: "+auth.HashAPIKey("k1")+"\n"), 0o600))
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /billing.Billing/*
+    cluster: c1
+    authorization: api_key
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("keys_file_and_default_header", func(t *testing.T) {
+		writeConfig(t, base+"auth:\n  api_key:\n    keys_file: "+keysPath+"\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err, "api_key routes do not need JWT_SECRET")
+		assert.Equal(t, domain.AuthorizationAPIKey, cfg.Routes.Routes[0].Authorization)
+		assert.Equal(t, domain.APIKeyConfig{Header: "x-api-key", KeysFile: keysPath}, cfg.Auth.APIKey)
+	})
+	t.Run("custom_header_lowercased", func(t *testing.T) {
+		writeConfig(t, base+"auth:\n  api_key:\n    header: X-Service-Key\n    keys_file: "+keysPath+"\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "x-service-key", cfg.Auth.APIKey.Header)
+	})
+	t.Run("keys_file_required", func(t *testing.T) {
+		writeConfig(t, base)
+		_, err := LoadConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "auth.api_key.keys_file is required when at least one route has authorization=api_key")
+	})
+	t.Run("invalid_keys_file", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad.yaml")
+		require.NoError(t, os.WriteFile(bad, []byte("keys:\n  - name: billing\n    sha256: plain-key\n"), 0o600))
+		writeConfig(t, base+"auth:\n  api_key:\n    keys_file: "+bad+"\n")
+		_, err := LoadConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "auth.api_key.keys_file: api key \"billing\": sha256 must be 64 hex characters")
+	})
+}
+
+func TestLoadConfig_ExternalAuth(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envJWTSecret, "")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /billing.Billing/*
+    cluster: c1
+    authorization: external
+    fail_open: true
+  - prefix: /orders.Orders/*
+    cluster: c1
+    authorization: external
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("full", func(t *testing.T) {
+		writeConfig(t, base+`
+auth:
+  external:
+    address: authz:50051
+    timeout_ms: 200
+    cache_key: [Authorization, ":peer"]
+    cache_ttl_ms: 30000
+    cache_max_entries: 500
+`)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.True(t, cfg.Routes.Routes[0].FailOpen)
+		assert.False(t, cfg.Routes.Routes[1].FailOpen)
+		assert.Equal(t, domain.ExternalAuthConfig{
+			Address:         "authz:50051",
+			Timeout:         200 * time.Millisecond,
+			CacheKey:        []string{"authorization", domain.ExternalAuthCacheKeyPeer},
+			CacheTTL:        30 * time.Second,
+			CacheMaxEntries: 500,
+		}, cfg.Auth.External)
+	})
+	t.Run("default_timeout_no_cache", func(t *testing.T) {
+		writeConfig(t, base+"auth:\n  external:\n    address: authz:50051\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, time.Second, cfg.Auth.External.Timeout)
+		assert.Zero(t, cfg.Auth.External.CacheTTL)
+	})
+
+	errorCases := []struct {
+		name        string
+		external    string
+		wantContain string
+	}{
+		{name: "address_required", external: "timeout_ms: 100", wantContain: "auth.external.address is required when at least one route has authorization=external"},
+		{name: "negative_timeout", external: "address: a:1\n    timeout_ms: -1", wantContain: "auth.external.timeout_ms must not be negative"},
+		{name: "negative_ttl", external: "address: a:1\n    cache_ttl_ms: -1", wantContain: "auth.external.cache_ttl_ms must not be negative"},
+		{name: "negative_max_entries", external: "address: a:1\n    cache_max_entries: -1", wantContain: "auth.external.cache_max_entries must not be negative"},
+		{name: "ttl_without_key", external: "address: a:1\n    cache_ttl_ms: 1000", wantContain: "auth.external.cache_key is required"},
+		{name: "empty_key", external: "address: a:1\n    cache_key: [\" \"]", wantContain: "auth.external.cache_key must not contain empty values"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, base+"auth:\n  external:\n    "+tc.external+"\n")
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+
+	t.Run("fail_open_requires_external", func(t *testing.T) {
+		writeConfig(t, strings.Replace(base, "authorization: external\n    fail_open: true", "authorization: none\n    fail_open: true", 1))
+		_, err := LoadConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "fail_open requires authorization=external")
+	})
+}
+
+func TestLoadConfig_Revocation(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envJWTSecret, "secret")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	dir := t.TempDir()
+	revokedPath := filepath.Join(dir, "revoked.yaml")
+	require.NoError(t, os.WriteFile(revokedPath, []byte("sessions: [s1]\n"), 0o600))
+	badPath := filepath.Join(dir, "bad.yaml")
+	require.NoError(t, os.WriteFile(badPath, []byte("issued_before: yesterday\n"), 0o600))
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /svc/*
+    cluster: c1
+    authorization: required
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		writeConfig(t, base)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.False(t, cfg.Auth.Revocation.Enabled())
+	})
+	t.Run("file_default_interval", func(t *testing.T) {
+		writeConfig(t, base+"auth:\n  revocation:\n    file: "+revokedPath+"\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.RevocationConfig{File: revokedPath, RefreshInterval: 5 * time.Second}, cfg.Auth.Revocation)
+	})
+	t.Run("redis", func(t *testing.T) {
+		writeConfig(t, base+"auth:\n  revocation:\n    redis_url: redis://redis:6379/1\n    key_prefix: \"gw:revoked:\"\n    refresh_interval_ms: 1000\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.RevocationConfig{RedisURL: "redis://redis:6379/1", KeyPrefix: "gw:revoked:", RefreshInterval: time.Second}, cfg.Auth.Revocation)
+	})
+
+	errorCases := []struct {
+		name        string
+		revocation  string
+		wantContain string
+	}{
+		{name: "file_and_redis", revocation: "file: " + revokedPath + "\n    redis_url: redis://redis:6379", wantContain: "auth.revocation.file and auth.revocation.redis_url are mutually exclusive"},
+		{name: "key_prefix_without_redis", revocation: "file: " + revokedPath + "\n    key_prefix: \"x:\"", wantContain: "auth.revocation.key_prefix requires auth.revocation.redis_url"},
+		{name: "negative_interval", revocation: "file: " + revokedPath + "\n    refresh_interval_ms: -1", wantContain: "auth.revocation.refresh_interval_ms must not be negative"},
+		{name: "bad_redis_url", revocation: "redis_url: http://redis:6379", wantContain: "auth.revocation.redis_url"},
+		{name: "missing_file", revocation: "file: " + filepath.Join(dir, "missing.yaml"), wantContain: "read auth.revocation.file"},
+		{name: "invalid_file", revocation: "file: " + badPath, wantContain: "auth.revocation.file: issued_before must be RFC3339"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, base+"auth:\n  revocation:\n    "+tc.revocation+"\n")
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_GRPCWeb(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envJWTSecret, "secret")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /svc/*
+    cluster: c1
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		writeConfig(t, base)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.GRPCWebConfig{}, cfg.GRPCWeb)
+	})
+	t.Run("disabled_ignores_settings", func(t *testing.T) {
+		writeConfig(t, base+"grpc_web:\n  port: 50051\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.GRPCWebConfig{}, cfg.GRPCWeb)
+	})
+	t.Run("shared_port", func(t *testing.T) {
+		writeConfig(t, base+"grpc_web:\n  enabled: true\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.GRPCWebConfig{Enabled: true}, cfg.GRPCWeb)
+	})
+	t.Run("separate_port_with_cors", func(t *testing.T) {
+		writeConfig(t, base+`grpc_web:
+  enabled: true
+  port: 8080
+  cors:
+    allowed_origins: ["https://app.example.com"]
+    allowed_headers: [X-Request-ID]
+    exposed_headers: [X-Trace-ID]
+    allow_credentials: true
+    max_age_ms: 600000
+`)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.GRPCWebConfig{
+			Enabled: true,
+			Port:    8080,
+			CORS: domain.CORSConfig{
+				AllowedOrigins:   []string{"https://app.example.com"},
+				AllowedHeaders:   []string{"x-request-id"},
+				ExposedHeaders:   []string{"x-trace-id"},
+				AllowCredentials: true,
+				MaxAge:           10 * time.Minute,
+			},
+		}, cfg.GRPCWeb)
+	})
+
+	errorCases := []struct {
+		name        string
+		grpcWeb     string
+		wantContain string
+	}{
+		{name: "port_out_of_range", grpcWeb: "port: 70000", wantContain: "grpc_web.port must be 0-65535"},
+		{name: "port_equals_grpc_port", grpcWeb: "port: 50051", wantContain: "grpc_web.port must differ from SERVICE_PORT_GRPC"},
+		{name: "negative_max_age", grpcWeb: "cors:\n    max_age_ms: -1", wantContain: "grpc_web.cors.max_age_ms must not be negative"},
+		{name: "empty_origin", grpcWeb: "cors:\n    allowed_origins: [\"\"]", wantContain: "grpc_web.cors.allowed_origins must not contain empty values"},
+		{name: "any_origin_with_credentials", grpcWeb: "cors:\n    allowed_origins: [\"*\"]\n    allow_credentials: true", wantContain: "cannot be combined with allow_credentials"},
+		{name: "empty_header", grpcWeb: "cors:\n    exposed_headers: [\" \"]", wantContain: "grpc_web.cors.exposed_headers must not contain empty values"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, base+"grpc_web:\n  enabled: true\n  "+tc.grpcWeb+"\n")
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+}
+
+// writeDescriptorSet writes a FileDescriptorSet with service test.Echo whose Get method has rule GET path (no rule when path is empty).
+func writeDescriptorSet(t *testing.T, dir, path string) string {
+	t.Helper()
+	method := &descriptorpb.MethodDescriptorProto{Name: proto.String("Get"), InputType: proto.String(".test.Req"), OutputType: proto.String(".test.Req")}
+	if path != "" {
+		method.Options = &descriptorpb.MethodOptions{}
+		proto.SetExtension(method.Options, annotations.E_Http, &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: path}})
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/api/annotations.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Req"), Field: []*descriptorpb.FieldDescriptorProto{{
+			Name: proto.String("name"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), JsonName: proto.String("name"),
+		}}}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{Name: proto.String("Echo"), Method: []*descriptorpb.MethodDescriptorProto{method}}},
+	}
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+		protodesc.ToFileDescriptorProto(annotations.File_google_api_http_proto),
+		protodesc.ToFileDescriptorProto(annotations.File_google_api_annotations_proto),
+		file,
+	}})
+	require.NoError(t, err)
+	out := filepath.Join(dir, "api-"+strings.NewReplacer("/", "_", "{", "", "}", "").Replace(path)+".pb")
+	require.NoError(t, os.WriteFile(out, data, 0o600))
+	return out
+}
+
+func TestLoadConfig_HTTPJSON(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envJWTSecret, "secret")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	dir := t.TempDir()
+	descPath := writeDescriptorSet(t, dir, "/v1/echo/{name}")
+	noRulesPath := writeDescriptorSet(t, dir, "")
+	badRulePath := writeDescriptorSet(t, dir, "/v1/{missing}")
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		writeConfig(t, base)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.HTTPJSONConfig{}, cfg.HTTPJSON)
+		assert.Empty(t, cfg.HTTPRules.Rules)
+	})
+	t.Run("enabled", func(t *testing.T) {
+		writeConfig(t, base+"http_json:\n  enabled: true\n  port: 8081\n  descriptor_set: "+descPath+"\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.HTTPJSONConfig{Enabled: true, Port: 8081, DescriptorSet: descPath}, cfg.HTTPJSON)
+		require.Len(t, cfg.HTTPRules.Rules, 1)
+		assert.Equal(t, "/test.Echo/Get", cfg.HTTPRules.Rules[0].FullMethod)
+	})
+
+	errorCases := []struct {
+		name        string
+		httpJSON    string
+		wantContain string
+	}{
+		{name: "missing_port", httpJSON: "descriptor_set: " + descPath, wantContain: "http_json.port must be 1-65535"},
+		{name: "port_equals_grpc_port", httpJSON: "port: 50051\n  descriptor_set: " + descPath, wantContain: "http_json.port must differ"},
+		{name: "port_equals_grpc_web_port", httpJSON: "port: 8080\n  descriptor_set: " + descPath + "\ngrpc_web:\n  enabled: true\n  port: 8080", wantContain: "http_json.port must differ"},
+		{name: "missing_descriptor_set", httpJSON: "port: 8081", wantContain: "http_json.descriptor_set is required"},
+		{name: "unreadable_descriptor_set", httpJSON: "port: 8081\n  descriptor_set: " + filepath.Join(dir, "missing.pb"), wantContain: "read http_json.descriptor_set"},
+		{name: "no_rules", httpJSON: "port: 8081\n  descriptor_set: " + noRulesPath, wantContain: "no methods with google.api.http rules"},
+		{name: "invalid_rule", httpJSON: "port: 8081\n  descriptor_set: " + badRulePath, wantContain: `http_json.descriptor_set: /test.Echo/Get: path "/v1/{missing}"`},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, base+"http_json:\n  enabled: true\n  "+tc.httpJSON+"\n")
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_StreamLimit(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	writeConfig := func(t *testing.T, cluster string) {
+		content := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+` + cluster
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+	dynamic := "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n"
+
+	t.Run("unlimited_by_default", func(t *testing.T) {
+		writeConfig(t, dynamic)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.StreamLimit{}, cfg.Clusters["c1"].StreamLimit)
+	})
+	t.Run("default_queue_timeout", func(t *testing.T) {
+		writeConfig(t, dynamic+"    max_concurrent_streams_per_instance: 50\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.StreamLimit{MaxPerInstance: 50, QueueTimeout: 100 * time.Millisecond}, cfg.Clusters["c1"].StreamLimit)
+	})
+	t.Run("explicit_queue_timeout", func(t *testing.T) {
+		writeConfig(t, dynamic+"    max_concurrent_streams_per_instance: 2\n    stream_queue_timeout_ms: 0\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.StreamLimit{MaxPerInstance: 2}, cfg.Clusters["c1"].StreamLimit)
+	})
+
+	errorCases := []struct {
+		name        string
+		cluster     string
+		wantContain string
+	}{
+		{name: "negative_limit", cluster: dynamic + "    max_concurrent_streams_per_instance: -1\n", wantContain: "max_concurrent_streams_per_instance must not be negative"},
+		{name: "static_cluster", cluster: "    type: static\n    address: localhost:50052\n    max_concurrent_streams_per_instance: 5\n", wantContain: "max_concurrent_streams_per_instance requires type dynamic"},
+		{name: "timeout_without_limit", cluster: dynamic + "    stream_queue_timeout_ms: 50\n", wantContain: "stream_queue_timeout_ms requires max_concurrent_streams_per_instance"},
+		{name: "negative_timeout", cluster: dynamic + "    max_concurrent_streams_per_instance: 5\n    stream_queue_timeout_ms: -1\n", wantContain: "stream_queue_timeout_ms must not be negative"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, tc.cluster)
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "cluster c1: "+tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_DNSDiscoverer(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	writeConfig := func(t *testing.T, cluster string) {
+		content := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+` + cluster
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+	dns := "    type: dynamic\n    discoverer: DNS\n    discoverer_interval_ms: 1000\n"
+
+	t.Run("http_by_default", func(t *testing.T) {
+		writeConfig(t, "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.DiscovererHTTP, cfg.Clusters["c1"].Discoverer)
+		assert.Equal(t, domain.DNSDiscovery{}, cfg.Clusters["c1"].DNS)
+	})
+	t.Run("srv", func(t *testing.T) {
+		writeConfig(t, dns+"    dns_name: _grpc._tcp.myservice.default.svc.cluster.local.\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		c := cfg.Clusters["c1"]
+		assert.Equal(t, domain.DiscovererDNS, c.Discoverer)
+		assert.Equal(t, domain.DNSDiscovery{Name: "_grpc._tcp.myservice.default.svc.cluster.local", ExclusionTTL: 30 * time.Second}, c.DNS)
+		assert.Equal(t, time.Second, c.DiscovererInterval)
+	})
+	t.Run("address_records", func(t *testing.T) {
+		writeConfig(t, dns+"    dns_name: myservice-headless\n    dns_port: 50051\n    dns_exclusion_ms: 0\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.DNSDiscovery{Name: "myservice-headless", Port: 50051}, cfg.Clusters["c1"].DNS)
+	})
+
+	errorCases := []struct {
+		name        string
+		cluster     string
+		wantContain string
+	}{
+		{name: "unknown_discoverer", cluster: "    type: dynamic\n    discoverer: consul\n    discoverer_interval_ms: 1000\n", wantContain: "discoverer must be http|dns|file|kubernetes"},
+		{name: "dns_name_missing", cluster: dns, wantContain: "dns_name is required for discoverer dns"},
+		{name: "dns_port_range", cluster: dns + "    dns_name: svc\n    dns_port: 70000\n", wantContain: "dns_port must be between 0 (SRV) and 65535"},
+		{name: "dns_exclusion_negative", cluster: dns + "    dns_name: svc\n    dns_exclusion_ms: -1\n", wantContain: "dns_exclusion_ms must not be negative"},
+		{name: "dns_fields_on_http", cluster: "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n    dns_name: svc\n", wantContain: "dns_name, dns_port and dns_exclusion_ms require discoverer dns"},
+		{name: "http_without_url", cluster: "    type: dynamic\n    discoverer: http\n    discoverer_interval_ms: 1000\n", wantContain: "discoverer_url is required for dynamic cluster"},
+		{name: "static_cluster", cluster: "    type: static\n    address: localhost:50052\n    discoverer: dns\n", wantContain: "discoverer requires type dynamic"},
+		{name: "dns_interval_required", cluster: "    type: dynamic\n    discoverer: dns\n    dns_name: svc\n", wantContain: "discoverer_interval_ms must be positive"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, tc.cluster)
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "cluster c1: "+tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_FileDiscoverer(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	instances := writeFile("instances.yaml", "instances:\n  - instance_id: i1\n    ipv4: 127.0.0.1\n    port: 50052\n")
+	broken := writeFile("broken.json", `{"instances": [{"instance_id": "i1", "ipv4": "127.0.0.1"}]}`)
+	writeConfig := func(t *testing.T, cluster string) {
+		content := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+    type: dynamic
+    discoverer_interval_ms: 1000
+` + cluster
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("file", func(t *testing.T) {
+		writeConfig(t, "    discoverer: file\n    path: "+instances+"\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		c := cfg.Clusters["c1"]
+		assert.Equal(t, domain.DiscovererFile, c.Discoverer)
+		assert.Equal(t, domain.FileDiscovery{Path: instances}, c.File)
+	})
+	t.Run("exclusion_ttl", func(t *testing.T) {
+		writeConfig(t, "    discoverer: file\n    path: "+instances+"\n    file_exclusion_ms: 60000\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, cfg.Clusters["c1"].File.ExclusionTTL)
+	})
+
+	errorCases := []struct {
+		name        string
+		cluster     string
+		wantContain string
+	}{
+		{name: "path_missing", cluster: "    discoverer: file\n", wantContain: "path is required for discoverer file"},
+		{name: "file_not_found", cluster: "    discoverer: file\n    path: " + filepath.Join(dir, "missing.yaml") + "\n", wantContain: "path: open"},
+		{name: "file_invalid", cluster: "    discoverer: file\n    path: " + broken + "\n", wantContain: "path: instance file: instances[0]: port must be between 1 and 65535"},
+		{name: "exclusion_negative", cluster: "    discoverer: file\n    path: " + instances + "\n    file_exclusion_ms: -1\n", wantContain: "file_exclusion_ms must not be negative"},
+		{name: "path_on_http", cluster: "    discoverer_url: http://disco:8080\n    path: " + instances + "\n", wantContain: "path and file_exclusion_ms require discoverer file"},
+		{name: "dns_fields_on_file", cluster: "    discoverer: file\n    path: " + instances + "\n    dns_name: svc\n", wantContain: "dns_name, dns_port and dns_exclusion_ms require discoverer dns"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, tc.cluster)
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "cluster c1: "+tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_KubernetesDiscoverer(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	writeConfig := func(t *testing.T, cluster string) {
+		content := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+    type: dynamic
+    discoverer_interval_ms: 1000
+` + cluster
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+	k8s := "    discoverer: kubernetes\n"
+
+	t.Run("defaults", func(t *testing.T) {
+		writeConfig(t, k8s+"    k8s_service: orders\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		c := cfg.Clusters["c1"]
+		assert.Equal(t, domain.DiscovererKubernetes, c.Discoverer)
+		assert.Equal(t, domain.KubernetesDiscovery{Service: "orders", Namespace: "default", ExclusionTTL: 30 * time.Second}, c.Kubernetes)
+	})
+	t.Run("all_fields", func(t *testing.T) {
+		writeConfig(t, k8s+"    k8s_service: orders\n    k8s_namespace: shop\n    k8s_port_name: grpc\n    k8s_exclusion_ms: 5000\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.KubernetesDiscovery{Service: "orders", Namespace: "shop", PortName: "grpc", ExclusionTTL: 5 * time.Second}, cfg.Clusters["c1"].Kubernetes)
+	})
+
+	errorCases := []struct {
+		name        string
+		cluster     string
+		wantContain string
+	}{
+		{name: "service_missing", cluster: k8s, wantContain: "k8s_service is required for discoverer kubernetes"},
+		{name: "service_invalid", cluster: k8s + "    k8s_service: Orders_v1\n", wantContain: `k8s_service "Orders_v1"`},
+		{name: "namespace_invalid", cluster: k8s + "    k8s_service: orders\n    k8s_namespace: shop.prod\n", wantContain: `k8s_namespace "shop.prod"`},
+		{name: "port_name_invalid", cluster: k8s + "    k8s_service: orders\n    k8s_port_name: grpc_port\n", wantContain: `k8s_port_name "grpc_port"`},
+		{name: "exclusion_negative", cluster: k8s + "    k8s_service: orders\n    k8s_exclusion_ms: -1\n", wantContain: "k8s_exclusion_ms must not be negative"},
+		{name: "k8s_fields_on_dns", cluster: "    discoverer: dns\n    dns_name: svc\n    k8s_service: orders\n", wantContain: "k8s_service, k8s_namespace, k8s_port_name and k8s_exclusion_ms require discoverer kubernetes"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, tc.cluster)
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "cluster c1: "+tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_Transport(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		writeConfig(t, base)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.ServerTransport{
+			Keepalive:      domain.ServerKeepalive{Time: time.Minute, Timeout: 20 * time.Second, MinTime: 10 * time.Second, PermitWithoutStream: true},
+			MaxRecvMsgSize: 16 << 20, MaxSendMsgSize: 16 << 20,
+		}, cfg.Server)
+		assert.Equal(t, domain.ClientTransport{
+			KeepaliveTimeout: 20 * time.Second, MaxRecvMsgSize: 16 << 20, MaxSendMsgSize: 16 << 20,
+		}, cfg.Clusters["c1"].Transport)
+	})
+	t.Run("explicit", func(t *testing.T) {
+		writeConfig(t, base+`    transport:
+      keepalive:
+        time_ms: 30000
+        timeout_ms: 5000
+        permit_without_stream: true
+      max_recv_msg_size_bytes: 67108864
+      max_send_msg_size_bytes: 8388608
+      initial_window_size: 1048576
+      initial_conn_window_size: 4194304
+server:
+  keepalive:
+    time_ms: 20000
+    timeout_ms: 3000
+    min_time_ms: 5000
+    permit_without_stream: false
+    max_connection_idle_ms: 600000
+    max_connection_age_ms: 3600000
+    max_connection_age_grace_ms: 30000
+  max_recv_msg_size_bytes: 33554432
+  max_send_msg_size_bytes: 33554432
+  max_concurrent_streams: 1000
+  initial_window_size: 65535
+  initial_conn_window_size: 1048576
+`)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.ServerTransport{
+			Keepalive: domain.ServerKeepalive{
+				Time: 20 * time.Second, Timeout: 3 * time.Second, MinTime: 5 * time.Second,
+				MaxConnectionIdle: 10 * time.Minute, MaxConnectionAge: time.Hour, MaxConnectionAgeGrace: 30 * time.Second,
+			},
+			MaxRecvMsgSize: 32 << 20, MaxSendMsgSize: 32 << 20, MaxConcurrentStreams: 1000,
+			InitialWindowSize: 65535, InitialConnWindowSize: 1 << 20,
+		}, cfg.Server)
+		assert.Equal(t, domain.ClientTransport{
+			KeepaliveTime: 30 * time.Second, KeepaliveTimeout: 5 * time.Second, PermitWithoutStream: true,
+			MaxRecvMsgSize: 64 << 20, MaxSendMsgSize: 8 << 20, InitialWindowSize: 1 << 20, InitialConnWindowSize: 4 << 20,
+		}, cfg.Clusters["c1"].Transport)
+	})
+
+	errorCases := []struct {
+		name        string
+		yaml        string
+		wantContain string
+	}{
+		{name: "server_negative_timeout", yaml: "server:\n  keepalive:\n    timeout_ms: -1\n", wantContain: "server.keepalive.timeout_ms must not be negative"},
+		{name: "server_negative_msg_size", yaml: "server:\n  max_recv_msg_size_bytes: -1\n", wantContain: "server.max_recv_msg_size_bytes must not be negative"},
+		{name: "server_negative_streams", yaml: "server:\n  max_concurrent_streams: -5\n", wantContain: "server.max_concurrent_streams must not be negative"},
+		{name: "server_keepalive_too_frequent", yaml: "server:\n  keepalive:\n    time_ms: 500\n", wantContain: "server.keepalive.time_ms must be at least 1000"},
+		{name: "server_small_window", yaml: "server:\n  initial_window_size: 1024\n", wantContain: "server.initial_window_size must be 0 or between 65535 and 2147483647"},
+		{name: "server_huge_conn_window", yaml: "server:\n  initial_conn_window_size: 4294967296\n", wantContain: "server.initial_conn_window_size must be 0 or between"},
+		{name: "cluster_keepalive_too_frequent", yaml: "    transport:\n      keepalive:\n        time_ms: 5000\n", wantContain: "cluster c1: transport.keepalive.time_ms must be 0 or at least 10000"},
+		{name: "cluster_permit_without_time", yaml: "    transport:\n      keepalive:\n        permit_without_stream: true\n", wantContain: "cluster c1: transport.keepalive.permit_without_stream requires keepalive.time_ms"},
+		{name: "cluster_negative_msg_size", yaml: "    transport:\n      max_send_msg_size_bytes: -1\n", wantContain: "cluster c1: transport.max_send_msg_size_bytes must not be negative"},
+		{name: "cluster_small_window", yaml: "    transport:\n      initial_conn_window_size: 100\n", wantContain: "cluster c1: transport.initial_conn_window_size must be 0 or between"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, base+tc.yaml)
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_Shutdown(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		writeConfig(t, base)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.ShutdownConfig{
+			DrainTimeout: 5 * time.Second, StatusCode: uint32(codes.Unavailable), StatusMessage: "gateway is shutting down, reconnect",
+		}, cfg.Shutdown)
+	})
+	t.Run("explicit", func(t *testing.T) {
+		writeConfig(t, base+`shutdown:
+  drain_timeout_ms: 30000
+  notify_before_ms: 2000
+  status_code: aborted
+  status_message: " reconnect to another gateway "
+  trailers:
+    X-Gateway-Draining: "true"
+`)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.ShutdownConfig{
+			DrainTimeout: 30 * time.Second, NotifyBefore: 2 * time.Second, StatusCode: uint32(codes.Aborted),
+			StatusMessage: "reconnect to another gateway", Trailers: map[string]string{"x-gateway-draining": "true"},
+		}, cfg.Shutdown)
+	})
+	t.Run("code_names", func(t *testing.T) {
+		for name, want := range map[string]codes.Code{"DEADLINE_EXCEEDED": codes.DeadlineExceeded, "ResourceExhausted": codes.ResourceExhausted, "unavailable": codes.Unavailable} {
+			writeConfig(t, base+"shutdown:\n  status_code: "+name+"\n")
+			cfg, err := LoadConfig()
+			require.NoError(t, err, name)
+			assert.Equal(t, uint32(want), cfg.Shutdown.StatusCode, name)
+		}
+	})
+
+	errorCases := []struct {
+		name        string
+		section     string
+		wantContain string
+	}{
+		{name: "negative_timeout", section: "drain_timeout_ms: -1", wantContain: "shutdown.drain_timeout_ms must not be negative"},
+		{name: "notify_negative", section: "notify_before_ms: -1", wantContain: "shutdown.notify_before_ms must be between 0 and drain_timeout_ms"},
+		{name: "notify_at_deadline", section: "notify_before_ms: 5000", wantContain: "shutdown.notify_before_ms must be between 0 and drain_timeout_ms (exclusive), got 5000"},
+		{name: "code_ok", section: "status_code: OK", wantContain: `shutdown.status_code "OK" is not a gRPC error code`},
+		{name: "code_unknown", section: "status_code: BROKEN", wantContain: `shutdown.status_code "BROKEN" is not a gRPC error code`},
+		{name: "trailer_reserved", section: "trailers: {grpc-status: \"1\"}", wantContain: `shutdown.trailers: invalid key "grpc-status"`},
+		{name: "trailer_invalid", section: "trailers: {\"bad key\": x}", wantContain: `shutdown.trailers: invalid key "bad key"`},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, base+"shutdown:\n  "+tc.section+"\n")
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_LoadShedding(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /myservice.Auth/Login
+    cluster: c1
+    priority: Critical
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("defaults_and_route_priority", func(t *testing.T) {
+		writeConfig(t, base+"load_shedding:\n  enabled: true\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.LoadSheddingConfig{
+			Enabled: true, InitialLimit: 100, MinLimit: 10, MaxLimit: 1000, LatencyTolerance: 2, Backoff: 0.9,
+			BaselineWindow: 30 * time.Second, RetryAfter: time.Second, PriorityHeader: "x-priority",
+		}, cfg.LoadShedding)
+		assert.Equal(t, domain.PriorityCritical, cfg.Routes.Routes[0].Priority)
+		assert.Equal(t, domain.Priority(""), cfg.Routes.Routes[1].Priority)
+	})
+	t.Run("disabled_by_default", func(t *testing.T) {
+		writeConfig(t, base)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.LoadSheddingConfig{}, cfg.LoadShedding)
+	})
+	t.Run("explicit", func(t *testing.T) {
+		writeConfig(t, base+`load_shedding:
+  enabled: true
+  initial_limit: 50
+  min_limit: 5
+  max_limit: 500
+  latency_tolerance: 1.5
+  backoff: 0.8
+  baseline_window_ms: 10000
+  retry_after_ms: 0
+  priority_header: X-Prio
+`)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.LoadSheddingConfig{
+			Enabled: true, InitialLimit: 50, MinLimit: 5, MaxLimit: 500, LatencyTolerance: 1.5, Backoff: 0.8,
+			BaselineWindow: 10 * time.Second, PriorityHeader: "x-prio",
+		}, cfg.LoadShedding)
+	})
+
+	errorCases := []struct {
+		name        string
+		section     string
+		wantContain string
+	}{
+		{name: "min_above_initial", section: "min_limit: 200", wantContain: "1 <= min_limit <= initial_limit <= max_limit, got 200/100/1000"},
+		{name: "initial_above_max", section: "initial_limit: 2000", wantContain: "1 <= min_limit <= initial_limit <= max_limit"},
+		{name: "negative_min", section: "min_limit: -1", wantContain: "1 <= min_limit"},
+		{name: "tolerance_too_low", section: "latency_tolerance: 0.5", wantContain: "load_shedding.latency_tolerance must be greater than 1"},
+		{name: "backoff_one", section: "backoff: 1", wantContain: "load_shedding.backoff must be between 0 and 1"},
+		{name: "negative_window", section: "baseline_window_ms: -5", wantContain: "load_shedding.baseline_window_ms must be positive"},
+		{name: "negative_retry_after", section: "retry_after_ms: -1", wantContain: "load_shedding.retry_after_ms must not be negative"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, base+"load_shedding:\n  enabled: true\n  "+tc.section+"\n")
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+	t.Run("invalid_route_priority", func(t *testing.T) {
+		writeConfig(t, strings.Replace(base, "priority: Critical", "priority: urgent", 1))
+		_, err := LoadConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "priority must be low|normal|high|critical")
+	})
+}
+
+func TestLoadConfig_AccessLog(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envJWTSecret, "secret")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	base := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+  - prefix: /test.Health/*
+    cluster: c1
+    access_log_sample_rate: 0.01
+clusters:
+  c1:
+    type: static
+    address: localhost:50052
+`
+	writeConfig := func(t *testing.T, content string) {
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		writeConfig(t, base)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.AccessLogConfig{}, cfg.AccessLog)
+	})
+	t.Run("defaults", func(t *testing.T) {
+		writeConfig(t, base+"access_log:\n  enabled: true\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.AccessLogConfig{
+			Enabled:          true,
+			Format:           domain.AccessLogFormatJSON,
+			Output:           domain.AccessLogOutputStderr,
+			MaxSizeBytes:     100 << 20,
+			MaxBackups:       5,
+			SampleRate:       1,
+			RouteSampleRates: map[string]float64{"/test.Health/": 0.01},
+		}, cfg.AccessLog)
+	})
+	t.Run("full", func(t *testing.T) {
+		writeConfig(t, base+`access_log:
+  enabled: true
+  format: logfmt
+  output: /var/log/gateway/access.log
+  max_size_mb: 10
+  max_backups: 0
+  hash_sticky_key: true
+  sample_rate: 0.25
+  headers: [X-Request-Id, " user-agent "]
+`)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.AccessLogConfig{
+			Enabled:          true,
+			Format:           domain.AccessLogFormatLogfmt,
+			Output:           "/var/log/gateway/access.log",
+			MaxSizeBytes:     10 << 20,
+			MaxBackups:       0,
+			HashStickyKey:    true,
+			SampleRate:       0.25,
+			RouteSampleRates: map[string]float64{"/test.Health/": 0.01},
+			Headers:          []string{"x-request-id", "user-agent"},
+		}, cfg.AccessLog)
+	})
+
+	errorCases := []struct {
+		name        string
+		accessLog   string
+		routes      string
+		wantContain string
+	}{
+		{name: "bad_format", accessLog: "format: xml", wantContain: "access_log.format must be json|logfmt"},
+		{name: "negative_size", accessLog: "max_size_mb: -1", wantContain: "access_log.max_size_mb must not be negative"},
+		{name: "negative_backups", accessLog: "max_backups: -1", wantContain: "access_log.max_backups must not be negative"},
+		{name: "sample_rate_above_one", accessLog: "sample_rate: 1.5", wantContain: "access_log.sample_rate must be between 0 and 1"},
+		{name: "empty_header", accessLog: `headers: [""]`, wantContain: "access_log.headers must not contain empty values"},
+		{name: "route_sample_rate_negative", accessLog: "sample_rate: 1", routes: "  - prefix: /test.Other/*\n    cluster: c1\n    access_log_sample_rate: -0.1\n",
+			wantContain: "route prefix /test.Other/*: access_log_sample_rate must be between 0 and 1"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content := strings.Replace(base, "clusters:", tc.routes+"clusters:", 1)
+			writeConfig(t, content+"access_log:\n  enabled: true\n  "+tc.accessLog+"\n")
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+}
+
 func TestLoadConfig_MissingConfigPath(t *testing.T) {
 	t.Setenv(envGRPCPort, "50051")
 	t.Setenv(envConfigPath, "")
@@ -182,3 +1444,250 @@ func TestNormalizePrefix(t *testing.T) {
 	assert.Equal(t, "/myservice/login", normalizePrefix("myservice/login*"))
 	assert.Equal(t, "/svc/method", normalizePrefix("/svc/method"))
 }
+
+func TestLoadConfig_Subset(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+	content := `
+default:
+  action: error
+routes:
+  - prefix: /orders.Orders/*
+    cluster: orders
+    subset: {" version ": " v2 "}
+    subset_headers: {zone: " X-Zone "}
+  - prefix: /auth.Auth/*
+    cluster: auth
+clusters:
+  orders:
+    type: dynamic
+    discoverer_url: http://disco:8080
+    discoverer_interval_ms: 1000
+  auth:
+    type: static
+    address: localhost:50052
+`
+	require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+	t.Setenv(envConfigPath, cfgPath)
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubsetConfig{
+		Labels:  map[string]string{"version": "v2"},
+		Headers: map[string]string{"zone": "x-zone"},
+	}, cfg.Routes.Routes[0].Subset)
+	assert.True(t, cfg.Routes.Routes[1].Subset.IsZero())
+
+	bad := strings.Replace(content, "    cluster: auth\n", "    cluster: auth\n    subset: {version: v1}\n", 1)
+	require.NoError(t, os.WriteFile(cfgPath, []byte(bad), 0o644))
+	_, err = LoadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `route prefix "/auth.Auth/": subset and subset_headers require a dynamic cluster`)
+
+	bad = strings.Replace(content, `zone: " X-Zone "`, `zone: " "`, 1)
+	require.NoError(t, os.WriteFile(cfgPath, []byte(bad), 0o644))
+	_, err = LoadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "route[0]: subset_headers must map non-empty labels to non-empty header names")
+}
+
+func TestLoadConfig_Locality(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	writeConfig := func(t *testing.T, cluster string) {
+		content := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+` + cluster
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+	const dynamic = "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n"
+
+	t.Run("no_zone", func(t *testing.T) {
+		t.Setenv(envZone, "")
+		writeConfig(t, dynamic+"    zone_spill_threshold: 2\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.Clusters["c1"].Locality)
+	})
+	t.Run("default_threshold", func(t *testing.T) {
+		t.Setenv(envZone, " eu-1a ")
+		writeConfig(t, dynamic)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.Locality{Zone: "eu-1a", SpillThreshold: 1}, cfg.Clusters["c1"].Locality)
+	})
+	t.Run("threshold", func(t *testing.T) {
+		t.Setenv(envZone, "eu-1a")
+		writeConfig(t, dynamic+"    zone_spill_threshold: 3\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.Locality{Zone: "eu-1a", SpillThreshold: 3}, cfg.Clusters["c1"].Locality)
+	})
+	t.Run("static_cluster_ignores_zone", func(t *testing.T) {
+		t.Setenv(envZone, "eu-1a")
+		writeConfig(t, "    type: static\n    address: 127.0.0.1:50052\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Zero(t, cfg.Clusters["c1"].Locality)
+	})
+
+	errorCases := []struct {
+		name        string
+		cluster     string
+		wantContain string
+	}{
+		{name: "zero", cluster: dynamic + "    zone_spill_threshold: 0\n", wantContain: "zone_spill_threshold must be at least 1"},
+		{name: "static", cluster: "    type: static\n    address: 127.0.0.1:50052\n    zone_spill_threshold: 1\n", wantContain: "zone_spill_threshold requires type dynamic"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, tc.cluster)
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "cluster c1: "+tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_Snapshot(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	writeConfig := func(t *testing.T, cluster string) {
+		content := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+` + cluster
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+	const dynamic = "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n"
+
+	t.Run("unset", func(t *testing.T) {
+		writeConfig(t, dynamic)
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.Clusters["c1"].SnapshotPath)
+		assert.Zero(t, cfg.Clusters["c1"].SnapshotMaxAge)
+	})
+	t.Run("path_and_max_age", func(t *testing.T) {
+		writeConfig(t, dynamic+"    snapshot_path: \" /var/lib/gateway/c1.json \"\n    snapshot_max_age_ms: 600000\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "/var/lib/gateway/c1.json", cfg.Clusters["c1"].SnapshotPath)
+		assert.Equal(t, 10*time.Minute, cfg.Clusters["c1"].SnapshotMaxAge)
+	})
+	t.Run("max_age_without_path", func(t *testing.T) {
+		writeConfig(t, dynamic+"    snapshot_max_age_ms: 30000\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Empty(t, cfg.Clusters["c1"].SnapshotPath)
+		assert.Equal(t, 30*time.Second, cfg.Clusters["c1"].SnapshotMaxAge)
+	})
+
+	errorCases := []struct {
+		name        string
+		cluster     string
+		wantContain string
+	}{
+		{name: "negative", cluster: dynamic + "    snapshot_max_age_ms: -1\n", wantContain: "snapshot_max_age_ms must not be negative"},
+		{name: "static", cluster: "    type: static\n    address: 127.0.0.1:50052\n    snapshot_path: /tmp/c1.json\n", wantContain: "snapshot_path and snapshot_max_age_ms require type dynamic"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, tc.cluster)
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "cluster c1: "+tc.wantContain)
+		})
+	}
+}
+
+func TestLoadConfig_HTTPDiscoverer(t *testing.T) {
+	t.Setenv(envGRPCPort, "50051")
+	t.Setenv(envRetryCount, "3")
+	t.Setenv(envRetryTimeoutMs, "5000")
+	writeConfig := func(t *testing.T, cluster string) {
+		content := `
+default:
+  action: error
+routes:
+  - prefix: /test.Echo/*
+    cluster: c1
+clusters:
+  c1:
+` + cluster
+		cfgPath := filepath.Join(t.TempDir(), "gateway.yaml")
+		require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0o644))
+		t.Setenv(envConfigPath, cfgPath)
+	}
+
+	t.Run("polling_by_default", func(t *testing.T) {
+		writeConfig(t, "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, domain.HTTPDiscovery{URLs: []string{"http://disco:8080"}, Timeout: 5 * time.Second}, cfg.Clusters["c1"].HTTP)
+	})
+	t.Run("service_type", func(t *testing.T) {
+		writeConfig(t, "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n    service_type: ' orders '\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "orders", cfg.Clusters["c1"].HTTP.ServiceType)
+	})
+	t.Run("watch", func(t *testing.T) {
+		writeConfig(t, "    type: dynamic\n    discoverer: http\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n    discoverer_watch_ms: 30000\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, cfg.Clusters["c1"].HTTP.Watch)
+	})
+	t.Run("replicas_and_timeout", func(t *testing.T) {
+		writeConfig(t, "    type: dynamic\n    discoverer_urls: [' http://disco-a:8080/ ', https://disco-b]\n    discoverer_interval_ms: 1000\n    discoverer_timeout_ms: 1500\n")
+		cfg, err := LoadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://disco-a:8080", "https://disco-b"}, cfg.Clusters["c1"].HTTP.URLs)
+		assert.Equal(t, 1500*time.Millisecond, cfg.Clusters["c1"].HTTP.Timeout)
+	})
+
+	errorCases := []struct {
+		name        string
+		cluster     string
+		wantContain string
+	}{
+		{name: "zero", cluster: "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n    discoverer_watch_ms: 0\n", wantContain: "discoverer_watch_ms must be between 1 and 60000"},
+		{name: "too_large", cluster: "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n    discoverer_watch_ms: 60001\n", wantContain: "discoverer_watch_ms must be between 1 and 60000"},
+		{name: "dns", cluster: "    type: dynamic\n    discoverer: dns\n    dns_name: svc\n    dns_port: 50051\n    discoverer_interval_ms: 1000\n    discoverer_watch_ms: 1000\n", wantContain: "discoverer_urls, service_type, discoverer_watch_ms and discoverer_timeout_ms require discoverer http"},
+		{name: "static", cluster: "    type: static\n    address: 127.0.0.1:50052\n    discoverer_watch_ms: 1000\n", wantContain: "discoverer requires type dynamic"},
+		{name: "service_type_on_kubernetes", cluster: "    type: dynamic\n    discoverer: kubernetes\n    k8s_service: orders\n    discoverer_interval_ms: 1000\n    service_type: orders\n", wantContain: "discoverer_urls, service_type, discoverer_watch_ms and discoverer_timeout_ms require discoverer http"},
+		{name: "service_type_on_static", cluster: "    type: static\n    address: 127.0.0.1:50052\n    service_type: orders\n", wantContain: "discoverer requires type dynamic"},
+		{name: "url_and_urls", cluster: "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_urls: [http://disco-b:8080]\n    discoverer_interval_ms: 1000\n", wantContain: "discoverer_url and discoverer_urls are mutually exclusive"},
+		{name: "empty_urls", cluster: "    type: dynamic\n    discoverer_urls: []\n    discoverer_interval_ms: 1000\n", wantContain: "discoverer_url is required for dynamic cluster"},
+		{name: "relative_url", cluster: "    type: dynamic\n    discoverer_urls: [disco:8080]\n    discoverer_interval_ms: 1000\n", wantContain: `discoverer URL "disco:8080" must be an absolute http or https URL`},
+		{name: "repeated_url", cluster: "    type: dynamic\n    discoverer_urls: [http://disco:8080, 'http://disco:8080/']\n    discoverer_interval_ms: 1000\n", wantContain: `discoverer_urls must not repeat "http://disco:8080"`},
+		{name: "zero_timeout", cluster: "    type: dynamic\n    discoverer_url: http://disco:8080\n    discoverer_interval_ms: 1000\n    discoverer_timeout_ms: 0\n", wantContain: "discoverer_timeout_ms must be positive"},
+		{name: "timeout_on_dns", cluster: "    type: dynamic\n    discoverer: dns\n    dns_name: svc\n    discoverer_interval_ms: 1000\n    discoverer_timeout_ms: 100\n", wantContain: "discoverer_urls, service_type, discoverer_watch_ms and discoverer_timeout_ms require discoverer http"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeConfig(t, tc.cluster)
+			_, err := LoadConfig()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "cluster c1: "+tc.wantContain)
+		})
+	}
+}
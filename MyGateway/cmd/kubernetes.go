@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// kubernetesClient builds the clientset for discoverer kubernetes: the in-cluster service account when running in a
+// pod, otherwise the kubeconfig (KUBECONFIG or ~/.kube/config, current context) for local runs.
+//
+// Returns: (clientset, nil); (nil, error) when neither configuration is usable.
+//
+// Called from main once, for the first cluster with discoverer kubernetes.
+func kubernetesClient() (kubernetes.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if errors.Is(err, rest.ErrNotInCluster) {
+		rules := clientcmd.NewDefaultClientConfigLoadingRules()
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
@@ -1,23 +1,38 @@
 // Package main is the entry point for the MyGateway generic gRPC proxy. It loads configuration
 // (env + YAML), builds the route matcher (service.NewRouteMatcherGeneric), static client connections and dynamic
-// ConnectionPools (DiscovererHTTP + service.NewConnectionPool per dynamic cluster), the cluster resolver
-// (service.NewConnectionResolverGeneric), the time provider and JWT validator, the header chain
-// (helpers.ConfigurableAuthProcessor), and the transparent proxy (service.NewTransparentProxy). The gRPC server
-// uses UnknownServiceHandler(proxy.Handler) so all RPCs are proxied. It listens on GRPCPort and
-// on SIGINT/SIGTERM performs GracefulStop with a 5s timeout, then Stop if needed.
+// ConnectionPools (adapters.DiscovererHTTP, or adapters.DiscovererDNS for discoverer dns, + service.NewConnectionPool per dynamic cluster), the cluster resolver
+// (service.NewConnectionResolverGeneric), the time provider and JWT validator (legacy or standard RFC 7519 with JWT_SECRET/JWT_SECRETS/JWT_KEYS_FILE/JWKS keys,
+// wrapped with a revocation check when auth.revocation is set — revoked sessions also lose their sticky bindings), the header chain
+// (helpers.ConfigurableAuthProcessor, plus helpers.APIKeyProcessor / helpers.ExternalAuthProcessor when an API key store /
+// external authz service is configured), and the transparent proxy (service.NewTransparentProxy). The gRPC server
+// uses UnknownServiceHandler(proxy.Handler) so all RPCs are proxied. It listens on GRPCPort (optionally also serving
+// gRPC-Web there, or on grpc_web.port, via service.GRPCWebHandler into the same proxy.Handler; REST clients are served on
+// http_json.port by service.HTTPTranscoder, also into proxy.Handler). When access_log is enabled every RPC on all frontends passes
+// service.AccessLogStreamInterceptor (JSON or logfmt, stderr or adapters.RotatingFile) ahead of the error interceptor; with
+// load_shedding the service.LoadShedder admission interceptor sits between them. The gRPC port serves the standard health
+// service as readiness. On SIGINT/SIGTERM it drains (shutdown section): readiness turns NOT_SERVING, new calls are refused
+// by service.StreamDrainer, GracefulStop (or, on the port shared with gRPC-Web, the http.Server Shutdown) sends GOAWAY
+// and waits for open streams; streams still open notify_before_ms
+// before the drain_timeout_ms deadline get the drain status, the rest are cut by Stop at the deadline; the resolver
+// (connection pools and static connections) is closed only after that.
 package main
 
 import (
 	"context"
+	"io"
+	"math/rand/v2"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
 	"mygateway/adapters"
+	"mygateway/api/authzpb"
+	"mygateway/auth"
 	"mygateway/domain"
 	"mygateway/helpers"
 	"mygateway/interfaces"
@@ -25,11 +40,15 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/go-redis/redis/v8"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"k8s.io/client-go/kubernetes"
 )
 
-// main is the MyGateway entry point: loads config (LoadConfig), builds route matcher, static connections and dynamic pools (DiscovererHTTP + NewConnectionPool per cluster), resolver (NewConnectionResolverGeneric), time provider and JWT validator, header chain (ConfigurableAuthProcessor) and transparent proxy (NewTransparentProxy). Registers UnknownServiceHandler(proxy.Handler) and stream interceptor for error mapping. Listens on GRPCPort; on SIGINT/SIGTERM performs GracefulStop (5s timeout), then Stop if needed.
+// main is the MyGateway entry point: loads config (LoadConfig), builds route matcher, static connections and dynamic pools (DiscovererHTTP, DiscovererDNS, DiscovererFile or DiscovererKubernetes + NewConnectionPool per cluster), resolver (NewConnectionResolverGeneric), time provider and JWT validator, header chain (ConfigurableAuthProcessor, APIKeyProcessor, ExternalAuthProcessor) and transparent proxy (NewTransparentProxy). Registers UnknownServiceHandler(proxy.Handler) and stream interceptor for error mapping. Listens on GRPCPort with the health service as readiness; on SIGINT/SIGTERM drains (see drain), then closes the resolver.
 //
 // Parameters and return: none (exits via os.Exit(1) on config/startup error).
 //
@@ -46,31 +65,59 @@ func main() {
 		level.Error(logger).Log("msg", "invalid route config", "err", routeErr)
 		os.Exit(1)
 	}
+	timeProvider := service.NewTimeProvider(func() time.Time { return time.Now().UTC() })
 	staticConns := map[domain.ClusterID]*grpc.ClientConn{}
 	dynamicPools := map[domain.ClusterID]interfaces.ConnectionPool{}
+	var k8sClient kubernetes.Interface
 	for clusterID, cluster := range cfg.Clusters {
 		switch cluster.Type {
 		case domain.ClusterTypeStatic:
-			conn, dialErr := grpc.NewClient(cluster.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			conn, dialErr := grpc.NewClient(cluster.Address, dialOptions(cluster.Transport)...)
 			if dialErr != nil {
 				level.Error(logger).Log("msg", "dial static cluster", "cluster", clusterID, "err", dialErr)
 				os.Exit(1)
 			}
 			staticConns[clusterID] = conn
 		case domain.ClusterTypeDynamic:
-			discoverer := adapters.DiscovererHTTP(cluster.DiscovererURL, &http.Client{Timeout: 10 * time.Second})
+			var discoverer interfaces.Discoverer
+			switch cluster.Discoverer {
+			case domain.DiscovererDNS:
+				discoverer = adapters.DiscovererDNS(cluster.DNS, net.DefaultResolver, timeProvider)
+			case domain.DiscovererFile:
+				discoverer = adapters.DiscovererFile(cluster.File, timeProvider, logger)
+			case domain.DiscovererKubernetes:
+				if k8sClient == nil {
+					if k8sClient, err = kubernetesClient(); err != nil {
+						level.Error(logger).Log("msg", "kubernetes client", "cluster", clusterID, "err", err)
+						os.Exit(1)
+					}
+				}
+				discoverer = adapters.DiscovererKubernetes(cluster.Kubernetes, k8sClient, timeProvider)
+			default:
+				// Every request is bounded by its own context (cluster.HTTP.Timeout, plus Watch for the long poll).
+				discoverer = adapters.DiscovererHTTP(cluster.HTTP, &http.Client{}, timeProvider)
+			}
+			dialOpts := dialOptions(cluster.Transport)
 			factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
-				addr := net.JoinHostPort(inst.Ipv4, strconv.Itoa(inst.Port))
-				return grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+				return grpc.NewClient(dialTarget(inst), dialOpts...)
+			}
+			var snapshots interfaces.InstanceSnapshotStore
+			if cluster.SnapshotPath != "" {
+				snapshots = adapters.InstanceSnapshotFile(cluster.SnapshotPath)
 			}
-			dynamicPools[clusterID] = service.NewConnectionPool(discoverer, factory, cluster.DiscovererInterval, logger)
+			pool := service.NewConnectionPool(discoverer, factory, cluster.DiscovererInterval, logger, timeProvider, cluster.StreamLimit, cluster.Locality, snapshots, cluster.SnapshotMaxAge)
+			if age, ok := pool.InstancesAge(); !ok {
+				level.Warn(logger).Log("msg", "cluster starts without instances", "cluster", clusterID)
+			} else if age > cluster.DiscovererInterval {
+				level.Warn(logger).Log("msg", "cluster starts from last-known-good snapshot", "cluster", clusterID, "age", age)
+			}
+			dynamicPools[clusterID] = pool
 		default:
 			level.Error(logger).Log("msg", "unknown cluster type", "cluster", clusterID, "type", cluster.Type)
 			os.Exit(1)
 		}
 	}
 	clusterResolver := service.NewConnectionResolverGeneric(staticConns, dynamicPools)
-	defer clusterResolver.Close()
 
 	dynamicClusterIDs := make(map[domain.ClusterID]struct{})
 	for clusterID, cluster := range cfg.Clusters {
@@ -79,16 +126,95 @@ func main() {
 		}
 	}
 
-	timeProvider := service.NewTimeProvider(func() time.Time { return time.Now().UTC() })
-	jwtService := service.NewJWTValidator(cfg.JWTSecret, timeProvider)
-	headerChain := helpers.NewHeaderProcessorChain(
-		helpers.NewConfigurableAuthProcessor(jwtService, cfg.Routes.Routes),
-	)
+	var keySources []interfaces.KeySource
+	if len(cfg.JWTKeys) > 0 {
+		keySources = append(keySources, auth.StaticKeys(cfg.JWTKeys))
+	}
+	if cfg.JWTKeysFile != "" {
+		keySources = append(keySources, adapters.SecretKeysFile(cfg.JWTKeysFile))
+	}
+	var jwtService interfaces.JwtService
+	switch cfg.Auth.JWT.Format {
+	case domain.JWTFormatStandard:
+		if cfg.Auth.JWT.JWKSFile != "" {
+			keySources = append(keySources, adapters.JWKSFile(cfg.Auth.JWT.JWKSFile))
+		}
+		if cfg.Auth.JWT.JWKSURL != "" {
+			keySources = append(keySources, adapters.JWKSHTTP(cfg.Auth.JWT.JWKSURL, &http.Client{Timeout: 10 * time.Second}, cfg.Auth.JWT.JWKSRefreshInterval, logger))
+		}
+		if len(keySources) == 0 {
+			keySources = append(keySources, auth.StaticKeys{})
+		}
+		jwtService = service.NewStandardJWTValidator(cfg.Auth.JWT, timeProvider, logger, keySources...)
+	default:
+		if len(keySources) == 0 {
+			keySources = append(keySources, auth.StaticKeys{})
+		}
+		jwtService = service.NewJWTValidator(timeProvider, logger, keySources...)
+	}
+	if cfg.Auth.Revocation.Enabled() {
+		var revocationSource interfaces.RevocationSource
+		if cfg.Auth.Revocation.RedisURL != "" {
+			redisOpts, redisErr := redis.ParseURL(cfg.Auth.Revocation.RedisURL)
+			if redisErr != nil {
+				level.Error(logger).Log("msg", "parse revocation redis url", "err", redisErr)
+				os.Exit(1)
+			}
+			redisClient := redis.NewClient(redisOpts)
+			defer redisClient.Close()
+			revocationSource = adapters.RevocationRedis(redisClient, cfg.Auth.Revocation.KeyPrefix)
+		} else {
+			revocationSource = adapters.RevocationFile(cfg.Auth.Revocation.File)
+		}
+		revocations := service.NewRevocationCache(revocationSource, cfg.Auth.Revocation.RefreshInterval, clusterResolver.ReleaseStickyKeys, logger)
+		jwtService = service.NewRevocationValidator(jwtService, revocations, logger)
+	}
+	headerProcessors := []interfaces.HeaderProcessor{
+		helpers.NewConfigurableAuthProcessor(jwtService, cfg.Routes.Routes, cfg.Auth),
+	}
+	if cfg.Auth.APIKey.KeysFile != "" {
+		apiKeyStore := adapters.APIKeyFile(cfg.Auth.APIKey.KeysFile)
+		headerProcessors = append(headerProcessors, helpers.NewAPIKeyProcessor(apiKeyStore, timeProvider, cfg.Routes.Routes, cfg.Auth.APIKey.Header))
+	}
+	if cfg.Auth.External.Address != "" {
+		authzConn, dialErr := grpc.NewClient(cfg.Auth.External.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if dialErr != nil {
+			level.Error(logger).Log("msg", "dial external authz", "address", cfg.Auth.External.Address, "err", dialErr)
+			os.Exit(1)
+		}
+		defer authzConn.Close()
+		authorizer := adapters.AuthzGRPC(authzpb.NewAuthorizerClient(authzConn), cfg.Auth.External.Timeout)
+		headerProcessors = append(headerProcessors, helpers.NewExternalAuthProcessor(authorizer, timeProvider, logger, cfg.Routes.Routes, cfg.Auth.External))
+	}
+	headerChain := helpers.NewHeaderProcessorChain(headerProcessors...)
 	transparentProxy := service.NewTransparentProxy(pathRouter, clusterResolver, headerChain, logger, cfg.RetryCount, cfg.RetryTimeout, dynamicClusterIDs)
-	srv := grpc.NewServer(
-		grpc.ChainStreamInterceptor(service.GatewayErrorToGRPCStreamInterceptor(logger)),
+	// Access log runs outside the error interceptor so it records the final gRPC code; the drainer and the load shedder
+	// run between them so their statuses keep the configured code, trailers and retry details.
+	streamInterceptors := []grpc.StreamServerInterceptor{service.GatewayErrorToGRPCStreamInterceptor(logger)}
+	if cfg.LoadShedding.Enabled {
+		loadShedder := service.NewLoadShedder(cfg.LoadShedding, pathRouter, timeProvider)
+		streamInterceptors = append([]grpc.StreamServerInterceptor{loadShedder.StreamInterceptor()}, streamInterceptors...)
+	}
+	drainer := service.NewStreamDrainer(cfg.Shutdown)
+	streamInterceptors = append([]grpc.StreamServerInterceptor{drainer.StreamInterceptor()}, streamInterceptors...)
+	if cfg.AccessLog.Enabled {
+		accessLogger, accessLogOut, err := newAccessLogger(cfg.AccessLog)
+		if err != nil {
+			level.Error(logger).Log("msg", "access log", "err", err)
+			os.Exit(1)
+		}
+		defer accessLogOut.Close()
+		accessLog := service.AccessLogStreamInterceptor(accessLogger, cfg.AccessLog, timeProvider, rand.Float64)
+		streamInterceptors = append([]grpc.StreamServerInterceptor{accessLog}, streamInterceptors...)
+	}
+	srv := grpc.NewServer(append(serverOptions(cfg.Server),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
 		grpc.UnknownServiceHandler(transparentProxy.Handler),
-	)
+	)...)
+	// Readiness: the standard health service answers SERVING until the drain starts (it is no longer proxied).
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(srv, healthServer)
 
 	lis, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.GRPCPort))
 	if err != nil {
@@ -97,8 +223,36 @@ func main() {
 	}
 	defer lis.Close()
 
-	level.Info(logger).Log("msg", "starting MyGateway generic proxy", "port", cfg.GRPCPort)
+	// gRPC-Web: on its own port, or multiplexed with native gRPC (h2c) on the gRPC port.
+	var webServer *http.Server
+	var httpServers []*http.Server
+	sharedPort := cfg.GRPCWeb.Enabled && cfg.GRPCWeb.Port == 0
+	if cfg.GRPCWeb.Enabled {
+		webHandler := service.NewGRPCWebHandler(transparentProxy.Handler, cfg.GRPCWeb.CORS, cfg.Server.MaxRecvMsgSize, logger, streamInterceptors...)
+		webServer = newHTTPServer(webHandler, cfg.Server)
+		httpServers = append(httpServers, webServer)
+		if sharedPort {
+			webServer.Handler = service.NewGRPCWebMux(srv, webHandler)
+		} else {
+			serveHTTP(webServer, cfg.GRPCWeb.Port, "gRPC-Web", logger)
+		}
+	}
+	// HTTP/JSON: REST endpoints from google.api.http annotations, always on its own port.
+	if cfg.HTTPJSON.Enabled {
+		jsonServer := newHTTPServer(service.NewHTTPTranscoder(cfg.HTTPRules, transparentProxy.Handler, cfg.Server.MaxRecvMsgSize, logger, streamInterceptors...), cfg.Server)
+		httpServers = append(httpServers, jsonServer)
+		serveHTTP(jsonServer, cfg.HTTPJSON.Port, "HTTP/JSON", logger)
+	}
+
+	level.Info(logger).Log("msg", "starting MyGateway generic proxy", "port", cfg.GRPCPort, "grpc_web", cfg.GRPCWeb.Enabled)
 	go func() {
+		if sharedPort {
+			if err := webServer.Serve(lis); err != nil && err != http.ErrServerClosed {
+				level.Error(logger).Log("msg", "serve", "err", err)
+				os.Exit(1)
+			}
+			return
+		}
 		if err := srv.Serve(lis); err != nil {
 			level.Error(logger).Log("msg", "serve", "err", err)
 			os.Exit(1)
@@ -108,15 +262,138 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	level.Info(logger).Log("msg", "shutting down")
+	level.Info(logger).Log("msg", "shutting down", "drain_timeout", cfg.Shutdown.DrainTimeout)
+	healthServer.Shutdown()
+	drain(srv, !sharedPort, httpServers, drainer, cfg.Shutdown, logger)
+	clusterResolver.Close()
+	level.Info(logger).Log("msg", "stopped")
+}
+
+// drain stops the frontends gracefully: new calls are refused (drainer.StartDrain), HTTP servers Shutdown and, when it
+// serves its own listener, the gRPC server GracefulStop (GOAWAY, wait for open streams). On the port shared with
+// gRPC-Web the native streams run through grpc.Server.ServeHTTP, whose transports do not support GracefulStop (grpc-go
+// panics in Drain): the gRPC-Web server Shutdown sends GOAWAY and waits for them, and srv is stopped once it has
+// returned. notify_before_ms before the deadline the streams still open get the drain status
+// (drainer.NotifyStreams); at the deadline whatever is left is closed (Close/Stop).
+//
+// Parameters: srv — gRPC server; ownListener — srv serves the gRPC port itself (false — behind NewGRPCWebMux);
+// httpServers — gRPC-Web and HTTP/JSON servers; drainer — stream drainer of the interceptor chain; cfg — shutdown
+// settings; logger — process logger.
+//
+// Called from main on SIGINT/SIGTERM; returns when every server has stopped.
+func drain(srv *grpc.Server, ownListener bool, httpServers []*http.Server, drainer *service.StreamDrainer, cfg domain.ShutdownConfig, logger log.Logger) {
+	drainer.StartDrain()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	defer cancelShutdown()
+	// All frontends stop in parallel so each sends GOAWAY right away.
+	var wg sync.WaitGroup
+	wg.Add(len(httpServers))
+	for _, s := range httpServers {
+		go func() {
+			defer wg.Done()
+			_ = s.Shutdown(shutdownCtx)
+		}()
+	}
+	if ownListener {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			srv.GracefulStop()
+		}()
+	}
 	stopped := make(chan struct{})
 	go func() {
-		srv.GracefulStop()
+		wg.Wait()
+		if !ownListener {
+			srv.Stop()
+		}
 		close(stopped)
 	}()
-	select {
-	case <-stopped:
-	case <-time.After(5 * time.Second):
-		srv.Stop()
+	var notify <-chan time.Time
+	if cfg.NotifyBefore > 0 {
+		notifyTimer := time.NewTimer(cfg.DrainTimeout - cfg.NotifyBefore)
+		defer notifyTimer.Stop()
+		notify = notifyTimer.C
+	}
+	for {
+		select {
+		case <-stopped:
+			return
+		case <-notify:
+			notify = nil
+			level.Info(logger).Log("msg", "notifying open streams of shutdown", "streams", drainer.NotifyStreams())
+		case <-shutdownCtx.Done():
+			level.Warn(logger).Log("msg", "drain timeout, closing remaining streams")
+			for _, s := range httpServers {
+				_ = s.Close()
+			}
+			srv.Stop()
+			return
+		}
 	}
 }
+
+// newHTTPServer creates an http.Server for a gateway HTTP frontend: HTTP/1.1 and cleartext HTTP/2 enabled, 10s header
+// timeout, HTTP/2 settings and idle timeout from the server section.
+//
+// Called from main for the gRPC-Web and HTTP/JSON frontends.
+func newHTTPServer(handler http.Handler, transport domain.ServerTransport) *http.Server {
+	s := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       transport.Keepalive.MaxConnectionIdle,
+		Protocols:         new(http.Protocols),
+		HTTP2:             http2Config(transport),
+	}
+	s.Protocols.SetHTTP1(true)
+	s.Protocols.SetUnencryptedHTTP2(true)
+	return s
+}
+
+// serveHTTP listens on port and serves s in the background; exits the process when listening or serving fails.
+//
+// Parameters: s — server; port — TCP port; name — frontend name for logs; logger — process logger.
+//
+// Called from main for HTTP frontends on their own port.
+func serveHTTP(s *http.Server, port int, name string, logger log.Logger) {
+	l, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		level.Error(logger).Log("msg", "listen "+name, "err", err)
+		os.Exit(1)
+	}
+	level.Info(logger).Log("msg", "starting "+name+" listener", "port", port)
+	go func() {
+		if err := s.Serve(l); err != nil && err != http.ErrServerClosed {
+			level.Error(logger).Log("msg", "serve "+name, "err", err)
+			os.Exit(1)
+		}
+	}()
+}
+
+// newAccessLogger builds the access log sink: a JSON or logfmt logger over stderr or a size-rotated file.
+//
+// Parameters: cfg — enabled access log settings.
+//
+// Returns: (logger, closer for the output, nil); (nil, nil, error) when the file cannot be opened.
+//
+// Called only from main when access_log.enabled is set.
+func newAccessLogger(cfg domain.AccessLogConfig) (log.Logger, io.Closer, error) {
+	var out io.WriteCloser = nopWriteCloser{os.Stderr}
+	if cfg.Output != domain.AccessLogOutputStderr {
+		file, err := adapters.RotatingFile(cfg.Output, cfg.MaxSizeBytes, cfg.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = file
+	}
+	w := log.NewSyncWriter(out)
+	if cfg.Format == domain.AccessLogFormatLogfmt {
+		return log.NewLogfmtLogger(w), out, nil
+	}
+	return log.NewJSONLogger(w), out, nil
+}
+
+// nopWriteCloser keeps stderr open when the access log output is closed on shutdown.
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/service"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// TestDrain opens a native gRPC stream that only ends when canceled and drains the gateway: on its own gRPC listener
+// (GracefulStop) and on the port shared with gRPC-Web (grpc.Server.ServeHTTP, where GracefulStop would panic). The
+// stream must get the drain status at notify_before_ms and drain must return before the deadline.
+func TestDrain(t *testing.T) {
+	cfg := domain.ShutdownConfig{
+		DrainTimeout:  3 * time.Second,
+		NotifyBefore:  2800 * time.Millisecond,
+		StatusCode:    uint32(codes.Unavailable),
+		StatusMessage: "gateway is shutting down",
+		Trailers:      map[string]string{"x-drain": "1"},
+	}
+	for _, tc := range []struct {
+		name       string
+		sharedPort bool
+	}{
+		{name: "own_listener"},
+		{name: "shared_port", sharedPort: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			drainer := service.NewStreamDrainer(cfg)
+			opened := make(chan struct{}, 1)
+			srv := grpc.NewServer(
+				grpc.ChainStreamInterceptor(drainer.StreamInterceptor()),
+				grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+					opened <- struct{}{}
+					<-stream.Context().Done()
+					return stream.Context().Err()
+				}),
+			)
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			var httpServers []*http.Server
+			if tc.sharedPort {
+				web := newHTTPServer(service.NewGRPCWebMux(srv, http.NotFoundHandler()), domain.ServerTransport{})
+				httpServers = append(httpServers, web)
+				go func() { _ = web.Serve(lis) }()
+			} else {
+				go func() { _ = srv.Serve(lis) }()
+			}
+			t.Cleanup(srv.Stop)
+
+			conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = conn.Close() })
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, "/test.Events/Watch")
+			require.NoError(t, err)
+			require.NoError(t, stream.SendMsg(&emptypb.Empty{}))
+			select {
+			case <-opened:
+			case <-ctx.Done():
+				t.Fatal("stream not opened")
+			}
+
+			start := time.Now()
+			done := make(chan struct{})
+			go func() {
+				drain(srv, !tc.sharedPort, httpServers, drainer, cfg, log.NewNopLogger())
+				close(done)
+			}()
+
+			err = stream.RecvMsg(&emptypb.Empty{})
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+			assert.Equal(t, "gateway is shutting down", status.Convert(err).Message())
+			assert.Equal(t, []string{"1"}, stream.Trailer().Get("x-drain"))
+			select {
+			case <-done:
+			case <-ctx.Done():
+				t.Fatal("drain did not return")
+			}
+			assert.Less(t, time.Since(start), cfg.DrainTimeout, "open stream ended by the drain status, not the deadline")
+		})
+	}
+}
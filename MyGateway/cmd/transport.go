@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"mygateway/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// serverOptions converts the server section into gRPC server options: keepalive parameters and enforcement policy,
+// message size limits, max concurrent streams and flow-control windows (windows only when set).
+//
+// Parameter cfg — validated server transport settings.
+//
+// Returns: options for grpc.NewServer.
+//
+// Called from main when creating the gRPC server.
+func serverOptions(cfg domain.ServerTransport) []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  cfg.Keepalive.Time,
+			Timeout:               cfg.Keepalive.Timeout,
+			MaxConnectionIdle:     cfg.Keepalive.MaxConnectionIdle,
+			MaxConnectionAge:      cfg.Keepalive.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.Keepalive.MaxConnectionAgeGrace,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.Keepalive.MinTime,
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		}),
+		grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(cfg.MaxSendMsgSize),
+	}
+	if cfg.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(cfg.MaxConcurrentStreams))
+	}
+	if cfg.InitialWindowSize > 0 {
+		opts = append(opts, grpc.InitialWindowSize(cfg.InitialWindowSize))
+	}
+	if cfg.InitialConnWindowSize > 0 {
+		opts = append(opts, grpc.InitialConnWindowSize(cfg.InitialConnWindowSize))
+	}
+	return opts
+}
+
+// dialOptions converts clusters.<name>.transport into dial options: insecure credentials (as before), keepalive when
+// KeepaliveTime is set, default call message size limits and flow-control windows (only when set).
+//
+// Parameter cfg — validated cluster transport settings.
+//
+// Returns: options for grpc.NewClient.
+//
+// Called from main for static clusters and from the pool factory of dynamic clusters.
+func dialOptions(cfg domain.ClientTransport) []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(cfg.MaxRecvMsgSize), grpc.MaxCallSendMsgSize(cfg.MaxSendMsgSize)),
+	}
+	if cfg.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.KeepaliveTime,
+			Timeout:             cfg.KeepaliveTimeout,
+			PermitWithoutStream: cfg.PermitWithoutStream,
+		}))
+	}
+	if cfg.InitialWindowSize > 0 {
+		opts = append(opts, grpc.WithInitialWindowSize(cfg.InitialWindowSize))
+	}
+	if cfg.InitialConnWindowSize > 0 {
+		opts = append(opts, grpc.WithInitialConnWindowSize(cfg.InitialConnWindowSize))
+	}
+	return opts
+}
+
+// dialTarget returns the gRPC target of an instance: host:port, with an IPv6 address in brackets; a host name is
+// resolved by the gRPC resolver on every reconnect.
+//
+// Parameter inst — instance from the discoverer.
+//
+// Returns: target for grpc.NewClient.
+//
+// Called from the connection factory in main.
+func dialTarget(inst domain.ServiceInstance) string {
+	return net.JoinHostPort(inst.Address, strconv.Itoa(inst.Port))
+}
+
+// http2Config applies the server section to the HTTP/2 side of the gateway http.Servers (gRPC-Web, HTTP/JSON and the
+// shared gRPC port): max concurrent streams, receive windows and keepalive pings. Native gRPC on the shared port also
+// gets the message size limits of serverOptions (grpc.Server.ServeHTTP).
+//
+// Parameter cfg — validated server transport settings.
+//
+// Returns: *http.HTTP2Config for http.Server.HTTP2.
+//
+// Called from newHTTPServer.
+func http2Config(cfg domain.ServerTransport) *http.HTTP2Config {
+	return &http.HTTP2Config{
+		MaxConcurrentStreams:          int(cfg.MaxConcurrentStreams),
+		MaxReceiveBufferPerStream:     int(cfg.InitialWindowSize),
+		MaxReceiveBufferPerConnection: int(cfg.InitialConnWindowSize),
+		SendPingTimeout:               cfg.Keepalive.Time,
+		PingTimeout:                   cfg.Keepalive.Timeout,
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// echoLarge starts a server with serverOptions(server) echoing any message back and calls it once through a client
+// built with dialOptions(client), sending a BytesValue of size bytes.
+func echoLarge(t *testing.T, server domain.ServerTransport, client domain.ClientTransport, size int) error {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(append(serverOptions(server), grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		var msg emptypb.Empty
+		if err := stream.RecvMsg(&msg); err != nil {
+			return err
+		}
+		return stream.SendMsg(&msg)
+	}))...)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), dialOptions(client)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var out wrapperspb.BytesValue
+	err = conn.Invoke(ctx, "/test.Echo/Large", wrapperspb.Bytes(make([]byte, size)), &out)
+	if err == nil {
+		assert.Len(t, out.GetValue(), size)
+	}
+	return err
+}
+
+func TestTransportOptions_MessageSize(t *testing.T) {
+	server, err := parseServerTransport(yamlServer{})
+	require.NoError(t, err)
+	client, err := parseClientTransport("c1", yamlClientTransport{})
+	require.NoError(t, err)
+
+	t.Run("defaults_allow_messages_above_4MiB", func(t *testing.T) {
+		assert.NoError(t, echoLarge(t, server, client, 6<<20))
+	})
+	t.Run("server_limit", func(t *testing.T) {
+		small := server
+		small.MaxRecvMsgSize = 1 << 20
+		assert.Equal(t, codes.ResourceExhausted, status.Code(echoLarge(t, small, client, 2<<20)))
+	})
+	t.Run("client_limit", func(t *testing.T) {
+		small := client
+		small.MaxRecvMsgSize = 1 << 20
+		assert.Equal(t, codes.ResourceExhausted, status.Code(echoLarge(t, server, small, 2<<20)))
+	})
+}
+
+func TestTransportOptions_KeepaliveAndWindows(t *testing.T) {
+	client := domain.ClientTransport{
+		KeepaliveTime: 10 * time.Second, KeepaliveTimeout: time.Second, MaxRecvMsgSize: 1 << 20, MaxSendMsgSize: 1 << 20,
+		InitialWindowSize: 1 << 20, InitialConnWindowSize: 2 << 20,
+	}
+	server := domain.ServerTransport{
+		Keepalive:      domain.ServerKeepalive{Time: time.Minute, Timeout: time.Second, MinTime: 5 * time.Second, PermitWithoutStream: true},
+		MaxRecvMsgSize: 1 << 20, MaxSendMsgSize: 1 << 20, MaxConcurrentStreams: 10, InitialWindowSize: 1 << 20, InitialConnWindowSize: 2 << 20,
+	}
+	assert.Len(t, dialOptions(client), 5)
+	assert.Len(t, serverOptions(server), 7)
+	assert.Len(t, dialOptions(domain.ClientTransport{MaxRecvMsgSize: 1, MaxSendMsgSize: 1}), 2, "keepalive and windows only when set")
+	assert.NoError(t, echoLarge(t, server, client, 512<<10))
+
+	h2 := http2Config(server)
+	assert.Equal(t, 10, h2.MaxConcurrentStreams)
+	assert.Equal(t, 1<<20, h2.MaxReceiveBufferPerStream)
+	assert.Equal(t, 2<<20, h2.MaxReceiveBufferPerConnection)
+	assert.Equal(t, time.Minute, h2.SendPingTimeout)
+	assert.Equal(t, time.Second, h2.PingTimeout)
+}
+
+func TestDialTarget(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:50051", dialTarget(domain.ServiceInstance{Address: "10.0.0.1", Port: 50051}))
+	assert.Equal(t, "[2001:db8::1]:50051", dialTarget(domain.ServiceInstance{Address: "2001:db8::1", Port: 50051}))
+	assert.Equal(t, "backend-0.backend.svc:50051", dialTarget(domain.ServiceInstance{Address: "backend-0.backend.svc", Port: 50051}))
+
+	t.Run("ipv6 loopback", func(t *testing.T) {
+		lis, err := net.Listen("tcp", "[::1]:0")
+		if err != nil {
+			t.Skipf("IPv6 loopback unavailable: %v", err)
+		}
+		srv := grpc.NewServer(grpc.UnknownServiceHandler(func(any, grpc.ServerStream) error {
+			return status.Error(codes.Unimplemented, "no services")
+		}))
+		go srv.Serve(lis)
+		defer srv.Stop()
+
+		inst := domain.ServiceInstance{Address: "::1", Port: lis.Addr().(*net.TCPAddr).Port}
+		conn, err := grpc.NewClient(dialTarget(inst), dialOptions(domain.ClientTransport{MaxRecvMsgSize: 1 << 20, MaxSendMsgSize: 1 << 20})...)
+		require.NoError(t, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = conn.Invoke(ctx, "/test.Echo/Echo", &emptypb.Empty{}, &emptypb.Empty{})
+		assert.Equal(t, codes.Unimplemented, status.Code(err), "request reached the server over IPv6: %v", err)
+	})
+}
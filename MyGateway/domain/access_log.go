@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Access log formats (YAML access_log.format).
+const (
+	AccessLogFormatJSON   = "json"
+	AccessLogFormatLogfmt = "logfmt"
+)
+
+// AccessLogOutputStderr is the access_log.output value that writes to stderr; any other value is a file path.
+const AccessLogOutputStderr = "stderr"
+
+// AccessLogConfig holds the per-RPC access log settings (YAML section "access_log"): Format (json|logfmt); Output
+// (stderr or a file path); MaxSizeBytes and MaxBackups — rotation of the file output; HashStickyKey logs a SHA-256
+// prefix instead of the raw sticky key; SampleRate (0..1) — share of successful calls logged by default;
+// RouteSampleRates — per-route override keyed by route prefix; Headers — request headers copied into the entry (lowercase).
+type AccessLogConfig struct {
+	Enabled          bool
+	Format           string
+	Output           string
+	MaxSizeBytes     int64
+	MaxBackups       int
+	HashStickyKey    bool
+	SampleRate       float64
+	RouteSampleRates map[string]float64
+	Headers          []string
+}
+
+// SampleRateFor returns the sample rate of the route with the given prefix (the default SampleRate when not overridden).
+//
+// Called from service.AccessLogStreamInterceptor once the call finished.
+func (c AccessLogConfig) SampleRateFor(routePrefix string) float64 {
+	if rate, ok := c.RouteSampleRates[routePrefix]; ok {
+		return rate
+	}
+	return c.SampleRate
+}
+
+// AccessRecord collects what the gateway learned about one RPC for the access log: the matched route and cluster,
+// the backend instance and sticky key of the last attempt, the authenticated user, the number of backend stream
+// attempts and session transfers. Filled by the proxy and auth processors through the request context; all methods are
+// safe on a nil record (access log disabled) and for concurrent use.
+type AccessRecord struct {
+	mu         sync.Mutex
+	route      string
+	cluster    string
+	instanceID string
+	stickyKey  string
+	user       string
+	attempts   atomic.Int64
+	transfers  atomic.Int64
+}
+
+// AccessRecordSnapshot is a point-in-time copy of an AccessRecord.
+type AccessRecordSnapshot struct {
+	Route      string
+	Cluster    string
+	InstanceID string
+	StickyKey  string
+	User       string
+	Attempts   int64
+	Transfers  int64
+}
+
+type accessRecordKey struct{}
+
+// WithAccessRecord returns ctx carrying rec.
+//
+// Called from service.AccessLogStreamInterceptor before running the handler.
+func WithAccessRecord(ctx context.Context, rec *AccessRecord) context.Context {
+	return context.WithValue(ctx, accessRecordKey{}, rec)
+}
+
+// AccessRecordFromContext returns the record of the current RPC, or nil when the access log is disabled.
+//
+// Called from service.TransparentProxy and the auth processors in helpers.
+func AccessRecordFromContext(ctx context.Context) *AccessRecord {
+	rec, _ := ctx.Value(accessRecordKey{}).(*AccessRecord)
+	return rec
+}
+
+// SetRoute records the matched route prefix and cluster.
+func (r *AccessRecord) SetRoute(prefix string, cluster ClusterID) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.route, r.cluster = prefix, string(cluster)
+}
+
+// SetBackend records the instance and sticky key of a backend stream attempt and counts the attempt.
+func (r *AccessRecord) SetBackend(instanceID, stickyKey string) {
+	if r == nil {
+		return
+	}
+	r.attempts.Add(1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instanceID, r.stickyKey = instanceID, stickyKey
+}
+
+// SetUser records the authenticated user (JWT login or API key name).
+func (r *AccessRecord) SetUser(user string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.user = user
+}
+
+// AddTransfer counts a transfer of the call to another instance after a mid-stream backend failure.
+func (r *AccessRecord) AddTransfer() {
+	if r == nil {
+		return
+	}
+	r.transfers.Add(1)
+}
+
+// Snapshot returns the current values (zero for a nil record).
+func (r *AccessRecord) Snapshot() AccessRecordSnapshot {
+	if r == nil {
+		return AccessRecordSnapshot{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return AccessRecordSnapshot{
+		Route:      r.route,
+		Cluster:    r.cluster,
+		InstanceID: r.instanceID,
+		StickyKey:  r.stickyKey,
+		User:       r.user,
+		Attempts:   r.attempts.Load(),
+		Transfers:  r.transfers.Load(),
+	}
+}
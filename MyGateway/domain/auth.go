@@ -0,0 +1,100 @@
+package domain
+
+import "time"
+
+// JWTFormat selects the token format verified on authorization=required routes.
+type JWTFormat string
+
+const (
+	// JWTFormatLegacy is the MyAuth format base64(payload).base64(signature) with RFC3339 expiry, signed with JWT_SECRET.
+	JWTFormatLegacy JWTFormat = "legacy"
+	// JWTFormatStandard is an RFC 7519 JWT (header.payload.signature, base64url, numeric exp/iat/nbf).
+	JWTFormatStandard JWTFormat = "standard"
+)
+
+// AuthConfig holds gateway-wide authentication options that are not tied to a single route (YAML section "auth").
+// ForwardClaims — after a successful JWT check the gateway injects the validated claims as trusted headers
+// (x-auth-login, x-auth-role, x-auth-session-id) and strips client-supplied copies of those headers on every route;
+// StripAuthorization — when ForwardClaims is set, the original authorization header is removed before forwarding;
+// JWT — token format and verification settings; APIKey — settings for authorization=api_key routes;
+// External — the authz service for authorization=external routes; Revocation — the token revocation store.
+type AuthConfig struct {
+	ForwardClaims      bool
+	StripAuthorization bool
+	JWT                JWTConfig
+	APIKey             APIKeyConfig
+	External           ExternalAuthConfig
+	Revocation         RevocationConfig
+}
+
+// RevocationConfig holds the token revocation store (YAML section "auth.revocation"), consulted after a token is
+// verified on authorization=required routes: File (local YAML/JSON file, for tests and single-node setups) or RedisURL
+// (redis://… with keys under KeyPrefix), at most one of them; RefreshInterval — how often the local copy is reloaded.
+// Both empty — revocation is not checked.
+type RevocationConfig struct {
+	File            string
+	RedisURL        string
+	KeyPrefix       string
+	RefreshInterval time.Duration
+}
+
+// Enabled reports whether a revocation store is configured.
+func (c RevocationConfig) Enabled() bool {
+	return c.File != "" || c.RedisURL != ""
+}
+
+// ExternalAuthConfig holds settings for routes with authorization=external (YAML section "auth.external"): Address of the
+// gRPC authz service (mygateway.authz.Authorizer), Timeout per Check call, and the decision cache — CacheKey lists the
+// metadata keys (and ExternalAuthCacheKeyPeer for the client address) that, together with method and route, identify a
+// decision; CacheTTL zero disables caching; CacheMaxEntries bounds the cache size.
+type ExternalAuthConfig struct {
+	Address         string
+	Timeout         time.Duration
+	CacheKey        []string
+	CacheTTL        time.Duration
+	CacheMaxEntries int
+}
+
+// ExternalAuthCacheKeyPeer in ExternalAuthConfig.CacheKey adds the client address to the decision cache key.
+const ExternalAuthCacheKeyPeer = ":peer"
+
+// AuthzRequest is what the gateway sends to the external authz service: full gRPC Method, incoming Metadata (lowercase
+// keys), client Peer address and the matched Route prefix.
+type AuthzRequest struct {
+	Method   string
+	Metadata map[string][]string
+	Peer     string
+	Route    string
+}
+
+// AuthzDecision is the external authz verdict: Allow; on allow HeadersToAdd (replace values) and HeadersToRemove are
+// applied to the forwarded metadata; on deny DenyCode (gRPC code, 0 — PERMISSION_DENIED) and DenyMessage form the status.
+type AuthzDecision struct {
+	Allow           bool
+	HeadersToAdd    map[string][]string
+	HeadersToRemove []string
+	DenyCode        uint32
+	DenyMessage     string
+}
+
+// APIKeyConfig holds settings for routes with authorization=api_key (YAML section "auth.api_key"): Header — metadata key
+// carrying the raw key (lowercase, default x-api-key); KeysFile — path to the hashed key store, re-read on change.
+type APIKeyConfig struct {
+	Header   string
+	KeysFile string
+}
+
+// JWTConfig holds token verification settings (YAML section "auth.jwt"). Format selects legacy or standard tokens;
+// the remaining fields apply to standard tokens only: Algorithms (allowed JWS "alg" values), Issuer and Audience
+// (checked when non-empty), Leeway (clock skew tolerance for exp/nbf/iat), and the JWKS source — JWKSFile or JWKSURL
+// refreshed every JWKSRefreshInterval. HMAC tokens may also be verified with JWT_SECRET.
+type JWTConfig struct {
+	Format              JWTFormat
+	Algorithms          []string
+	Issuer              string
+	Audience            string
+	Leeway              time.Duration
+	JWKSFile            string
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+}
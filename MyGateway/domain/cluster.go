@@ -10,10 +10,88 @@ const (
 	ClusterTypeDynamic ClusterType = "dynamic"
 )
 
-// ClusterConfig holds cluster type and, for static, Address; for dynamic, DiscovererURL and DiscovererInterval.
+// DiscovererType selects where a dynamic cluster gets its instances: MyDiscoverer over HTTP, DNS records, a local file
+// or Kubernetes EndpointSlices.
+type DiscovererType string
+
+const (
+	DiscovererHTTP       DiscovererType = "http"
+	DiscovererDNS        DiscovererType = "dns"
+	DiscovererFile       DiscovererType = "file"
+	DiscovererKubernetes DiscovererType = "kubernetes"
+)
+
+// ClusterConfig holds cluster type and, for static, Address; for dynamic, Discoverer (http: HTTP; dns: DNS; file: File;
+// kubernetes: Kubernetes),
+// DiscovererInterval, StreamLimit, Locality, SnapshotPath (last-known-good instance file, "" — none) and SnapshotMaxAge
+// (how long the instance list may go without a successful discovery answer before it is no longer trusted, 0 — no
+// limit); Transport — dial settings of the backend connections.
 type ClusterConfig struct {
 	Type               ClusterType
 	Address            string
-	DiscovererURL      string
+	Discoverer         DiscovererType
+	HTTP               HTTPDiscovery
+	DNS                DNSDiscovery
+	File               FileDiscovery
+	Kubernetes         KubernetesDiscovery
 	DiscovererInterval time.Duration
+	StreamLimit        StreamLimit
+	Locality           Locality
+	SnapshotPath       string
+	SnapshotMaxAge     time.Duration
+	Transport          ClientTransport
+}
+
+// HTTPDiscovery configures MyDiscoverer discovery of a dynamic cluster: URLs are the base URLs of the MyDiscoverer
+// replicas (the first answering one is used until it fails); ServiceType keeps only the instances registered with this
+// service_type ("" — all); Watch is the long-poll timeout of GET /v1/instances/watch (0 — polling only); Timeout bounds
+// one request to one replica (the watch gets Watch on top).
+type HTTPDiscovery struct {
+	URLs        []string
+	ServiceType string
+	Watch       time.Duration
+	Timeout     time.Duration
+}
+
+// DNSDiscovery configures DNS discovery of a dynamic cluster: Name is an SRV record name (e.g.
+// _grpc._tcp.myservice.default.svc.cluster.local) when Port is 0, otherwise a host name whose A/AAAA addresses are used
+// with Port; ExclusionTTL is how long an instance reported as failed is left out of the results.
+type DNSDiscovery struct {
+	Name         string
+	Port         int
+	ExclusionTTL time.Duration
+}
+
+// FileDiscovery configures file discovery of a dynamic cluster: Path is a JSON or YAML instance list in the shape of
+// the MyDiscoverer GET /v1/instances response; ExclusionTTL is how long an instance reported as failed is left out of
+// the results (0 — until the file changes).
+type FileDiscovery struct {
+	Path         string
+	ExclusionTTL time.Duration
+}
+
+// KubernetesDiscovery configures EndpointSlice discovery of a dynamic cluster: the slices of Service in Namespace are
+// watched and PortName selects the port of each slice ("" — the unnamed port); ExclusionTTL is how long an instance
+// reported as failed is left out of the results.
+type KubernetesDiscovery struct {
+	Service      string
+	Namespace    string
+	PortName     string
+	ExclusionTTL time.Duration
+}
+
+// StreamLimit caps concurrent backend streams per instance of a dynamic cluster: MaxPerInstance (0 — unlimited) and
+// QueueTimeout — how long a call waits for a free slot when every candidate instance is saturated.
+type StreamLimit struct {
+	MaxPerInstance int
+	QueueTimeout   time.Duration
+}
+
+// Locality makes a dynamic cluster prefer instances in the zone of the gateway (instance label ZoneLabel): Zone is the
+// gateway zone ("" — no preference); SpillThreshold is the minimum number of available same-zone instances (not
+// draining, not at the stream limit and, for a new sticky key, not bound to another key) — with fewer, calls spread over
+// all zones.
+type Locality struct {
+	Zone           string
+	SpillThreshold int
 }
@@ -1,10 +1,66 @@
 package domain
 
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
 // ServiceInstance is a single backend instance from the discoverer (e.g. GET /v1/instances).
-// AssignedClientSessionID is the session bound to this instance, or empty if free.
+// AssignedClientSessionID is the session bound to this instance, or empty if free. Draining marks an instance that is
+// shutting down (e.g. a terminating Kubernetes pod): it keeps its sticky sessions but gets no new ones. Labels are the
+// labels the instance registered with (zone, version, build, ...; nil when none), matched by route subsets. Address is an
+// IPv4 address, an IPv6 address without brackets or a DNS hostname; the pool dials net.JoinHostPort(Address, Port).
 type ServiceInstance struct {
 	InstanceID              string
-	Ipv4                    string
+	Address                 string
 	Port                    int
 	AssignedClientSessionID string // empty if free
+	Draining                bool
+	Labels                  map[string]string
+}
+
+// ZoneLabel is the instance label holding its availability zone, matched against Locality.Zone by the connection pool.
+const ZoneLabel = "zone"
+
+// LabelSelector is a set of label → value requirements on ServiceInstance.Labels; an empty selector matches every
+// instance. Built per request by service.connectionResolverGeneric from Route.Subset.
+type LabelSelector map[string]string
+
+// Matches reports whether labels carry every label of s with the same value.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for k, v := range s {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Key returns a canonical form of s (labels sorted by name; "" for an empty selector), used by connectionPool to keep
+// the round-robin position of each subset.
+func (s LabelSelector) Key() string {
+	if len(s) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte(0)
+		b.WriteString(s[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// InstanceSnapshot is an instance list of a dynamic cluster and the time it was obtained from the discoverer (SavedAt),
+// persisted by interfaces.InstanceSnapshotStore as the last-known-good list.
+type InstanceSnapshot struct {
+	SavedAt   time.Time
+	Instances []ServiceInstance
 }
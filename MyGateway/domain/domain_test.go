@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{"zone": "eu-1a", "version": "v2"}
+	tests := []struct {
+		name     string
+		selector LabelSelector
+		want     bool
+	}{
+		{name: "nil_matches_all", selector: nil, want: true},
+		{name: "subset_of_labels", selector: LabelSelector{"version": "v2"}, want: true},
+		{name: "all_labels", selector: LabelSelector{"version": "v2", "zone": "eu-1a"}, want: true},
+		{name: "other_value", selector: LabelSelector{"version": "v1"}, want: false},
+		{name: "missing_label", selector: LabelSelector{"build": "42"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.selector.Matches(labels))
+		})
+	}
+	assert.False(t, LabelSelector{"version": "v2"}.Matches(nil), "unlabeled instance matches only the empty selector")
+
+	assert.Empty(t, LabelSelector{}.Key())
+	assert.Equal(t, LabelSelector{"a": "1", "b": "2"}.Key(), LabelSelector{"b": "2", "a": "1"}.Key(), "key does not depend on map order")
+	assert.NotEqual(t, LabelSelector{"a": "1"}.Key(), LabelSelector{"a": "2"}.Key())
+}
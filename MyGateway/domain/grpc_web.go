@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// AnyOrigin in CORSConfig.AllowedOrigins allows requests from every origin.
+const AnyOrigin = "*"
+
+// GRPCWebConfig holds the gRPC-Web frontend settings (YAML section "grpc_web"): Enabled turns it on; Port is a separate
+// HTTP listener for gRPC-Web (0 — the gRPC port serves both native gRPC and gRPC-Web); CORS — cross-origin policy for
+// browser clients.
+type GRPCWebConfig struct {
+	Enabled bool
+	Port    int
+	CORS    CORSConfig
+}
+
+// CORSConfig is the cross-origin policy of the gRPC-Web frontend: AllowedOrigins (exact origins or AnyOrigin; empty —
+// same-origin only, no CORS headers), AllowedHeaders and ExposedHeaders (added to the built-in gRPC-Web lists, lowercase),
+// AllowCredentials (cookies/authorization from the browser; not allowed with AnyOrigin) and MaxAge of a preflight answer.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// AllowsOrigin reports whether a request with the given Origin header may be answered with CORS headers.
+//
+// Called from service.GRPCWebHandler for preflight and actual requests.
+func (c CORSConfig) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	return slices.Contains(c.AllowedOrigins, AnyOrigin) || slices.Contains(c.AllowedOrigins, origin)
+}
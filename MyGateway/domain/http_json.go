@@ -0,0 +1,10 @@
+package domain
+
+// HTTPJSONConfig holds the HTTP/JSON transcoding frontend settings (YAML section "http_json"): Enabled turns it on; Port
+// is its HTTP listener (required, distinct from the gRPC and gRPC-Web ports); DescriptorSet is the path to a
+// FileDescriptorSet (protoc --include_imports) whose google.api.http annotations define the REST endpoints.
+type HTTPJSONConfig struct {
+	Enabled       bool
+	Port          int
+	DescriptorSet string
+}
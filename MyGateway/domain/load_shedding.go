@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// DefaultPriorityHeader is the metadata header carrying a client-assigned priority (low|normal|high).
+const DefaultPriorityHeader = "x-priority"
+
+// LoadSheddingConfig holds gateway-wide admission control (YAML section "load_shedding"): the adaptive concurrency
+// limit starts at InitialLimit and moves within MinLimit..MaxLimit; a call whose latency exceeds the baseline (lowest
+// latency seen in the last BaselineWindow) times LatencyTolerance, or that ends with an overload code, shrinks the limit
+// by Backoff; calls within tolerance grow it additively. RetryAfter is the retry hint sent with shed calls;
+// PriorityHeader names the client priority header.
+type LoadSheddingConfig struct {
+	Enabled          bool
+	InitialLimit     int
+	MinLimit         int
+	MaxLimit         int
+	LatencyTolerance float64
+	Backoff          float64
+	BaselineWindow   time.Duration
+	RetryAfter       time.Duration
+	PriorityHeader   string
+}
+
+// LimitShare returns the part of the concurrency limit calls of priority p may fill: lower priorities are shed while
+// higher ones are still admitted. Critical calls are never shed (share 0 means "no cap").
+//
+// Parameters: p — effective priority of the call (empty — normal).
+//
+// Returns: share of the limit in (0, 1], or 0 for critical.
+//
+// Called from service.LoadShedder on admission.
+func LimitShare(p Priority) float64 {
+	switch p {
+	case PriorityCritical:
+		return 0
+	case PriorityHigh:
+		return 1
+	case PriorityLow:
+		return 0.5
+	default:
+		return 0.8
+	}
+}
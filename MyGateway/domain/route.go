@@ -8,14 +8,43 @@ import (
 // ClusterID identifies a backend cluster (e.g. "myauth", "my_service").
 type ClusterID string
 
-// AuthorizationMode is the per-route auth policy: none (pass through) or required (JWT + session-id).
+// AuthorizationMode is the per-route auth policy: none (pass through), required (JWT + session-id), api_key (API key
+// header checked against the key store) or external (decision from the external gRPC authz service).
 type AuthorizationMode string
 
 const (
 	AuthorizationNone     AuthorizationMode = "none"
 	AuthorizationRequired AuthorizationMode = "required"
+	AuthorizationAPIKey   AuthorizationMode = "api_key"
+	AuthorizationExternal AuthorizationMode = "external"
 )
 
+// AnyRole in Route.AllowedRoles admits a token with any non-empty role claim.
+const AnyRole = "*"
+
+// RoleAllowed reports whether role satisfies allowed: empty allowed means no role restriction; otherwise role must be
+// non-empty and either listed or allowed must contain AnyRole.
+//
+// Parameters: allowed — route allowed_roles (nil/empty — no restriction); role — role claim from the validated token.
+//
+// Returns: true when the caller may use the route.
+//
+// Called from helpers.ConfigurableAuthProcessor.Process after signature and expiry checks.
+func RoleAllowed(allowed []string, role string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	if role == "" {
+		return false
+	}
+	for _, r := range allowed {
+		if r == AnyRole || r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // BalancerType selects how a backend instance is chosen: round_robin or sticky_sessions.
 type BalancerType string
 
@@ -30,13 +59,44 @@ type BalancerConfig struct {
 	Header string
 }
 
+// SubsetConfig restricts a route to the instances of its cluster whose labels match. Labels are fixed label → value
+// requirements (e.g. version: v2); Headers maps a label to a request metadata header whose value, when present, sets or
+// overrides the requirement for that label (a missing or empty header adds nothing). Zero value — no subset.
+type SubsetConfig struct {
+	Labels  map[string]string
+	Headers map[string]string
+}
+
+// IsZero reports whether the subset has no labels and no headers.
+func (s SubsetConfig) IsZero() bool {
+	return len(s.Labels) == 0 && len(s.Headers) == 0
+}
+
+// Priority orders calls for load shedding: low is shed first, critical (route-only, e.g. Login) is never shed.
+type Priority string
+
+const (
+	PriorityLow      Priority = "low"
+	PriorityNormal   Priority = "normal"
+	PriorityHigh     Priority = "high"
+	PriorityCritical Priority = "critical"
+)
+
 // Route maps a path prefix to a cluster.
 // Prefix must start with "/" and is matched with strings.HasPrefix(fullMethod, Prefix).
+// AllowedRoles (authorization=required only) restricts the route to tokens whose role claim is listed; AnyRole admits any role.
+// FailOpen (authorization=external only) forwards the call when the authz service is unreachable instead of rejecting it.
+// Priority is the load shedding priority of the route (empty — normal).
+// Subset (dynamic clusters only) limits the instances the route is balanced over by their labels.
 type Route struct {
 	Prefix        string
 	Cluster       ClusterID
 	Authorization AuthorizationMode
+	AllowedRoles  []string
+	FailOpen      bool
 	Balancer      BalancerConfig
+	Priority      Priority
+	Subset        SubsetConfig
 }
 
 // DefaultRouteAction is the behavior when no route prefix matches: error (return Unimplemented) or use_cluster.
@@ -60,7 +120,7 @@ type RouteConfig struct {
 	Default DefaultRoute
 }
 
-// ValidateRouteConfig validates route and default config: each route has non-empty Prefix starting with "/", authorization none|required, balancer.type round_robin|sticky_sessions; for sticky_sessions balancer.header is set; default.action error|use_cluster; for use_cluster default.cluster is non-empty.
+// ValidateRouteConfig validates route and default config: each route has non-empty Prefix starting with "/", authorization none|required|api_key|external, allowed_roles only with authorization=required and without empty entries, fail_open only with authorization=external, priority low|normal|high|critical, balancer.type round_robin|sticky_sessions; for sticky_sessions balancer.header is set; subset labels and headers have non-empty names and header names; default.action error|use_cluster; for use_cluster default.cluster is non-empty.
 //
 // Parameter cfg — route config (usually from YAML via cmd.LoadConfig). Routes may be in any order; validation does not check cluster references (LoadConfig does that).
 //
@@ -76,9 +136,25 @@ func ValidateRouteConfig(cfg RouteConfig) error {
 			return &RouteConfigError{Index: i, Reason: "prefix must start with /"}
 		}
 		switch r.Authorization {
-		case "", AuthorizationNone, AuthorizationRequired:
+		case "", AuthorizationNone, AuthorizationRequired, AuthorizationAPIKey, AuthorizationExternal:
+		default:
+			return &RouteConfigError{Index: i, Reason: "authorization must be none|required|api_key|external"}
+		}
+		if r.FailOpen && r.Authorization != AuthorizationExternal {
+			return &RouteConfigError{Index: i, Reason: "fail_open requires authorization=external"}
+		}
+		if len(r.AllowedRoles) > 0 && r.Authorization != AuthorizationRequired {
+			return &RouteConfigError{Index: i, Reason: "allowed_roles requires authorization=required"}
+		}
+		for _, role := range r.AllowedRoles {
+			if strings.TrimSpace(role) == "" {
+				return &RouteConfigError{Index: i, Reason: "allowed_roles must not contain empty values"}
+			}
+		}
+		switch r.Priority {
+		case "", PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical:
 		default:
-			return &RouteConfigError{Index: i, Reason: "authorization must be none|required"}
+			return &RouteConfigError{Index: i, Reason: "priority must be low|normal|high|critical"}
 		}
 		switch r.Balancer.Type {
 		case "", BalancerRoundRobin, BalancerStickySession:
@@ -88,6 +164,16 @@ func ValidateRouteConfig(cfg RouteConfig) error {
 		if r.Balancer.Type == BalancerStickySession && strings.TrimSpace(r.Balancer.Header) == "" {
 			return &RouteConfigError{Index: i, Reason: "balancer.header is required for sticky_sessions"}
 		}
+		for label := range r.Subset.Labels {
+			if strings.TrimSpace(label) == "" {
+				return &RouteConfigError{Index: i, Reason: "subset must not contain empty label names"}
+			}
+		}
+		for label, header := range r.Subset.Headers {
+			if strings.TrimSpace(label) == "" || strings.TrimSpace(header) == "" {
+				return &RouteConfigError{Index: i, Reason: "subset_headers must map non-empty labels to non-empty header names"}
+			}
+		}
 	}
 	switch cfg.Default.Action {
 	case "", DefaultRouteError:
@@ -53,6 +53,56 @@ func TestValidateRouteConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid_authorization_api_key",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Authorization: AuthorizationAPIKey},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid_authorization_external_fail_open",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Authorization: AuthorizationExternal, FailOpen: true},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "err_fail_open_without_external",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Authorization: AuthorizationRequired, FailOpen: true},
+				},
+			},
+			wantErr:     true,
+			wantIndex:   0,
+			wantContain: "fail_open requires authorization=external",
+		},
+		{
+			name: "valid_priority_critical",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Priority: PriorityCritical},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "err_priority_unknown",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1"},
+					{Prefix: "/y", Cluster: "c1", Priority: "urgent"},
+				},
+			},
+			wantErr:     true,
+			wantIndex:   1,
+			wantContain: "priority must be low|normal|high|critical",
+		},
 		{
 			name: "valid_authorization_empty",
 			cfg: RouteConfig{
@@ -62,6 +112,15 @@ func TestValidateRouteConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid_allowed_roles_with_required",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Authorization: AuthorizationRequired, AllowedRoles: []string{"admin", AnyRole}},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "valid_balancer_round_robin",
 			cfg: RouteConfig{
@@ -145,7 +204,29 @@ func TestValidateRouteConfig(t *testing.T) {
 			},
 			wantErr:     true,
 			wantIndex:   0,
-			wantContain: "authorization must be none|required",
+			wantContain: "authorization must be none|required|api_key|external",
+		},
+		{
+			name: "err_allowed_roles_without_required",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Authorization: AuthorizationNone, AllowedRoles: []string{"admin"}},
+				},
+			},
+			wantErr:     true,
+			wantIndex:   0,
+			wantContain: "allowed_roles requires authorization=required",
+		},
+		{
+			name: "err_allowed_roles_empty_value",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Authorization: AuthorizationRequired, AllowedRoles: []string{"admin", " "}},
+				},
+			},
+			wantErr:     true,
+			wantIndex:   0,
+			wantContain: "allowed_roles must not contain empty values",
 		},
 		{
 			name: "err_invalid_balancer_type",
@@ -180,6 +261,36 @@ func TestValidateRouteConfig(t *testing.T) {
 			wantIndex:   0,
 			wantContain: "balancer.header is required for sticky_sessions",
 		},
+		{
+			name: "ok_subset",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Subset: SubsetConfig{Labels: map[string]string{"version": "v2"}, Headers: map[string]string{"zone": "x-zone"}}},
+				},
+			},
+		},
+		{
+			name: "err_subset_empty_label",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Subset: SubsetConfig{Labels: map[string]string{" ": "v2"}}},
+				},
+			},
+			wantErr:     true,
+			wantIndex:   0,
+			wantContain: "subset must not contain empty label names",
+		},
+		{
+			name: "err_subset_headers_empty_header",
+			cfg: RouteConfig{
+				Routes: []Route{
+					{Prefix: "/x", Cluster: "c1", Subset: SubsetConfig{Headers: map[string]string{"version": ""}}},
+				},
+			},
+			wantErr:     true,
+			wantIndex:   0,
+			wantContain: "subset_headers must map non-empty labels to non-empty header names",
+		},
 		{
 			name: "err_default_use_cluster_empty_cluster",
 			cfg: RouteConfig{
@@ -245,3 +356,24 @@ func TestRouteConfigError_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestRoleAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		role    string
+		want    bool
+	}{
+		{name: "no_restriction", allowed: nil, role: "", want: true},
+		{name: "listed_role", allowed: []string{"admin", "operator"}, role: "operator", want: true},
+		{name: "role_mismatch", allowed: []string{"admin", "operator"}, role: "viewer", want: false},
+		{name: "missing_role", allowed: []string{"admin"}, role: "", want: false},
+		{name: "wildcard_any_role", allowed: []string{AnyRole}, role: "viewer", want: true},
+		{name: "wildcard_missing_role", allowed: []string{AnyRole}, role: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RoleAllowed(tt.allowed, tt.role))
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// ShutdownConfig holds the graceful drain on SIGINT/SIGTERM (YAML section "shutdown"): DrainTimeout is how long open
+// streams may finish before the gateway force-closes them; NotifyBefore (0 — never) ends the streams still open that
+// long before the deadline with StatusCode/StatusMessage and Trailers, so clients reconnect to another gateway instead
+// of being cut. StatusCode is a gRPC code (codes.Code); StatusMessage and Trailers also answer calls arriving while draining.
+type ShutdownConfig struct {
+	DrainTimeout  time.Duration
+	NotifyBefore  time.Duration
+	StatusCode    uint32
+	StatusMessage string
+	Trailers      map[string]string
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// ServerTransport holds the HTTP/2 settings of the gateway listeners (YAML section "server"): Keepalive, the largest
+// request/response message (MaxRecvMsgSize/MaxSendMsgSize, bytes), MaxConcurrentStreams per client connection
+// (0 — unlimited) and the HTTP/2 flow-control windows (0 — gRPC default with dynamic BDP sizing).
+type ServerTransport struct {
+	Keepalive             ServerKeepalive
+	MaxRecvMsgSize        int
+	MaxSendMsgSize        int
+	MaxConcurrentStreams  uint32
+	InitialWindowSize     int32
+	InitialConnWindowSize int32
+}
+
+// ServerKeepalive controls pings to clients and connection lifetime: after Time without activity the gateway pings and
+// closes the connection if no ack arrives within Timeout; clients may ping at most every MinTime (also without open
+// streams when PermitWithoutStream); MaxConnectionIdle, MaxConnectionAge and MaxConnectionAgeGrace bound connection
+// lifetime (0 — infinite).
+type ServerKeepalive struct {
+	Time                  time.Duration
+	Timeout               time.Duration
+	MinTime               time.Duration
+	PermitWithoutStream   bool
+	MaxConnectionIdle     time.Duration
+	MaxConnectionAge      time.Duration
+	MaxConnectionAgeGrace time.Duration
+}
+
+// ClientTransport holds the dial settings of the connections to one cluster (YAML "clusters.<name>.transport"), used
+// for the static connection and for every instance connection of the pool: KeepaliveTime (0 — no pings) and
+// KeepaliveTimeout, PermitWithoutStream (ping idle connections too), message size limits and flow-control windows as in
+// ServerTransport.
+type ClientTransport struct {
+	KeepaliveTime         time.Duration
+	KeepaliveTimeout      time.Duration
+	PermitWithoutStream   bool
+	MaxRecvMsgSize        int
+	MaxSendMsgSize        int
+	InitialWindowSize     int32
+	InitialConnWindowSize int32
+}
@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"context"
+	"strings"
+
+	"mygateway/domain"
+	"mygateway/interfaces"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyProcessor implements interfaces.HeaderProcessor for routes with authorization=api_key (service-to-service callers
+// without a Login session). It reads the raw key from the configured header, looks it up in the APIKeyStore (hashed keys),
+// checks expiry and the key allowed prefixes, then replaces the key header with the trusted x-auth-api-key-name header
+// so backends can audit the caller without seeing the secret. Client-supplied x-auth-api-key-name is stripped on every route.
+type APIKeyProcessor struct {
+	store        interfaces.APIKeyStore
+	timeProvider interfaces.TimeProvider
+	header       string
+	rules        []AuthRule
+}
+
+// NewAPIKeyProcessor creates a header processor for authorization=api_key routes. Panics on nil store or timeProvider.
+//
+// Parameters: store — hashed API key store; timeProvider — source of current time for key expiry; routes — routes from
+// config (same longest-prefix rules as ConfigurableAuthProcessor); header — metadata key with the raw key (empty — DefaultAPIKeyHeader).
+//
+// Returns: *APIKeyProcessor implementing interfaces.HeaderProcessor.
+//
+// Called from cmd/main when building the header chain (after ConfigurableAuthProcessor).
+func NewAPIKeyProcessor(store interfaces.APIKeyStore, timeProvider interfaces.TimeProvider, routes []domain.Route, header string) *APIKeyProcessor {
+	header = strings.ToLower(strings.TrimSpace(header))
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+	return &APIKeyProcessor{
+		store:        NilPanic(store, "helpers.api_key_processor.go: APIKeyStore is required"),
+		timeProvider: NilPanic(timeProvider, "helpers.api_key_processor.go: time provider is required"),
+		header:       header,
+		rules:        buildAuthRules(routes),
+	}
+}
+
+// Process selects a rule by longest-prefix for method; when authorization=api_key it validates the key header and on
+// success returns headers without the raw key and with x-auth-api-key-name set (the key name is also the access log user). Other routes pass through (minus a
+// client-supplied x-auth-api-key-name).
+//
+// Parameters: ctx — request context; headers — metadata from the previous processor; method — full gRPC method name.
+//
+// Returns: (headers, nil) when the route is not api_key or the key is valid; (nil, status.Error) when the key is missing
+// or unknown/expired (Unauthenticated "missing or invalid api key"), not allowed for the method (PermissionDenied
+// "api key is not allowed for this method") or the store cannot be loaded (Internal).
+//
+// Called from HeaderProcessorChain.Process inside TransparentProxy.Handler.
+func (p *APIKeyProcessor) Process(ctx context.Context, headers metadata.MD, method string) (metadata.MD, error) {
+	headers = headers.Copy()
+	headers.Delete(HeaderAuthAPIKeyName)
+	rule := matchAuthRule(p.rules, method)
+	if rule.Authorization != domain.AuthorizationAPIKey {
+		return headers, nil
+	}
+	rawKey, ok := GetHeaderValue(headers, p.header)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid api key")
+	}
+	key, found, err := p.store.Lookup(rawKey)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !found || !key.Active(p.timeProvider.Now()) {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid api key")
+	}
+	if !key.Allows(method) {
+		return nil, status.Error(codes.PermissionDenied, "api key is not allowed for this method")
+	}
+	headers.Delete(p.header)
+	headers.Set(HeaderAuthAPIKeyName, key.Name)
+	domain.AccessRecordFromContext(ctx).SetUser(key.Name)
+	return headers, nil
+}
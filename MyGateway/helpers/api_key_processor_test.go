@@ -0,0 +1,111 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mygateway/auth"
+	"mygateway/domain"
+	"mygateway/interfaces/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewAPIKeyProcessor_Panics(t *testing.T) {
+	tp := &mock.TimeProviderMock{}
+	t.Run("store_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "helpers.api_key_processor.go: APIKeyStore is required", func() {
+			NewAPIKeyProcessor(nil, tp, nil, "")
+		})
+	})
+	t.Run("time_provider_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "helpers.api_key_processor.go: time provider is required", func() {
+			NewAPIKeyProcessor(&mock.APIKeyStoreMock{}, nil, nil, "")
+		})
+	})
+}
+
+func TestAPIKeyProcessor_Process(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 2, 11, 12, 0, 0, 0, time.UTC)
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
+	keys := map[string]auth.APIKey{
+		"billing-key": {Name: "billing", AllowedPrefixes: []string{"/billing.Billing/"}},
+		"any-key":     {Name: "any"},
+		"old-key":     {Name: "old", ExpiresAt: now.Add(-time.Second)},
+	}
+	store := &mock.APIKeyStoreMock{LookupFunc: func(rawKey string) (auth.APIKey, bool, error) {
+		k, ok := keys[rawKey]
+		return k, ok, nil
+	}}
+	routes := []domain.Route{
+		{Prefix: "/billing.Billing/", Cluster: "c1", Authorization: domain.AuthorizationAPIKey},
+		{Prefix: "/reports.Reports/", Cluster: "c1", Authorization: domain.AuthorizationAPIKey},
+		{Prefix: "/public.Public/", Cluster: "c1", Authorization: domain.AuthorizationNone},
+	}
+	p := NewAPIKeyProcessor(store, tp, routes, "")
+
+	tests := []struct {
+		name     string
+		headers  metadata.MD
+		method   string
+		wantCode codes.Code
+		wantName string
+	}{
+		{name: "valid_key_with_prefix", headers: metadata.Pairs("x-api-key", "billing-key"), method: "/billing.Billing/Charge", wantName: "billing"},
+		{name: "valid_key_any_prefix", headers: metadata.Pairs("x-api-key", "any-key"), method: "/reports.Reports/Get", wantName: "any"},
+		{name: "missing_key", headers: metadata.MD{}, method: "/billing.Billing/Charge", wantCode: codes.Unauthenticated},
+		{name: "unknown_key", headers: metadata.Pairs("x-api-key", "nope"), method: "/billing.Billing/Charge", wantCode: codes.Unauthenticated},
+		{name: "expired_key", headers: metadata.Pairs("x-api-key", "old-key"), method: "/billing.Billing/Charge", wantCode: codes.Unauthenticated},
+		{name: "prefix_not_allowed", headers: metadata.Pairs("x-api-key", "billing-key"), method: "/reports.Reports/Get", wantCode: codes.PermissionDenied},
+		{name: "spoofed_name_on_public_route_stripped", headers: metadata.Pairs(HeaderAuthAPIKeyName, "admin"), method: "/public.Public/Get"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := tt.headers.Copy()
+			in.Set(HeaderAuthAPIKeyName, "spoofed")
+			rec := &domain.AccessRecord{}
+			out, err := p.Process(domain.WithAccessRecord(ctx, rec), in, tt.method)
+			if tt.wantCode != codes.OK {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Empty(t, out.Get("x-api-key"), "raw key is not forwarded")
+			if tt.wantName == "" {
+				assert.Empty(t, out.Get(HeaderAuthAPIKeyName))
+				return
+			}
+			assert.Equal(t, []string{tt.wantName}, out.Get(HeaderAuthAPIKeyName))
+			assert.Equal(t, tt.wantName, rec.Snapshot().User, "key name is recorded for the access log")
+			assert.Equal(t, []string{"spoofed"}, in.Get(HeaderAuthAPIKeyName), "input is not mutated")
+		})
+	}
+}
+
+func TestAPIKeyProcessor_CustomHeaderAndStoreError(t *testing.T) {
+	ctx := context.Background()
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	routes := []domain.Route{{Prefix: "/svc/", Cluster: "c1", Authorization: domain.AuthorizationAPIKey}}
+
+	store := &mock.APIKeyStoreMock{LookupFunc: func(rawKey string) (auth.APIKey, bool, error) {
+		return auth.APIKey{Name: "svc"}, rawKey == "k", nil
+	}}
+	out, err := NewAPIKeyProcessor(store, tp, routes, "X-Service-Key").Process(ctx, metadata.Pairs("x-service-key", "k"), "/svc/Call")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc"}, out.Get(HeaderAuthAPIKeyName))
+	assert.Empty(t, out.Get("x-service-key"))
+
+	failing := &mock.APIKeyStoreMock{LookupFunc: func(string) (auth.APIKey, bool, error) {
+		return auth.APIKey{}, false, errors.New("store unavailable")
+	}}
+	_, err = NewAPIKeyProcessor(failing, tp, routes, "").Process(ctx, metadata.Pairs("x-api-key", "k"), "/svc/Call")
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
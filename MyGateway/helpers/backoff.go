@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay before retry number attempt: base doubled for every earlier attempt and capped at max, with
+// "equal jitter" — uniformly random between half and all of that delay, so clients that failed together do not retry in
+// lockstep.
+//
+// Parameters: attempt — 1 for the first retry (values below 1 are treated as 1); base — delay of the first retry; max —
+// upper bound of the delay before jitter.
+//
+// Returns: a delay in [d/2, d], where d = min(base·2^(attempt-1), max); 0 when base ≤ 0.
+//
+// Called from adapters.discovererHTTP (endpoint backoff) and service.connectionPool (watch reconnects, unregister retries).
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	d = min(d, max)
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// Jitter spreads d uniformly over ±fraction of itself (fraction 0.1 — between 90% and 110% of d), so periodic work of
+// many processes started together does not stay aligned.
+//
+// Parameters: d — nominal delay; fraction — relative spread, 0–1.
+//
+// Returns: the jittered delay; d unchanged when d ≤ 0 or fraction ≤ 0.
+//
+// Called from service.connectionPool.refreshLoop.
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	spread := time.Duration(float64(d) * fraction)
+	if d <= 0 || spread <= 0 {
+		return d
+	}
+	return d - spread + rand.N(2*spread+1)
+}
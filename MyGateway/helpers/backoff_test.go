@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		want    time.Duration
+	}{
+		{name: "first_retry", attempt: 1, want: 100 * time.Millisecond},
+		{name: "below_one_is_first", attempt: 0, want: 100 * time.Millisecond},
+		{name: "doubles", attempt: 3, want: 400 * time.Millisecond},
+		{name: "capped", attempt: 40, want: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				got := Backoff(tt.attempt, 100*time.Millisecond, time.Second)
+				assert.GreaterOrEqual(t, got, tt.want/2)
+				assert.LessOrEqual(t, got, tt.want)
+			}
+		})
+	}
+	assert.Zero(t, Backoff(3, 0, time.Second))
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		got := Jitter(time.Second, 0.1)
+		assert.GreaterOrEqual(t, got, 900*time.Millisecond)
+		assert.LessOrEqual(t, got, 1100*time.Millisecond)
+	}
+	assert.Equal(t, time.Second, Jitter(time.Second, 0))
+	assert.Equal(t, time.Duration(0), Jitter(0, 0.1))
+}
@@ -13,63 +13,56 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// AuthRule is an internal rule built from route config: a method prefix and the authorization mode for that prefix.
-// Used for longest-prefix lookup in Process to decide whether to require JWT for the current method.
+// AuthRule is an internal rule built from route config: a method prefix, the authorization mode, allowed roles and the
+// external authz failure policy for that prefix. Used for longest-prefix lookup in Process to decide whether to require
+// JWT (and which roles), an API key or an external decision for the current method.
 type AuthRule struct {
 	Prefix        string
 	Authorization domain.AuthorizationMode
+	AllowedRoles  []string
+	FailOpen      bool
 }
 
 // ConfigurableAuthProcessor implements interfaces.HeaderProcessor. It applies per-route authorization:
 // for methods matching a route with authorization=required it requires session-id and authorization metadata
 // and validates the JWT via JwtService; for authorization=none it passes headers through unchanged.
-// Holds JwtService and a list of AuthRules sorted by prefix length (descending) for longest-prefix match.
+// When claims forwarding is enabled it strips client-supplied x-auth-* headers on every route and injects the
+// validated claims on authorization=required routes (optionally dropping the authorization header).
+// Holds JwtService, auth options and a list of AuthRules sorted by prefix length (descending) for longest-prefix match.
 type ConfigurableAuthProcessor struct {
 	JwtService interfaces.JwtService
+	options    domain.AuthConfig
 	rules      []AuthRule
 }
 
 // NewConfigurableAuthProcessor creates a header processor with per-route authorization: AuthRules are built from routes and sorted by descending prefix length for longest-prefix in Process. Panics on nil jwt.
 //
-// Parameters: jwt — JWT validation service; routes — routes from config (may be empty — then authorization=none for any method).
+// Parameters: jwt — JWT validation service; routes — routes from config (may be empty — then authorization=none for any method); options — gateway-wide auth options (claims forwarding, authorization stripping).
 //
 // Returns: *ConfigurableAuthProcessor implementing interfaces.HeaderProcessor.
 //
 // Called from cmd/main when building the header chain.
-func NewConfigurableAuthProcessor(jwt interfaces.JwtService, routes []domain.Route) *ConfigurableAuthProcessor {
-	rules := make([]AuthRule, 0, len(routes))
-	for _, r := range routes {
-		mode := r.Authorization
-		if mode == "" {
-			mode = domain.AuthorizationNone
-		}
-		rules = append(rules, AuthRule{Prefix: r.Prefix, Authorization: mode})
-	}
-	sort.Slice(rules, func(i, j int) bool {
-		return len(rules[i].Prefix) > len(rules[j].Prefix)
-	})
+func NewConfigurableAuthProcessor(jwt interfaces.JwtService, routes []domain.Route, options domain.AuthConfig) *ConfigurableAuthProcessor {
 	return &ConfigurableAuthProcessor{
 		JwtService: NilPanic(jwt, "helpers.configurable_auth_processor.go: JwtService is required"),
-		rules:      rules,
+		options:    options,
+		rules:      buildAuthRules(routes),
 	}
 }
 
-// Process selects a rule by longest-prefix for method; when authorization=required it extracts session-id and authorization, validates token via JwtService and on success returns headers unchanged, on error returns gRPC status (Unauthenticated or Internal). When authorization=none returns headers unchanged.
+// Process selects a rule by longest-prefix for method; when authorization=required it extracts session-id and authorization, validates token via JwtService and checks the role claim against the route allowed_roles, on success records the login in the access log record and returns headers (with claims injected when forwarding is on), on error returns gRPC status (Unauthenticated, PermissionDenied or Internal). When authorization=none returns headers unchanged (minus client-supplied x-auth-* headers when forwarding is on).
 //
 // Parameters: ctx — request context (passed to JwtService if needed); headers — incoming client metadata; method — full gRPC method name.
 //
-// Returns: (headers, nil) when auth is not required or validation passed; (nil, status.Error) when session-id is missing ("missing session-id"), token missing/invalid ("missing or invalid token") or validation internal error (Internal).
+// Returns: (headers, nil) when auth is not required or validation passed; (nil, status.Error) when session-id is missing ("missing session-id"), token missing/invalid ("missing or invalid token"), role not in allowed_roles (PermissionDenied "role is not allowed for this method") or validation internal error (Internal).
 //
 // Called from HeaderProcessorChain.Process inside TransparentProxy.Handler.
 func (p *ConfigurableAuthProcessor) Process(ctx context.Context, headers metadata.MD, method string) (metadata.MD, error) {
-	mode := domain.AuthorizationNone
-	for _, rule := range p.rules {
-		if strings.HasPrefix(method, rule.Prefix) {
-			mode = rule.Authorization
-			break
-		}
+	rule := matchAuthRule(p.rules, method)
+	if p.options.ForwardClaims {
+		headers = stripTrustedAuthHeaders(headers)
 	}
-	if mode != domain.AuthorizationRequired {
+	if rule.Authorization != domain.AuthorizationRequired {
 		return headers, nil
 	}
 	sessionID, ok := GetSessionID(headers)
@@ -80,12 +73,77 @@ func (p *ConfigurableAuthProcessor) Process(ctx context.Context, headers metadat
 	if !ok {
 		return nil, status.Error(codes.Unauthenticated, "missing or invalid token")
 	}
-	valid, err := p.JwtService.ValidateToken(sessionID, token)
+	claims, valid, err := p.JwtService.ValidateToken(sessionID, token)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	if !valid {
 		return nil, status.Error(codes.Unauthenticated, "missing or invalid token")
 	}
+	if !domain.RoleAllowed(rule.AllowedRoles, claims.Role) {
+		return nil, status.Error(codes.PermissionDenied, "role is not allowed for this method")
+	}
+	domain.AccessRecordFromContext(ctx).SetUser(claims.Login)
+	if p.options.ForwardClaims {
+		headers.Set(HeaderAuthLogin, claims.Login)
+		headers.Set(HeaderAuthRole, claims.Role)
+		headers.Set(HeaderAuthSessionID, claims.SessionID)
+		if p.options.StripAuthorization {
+			headers.Delete(HeaderAuthorization)
+		}
+	}
 	return headers, nil
 }
+
+// buildAuthRules converts routes to AuthRules (empty authorization — none) sorted by descending prefix length for longest-prefix match.
+//
+// Parameter routes — routes from config.
+//
+// Returns: rules, longest prefix first.
+//
+// Called from NewConfigurableAuthProcessor, NewAPIKeyProcessor and NewExternalAuthProcessor.
+func buildAuthRules(routes []domain.Route) []AuthRule {
+	rules := make([]AuthRule, 0, len(routes))
+	for _, r := range routes {
+		mode := r.Authorization
+		if mode == "" {
+			mode = domain.AuthorizationNone
+		}
+		rules = append(rules, AuthRule{Prefix: r.Prefix, Authorization: mode, AllowedRoles: r.AllowedRoles, FailOpen: r.FailOpen})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return len(rules[i].Prefix) > len(rules[j].Prefix)
+	})
+	return rules
+}
+
+// matchAuthRule returns the first rule (rules sorted by buildAuthRules) whose prefix matches method.
+//
+// Parameters: rules — sorted rules; method — full gRPC method name.
+//
+// Returns: the matching rule; a rule with authorization=none when nothing matches.
+//
+// Called from ConfigurableAuthProcessor.Process, APIKeyProcessor.Process and ExternalAuthProcessor.Process.
+func matchAuthRule(rules []AuthRule, method string) AuthRule {
+	for _, rule := range rules {
+		if strings.HasPrefix(method, rule.Prefix) {
+			return rule
+		}
+	}
+	return AuthRule{Authorization: domain.AuthorizationNone}
+}
+
+// stripTrustedAuthHeaders returns a copy of headers without the gateway-owned x-auth-* keys so a client cannot spoof claims.
+//
+// Parameter headers — incoming metadata (nil allowed — an empty MD is returned).
+//
+// Returns: new metadata.MD; the input is not mutated.
+//
+// Called only from ConfigurableAuthProcessor.Process when ForwardClaims is enabled.
+func stripTrustedAuthHeaders(headers metadata.MD) metadata.MD {
+	out := headers.Copy()
+	for _, key := range TrustedAuthHeaders {
+		out.Delete(key)
+	}
+	return out
+}
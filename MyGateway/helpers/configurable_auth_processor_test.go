@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"mygateway/auth"
 	"mygateway/domain"
 	"mygateway/interfaces/mock"
 
@@ -18,7 +19,7 @@ import (
 func TestNewConfigurableAuthProcessor_Panics(t *testing.T) {
 	t.Run("jwt_nil", func(t *testing.T) {
 		assert.PanicsWithValue(t, "helpers.configurable_auth_processor.go: JwtService is required", func() {
-			NewConfigurableAuthProcessor(nil, []domain.Route{})
+			NewConfigurableAuthProcessor(nil, []domain.Route{}, domain.AuthConfig{})
 		})
 	})
 }
@@ -126,11 +127,12 @@ func TestConfigurableAuthProcessor_Process(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			jwtMock := &mock.JwtServiceMock{
-				ValidateTokenFunc: func(sessionID string, token string) (bool, error) {
-					return tt.jwtValidate(sessionID, token)
+				ValidateTokenFunc: func(sessionID string, token string) (auth.TokenClaims, bool, error) {
+					ok, err := tt.jwtValidate(sessionID, token)
+					return auth.TokenClaims{SessionID: sessionID}, ok, err
 				},
 			}
-			p := NewConfigurableAuthProcessor(jwtMock, tt.routes)
+			p := NewConfigurableAuthProcessor(jwtMock, tt.routes, domain.AuthConfig{})
 			out, err := p.Process(ctx, tt.headers, tt.method)
 			if tt.wantPassthrough {
 				require.NoError(t, err)
@@ -144,3 +146,121 @@ func TestConfigurableAuthProcessor_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigurableAuthProcessor_ForwardClaims(t *testing.T) {
+	ctx := context.Background()
+	routes := []domain.Route{
+		{Prefix: "/svc/Login", Cluster: "c1", Authorization: domain.AuthorizationNone},
+		{Prefix: "/svc/Secure", Cluster: "c2", Authorization: domain.AuthorizationRequired},
+	}
+	jwtMock := &mock.JwtServiceMock{
+		ValidateTokenFunc: func(sessionID string, token string) (auth.TokenClaims, bool, error) {
+			return auth.TokenClaims{Login: "alice", Role: "admin", SessionID: sessionID}, true, nil
+		},
+	}
+
+	tests := []struct {
+		name    string
+		options domain.AuthConfig
+		headers metadata.MD
+		method  string
+		wantMD  metadata.MD
+	}{
+		{
+			name:    "disabled_keeps_client_headers",
+			options: domain.AuthConfig{},
+			headers: metadata.Pairs("session-id", "s1", "authorization", "t1", "x-auth-role", "root"),
+			method:  "/svc/Secure/Call",
+			wantMD:  metadata.Pairs("session-id", "s1", "authorization", "t1", "x-auth-role", "root"),
+		},
+		{
+			name:    "required_injects_claims_over_spoofed_headers",
+			options: domain.AuthConfig{ForwardClaims: true},
+			headers: metadata.Pairs("session-id", "s1", "authorization", "t1", "x-auth-role", "root", "x-auth-login", "mallory"),
+			method:  "/svc/Secure/Call",
+			wantMD: metadata.Pairs(
+				"session-id", "s1", "authorization", "t1",
+				"x-auth-login", "alice", "x-auth-role", "admin", "x-auth-session-id", "s1",
+			),
+		},
+		{
+			name:    "required_strip_authorization",
+			options: domain.AuthConfig{ForwardClaims: true, StripAuthorization: true},
+			headers: metadata.Pairs("session-id", "s1", "authorization", "t1"),
+			method:  "/svc/Secure/Call",
+			wantMD:  metadata.Pairs("session-id", "s1", "x-auth-login", "alice", "x-auth-role", "admin", "x-auth-session-id", "s1"),
+		},
+		{
+			name:    "none_strips_spoofed_headers_without_injecting",
+			options: domain.AuthConfig{ForwardClaims: true, StripAuthorization: true},
+			headers: metadata.Pairs("authorization", "t1", "x-auth-login", "mallory", "x-auth-session-id", "s9"),
+			method:  "/svc/Login",
+			wantMD:  metadata.Pairs("authorization", "t1"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.headers.Copy()
+			p := NewConfigurableAuthProcessor(jwtMock, routes, tt.options)
+			out, err := p.Process(ctx, tt.headers, tt.method)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMD, out)
+			assert.Equal(t, input, tt.headers, "input headers must not be mutated")
+		})
+	}
+}
+
+func TestConfigurableAuthProcessor_AllowedRoles(t *testing.T) {
+	ctx := context.Background()
+	headers := metadata.Pairs("session-id", "s1", "authorization", "t1")
+
+	tests := []struct {
+		name         string
+		allowedRoles []string
+		tokenRole    string
+		wantCode     codes.Code
+	}{
+		{name: "role_listed", allowedRoles: []string{"admin", "operator"}, tokenRole: "operator", wantCode: codes.OK},
+		{name: "role_mismatch", allowedRoles: []string{"admin", "operator"}, tokenRole: "viewer", wantCode: codes.PermissionDenied},
+		{name: "missing_role", allowedRoles: []string{"admin"}, tokenRole: "", wantCode: codes.PermissionDenied},
+		{name: "wildcard_role", allowedRoles: []string{domain.AnyRole}, tokenRole: "viewer", wantCode: codes.OK},
+		{name: "wildcard_missing_role", allowedRoles: []string{domain.AnyRole}, tokenRole: "", wantCode: codes.PermissionDenied},
+		{name: "no_restriction", allowedRoles: nil, tokenRole: "", wantCode: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtMock := &mock.JwtServiceMock{
+				ValidateTokenFunc: func(sessionID string, token string) (auth.TokenClaims, bool, error) {
+					return auth.TokenClaims{Login: "u", Role: tt.tokenRole, SessionID: sessionID}, true, nil
+				},
+			}
+			routes := []domain.Route{
+				{Prefix: "/svc/Admin", Cluster: "c1", Authorization: domain.AuthorizationRequired, AllowedRoles: tt.allowedRoles},
+			}
+			p := NewConfigurableAuthProcessor(jwtMock, routes, domain.AuthConfig{})
+			out, err := p.Process(ctx, headers, "/svc/Admin/Do")
+			if tt.wantCode == codes.OK {
+				require.NoError(t, err)
+				require.NotNil(t, out)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestConfigurableAuthProcessor_AllowedRoles_InvalidTokenIsUnauthenticated(t *testing.T) {
+	jwtMock := &mock.JwtServiceMock{
+		ValidateTokenFunc: func(sessionID string, token string) (auth.TokenClaims, bool, error) {
+			return auth.TokenClaims{}, false, nil
+		},
+	}
+	routes := []domain.Route{
+		{Prefix: "/svc/Admin", Cluster: "c1", Authorization: domain.AuthorizationRequired, AllowedRoles: []string{"admin"}},
+	}
+	p := NewConfigurableAuthProcessor(jwtMock, routes, domain.AuthConfig{})
+	_, err := p.Process(context.Background(), metadata.Pairs("session-id", "s1", "authorization", "t1"), "/svc/Admin/Do")
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,199 @@
+package helpers
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/interfaces"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultExternalAuthCacheMaxEntries bounds the decision cache when auth.external.cache_max_entries is not set.
+const DefaultExternalAuthCacheMaxEntries = 10000
+
+// ExternalAuthProcessor implements interfaces.HeaderProcessor for routes with authorization=external. It sends the method,
+// metadata, peer and matched route to an external Authorizer (policy engine) and applies its decision: on allow the
+// returned headers are added/removed, on deny the call is rejected with the returned status. Decisions are cached by
+// method, route and the configured cache key (metadata values and optionally the peer) for CacheTTL. When the Authorizer
+// fails the route FailOpen flag decides: forward unchanged (fail-open) or reject with Unavailable (fail-closed).
+// Under mu: cache (decision by hashed key with expiry).
+type ExternalAuthProcessor struct {
+	authorizer   interfaces.Authorizer
+	timeProvider interfaces.TimeProvider
+	logger       log.Logger
+	options      domain.ExternalAuthConfig
+	rules        []AuthRule
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedAuthzDecision
+}
+
+// cachedAuthzDecision is one decision cache entry with its expiry time.
+type cachedAuthzDecision struct {
+	decision  domain.AuthzDecision
+	expiresAt time.Time
+}
+
+// NewExternalAuthProcessor creates a header processor for authorization=external routes. Panics on nil authorizer, timeProvider or logger.
+//
+// Parameters: authorizer — external authz client; timeProvider — source of current time for cache expiry; logger — logs fail-open
+// decisions; routes — routes from config (same longest-prefix rules as ConfigurableAuthProcessor); options — cache key, TTL
+// (zero — no caching) and max entries (zero — DefaultExternalAuthCacheMaxEntries).
+//
+// Returns: *ExternalAuthProcessor implementing interfaces.HeaderProcessor.
+//
+// Called from cmd/main when building the header chain (when auth.external.address is set).
+func NewExternalAuthProcessor(authorizer interfaces.Authorizer, timeProvider interfaces.TimeProvider, logger log.Logger, routes []domain.Route, options domain.ExternalAuthConfig) *ExternalAuthProcessor {
+	if options.CacheMaxEntries <= 0 {
+		options.CacheMaxEntries = DefaultExternalAuthCacheMaxEntries
+	}
+	return &ExternalAuthProcessor{
+		authorizer:   NilPanic(authorizer, "helpers.external_auth_processor.go: Authorizer is required"),
+		timeProvider: NilPanic(timeProvider, "helpers.external_auth_processor.go: time provider is required"),
+		logger:       NilPanic(logger, "helpers.external_auth_processor.go: logger is required"),
+		options:      options,
+		rules:        buildAuthRules(routes),
+		cache:        make(map[[sha256.Size]byte]cachedAuthzDecision),
+	}
+}
+
+// Process selects a rule by longest-prefix for method; when authorization=external it obtains a decision (cache or
+// Authorizer.Check) and applies it. Other routes pass through unchanged.
+//
+// Parameters: ctx — request context (peer address is read from it; passed to the Authorizer); headers — metadata from the
+// previous processor; method — full gRPC method name.
+//
+// Returns: (headers, nil) when the route is not external or the call is allowed (with headers added/removed), or when the
+// Authorizer failed on a fail-open route; (nil, status.Error) when denied (decision code, default PermissionDenied) or
+// when the Authorizer failed on a fail-closed route (Unavailable "external authorization unavailable").
+//
+// Called from HeaderProcessorChain.Process inside TransparentProxy.Handler.
+func (p *ExternalAuthProcessor) Process(ctx context.Context, headers metadata.MD, method string) (metadata.MD, error) {
+	rule := matchAuthRule(p.rules, method)
+	if rule.Authorization != domain.AuthorizationExternal {
+		return headers, nil
+	}
+	peerAddr := ""
+	if pr, ok := peer.FromContext(ctx); ok && pr.Addr != nil {
+		peerAddr = pr.Addr.String()
+	}
+	key := p.cacheKey(headers, method, rule.Prefix, peerAddr)
+	decision, ok := p.cached(key)
+	if !ok {
+		var err error
+		decision, err = p.authorizer.Check(ctx, domain.AuthzRequest{
+			Method:   method,
+			Metadata: headers,
+			Peer:     peerAddr,
+			Route:    rule.Prefix,
+		})
+		if err != nil {
+			if rule.FailOpen {
+				level.Warn(p.logger).Log("msg", "external authorization failed, failing open", "method", method, "route", rule.Prefix, "err", err)
+				return headers, nil
+			}
+			return nil, status.Error(codes.Unavailable, "external authorization unavailable")
+		}
+		p.store(key, decision)
+	}
+	if !decision.Allow {
+		code := codes.Code(decision.DenyCode)
+		if code == codes.OK || code > codes.Unauthenticated {
+			code = codes.PermissionDenied
+		}
+		msg := decision.DenyMessage
+		if msg == "" {
+			msg = "denied by external authorization"
+		}
+		return nil, status.Error(code, msg)
+	}
+	if len(decision.HeadersToAdd) == 0 && len(decision.HeadersToRemove) == 0 {
+		return headers, nil
+	}
+	out := headers.Copy()
+	for _, k := range decision.HeadersToRemove {
+		out.Delete(k)
+	}
+	for k, v := range decision.HeadersToAdd {
+		out.Set(k, v...)
+	}
+	return out, nil
+}
+
+// cacheKey hashes method, route and the values of the configured cache key entries (metadata keys or ExternalAuthCacheKeyPeer).
+//
+// Parameters: headers — request metadata; method — full method; route — matched prefix; peerAddr — client address.
+//
+// Returns: SHA-256 of the composite key (raw tokens are not kept in memory as map keys).
+//
+// Called only from Process.
+func (p *ExternalAuthProcessor) cacheKey(headers metadata.MD, method, route, peerAddr string) [sha256.Size]byte {
+	var b strings.Builder
+	b.WriteString(method)
+	b.WriteByte(0)
+	b.WriteString(route)
+	for _, k := range p.options.CacheKey {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		if k == domain.ExternalAuthCacheKeyPeer {
+			b.WriteString(peerAddr)
+			continue
+		}
+		b.WriteString(strings.Join(headers.Get(k), "\x01"))
+	}
+	return sha256.Sum256([]byte(b.String()))
+}
+
+// cached returns a non-expired cached decision; expired entries are dropped.
+//
+// Called only from Process.
+func (p *ExternalAuthProcessor) cached(key [sha256.Size]byte) (domain.AuthzDecision, bool) {
+	if p.options.CacheTTL <= 0 {
+		return domain.AuthzDecision{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.cache[key]
+	if !ok {
+		return domain.AuthzDecision{}, false
+	}
+	if !p.timeProvider.Now().Before(entry.expiresAt) {
+		delete(p.cache, key)
+		return domain.AuthzDecision{}, false
+	}
+	return entry.decision, true
+}
+
+// store caches decision for CacheTTL. When the cache is full expired entries are pruned; if it is still full the decision is not cached.
+//
+// Called only from Process after a successful Authorizer.Check.
+func (p *ExternalAuthProcessor) store(key [sha256.Size]byte, decision domain.AuthzDecision) {
+	if p.options.CacheTTL <= 0 {
+		return
+	}
+	now := p.timeProvider.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.cache) >= p.options.CacheMaxEntries {
+		for k, e := range p.cache {
+			if !now.Before(e.expiresAt) {
+				delete(p.cache, k)
+			}
+		}
+		if len(p.cache) >= p.options.CacheMaxEntries {
+			return
+		}
+	}
+	p.cache[key] = cachedAuthzDecision{decision: decision, expiresAt: now.Add(p.options.CacheTTL)}
+}
@@ -0,0 +1,199 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/interfaces/mock"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewExternalAuthProcessor_Panics(t *testing.T) {
+	tp := &mock.TimeProviderMock{}
+	authorizer := &mock.AuthorizerMock{}
+	t.Run("authorizer_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "helpers.external_auth_processor.go: Authorizer is required", func() {
+			NewExternalAuthProcessor(nil, tp, log.NewNopLogger(), nil, domain.ExternalAuthConfig{})
+		})
+	})
+	t.Run("time_provider_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "helpers.external_auth_processor.go: time provider is required", func() {
+			NewExternalAuthProcessor(authorizer, nil, log.NewNopLogger(), nil, domain.ExternalAuthConfig{})
+		})
+	})
+	t.Run("logger_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "helpers.external_auth_processor.go: logger is required", func() {
+			NewExternalAuthProcessor(authorizer, tp, nil, nil, domain.ExternalAuthConfig{})
+		})
+	})
+}
+
+func TestExternalAuthProcessor_Process(t *testing.T) {
+	routes := []domain.Route{
+		{Prefix: "/svc.Svc/", Cluster: "c1", Authorization: domain.AuthorizationExternal},
+		{Prefix: "/svc.Svc/Open", Cluster: "c1", Authorization: domain.AuthorizationExternal, FailOpen: true},
+		{Prefix: "/public.Public/", Cluster: "c1", Authorization: domain.AuthorizationNone},
+	}
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4321}})
+
+	tests := []struct {
+		name       string
+		method     string
+		decision   domain.AuthzDecision
+		checkErr   error
+		wantCode   codes.Code
+		wantMsg    string
+		wantCalled bool
+		check      func(t *testing.T, out metadata.MD)
+	}{
+		{
+			name: "not_external_passthrough", method: "/public.Public/Get",
+			check: func(t *testing.T, out metadata.MD) { assert.Equal(t, []string{"tok"}, out.Get("authorization")) },
+		},
+		{
+			name: "allow_adds_and_removes_headers", method: "/svc.Svc/Call", wantCalled: true,
+			decision: domain.AuthzDecision{Allow: true, HeadersToAdd: map[string][]string{"X-Tenant": {"t1"}}, HeadersToRemove: []string{"authorization"}},
+			check: func(t *testing.T, out metadata.MD) {
+				assert.Empty(t, out.Get("authorization"))
+				assert.Equal(t, []string{"t1"}, out.Get("x-tenant"))
+			},
+		},
+		{
+			name: "deny_default_code", method: "/svc.Svc/Call", wantCalled: true,
+			decision: domain.AuthzDecision{}, wantCode: codes.PermissionDenied, wantMsg: "denied by external authorization",
+		},
+		{
+			name: "deny_custom_status", method: "/svc.Svc/Call", wantCalled: true,
+			decision: domain.AuthzDecision{DenyCode: uint32(codes.ResourceExhausted), DenyMessage: "quota exceeded"}, wantCode: codes.ResourceExhausted, wantMsg: "quota exceeded",
+		},
+		{
+			name: "deny_invalid_code_is_permission_denied", method: "/svc.Svc/Call", wantCalled: true,
+			decision: domain.AuthzDecision{DenyCode: 99}, wantCode: codes.PermissionDenied,
+		},
+		{
+			name: "error_fail_closed", method: "/svc.Svc/Call", wantCalled: true,
+			checkErr: errors.New("unreachable"), wantCode: codes.Unavailable, wantMsg: "external authorization unavailable",
+		},
+		{
+			name: "error_fail_open", method: "/svc.Svc/OpenCall", wantCalled: true,
+			checkErr: errors.New("unreachable"),
+			check:    func(t *testing.T, out metadata.MD) { assert.Equal(t, []string{"tok"}, out.Get("authorization")) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizer := &mock.AuthorizerMock{CheckFunc: func(_ context.Context, req domain.AuthzRequest) (domain.AuthzDecision, error) {
+				assert.Equal(t, tt.method, req.Method)
+				assert.Equal(t, "10.0.0.1:4321", req.Peer)
+				assert.Equal(t, []string{"tok"}, req.Metadata["authorization"])
+				return tt.decision, tt.checkErr
+			}}
+			p := NewExternalAuthProcessor(authorizer, tp, log.NewNopLogger(), routes, domain.ExternalAuthConfig{})
+			in := metadata.Pairs("authorization", "tok")
+			out, err := p.Process(ctx, in, tt.method)
+			assert.Equal(t, tt.wantCalled, len(authorizer.CheckCalls()) == 1)
+			if tt.wantCode != codes.OK {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantCode, status.Code(err))
+				if tt.wantMsg != "" {
+					assert.Equal(t, tt.wantMsg, status.Convert(err).Message())
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"tok"}, in.Get("authorization"), "input is not mutated")
+			tt.check(t, out)
+		})
+	}
+}
+
+func TestExternalAuthProcessor_Route(t *testing.T) {
+	routes := []domain.Route{
+		{Prefix: "/svc.Svc/", Cluster: "c1", Authorization: domain.AuthorizationExternal},
+		{Prefix: "/svc.Svc/Admin", Cluster: "c1", Authorization: domain.AuthorizationExternal},
+	}
+	var gotRoute string
+	authorizer := &mock.AuthorizerMock{CheckFunc: func(_ context.Context, req domain.AuthzRequest) (domain.AuthzDecision, error) {
+		gotRoute = req.Route
+		return domain.AuthzDecision{Allow: true}, nil
+	}}
+	p := NewExternalAuthProcessor(authorizer, &mock.TimeProviderMock{NowFunc: time.Now}, log.NewNopLogger(), routes, domain.ExternalAuthConfig{})
+	_, err := p.Process(context.Background(), metadata.MD{}, "/svc.Svc/AdminDelete")
+	require.NoError(t, err)
+	assert.Equal(t, "/svc.Svc/Admin", gotRoute, "longest prefix is sent as route")
+}
+
+func TestExternalAuthProcessor_Cache(t *testing.T) {
+	now := time.Date(2026, 2, 11, 12, 0, 0, 0, time.UTC)
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
+	routes := []domain.Route{{Prefix: "/svc.Svc/", Cluster: "c1", Authorization: domain.AuthorizationExternal}}
+	authorizer := &mock.AuthorizerMock{CheckFunc: func(_ context.Context, req domain.AuthzRequest) (domain.AuthzDecision, error) {
+		return domain.AuthzDecision{Allow: req.Metadata["x-user"][0] == "alice"}, nil
+	}}
+	p := NewExternalAuthProcessor(authorizer, tp, log.NewNopLogger(), routes, domain.ExternalAuthConfig{
+		CacheKey: []string{"x-user", domain.ExternalAuthCacheKeyPeer},
+		CacheTTL: time.Minute,
+	})
+	ctx := context.Background()
+	call := func(user, method string) error {
+		_, err := p.Process(ctx, metadata.Pairs("x-user", user), method)
+		return err
+	}
+
+	require.NoError(t, call("alice", "/svc.Svc/A"))
+	require.NoError(t, call("alice", "/svc.Svc/A"))
+	assert.Len(t, authorizer.CheckCalls(), 1, "second call served from cache")
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(call("bob", "/svc.Svc/A")))
+	assert.Equal(t, codes.PermissionDenied, status.Code(call("bob", "/svc.Svc/A")))
+	assert.Len(t, authorizer.CheckCalls(), 2, "deny decisions are cached per key")
+
+	require.NoError(t, call("alice", "/svc.Svc/B"))
+	assert.Len(t, authorizer.CheckCalls(), 3, "method is part of the key")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, call("alice", "/svc.Svc/A"))
+	assert.Len(t, authorizer.CheckCalls(), 4, "expired entry is re-checked")
+}
+
+func TestExternalAuthProcessor_CacheErrorsAndLimit(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	routes := []domain.Route{{Prefix: "/svc.Svc/", Cluster: "c1", Authorization: domain.AuthorizationExternal}}
+	fail := true
+	authorizer := &mock.AuthorizerMock{CheckFunc: func(context.Context, domain.AuthzRequest) (domain.AuthzDecision, error) {
+		if fail {
+			return domain.AuthzDecision{}, errors.New("unreachable")
+		}
+		return domain.AuthzDecision{Allow: true}, nil
+	}}
+	p := NewExternalAuthProcessor(authorizer, tp, log.NewNopLogger(), routes, domain.ExternalAuthConfig{
+		CacheKey:        []string{"x-user"},
+		CacheTTL:        time.Minute,
+		CacheMaxEntries: 1,
+	})
+	ctx := context.Background()
+
+	_, err := p.Process(ctx, metadata.Pairs("x-user", "a"), "/svc.Svc/A")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	fail = false
+	_, err = p.Process(ctx, metadata.Pairs("x-user", "a"), "/svc.Svc/A")
+	require.NoError(t, err, "errors are not cached")
+
+	_, err = p.Process(ctx, metadata.Pairs("x-user", "b"), "/svc.Svc/A")
+	require.NoError(t, err)
+	_, err = p.Process(ctx, metadata.Pairs("x-user", "b"), "/svc.Svc/A")
+	require.NoError(t, err)
+	assert.Len(t, authorizer.CheckCalls(), 4, "full cache does not store new decisions")
+}
@@ -12,13 +12,31 @@ const HeaderSessionID = "session-id"
 // HeaderAuthorization is the gRPC metadata key for the raw token value (no "Bearer " prefix).
 const HeaderAuthorization = "authorization"
 
+// HeaderAuthLogin is the trusted gRPC metadata key with the login claim injected by the gateway after JWT validation.
+const HeaderAuthLogin = "x-auth-login"
+
+// HeaderAuthRole is the trusted gRPC metadata key with the role claim injected by the gateway after JWT validation.
+const HeaderAuthRole = "x-auth-role"
+
+// HeaderAuthSessionID is the trusted gRPC metadata key with the session_id claim injected by the gateway after JWT validation.
+const HeaderAuthSessionID = "x-auth-session-id"
+
+// HeaderAuthAPIKeyName is the trusted gRPC metadata key with the name of the API key that authenticated the call (authorization=api_key routes).
+const HeaderAuthAPIKeyName = "x-auth-api-key-name"
+
+// DefaultAPIKeyHeader is the metadata key carrying the raw API key when auth.api_key.header is not set.
+const DefaultAPIKeyHeader = "x-api-key"
+
+// TrustedAuthHeaders lists the metadata keys only the gateway may set; client-supplied copies are stripped before forwarding.
+var TrustedAuthHeaders = []string{HeaderAuthLogin, HeaderAuthRole, HeaderAuthSessionID, HeaderAuthAPIKeyName}
+
 // GetHeaderValue returns the first value of header key in metadata. Key is lowercased (gRPC canonicalizes keys).
 //
 // Parameters: md — incoming or outgoing metadata (nil allowed — returns ("", false)); key — header name (empty string gives ("", false)).
 //
 // Returns: (value, true) when there is a non-empty value; ("", false) when md is nil, key is missing or value is empty.
 //
-// Called from GetSessionID, GetAuthToken and connectionResolverGeneric.GetConnection when reading sticky header.
+// Called from GetSessionID, GetAuthToken and connectionResolverGeneric.GetConnection when reading sticky and subset headers.
 func GetHeaderValue(md metadata.MD, key string) (string, bool) {
 	if md == nil {
 		return "", false
@@ -0,0 +1,19 @@
+package interfaces
+
+import "mygateway/auth"
+
+// APIKeyStore looks up API keys for routes with authorization=api_key.
+//
+// Lookup receives the raw key from the request header and compares its hash with the stored hashes;
+// the store never holds raw keys. Implementations may reload the store in the background or on change.
+//
+// Implemented by adapters.APIKeyFile. Called from helpers.APIKeyProcessor.Process.
+//
+//go:generate moq -stub -out mock/api_key_store.go -pkg mock . APIKeyStore
+type APIKeyStore interface {
+	// Lookup finds the key matching rawKey.
+	// Parameters: rawKey — value of the API key header.
+	// Returns: (key, true, nil) when found (expiry is not checked); (zero, false, nil) when unknown; (zero, false, error) when the store cannot be loaded.
+	// Called from helpers.APIKeyProcessor.Process.
+	Lookup(rawKey string) (auth.APIKey, bool, error)
+}
@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+
+	"mygateway/domain"
+)
+
+// Authorizer asks an external policy engine whether a call may proceed (routes with authorization=external).
+//
+// Check sends the method, metadata, peer and route and returns the decision; transport failures are
+// returned as errors so the caller can apply the route fail-open/fail-closed policy.
+//
+// Implemented by adapters.AuthzGRPC. Called from helpers.ExternalAuthProcessor.Process on a decision cache miss.
+//
+//go:generate moq -stub -out mock/authorizer.go -pkg mock . Authorizer
+type Authorizer interface {
+	// Check returns the authz decision for one call.
+	// Parameters: ctx — request context with the call timeout; req — method, metadata, peer and matched route.
+	// Returns: (decision, nil) when the service answered (allow or deny); (zero, error) when it could not be reached or failed.
+	// Called from helpers.ExternalAuthProcessor.Process.
+	Check(ctx context.Context, req domain.AuthzRequest) (domain.AuthzDecision, error)
+}
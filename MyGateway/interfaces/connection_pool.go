@@ -2,6 +2,9 @@ package interfaces
 
 import (
 	"context"
+	"time"
+
+	"mygateway/domain"
 
 	"google.golang.org/grpc"
 )
@@ -13,32 +16,53 @@ import (
 // used when the route does not require sticky sessions.
 // GetConnForKey returns a connection bound to the given key (e.g. session-id value);
 // the same key always gets the same instance until OnBackendFailure or instance removal.
+// Both take a label selector (route subset): only instances whose labels match are candidates.
 // OnBackendFailure unbinds the key from the instance, closes the connection to that
 // instance, and notifies the discoverer (e.g. UnregisterInstance).
+// ReleaseKeys unbinds keys (e.g. revoked sessions) without touching the instances.
+// InstancesAge reports the age of the instance list (time since the last successful discovery).
+// ReleaseStream frees the stream slot taken by a successful GetConnection* when the pool limits concurrent
+// streams per instance.
 // Close closes all connections and stops the pool; idempotent.
 //
 // Called by service.connectionResolverGeneric (GetConnection delegates to GetConnectionRoundRobin or
-// GetConnectionForKey; OnBackendFailure and Close are called by the resolver on behalf of the proxy).
+// GetConnectionForKey; OnBackendFailure, ReleaseStream and Close are called by the resolver on behalf of the proxy;
+// ReleaseKeys is called by the resolver when sessions are revoked).
 //
 //go:generate moq -stub -out mock/connection_pool.go -pkg mock . ConnectionPool
 type ConnectionPool interface {
 	// GetConnectionRoundRobin returns a connection to the next instance in round-robin order; used for routes without sticky sessions.
-	// Parameter ctx — context for dial when creating a new connection; cancel/timeout lead to factory error.
-	// Returns: (conn, instanceID, nil) on success; (nil, "", err) when pool is closed (ErrConnPoolClosed), no instances or dial error (ErrNoAvailableConnInstance, etc.).
+	// Parameters: ctx — context for dial when creating a new connection; cancel/timeout lead to factory error; selector — labels the instance must carry (nil — any instance); each selector has its own round-robin position.
+	// Returns: (conn, instanceID, nil) on success; (nil, "", err) when pool is closed (ErrConnPoolClosed), no (matching) instances or dial error (ErrNoAvailableConnInstance, etc.), every instance at the stream limit after the queue timeout (ErrInstancesSaturated).
 	// Called from service.connectionResolverGeneric.GetConnection when route.Balancer.Type != sticky_sessions.
-	GetConnectionRoundRobin(ctx context.Context) (conn *grpc.ClientConn, instanceID string, err error)
+	GetConnectionRoundRobin(ctx context.Context, selector domain.LabelSelector) (conn *grpc.ClientConn, instanceID string, err error)
 
 	// GetConnectionForKey returns a connection bound to the sticky key (e.g. session-id); the same key gets the same instance until OnBackendFailure or instance removal.
-	// Parameters: ctx — for dial when creating connection; key — sticky header value (e.g. session-id). Empty key usually yields ErrNoAvailableConnInstance.
-	// Returns: (conn, instanceID, nil) on success; (nil, "", err) when pool closed, empty key, no free instance or dial error.
+	// Parameters: ctx — for dial when creating connection; key — sticky header value (e.g. session-id). Empty key usually yields ErrNoAvailableConnInstance; selector — labels the instance must carry (nil — any instance); a key bound to an instance outside the selector is rebound.
+	// Returns: (conn, instanceID, nil) on success; (nil, "", err) when pool closed, empty key, no free instance, dial error or the instance at the stream limit after the queue timeout.
 	// Called from service.connectionResolverGeneric.GetConnection when route.Balancer.Type == sticky_sessions.
-	GetConnectionForKey(ctx context.Context, key string) (conn *grpc.ClientConn, instanceID string, err error)
+	GetConnectionForKey(ctx context.Context, key string, selector domain.LabelSelector) (conn *grpc.ClientConn, instanceID string, err error)
 
-	// OnBackendFailure unbinds the key from the instance (if key non-empty), closes the connection to instanceID, removes the instance from the list and calls discoverer.UnregisterInstance(instanceID).
+	// OnBackendFailure unbinds the key from the instance (if key non-empty), closes the connection to instanceID, removes the instance from the list and queues discoverer.UnregisterInstance(instanceID) (sent in the background, retried on error).
 	// Parameters: key — sticky key of the failed request (empty string allowed, then only close/unregister); instanceID — identifier of the instance that failed.
 	// Called from service.connectionResolverGeneric.OnBackendFailure on stream or dial failure to the backend.
 	OnBackendFailure(key string, instanceID string)
 
+	// ReleaseKeys drops the sticky bindings of keys so their instances become free for other keys; connections stay open and instances stay registered.
+	// Parameters: keys — sticky key values (e.g. revoked session IDs); unknown keys are ignored.
+	// Called from service.connectionResolverGeneric.ReleaseStickyKeys when the revocation store reports newly revoked sessions.
+	ReleaseKeys(keys []string)
+
+	// ReleaseStream frees one stream slot of instanceID taken by GetConnectionRoundRobin/GetConnectionForKey and wakes calls waiting for a slot; no-op without a stream limit.
+	// Parameter instanceID — instance returned by the GetConnection* call.
+	// Called from service.connectionResolverGeneric.ReleaseStream when the proxy closes the backend stream (or failed to open it).
+	ReleaseStream(instanceID string)
+
+	// InstancesAge reports how long ago the instance list was obtained from the discoverer (a last-known-good snapshot loaded at startup counts from when it was saved).
+	// Returns: (age, true); (0, false) when the pool never had a list.
+	// Called from cmd/main at startup and by the pool itself when logging discoverer errors.
+	InstancesAge() (age time.Duration, ok bool)
+
 	// Close closes all pool connections and marks the pool closed; idempotent. Subsequent GetConnection* return ErrConnPoolClosed.
 	// Returns: nil (errors from closing individual connections are not aggregated).
 	// Called from service.connectionResolverGeneric.Close on shutdown (cmd/main defer).
@@ -12,7 +12,7 @@ import (
 // ConnectionResolver provides a backend gRPC connection for a (route, headers) and reports backend failures.
 // GetConnection returns a *grpc.ClientConn, the sticky key (if any), and the instance ID; OnBackendFailure
 // notifies the resolver that a backend stream failed so it can unbind sticky sessions and close/unregister
-// the instance. Implemented by service.connectionResolverGeneric. Called from service.TransparentProxy.Handler for every request and on stream errors.
+// the instance; ReleaseStream returns the stream slot taken by GetConnection once the backend stream is closed. Implemented by service.connectionResolverGeneric. Called from service.TransparentProxy.Handler for every request and on stream errors.
 //
 //go:generate moq -stub -out mock/connection_resolver.go -pkg mock . ConnectionResolver
 type ConnectionResolver interface {
@@ -26,4 +26,9 @@ type ConnectionResolver interface {
 	// Parameters: route — request route; stickyKey — sticky key from the failed request (may be empty); instanceID — identifier of the instance that failed.
 	// Called from service.TransparentProxy.Handler on NewStream error or stream message forward error.
 	OnBackendFailure(route domain.Route, stickyKey, instanceID string)
+
+	// ReleaseStream reports that the backend stream opened on a connection from GetConnection is finished, freeing its slot in the per-instance stream limit (no-op for static clusters and pools without a limit).
+	// Parameters: route — request route; instanceID — instance identifier returned by GetConnection. Must be called exactly once per successful GetConnection.
+	// Called from service.TransparentProxy.Handler when the backend stream ends, fails to open or is replaced by a transfer.
+	ReleaseStream(route domain.Route, instanceID string)
 }
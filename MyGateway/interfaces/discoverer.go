@@ -1,6 +1,10 @@
 package interfaces
 
-import "mygateway/domain"
+import (
+	"context"
+
+	"mygateway/domain"
+)
 
 // Discoverer provides the list of backend instances for dynamic clusters and supports
 // unregistering an instance on failure.
@@ -10,20 +14,39 @@ import "mygateway/domain"
 // (e.g. POST /v1/unregister/{instance_id}), typically called when a backend connection
 // fails so the instance can be removed or marked unhealthy.
 //
-// Implemented by adapters.DiscovererHTTP. Called from service.connectionPool in refresh (GetInstances)
-// and in OnBackendFailure (UnregisterInstance).
+// Implemented by adapters.DiscovererHTTP, adapters.DiscovererDNS, adapters.DiscovererFile and
+// adapters.DiscovererKubernetes (the last three treat UnregisterInstance as a local temporary exclusion). Called from service.connectionPool in refresh (GetInstances)
+// and in unregisterLoop (UnregisterInstance, queued by OnBackendFailure and retried on error).
 //
 //go:generate moq -stub -out mock/discoverer.go -pkg mock . Discoverer
 type Discoverer interface {
 	// GetInstances returns the current list of backend instances for the dynamic cluster (e.g. from HTTP GET /v1/instances).
-	// Parameters: none.
+	// Parameter ctx — cancelled when the pool is closed; implementations add their own per-request timeout.
 	// Returns: ([]ServiceInstance, nil) on success; (nil, error) on network or response parse error. Empty list is valid (e.g. 404 from discoverer).
 	// Called from service.connectionPool.refresh (background refresh) and at pool startup in NewConnectionPool.
-	GetInstances() ([]domain.ServiceInstance, error)
+	GetInstances(ctx context.Context) ([]domain.ServiceInstance, error)
 
 	// UnregisterInstance notifies the discoverer to remove the instance from the registry (e.g. POST /v1/unregister/{id}) so the instance can be marked unavailable.
-	// Parameter instanceID — identifier of the instance that failed; empty string is allowed but the call may have no effect on the discoverer side.
-	// Returns: nil on success (e.g. 200); error on request error or non-200 response.
-	// Called from service.connectionPool.OnBackendFailure after closing the connection to this instance.
-	UnregisterInstance(instanceID string) error
+	// Parameters: ctx — cancelled when the pool is closed; instanceID — identifier of the instance that failed; empty string is allowed but the call may have no effect on the discoverer side.
+	// Returns: nil on success (e.g. 200); error on request error or non-200 response (the pool retries it).
+	// Called from service.connectionPool.unregisterLoop, outside the pool lock, after OnBackendFailure closed the connection to this instance.
+	UnregisterInstance(ctx context.Context, instanceID string) error
+}
+
+// InstanceWatcher is an optional capability of a Discoverer: long-polling the instance list instead of re-reading it on
+// a timer. service.connectionPool type-asserts its discoverer to InstanceWatcher and, when it is implemented, applies
+// every watched list as it arrives; the refreshInterval ticker is then only the fallback while the watch fails.
+//
+// Implemented by adapters.DiscovererHTTP with a watch timeout (MyDiscoverer GET /v1/instances/watch) and by
+// adapters.DiscovererFile while its fsnotify watcher runs. Called from service.connectionPool.watchLoop.
+//
+//go:generate moq -stub -out mock/instance_watcher.go -pkg mock . InstanceWatcher
+type InstanceWatcher interface {
+	// WatchInstances returns the instance list once it differs from version, or the unchanged list when the
+	// discoverer-side wait times out.
+	// Parameters: ctx — cancels the wait (pool close); version — version returned by the previous call, "" for the first.
+	// Returns: (instances, version, nil) on success (same version — nothing changed); (nil, "", error) on network,
+	// status or parse error.
+	// Called from service.connectionPool.watchLoop in a loop.
+	WatchInstances(ctx context.Context, version string) ([]domain.ServiceInstance, string, error)
 }
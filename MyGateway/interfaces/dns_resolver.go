@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+	"net"
+)
+
+// DNSResolver is the part of *net.Resolver used by the DNS discoverer: SRV records and A/AAAA addresses.
+//
+// Implemented by *net.Resolver (net.DefaultResolver in cmd/main); tests use a stub. Called from adapters.DiscovererDNS
+// on each GetInstances.
+//
+//go:generate moq -stub -out mock/dns_resolver.go -pkg mock . DNSResolver
+type DNSResolver interface {
+	// LookupSRV resolves SRV records; with empty service and proto, name is the full record name (e.g. _grpc._tcp.svc.example).
+	// Parameters: ctx — lookup context (deadline); service, proto, name — as in net.Resolver.LookupSRV.
+	// Returns: (cname, records, nil) on success; error on lookup failure (*net.DNSError with IsNotFound for NXDOMAIN).
+	// Called from adapters.discovererDNS.GetInstances in SRV mode.
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+	// LookupIPAddr resolves the A and AAAA records of host.
+	// Parameters: ctx — lookup context (deadline); host — host name (or an IP literal, returned as is).
+	// Returns: (addresses, nil) on success; error on lookup failure (*net.DNSError with IsNotFound for NXDOMAIN).
+	// Called from adapters.discovererDNS.GetInstances for the cluster name (address mode) and for SRV targets.
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
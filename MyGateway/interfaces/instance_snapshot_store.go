@@ -0,0 +1,23 @@
+package interfaces
+
+import "mygateway/domain"
+
+// InstanceSnapshotStore persists the last instance list a dynamic cluster got from its discoverer (the last-known-good
+// snapshot), so a gateway restarted while the discoverer is down still has backends to route to.
+//
+// Implemented by adapters.InstanceSnapshotFile (one JSON file per cluster). Called from service.connectionPool: Save
+// after every successful refresh or watch answer, Load once in NewConnectionPool when the first refresh fails.
+//
+//go:generate moq -stub -out mock/instance_snapshot_store.go -pkg mock . InstanceSnapshotStore
+type InstanceSnapshotStore interface {
+	// Load returns the stored snapshot.
+	// Returns: (snapshot, nil); (zero, error) when nothing was stored yet (error wraps fs.ErrNotExist) or the snapshot cannot be read or parsed.
+	// Called from service.NewConnectionPool when the first GetInstances fails.
+	Load() (domain.InstanceSnapshot, error)
+
+	// Save replaces the stored snapshot; a failed Save leaves the previous snapshot intact.
+	// Parameter snapshot — instance list and the time it was obtained.
+	// Returns: nil on success; error when the snapshot cannot be written.
+	// Called from service.connectionPool after a successful discovery answer that changed the list.
+	Save(snapshot domain.InstanceSnapshot) error
+}
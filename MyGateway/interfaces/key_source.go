@@ -0,0 +1,20 @@
+package interfaces
+
+import "mygateway/auth"
+
+// KeySource supplies the verification keys for standard (RFC 7519) JWTs.
+//
+// Keys returns the current key set; implementations may cache and refresh (e.g. a JWKS URL) so
+// the result can change between calls. The validator selects a key by the token "kid" and "alg".
+//
+// Implemented by auth.StaticKeys (JWT_SECRET), adapters.JWKSFile and adapters.JWKSHTTP. Called from
+// service.standardJWTValidator.ValidateToken for every token.
+//
+//go:generate moq -stub -out mock/key_source.go -pkg mock . KeySource
+type KeySource interface {
+	// Keys returns the current verification keys.
+	// Parameters: none.
+	// Returns: (keys, nil) on success (may be empty); (nil, error) when the keys cannot be loaded and no cached copy exists.
+	// Called from service.standardJWTValidator.ValidateToken.
+	Keys() ([]auth.VerificationKey, error)
+}
@@ -0,0 +1,81 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"mygateway/auth"
+	"mygateway/interfaces"
+	"sync"
+)
+
+// Ensure, that APIKeyStoreMock does implement interfaces.APIKeyStore.
+// If this is not the case, regenerate this file with moq.
+var _ interfaces.APIKeyStore = &APIKeyStoreMock{}
+
+// APIKeyStoreMock is a mock implementation of interfaces.APIKeyStore.
+//
+//	func TestSomethingThatUsesAPIKeyStore(t *testing.T) {
+//
+//		// make and configure a mocked interfaces.APIKeyStore
+//		mockedAPIKeyStore := &APIKeyStoreMock{
+//			LookupFunc: func(rawKey string) (auth.APIKey, bool, error) {
+//				panic("mock out the Lookup method")
+//			},
+//		}
+//
+//		// use mockedAPIKeyStore in code that requires interfaces.APIKeyStore
+//		// and then make assertions.
+//
+//	}
+type APIKeyStoreMock struct {
+	// LookupFunc mocks the Lookup method.
+	LookupFunc func(rawKey string) (auth.APIKey, bool, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Lookup holds details about calls to the Lookup method.
+		Lookup []struct {
+			// RawKey is the rawKey argument value.
+			RawKey string
+		}
+	}
+	lockLookup sync.RWMutex
+}
+
+// Lookup calls LookupFunc.
+func (mock *APIKeyStoreMock) Lookup(rawKey string) (auth.APIKey, bool, error) {
+	callInfo := struct {
+		RawKey string
+	}{
+		RawKey: rawKey,
+	}
+	mock.lockLookup.Lock()
+	mock.calls.Lookup = append(mock.calls.Lookup, callInfo)
+	mock.lockLookup.Unlock()
+	if mock.LookupFunc == nil {
+		var (
+			aPIKeyOut auth.APIKey
+			bOut      bool
+			errOut    error
+		)
+		return aPIKeyOut, bOut, errOut
+	}
+	return mock.LookupFunc(rawKey)
+}
+
+// LookupCalls gets all the calls that were made to Lookup.
+// Check the length with:
+//
+//	len(mockedAPIKeyStore.LookupCalls())
+func (mock *APIKeyStoreMock) LookupCalls() []struct {
+	RawKey string
+} {
+	var calls []struct {
+		RawKey string
+	}
+	mock.lockLookup.RLock()
+	calls = mock.calls.Lookup
+	mock.lockLookup.RUnlock()
+	return calls
+}
@@ -0,0 +1,87 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"mygateway/domain"
+	"mygateway/interfaces"
+	"sync"
+)
+
+// Ensure, that AuthorizerMock does implement interfaces.Authorizer.
+// If this is not the case, regenerate this file with moq.
+var _ interfaces.Authorizer = &AuthorizerMock{}
+
+// AuthorizerMock is a mock implementation of interfaces.Authorizer.
+//
+//	func TestSomethingThatUsesAuthorizer(t *testing.T) {
+//
+//		// make and configure a mocked interfaces.Authorizer
+//		mockedAuthorizer := &AuthorizerMock{
+//			CheckFunc: func(ctx context.Context, req domain.AuthzRequest) (domain.AuthzDecision, error) {
+//				panic("mock out the Check method")
+//			},
+//		}
+//
+//		// use mockedAuthorizer in code that requires interfaces.Authorizer
+//		// and then make assertions.
+//
+//	}
+type AuthorizerMock struct {
+	// CheckFunc mocks the Check method.
+	CheckFunc func(ctx context.Context, req domain.AuthzRequest) (domain.AuthzDecision, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Check holds details about calls to the Check method.
+		Check []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Req is the req argument value.
+			Req domain.AuthzRequest
+		}
+	}
+	lockCheck sync.RWMutex
+}
+
+// Check calls CheckFunc.
+func (mock *AuthorizerMock) Check(ctx context.Context, req domain.AuthzRequest) (domain.AuthzDecision, error) {
+	callInfo := struct {
+		Ctx context.Context
+		Req domain.AuthzRequest
+	}{
+		Ctx: ctx,
+		Req: req,
+	}
+	mock.lockCheck.Lock()
+	mock.calls.Check = append(mock.calls.Check, callInfo)
+	mock.lockCheck.Unlock()
+	if mock.CheckFunc == nil {
+		var (
+			authzDecisionOut domain.AuthzDecision
+			errOut           error
+		)
+		return authzDecisionOut, errOut
+	}
+	return mock.CheckFunc(ctx, req)
+}
+
+// CheckCalls gets all the calls that were made to Check.
+// Check the length with:
+//
+//	len(mockedAuthorizer.CheckCalls())
+func (mock *AuthorizerMock) CheckCalls() []struct {
+	Ctx context.Context
+	Req domain.AuthzRequest
+} {
+	var calls []struct {
+		Ctx context.Context
+		Req domain.AuthzRequest
+	}
+	mock.lockCheck.RLock()
+	calls = mock.calls.Check
+	mock.lockCheck.RUnlock()
+	return calls
+}
@@ -6,8 +6,10 @@ package mock
 import (
 	"context"
 	"google.golang.org/grpc"
+	"mygateway/domain"
 	"mygateway/interfaces"
 	"sync"
+	"time"
 )
 
 // Ensure, that ConnectionPoolMock does implement interfaces.ConnectionPool.
@@ -23,15 +25,24 @@ var _ interfaces.ConnectionPool = &ConnectionPoolMock{}
 //			CloseFunc: func() error {
 //				panic("mock out the Close method")
 //			},
-//			GetConnectionForKeyFunc: func(ctx context.Context, key string) (*grpc.ClientConn, string, error) {
+//			GetConnectionForKeyFunc: func(ctx context.Context, key string, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 //				panic("mock out the GetConnectionForKey method")
 //			},
-//			GetConnectionRoundRobinFunc: func(ctx context.Context) (*grpc.ClientConn, string, error) {
+//			GetConnectionRoundRobinFunc: func(ctx context.Context, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 //				panic("mock out the GetConnectionRoundRobin method")
 //			},
+//			InstancesAgeFunc: func() (time.Duration, bool) {
+//				panic("mock out the InstancesAge method")
+//			},
 //			OnBackendFailureFunc: func(key string, instanceID string)  {
 //				panic("mock out the OnBackendFailure method")
 //			},
+//			ReleaseKeysFunc: func(keys []string)  {
+//				panic("mock out the ReleaseKeys method")
+//			},
+//			ReleaseStreamFunc: func(instanceID string)  {
+//				panic("mock out the ReleaseStream method")
+//			},
 //		}
 //
 //		// use mockedConnectionPool in code that requires interfaces.ConnectionPool
@@ -43,14 +54,23 @@ type ConnectionPoolMock struct {
 	CloseFunc func() error
 
 	// GetConnectionForKeyFunc mocks the GetConnectionForKey method.
-	GetConnectionForKeyFunc func(ctx context.Context, key string) (*grpc.ClientConn, string, error)
+	GetConnectionForKeyFunc func(ctx context.Context, key string, selector domain.LabelSelector) (*grpc.ClientConn, string, error)
 
 	// GetConnectionRoundRobinFunc mocks the GetConnectionRoundRobin method.
-	GetConnectionRoundRobinFunc func(ctx context.Context) (*grpc.ClientConn, string, error)
+	GetConnectionRoundRobinFunc func(ctx context.Context, selector domain.LabelSelector) (*grpc.ClientConn, string, error)
+
+	// InstancesAgeFunc mocks the InstancesAge method.
+	InstancesAgeFunc func() (time.Duration, bool)
 
 	// OnBackendFailureFunc mocks the OnBackendFailure method.
 	OnBackendFailureFunc func(key string, instanceID string)
 
+	// ReleaseKeysFunc mocks the ReleaseKeys method.
+	ReleaseKeysFunc func(keys []string)
+
+	// ReleaseStreamFunc mocks the ReleaseStream method.
+	ReleaseStreamFunc func(instanceID string)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// Close holds details about calls to the Close method.
@@ -62,11 +82,18 @@ type ConnectionPoolMock struct {
 			Ctx context.Context
 			// Key is the key argument value.
 			Key string
+			// Selector is the selector argument value.
+			Selector domain.LabelSelector
 		}
 		// GetConnectionRoundRobin holds details about calls to the GetConnectionRoundRobin method.
 		GetConnectionRoundRobin []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
+			// Selector is the selector argument value.
+			Selector domain.LabelSelector
+		}
+		// InstancesAge holds details about calls to the InstancesAge method.
+		InstancesAge []struct {
 		}
 		// OnBackendFailure holds details about calls to the OnBackendFailure method.
 		OnBackendFailure []struct {
@@ -75,11 +102,24 @@ type ConnectionPoolMock struct {
 			// InstanceID is the instanceID argument value.
 			InstanceID string
 		}
+		// ReleaseKeys holds details about calls to the ReleaseKeys method.
+		ReleaseKeys []struct {
+			// Keys is the keys argument value.
+			Keys []string
+		}
+		// ReleaseStream holds details about calls to the ReleaseStream method.
+		ReleaseStream []struct {
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+		}
 	}
 	lockClose                   sync.RWMutex
 	lockGetConnectionForKey     sync.RWMutex
 	lockGetConnectionRoundRobin sync.RWMutex
+	lockInstancesAge            sync.RWMutex
 	lockOnBackendFailure        sync.RWMutex
+	lockReleaseKeys             sync.RWMutex
+	lockReleaseStream           sync.RWMutex
 }
 
 // Close calls CloseFunc.
@@ -113,13 +153,15 @@ func (mock *ConnectionPoolMock) CloseCalls() []struct {
 }
 
 // GetConnectionForKey calls GetConnectionForKeyFunc.
-func (mock *ConnectionPoolMock) GetConnectionForKey(ctx context.Context, key string) (*grpc.ClientConn, string, error) {
+func (mock *ConnectionPoolMock) GetConnectionForKey(ctx context.Context, key string, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 	callInfo := struct {
-		Ctx context.Context
-		Key string
+		Ctx      context.Context
+		Key      string
+		Selector domain.LabelSelector
 	}{
-		Ctx: ctx,
-		Key: key,
+		Ctx:      ctx,
+		Key:      key,
+		Selector: selector,
 	}
 	mock.lockGetConnectionForKey.Lock()
 	mock.calls.GetConnectionForKey = append(mock.calls.GetConnectionForKey, callInfo)
@@ -132,7 +174,7 @@ func (mock *ConnectionPoolMock) GetConnectionForKey(ctx context.Context, key str
 		)
 		return connOut, instanceIDOut, errOut
 	}
-	return mock.GetConnectionForKeyFunc(ctx, key)
+	return mock.GetConnectionForKeyFunc(ctx, key, selector)
 }
 
 // GetConnectionForKeyCalls gets all the calls that were made to GetConnectionForKey.
@@ -140,12 +182,14 @@ func (mock *ConnectionPoolMock) GetConnectionForKey(ctx context.Context, key str
 //
 //	len(mockedConnectionPool.GetConnectionForKeyCalls())
 func (mock *ConnectionPoolMock) GetConnectionForKeyCalls() []struct {
-	Ctx context.Context
-	Key string
+	Ctx      context.Context
+	Key      string
+	Selector domain.LabelSelector
 } {
 	var calls []struct {
-		Ctx context.Context
-		Key string
+		Ctx      context.Context
+		Key      string
+		Selector domain.LabelSelector
 	}
 	mock.lockGetConnectionForKey.RLock()
 	calls = mock.calls.GetConnectionForKey
@@ -154,11 +198,13 @@ func (mock *ConnectionPoolMock) GetConnectionForKeyCalls() []struct {
 }
 
 // GetConnectionRoundRobin calls GetConnectionRoundRobinFunc.
-func (mock *ConnectionPoolMock) GetConnectionRoundRobin(ctx context.Context) (*grpc.ClientConn, string, error) {
+func (mock *ConnectionPoolMock) GetConnectionRoundRobin(ctx context.Context, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 	callInfo := struct {
-		Ctx context.Context
+		Ctx      context.Context
+		Selector domain.LabelSelector
 	}{
-		Ctx: ctx,
+		Ctx:      ctx,
+		Selector: selector,
 	}
 	mock.lockGetConnectionRoundRobin.Lock()
 	mock.calls.GetConnectionRoundRobin = append(mock.calls.GetConnectionRoundRobin, callInfo)
@@ -171,7 +217,7 @@ func (mock *ConnectionPoolMock) GetConnectionRoundRobin(ctx context.Context) (*g
 		)
 		return connOut, instanceIDOut, errOut
 	}
-	return mock.GetConnectionRoundRobinFunc(ctx)
+	return mock.GetConnectionRoundRobinFunc(ctx, selector)
 }
 
 // GetConnectionRoundRobinCalls gets all the calls that were made to GetConnectionRoundRobin.
@@ -179,10 +225,12 @@ func (mock *ConnectionPoolMock) GetConnectionRoundRobin(ctx context.Context) (*g
 //
 //	len(mockedConnectionPool.GetConnectionRoundRobinCalls())
 func (mock *ConnectionPoolMock) GetConnectionRoundRobinCalls() []struct {
-	Ctx context.Context
+	Ctx      context.Context
+	Selector domain.LabelSelector
 } {
 	var calls []struct {
-		Ctx context.Context
+		Ctx      context.Context
+		Selector domain.LabelSelector
 	}
 	mock.lockGetConnectionRoundRobin.RLock()
 	calls = mock.calls.GetConnectionRoundRobin
@@ -190,6 +238,37 @@ func (mock *ConnectionPoolMock) GetConnectionRoundRobinCalls() []struct {
 	return calls
 }
 
+// InstancesAge calls InstancesAgeFunc.
+func (mock *ConnectionPoolMock) InstancesAge() (time.Duration, bool) {
+	callInfo := struct {
+	}{}
+	mock.lockInstancesAge.Lock()
+	mock.calls.InstancesAge = append(mock.calls.InstancesAge, callInfo)
+	mock.lockInstancesAge.Unlock()
+	if mock.InstancesAgeFunc == nil {
+		var (
+			ageOut time.Duration
+			okOut  bool
+		)
+		return ageOut, okOut
+	}
+	return mock.InstancesAgeFunc()
+}
+
+// InstancesAgeCalls gets all the calls that were made to InstancesAge.
+// Check the length with:
+//
+//	len(mockedConnectionPool.InstancesAgeCalls())
+func (mock *ConnectionPoolMock) InstancesAgeCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockInstancesAge.RLock()
+	calls = mock.calls.InstancesAge
+	mock.lockInstancesAge.RUnlock()
+	return calls
+}
+
 // OnBackendFailure calls OnBackendFailureFunc.
 func (mock *ConnectionPoolMock) OnBackendFailure(key string, instanceID string) {
 	callInfo := struct {
@@ -225,3 +304,67 @@ func (mock *ConnectionPoolMock) OnBackendFailureCalls() []struct {
 	mock.lockOnBackendFailure.RUnlock()
 	return calls
 }
+
+// ReleaseKeys calls ReleaseKeysFunc.
+func (mock *ConnectionPoolMock) ReleaseKeys(keys []string) {
+	callInfo := struct {
+		Keys []string
+	}{
+		Keys: keys,
+	}
+	mock.lockReleaseKeys.Lock()
+	mock.calls.ReleaseKeys = append(mock.calls.ReleaseKeys, callInfo)
+	mock.lockReleaseKeys.Unlock()
+	if mock.ReleaseKeysFunc == nil {
+		return
+	}
+	mock.ReleaseKeysFunc(keys)
+}
+
+// ReleaseKeysCalls gets all the calls that were made to ReleaseKeys.
+// Check the length with:
+//
+//	len(mockedConnectionPool.ReleaseKeysCalls())
+func (mock *ConnectionPoolMock) ReleaseKeysCalls() []struct {
+	Keys []string
+} {
+	var calls []struct {
+		Keys []string
+	}
+	mock.lockReleaseKeys.RLock()
+	calls = mock.calls.ReleaseKeys
+	mock.lockReleaseKeys.RUnlock()
+	return calls
+}
+
+// ReleaseStream calls ReleaseStreamFunc.
+func (mock *ConnectionPoolMock) ReleaseStream(instanceID string) {
+	callInfo := struct {
+		InstanceID string
+	}{
+		InstanceID: instanceID,
+	}
+	mock.lockReleaseStream.Lock()
+	mock.calls.ReleaseStream = append(mock.calls.ReleaseStream, callInfo)
+	mock.lockReleaseStream.Unlock()
+	if mock.ReleaseStreamFunc == nil {
+		return
+	}
+	mock.ReleaseStreamFunc(instanceID)
+}
+
+// ReleaseStreamCalls gets all the calls that were made to ReleaseStream.
+// Check the length with:
+//
+//	len(mockedConnectionPool.ReleaseStreamCalls())
+func (mock *ConnectionPoolMock) ReleaseStreamCalls() []struct {
+	InstanceID string
+} {
+	var calls []struct {
+		InstanceID string
+	}
+	mock.lockReleaseStream.RLock()
+	calls = mock.calls.ReleaseStream
+	mock.lockReleaseStream.RUnlock()
+	return calls
+}
@@ -28,6 +28,9 @@ var _ interfaces.ConnectionResolver = &ConnectionResolverMock{}
 //			OnBackendFailureFunc: func(route domain.Route, stickyKey string, instanceID string)  {
 //				panic("mock out the OnBackendFailure method")
 //			},
+//			ReleaseStreamFunc: func(route domain.Route, instanceID string)  {
+//				panic("mock out the ReleaseStream method")
+//			},
 //		}
 //
 //		// use mockedConnectionResolver in code that requires interfaces.ConnectionResolver
@@ -41,6 +44,9 @@ type ConnectionResolverMock struct {
 	// OnBackendFailureFunc mocks the OnBackendFailure method.
 	OnBackendFailureFunc func(route domain.Route, stickyKey string, instanceID string)
 
+	// ReleaseStreamFunc mocks the ReleaseStream method.
+	ReleaseStreamFunc func(route domain.Route, instanceID string)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// GetConnection holds details about calls to the GetConnection method.
@@ -61,9 +67,17 @@ type ConnectionResolverMock struct {
 			// InstanceID is the instanceID argument value.
 			InstanceID string
 		}
+		// ReleaseStream holds details about calls to the ReleaseStream method.
+		ReleaseStream []struct {
+			// Route is the route argument value.
+			Route domain.Route
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+		}
 	}
 	lockGetConnection    sync.RWMutex
 	lockOnBackendFailure sync.RWMutex
+	lockReleaseStream    sync.RWMutex
 }
 
 // GetConnection calls GetConnectionFunc.
@@ -151,3 +165,39 @@ func (mock *ConnectionResolverMock) OnBackendFailureCalls() []struct {
 	mock.lockOnBackendFailure.RUnlock()
 	return calls
 }
+
+// ReleaseStream calls ReleaseStreamFunc.
+func (mock *ConnectionResolverMock) ReleaseStream(route domain.Route, instanceID string) {
+	callInfo := struct {
+		Route      domain.Route
+		InstanceID string
+	}{
+		Route:      route,
+		InstanceID: instanceID,
+	}
+	mock.lockReleaseStream.Lock()
+	mock.calls.ReleaseStream = append(mock.calls.ReleaseStream, callInfo)
+	mock.lockReleaseStream.Unlock()
+	if mock.ReleaseStreamFunc == nil {
+		return
+	}
+	mock.ReleaseStreamFunc(route, instanceID)
+}
+
+// ReleaseStreamCalls gets all the calls that were made to ReleaseStream.
+// Check the length with:
+//
+//	len(mockedConnectionResolver.ReleaseStreamCalls())
+func (mock *ConnectionResolverMock) ReleaseStreamCalls() []struct {
+	Route      domain.Route
+	InstanceID string
+} {
+	var calls []struct {
+		Route      domain.Route
+		InstanceID string
+	}
+	mock.lockReleaseStream.RLock()
+	calls = mock.calls.ReleaseStream
+	mock.lockReleaseStream.RUnlock()
+	return calls
+}
@@ -4,6 +4,7 @@
 package mock
 
 import (
+	"context"
 	"mygateway/domain"
 	"mygateway/interfaces"
 	"sync"
@@ -19,10 +20,10 @@ var _ interfaces.Discoverer = &DiscovererMock{}
 //
 //		// make and configure a mocked interfaces.Discoverer
 //		mockedDiscoverer := &DiscovererMock{
-//			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+//			GetInstancesFunc: func(ctx context.Context) ([]domain.ServiceInstance, error) {
 //				panic("mock out the GetInstances method")
 //			},
-//			UnregisterInstanceFunc: func(instanceID string) error {
+//			UnregisterInstanceFunc: func(ctx context.Context, instanceID string) error {
 //				panic("mock out the UnregisterInstance method")
 //			},
 //		}
@@ -33,18 +34,22 @@ var _ interfaces.Discoverer = &DiscovererMock{}
 //	}
 type DiscovererMock struct {
 	// GetInstancesFunc mocks the GetInstances method.
-	GetInstancesFunc func() ([]domain.ServiceInstance, error)
+	GetInstancesFunc func(ctx context.Context) ([]domain.ServiceInstance, error)
 
 	// UnregisterInstanceFunc mocks the UnregisterInstance method.
-	UnregisterInstanceFunc func(instanceID string) error
+	UnregisterInstanceFunc func(ctx context.Context, instanceID string) error
 
 	// calls tracks calls to the methods.
 	calls struct {
 		// GetInstances holds details about calls to the GetInstances method.
 		GetInstances []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
 		}
 		// UnregisterInstance holds details about calls to the UnregisterInstance method.
 		UnregisterInstance []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
 			// InstanceID is the instanceID argument value.
 			InstanceID string
 		}
@@ -54,9 +59,12 @@ type DiscovererMock struct {
 }
 
 // GetInstances calls GetInstancesFunc.
-func (mock *DiscovererMock) GetInstances() ([]domain.ServiceInstance, error) {
+func (mock *DiscovererMock) GetInstances(ctx context.Context) ([]domain.ServiceInstance, error) {
 	callInfo := struct {
-	}{}
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
 	mock.lockGetInstances.Lock()
 	mock.calls.GetInstances = append(mock.calls.GetInstances, callInfo)
 	mock.lockGetInstances.Unlock()
@@ -67,7 +75,7 @@ func (mock *DiscovererMock) GetInstances() ([]domain.ServiceInstance, error) {
 		)
 		return serviceInstancesOut, errOut
 	}
-	return mock.GetInstancesFunc()
+	return mock.GetInstancesFunc(ctx)
 }
 
 // GetInstancesCalls gets all the calls that were made to GetInstances.
@@ -75,8 +83,10 @@ func (mock *DiscovererMock) GetInstances() ([]domain.ServiceInstance, error) {
 //
 //	len(mockedDiscoverer.GetInstancesCalls())
 func (mock *DiscovererMock) GetInstancesCalls() []struct {
+	Ctx context.Context
 } {
 	var calls []struct {
+		Ctx context.Context
 	}
 	mock.lockGetInstances.RLock()
 	calls = mock.calls.GetInstances
@@ -85,10 +95,12 @@ func (mock *DiscovererMock) GetInstancesCalls() []struct {
 }
 
 // UnregisterInstance calls UnregisterInstanceFunc.
-func (mock *DiscovererMock) UnregisterInstance(instanceID string) error {
+func (mock *DiscovererMock) UnregisterInstance(ctx context.Context, instanceID string) error {
 	callInfo := struct {
+		Ctx        context.Context
 		InstanceID string
 	}{
+		Ctx:        ctx,
 		InstanceID: instanceID,
 	}
 	mock.lockUnregisterInstance.Lock()
@@ -100,7 +112,7 @@ func (mock *DiscovererMock) UnregisterInstance(instanceID string) error {
 		)
 		return errOut
 	}
-	return mock.UnregisterInstanceFunc(instanceID)
+	return mock.UnregisterInstanceFunc(ctx, instanceID)
 }
 
 // UnregisterInstanceCalls gets all the calls that were made to UnregisterInstance.
@@ -108,9 +120,11 @@ func (mock *DiscovererMock) UnregisterInstance(instanceID string) error {
 //
 //	len(mockedDiscoverer.UnregisterInstanceCalls())
 func (mock *DiscovererMock) UnregisterInstanceCalls() []struct {
+	Ctx        context.Context
 	InstanceID string
 } {
 	var calls []struct {
+		Ctx        context.Context
 		InstanceID string
 	}
 	mock.lockUnregisterInstance.RLock()
@@ -0,0 +1,154 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"mygateway/interfaces"
+	"net"
+	"sync"
+)
+
+// Ensure, that DNSResolverMock does implement interfaces.DNSResolver.
+// If this is not the case, regenerate this file with moq.
+var _ interfaces.DNSResolver = &DNSResolverMock{}
+
+// DNSResolverMock is a mock implementation of interfaces.DNSResolver.
+//
+//	func TestSomethingThatUsesDNSResolver(t *testing.T) {
+//
+//		// make and configure a mocked interfaces.DNSResolver
+//		mockedDNSResolver := &DNSResolverMock{
+//			LookupIPAddrFunc: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+//				panic("mock out the LookupIPAddr method")
+//			},
+//			LookupSRVFunc: func(ctx context.Context, service string, proto string, name string) (string, []*net.SRV, error) {
+//				panic("mock out the LookupSRV method")
+//			},
+//		}
+//
+//		// use mockedDNSResolver in code that requires interfaces.DNSResolver
+//		// and then make assertions.
+//
+//	}
+type DNSResolverMock struct {
+	// LookupIPAddrFunc mocks the LookupIPAddr method.
+	LookupIPAddrFunc func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	// LookupSRVFunc mocks the LookupSRV method.
+	LookupSRVFunc func(ctx context.Context, service string, proto string, name string) (string, []*net.SRV, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// LookupIPAddr holds details about calls to the LookupIPAddr method.
+		LookupIPAddr []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Host is the host argument value.
+			Host string
+		}
+		// LookupSRV holds details about calls to the LookupSRV method.
+		LookupSRV []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Service is the service argument value.
+			Service string
+			// Proto is the proto argument value.
+			Proto string
+			// Name is the name argument value.
+			Name string
+		}
+	}
+	lockLookupIPAddr sync.RWMutex
+	lockLookupSRV    sync.RWMutex
+}
+
+// LookupIPAddr calls LookupIPAddrFunc.
+func (mock *DNSResolverMock) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	callInfo := struct {
+		Ctx  context.Context
+		Host string
+	}{
+		Ctx:  ctx,
+		Host: host,
+	}
+	mock.lockLookupIPAddr.Lock()
+	mock.calls.LookupIPAddr = append(mock.calls.LookupIPAddr, callInfo)
+	mock.lockLookupIPAddr.Unlock()
+	if mock.LookupIPAddrFunc == nil {
+		var (
+			iPAddrsOut []net.IPAddr
+			errOut     error
+		)
+		return iPAddrsOut, errOut
+	}
+	return mock.LookupIPAddrFunc(ctx, host)
+}
+
+// LookupIPAddrCalls gets all the calls that were made to LookupIPAddr.
+// Check the length with:
+//
+//	len(mockedDNSResolver.LookupIPAddrCalls())
+func (mock *DNSResolverMock) LookupIPAddrCalls() []struct {
+	Ctx  context.Context
+	Host string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Host string
+	}
+	mock.lockLookupIPAddr.RLock()
+	calls = mock.calls.LookupIPAddr
+	mock.lockLookupIPAddr.RUnlock()
+	return calls
+}
+
+// LookupSRV calls LookupSRVFunc.
+func (mock *DNSResolverMock) LookupSRV(ctx context.Context, service string, proto string, name string) (string, []*net.SRV, error) {
+	callInfo := struct {
+		Ctx     context.Context
+		Service string
+		Proto   string
+		Name    string
+	}{
+		Ctx:     ctx,
+		Service: service,
+		Proto:   proto,
+		Name:    name,
+	}
+	mock.lockLookupSRV.Lock()
+	mock.calls.LookupSRV = append(mock.calls.LookupSRV, callInfo)
+	mock.lockLookupSRV.Unlock()
+	if mock.LookupSRVFunc == nil {
+		var (
+			sOut    string
+			sRVsOut []*net.SRV
+			errOut  error
+		)
+		return sOut, sRVsOut, errOut
+	}
+	return mock.LookupSRVFunc(ctx, service, proto, name)
+}
+
+// LookupSRVCalls gets all the calls that were made to LookupSRV.
+// Check the length with:
+//
+//	len(mockedDNSResolver.LookupSRVCalls())
+func (mock *DNSResolverMock) LookupSRVCalls() []struct {
+	Ctx     context.Context
+	Service string
+	Proto   string
+	Name    string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Service string
+		Proto   string
+		Name    string
+	}
+	mock.lockLookupSRV.RLock()
+	calls = mock.calls.LookupSRV
+	mock.lockLookupSRV.RUnlock()
+	return calls
+}
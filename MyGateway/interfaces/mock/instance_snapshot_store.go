@@ -0,0 +1,120 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"mygateway/domain"
+	"mygateway/interfaces"
+	"sync"
+)
+
+// Ensure, that InstanceSnapshotStoreMock does implement interfaces.InstanceSnapshotStore.
+// If this is not the case, regenerate this file with moq.
+var _ interfaces.InstanceSnapshotStore = &InstanceSnapshotStoreMock{}
+
+// InstanceSnapshotStoreMock is a mock implementation of interfaces.InstanceSnapshotStore.
+//
+//	func TestSomethingThatUsesInstanceSnapshotStore(t *testing.T) {
+//
+//		// make and configure a mocked interfaces.InstanceSnapshotStore
+//		mockedInstanceSnapshotStore := &InstanceSnapshotStoreMock{
+//			LoadFunc: func() (domain.InstanceSnapshot, error) {
+//				panic("mock out the Load method")
+//			},
+//			SaveFunc: func(snapshot domain.InstanceSnapshot) error {
+//				panic("mock out the Save method")
+//			},
+//		}
+//
+//		// use mockedInstanceSnapshotStore in code that requires interfaces.InstanceSnapshotStore
+//		// and then make assertions.
+//
+//	}
+type InstanceSnapshotStoreMock struct {
+	// LoadFunc mocks the Load method.
+	LoadFunc func() (domain.InstanceSnapshot, error)
+
+	// SaveFunc mocks the Save method.
+	SaveFunc func(snapshot domain.InstanceSnapshot) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Load holds details about calls to the Load method.
+		Load []struct {
+		}
+		// Save holds details about calls to the Save method.
+		Save []struct {
+			// Snapshot is the snapshot argument value.
+			Snapshot domain.InstanceSnapshot
+		}
+	}
+	lockLoad sync.RWMutex
+	lockSave sync.RWMutex
+}
+
+// Load calls LoadFunc.
+func (mock *InstanceSnapshotStoreMock) Load() (domain.InstanceSnapshot, error) {
+	callInfo := struct {
+	}{}
+	mock.lockLoad.Lock()
+	mock.calls.Load = append(mock.calls.Load, callInfo)
+	mock.lockLoad.Unlock()
+	if mock.LoadFunc == nil {
+		var (
+			instanceSnapshotOut domain.InstanceSnapshot
+			errOut              error
+		)
+		return instanceSnapshotOut, errOut
+	}
+	return mock.LoadFunc()
+}
+
+// LoadCalls gets all the calls that were made to Load.
+// Check the length with:
+//
+//	len(mockedInstanceSnapshotStore.LoadCalls())
+func (mock *InstanceSnapshotStoreMock) LoadCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockLoad.RLock()
+	calls = mock.calls.Load
+	mock.lockLoad.RUnlock()
+	return calls
+}
+
+// Save calls SaveFunc.
+func (mock *InstanceSnapshotStoreMock) Save(snapshot domain.InstanceSnapshot) error {
+	callInfo := struct {
+		Snapshot domain.InstanceSnapshot
+	}{
+		Snapshot: snapshot,
+	}
+	mock.lockSave.Lock()
+	mock.calls.Save = append(mock.calls.Save, callInfo)
+	mock.lockSave.Unlock()
+	if mock.SaveFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.SaveFunc(snapshot)
+}
+
+// SaveCalls gets all the calls that were made to Save.
+// Check the length with:
+//
+//	len(mockedInstanceSnapshotStore.SaveCalls())
+func (mock *InstanceSnapshotStoreMock) SaveCalls() []struct {
+	Snapshot domain.InstanceSnapshot
+} {
+	var calls []struct {
+		Snapshot domain.InstanceSnapshot
+	}
+	mock.lockSave.RLock()
+	calls = mock.calls.Save
+	mock.lockSave.RUnlock()
+	return calls
+}
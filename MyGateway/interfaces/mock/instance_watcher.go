@@ -0,0 +1,88 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"mygateway/domain"
+	"mygateway/interfaces"
+	"sync"
+)
+
+// Ensure, that InstanceWatcherMock does implement interfaces.InstanceWatcher.
+// If this is not the case, regenerate this file with moq.
+var _ interfaces.InstanceWatcher = &InstanceWatcherMock{}
+
+// InstanceWatcherMock is a mock implementation of interfaces.InstanceWatcher.
+//
+//	func TestSomethingThatUsesInstanceWatcher(t *testing.T) {
+//
+//		// make and configure a mocked interfaces.InstanceWatcher
+//		mockedInstanceWatcher := &InstanceWatcherMock{
+//			WatchInstancesFunc: func(ctx context.Context, version string) ([]domain.ServiceInstance, string, error) {
+//				panic("mock out the WatchInstances method")
+//			},
+//		}
+//
+//		// use mockedInstanceWatcher in code that requires interfaces.InstanceWatcher
+//		// and then make assertions.
+//
+//	}
+type InstanceWatcherMock struct {
+	// WatchInstancesFunc mocks the WatchInstances method.
+	WatchInstancesFunc func(ctx context.Context, version string) ([]domain.ServiceInstance, string, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// WatchInstances holds details about calls to the WatchInstances method.
+		WatchInstances []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Version is the version argument value.
+			Version string
+		}
+	}
+	lockWatchInstances sync.RWMutex
+}
+
+// WatchInstances calls WatchInstancesFunc.
+func (mock *InstanceWatcherMock) WatchInstances(ctx context.Context, version string) ([]domain.ServiceInstance, string, error) {
+	callInfo := struct {
+		Ctx     context.Context
+		Version string
+	}{
+		Ctx:     ctx,
+		Version: version,
+	}
+	mock.lockWatchInstances.Lock()
+	mock.calls.WatchInstances = append(mock.calls.WatchInstances, callInfo)
+	mock.lockWatchInstances.Unlock()
+	if mock.WatchInstancesFunc == nil {
+		var (
+			serviceInstancesOut []domain.ServiceInstance
+			sOut                string
+			errOut              error
+		)
+		return serviceInstancesOut, sOut, errOut
+	}
+	return mock.WatchInstancesFunc(ctx, version)
+}
+
+// WatchInstancesCalls gets all the calls that were made to WatchInstances.
+// Check the length with:
+//
+//	len(mockedInstanceWatcher.WatchInstancesCalls())
+func (mock *InstanceWatcherMock) WatchInstancesCalls() []struct {
+	Ctx     context.Context
+	Version string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Version string
+	}
+	mock.lockWatchInstances.RLock()
+	calls = mock.calls.WatchInstances
+	mock.lockWatchInstances.RUnlock()
+	return calls
+}
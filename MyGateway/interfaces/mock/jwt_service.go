@@ -4,6 +4,7 @@
 package mock
 
 import (
+	"mygateway/auth"
 	"mygateway/interfaces"
 	"sync"
 )
@@ -18,7 +19,7 @@ var _ interfaces.JwtService = &JwtServiceMock{}
 //
 //		// make and configure a mocked interfaces.JwtService
 //		mockedJwtService := &JwtServiceMock{
-//			ValidateTokenFunc: func(sessionID string, token string) (bool, error) {
+//			ValidateTokenFunc: func(sessionID string, token string) (auth.TokenClaims, bool, error) {
 //				panic("mock out the ValidateToken method")
 //			},
 //		}
@@ -29,7 +30,7 @@ var _ interfaces.JwtService = &JwtServiceMock{}
 //	}
 type JwtServiceMock struct {
 	// ValidateTokenFunc mocks the ValidateToken method.
-	ValidateTokenFunc func(sessionID string, token string) (bool, error)
+	ValidateTokenFunc func(sessionID string, token string) (auth.TokenClaims, bool, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
@@ -45,7 +46,7 @@ type JwtServiceMock struct {
 }
 
 // ValidateToken calls ValidateTokenFunc.
-func (mock *JwtServiceMock) ValidateToken(sessionID string, token string) (bool, error) {
+func (mock *JwtServiceMock) ValidateToken(sessionID string, token string) (auth.TokenClaims, bool, error) {
 	callInfo := struct {
 		SessionID string
 		Token     string
@@ -58,10 +59,11 @@ func (mock *JwtServiceMock) ValidateToken(sessionID string, token string) (bool,
 	mock.lockValidateToken.Unlock()
 	if mock.ValidateTokenFunc == nil {
 		var (
-			bOut   bool
-			errOut error
+			tokenClaimsOut auth.TokenClaims
+			bOut           bool
+			errOut         error
 		)
-		return bOut, errOut
+		return tokenClaimsOut, bOut, errOut
 	}
 	return mock.ValidateTokenFunc(sessionID, token)
 }
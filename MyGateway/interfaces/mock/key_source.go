@@ -0,0 +1,73 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"mygateway/auth"
+	"mygateway/interfaces"
+	"sync"
+)
+
+// Ensure, that KeySourceMock does implement interfaces.KeySource.
+// If this is not the case, regenerate this file with moq.
+var _ interfaces.KeySource = &KeySourceMock{}
+
+// KeySourceMock is a mock implementation of interfaces.KeySource.
+//
+//	func TestSomethingThatUsesKeySource(t *testing.T) {
+//
+//		// make and configure a mocked interfaces.KeySource
+//		mockedKeySource := &KeySourceMock{
+//			KeysFunc: func() ([]auth.VerificationKey, error) {
+//				panic("mock out the Keys method")
+//			},
+//		}
+//
+//		// use mockedKeySource in code that requires interfaces.KeySource
+//		// and then make assertions.
+//
+//	}
+type KeySourceMock struct {
+	// KeysFunc mocks the Keys method.
+	KeysFunc func() ([]auth.VerificationKey, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Keys holds details about calls to the Keys method.
+		Keys []struct {
+		}
+	}
+	lockKeys sync.RWMutex
+}
+
+// Keys calls KeysFunc.
+func (mock *KeySourceMock) Keys() ([]auth.VerificationKey, error) {
+	callInfo := struct {
+	}{}
+	mock.lockKeys.Lock()
+	mock.calls.Keys = append(mock.calls.Keys, callInfo)
+	mock.lockKeys.Unlock()
+	if mock.KeysFunc == nil {
+		var (
+			verificationKeysOut []auth.VerificationKey
+			errOut              error
+		)
+		return verificationKeysOut, errOut
+	}
+	return mock.KeysFunc()
+}
+
+// KeysCalls gets all the calls that were made to Keys.
+// Check the length with:
+//
+//	len(mockedKeySource.KeysCalls())
+func (mock *KeySourceMock) KeysCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockKeys.RLock()
+	calls = mock.calls.Keys
+	mock.lockKeys.RUnlock()
+	return calls
+}
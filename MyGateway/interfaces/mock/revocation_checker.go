@@ -0,0 +1,79 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"mygateway/auth"
+	"mygateway/interfaces"
+	"sync"
+)
+
+// Ensure, that RevocationCheckerMock does implement interfaces.RevocationChecker.
+// If this is not the case, regenerate this file with moq.
+var _ interfaces.RevocationChecker = &RevocationCheckerMock{}
+
+// RevocationCheckerMock is a mock implementation of interfaces.RevocationChecker.
+//
+//	func TestSomethingThatUsesRevocationChecker(t *testing.T) {
+//
+//		// make and configure a mocked interfaces.RevocationChecker
+//		mockedRevocationChecker := &RevocationCheckerMock{
+//			IsRevokedFunc: func(claims auth.TokenClaims) bool {
+//				panic("mock out the IsRevoked method")
+//			},
+//		}
+//
+//		// use mockedRevocationChecker in code that requires interfaces.RevocationChecker
+//		// and then make assertions.
+//
+//	}
+type RevocationCheckerMock struct {
+	// IsRevokedFunc mocks the IsRevoked method.
+	IsRevokedFunc func(claims auth.TokenClaims) bool
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// IsRevoked holds details about calls to the IsRevoked method.
+		IsRevoked []struct {
+			// Claims is the claims argument value.
+			Claims auth.TokenClaims
+		}
+	}
+	lockIsRevoked sync.RWMutex
+}
+
+// IsRevoked calls IsRevokedFunc.
+func (mock *RevocationCheckerMock) IsRevoked(claims auth.TokenClaims) bool {
+	callInfo := struct {
+		Claims auth.TokenClaims
+	}{
+		Claims: claims,
+	}
+	mock.lockIsRevoked.Lock()
+	mock.calls.IsRevoked = append(mock.calls.IsRevoked, callInfo)
+	mock.lockIsRevoked.Unlock()
+	if mock.IsRevokedFunc == nil {
+		var (
+			bOut bool
+		)
+		return bOut
+	}
+	return mock.IsRevokedFunc(claims)
+}
+
+// IsRevokedCalls gets all the calls that were made to IsRevoked.
+// Check the length with:
+//
+//	len(mockedRevocationChecker.IsRevokedCalls())
+func (mock *RevocationCheckerMock) IsRevokedCalls() []struct {
+	Claims auth.TokenClaims
+} {
+	var calls []struct {
+		Claims auth.TokenClaims
+	}
+	mock.lockIsRevoked.RLock()
+	calls = mock.calls.IsRevoked
+	mock.lockIsRevoked.RUnlock()
+	return calls
+}
@@ -0,0 +1,81 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"mygateway/auth"
+	"mygateway/interfaces"
+	"sync"
+)
+
+// Ensure, that RevocationSourceMock does implement interfaces.RevocationSource.
+// If this is not the case, regenerate this file with moq.
+var _ interfaces.RevocationSource = &RevocationSourceMock{}
+
+// RevocationSourceMock is a mock implementation of interfaces.RevocationSource.
+//
+//	func TestSomethingThatUsesRevocationSource(t *testing.T) {
+//
+//		// make and configure a mocked interfaces.RevocationSource
+//		mockedRevocationSource := &RevocationSourceMock{
+//			LoadFunc: func(ctx context.Context) (auth.Revocations, error) {
+//				panic("mock out the Load method")
+//			},
+//		}
+//
+//		// use mockedRevocationSource in code that requires interfaces.RevocationSource
+//		// and then make assertions.
+//
+//	}
+type RevocationSourceMock struct {
+	// LoadFunc mocks the Load method.
+	LoadFunc func(ctx context.Context) (auth.Revocations, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Load holds details about calls to the Load method.
+		Load []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+	}
+	lockLoad sync.RWMutex
+}
+
+// Load calls LoadFunc.
+func (mock *RevocationSourceMock) Load(ctx context.Context) (auth.Revocations, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockLoad.Lock()
+	mock.calls.Load = append(mock.calls.Load, callInfo)
+	mock.lockLoad.Unlock()
+	if mock.LoadFunc == nil {
+		var (
+			revocationsOut auth.Revocations
+			errOut         error
+		)
+		return revocationsOut, errOut
+	}
+	return mock.LoadFunc(ctx)
+}
+
+// LoadCalls gets all the calls that were made to Load.
+// Check the length with:
+//
+//	len(mockedRevocationSource.LoadCalls())
+func (mock *RevocationSourceMock) LoadCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockLoad.RLock()
+	calls = mock.calls.Load
+	mock.lockLoad.RUnlock()
+	return calls
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import "mygateway/auth"
+
+// RevocationChecker decides whether a validated token has been revoked (its session or an issued-at cutoff).
+//
+// IsRevoked answers from a locally cached snapshot so it is cheap enough for every request.
+//
+// Implemented by service.revocationCache. Called from the JwtService returned by service.NewRevocationValidator.
+//
+//go:generate moq -stub -out mock/revocation_checker.go -pkg mock . RevocationChecker
+type RevocationChecker interface {
+	// IsRevoked reports whether the token with the given claims is revoked.
+	// Parameters: claims — claims of a token whose signature and expiry were already verified.
+	// Returns: true when the session_id is revoked or issued_at is before an applicable cutoff.
+	// Called from the revocation-checking JwtService for routes with authorization=required.
+	IsRevoked(claims auth.TokenClaims) bool
+}
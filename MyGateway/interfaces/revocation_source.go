@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+
+	"mygateway/auth"
+)
+
+// RevocationSource loads the revocation store (revoked session IDs and issued-at cutoffs).
+//
+// Load returns a full snapshot on every call; caching and periodic refresh are done by service.revocationCache,
+// so implementations should read the backing store directly.
+//
+// Implemented by adapters.RevocationFile and adapters.RevocationRedis. Called from service.revocationCache on refresh.
+//
+//go:generate moq -stub -out mock/revocation_source.go -pkg mock . RevocationSource
+type RevocationSource interface {
+	// Load reads the current revocations.
+	// Parameters: ctx — refresh context (deadline bounds a remote store call).
+	// Returns: (revocations, nil) on success (may be empty); (zero, error) when the store cannot be read or parsed.
+	// Called from service.revocationCache on refresh.
+	Load(ctx context.Context) (auth.Revocations, error)
+}
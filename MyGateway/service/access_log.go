@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+
+	"github.com/go-kit/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// AccessLogStreamInterceptor returns a stream server interceptor that writes one access log entry per RPC: it puts a
+// domain.AccessRecord into the context (filled by TransparentProxy and the auth processors), counts messages and bytes in
+// both directions, and after the handler returns logs method, route, cluster, instance_id, sticky_key (or its hash),
+// peer, user, attempts, transfers, req_msgs/req_bytes (client → gateway), resp_msgs/resp_bytes (gateway → client), code,
+// duration_ms and the allow-listed request headers ("header.<name>"). Failed calls are always logged; successful ones
+// with the route's sample rate. Must run outside GatewayErrorToGRPCStreamInterceptor so the logged code is the final one.
+// Panics on nil logger, time provider or sample function.
+//
+// Parameters: logger — access log sink (JSON or logfmt logger over stderr or a rotating file); cfg — access log settings;
+// timeProvider — clock for ts and duration; sample — returns a uniform value in [0, 1) (math/rand.Float64 in cmd/main).
+//
+// Returns: grpc.StreamServerInterceptor (the handler error is returned unchanged).
+//
+// Called from cmd/main when access_log.enabled is set (for the gRPC server and the gRPC-Web and HTTP/JSON frontends).
+func AccessLogStreamInterceptor(logger log.Logger, cfg domain.AccessLogConfig, timeProvider interfaces.TimeProvider, sample func() float64) grpc.StreamServerInterceptor {
+	helpers.NilPanic(logger, "service.access_log.go: logger is required")
+	helpers.NilPanic(timeProvider, "service.access_log.go: time provider is required")
+	helpers.NilPanic(sample, "service.access_log.go: sample function is required")
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := timeProvider.Now()
+		rec := &domain.AccessRecord{}
+		counted := &countingServerStream{ServerStream: ss, ctx: domain.WithAccessRecord(ss.Context(), rec)}
+		err := handler(srv, counted)
+
+		code := status.Code(err)
+		snap := rec.Snapshot()
+		if code == codes.OK && sample() >= cfg.SampleRateFor(snap.Route) {
+			return err
+		}
+		stickyKey := snap.StickyKey
+		if cfg.HashStickyKey && stickyKey != "" {
+			stickyKey = hashStickyKey(stickyKey)
+		}
+		peerAddr := ""
+		if p, ok := peer.FromContext(ss.Context()); ok && p.Addr != nil {
+			peerAddr = p.Addr.String()
+		}
+		keyvals := []any{
+			"ts", start.UTC().Format(time.RFC3339Nano),
+			"method", info.FullMethod,
+			"route", snap.Route,
+			"cluster", snap.Cluster,
+			"instance_id", snap.InstanceID,
+			"sticky_key", stickyKey,
+			"peer", peerAddr,
+			"user", snap.User,
+			"attempts", snap.Attempts,
+			"transfers", snap.Transfers,
+			"req_msgs", counted.reqMsgs.Load(),
+			"req_bytes", counted.reqBytes.Load(),
+			"resp_msgs", counted.respMsgs.Load(),
+			"resp_bytes", counted.respBytes.Load(),
+			"code", code.String(),
+			"duration_ms", float64(timeProvider.Now().Sub(start).Microseconds()) / 1000,
+		}
+		if len(cfg.Headers) > 0 {
+			md, _ := metadata.FromIncomingContext(ss.Context())
+			for _, h := range cfg.Headers {
+				if values := md.Get(h); len(values) > 0 {
+					keyvals = append(keyvals, "header."+h, strings.Join(values, ","))
+				}
+			}
+		}
+		_ = logger.Log(keyvals...)
+		return err
+	}
+}
+
+// hashStickyKey returns "sha256:" and the first 16 hex digits of the SHA-256 of key: stable for correlating entries of
+// one session without writing the session ID itself.
+func hashStickyKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// countingServerStream wraps a grpc.ServerStream: Context carries the access record; RecvMsg/SendMsg count messages and
+// protobuf bytes (for the proxy — the raw payload size). Counters are atomic (the proxy sends and receives from different goroutines).
+type countingServerStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	reqMsgs   atomic.Int64
+	reqBytes  atomic.Int64
+	respMsgs  atomic.Int64
+	respBytes atomic.Int64
+}
+
+// Context returns the stream context with the access record.
+func (s *countingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg receives from the client and counts the message.
+func (s *countingServerStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.reqMsgs.Add(1)
+		s.reqBytes.Add(messageSize(m))
+	}
+	return err
+}
+
+// SendMsg sends to the client and counts the message.
+func (s *countingServerStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.respMsgs.Add(1)
+		s.respBytes.Add(messageSize(m))
+	}
+	return err
+}
+
+// messageSize returns the encoded size of a protobuf message (0 for other types).
+func messageSize(m any) int64 {
+	if pm, ok := m.(proto.Message); ok {
+		return int64(proto.Size(pm))
+	}
+	return 0
+}
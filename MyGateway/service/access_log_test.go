@@ -0,0 +1,160 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/interfaces/mock"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// accessLogStream is a fakeServerStream whose RecvMsg returns one StringValue before io.EOF.
+type accessLogStream struct {
+	fakeServerStream
+	received bool
+}
+
+func (s *accessLogStream) RecvMsg(m any) error {
+	if s.received {
+		return s.fakeServerStream.RecvMsg(m)
+	}
+	s.received = true
+	proto.Merge(m.(proto.Message), wrapperspb.String("ping"))
+	return nil
+}
+
+// runAccessLog runs one call through AccessLogStreamInterceptor with a JSON logger and returns the decoded entries.
+func runAccessLog(t *testing.T, cfg domain.AccessLogConfig, sample float64, md metadata.MD, handler grpc.StreamHandler) []map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	calls := 0
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time {
+		calls++
+		if calls == 1 {
+			return start
+		}
+		return start.Add(1500 * time.Microsecond)
+	}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+	ctx = metadata.NewIncomingContext(ctx, md)
+	interceptor := AccessLogStreamInterceptor(log.NewJSONLogger(&buf), cfg, tp, func() float64 { return sample })
+	_ = interceptor(nil, &accessLogStream{fakeServerStream: fakeServerStream{ctx: ctx}}, &grpc.StreamServerInfo{FullMethod: "/pkg.Svc/Call"}, handler)
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAccessLogStreamInterceptor_Panics(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	sample := func() float64 { return 0 }
+	assert.PanicsWithValue(t, "service.access_log.go: logger is required", func() {
+		AccessLogStreamInterceptor(nil, domain.AccessLogConfig{}, tp, sample)
+	})
+	assert.PanicsWithValue(t, "service.access_log.go: time provider is required", func() {
+		AccessLogStreamInterceptor(log.NewNopLogger(), domain.AccessLogConfig{}, nil, sample)
+	})
+	assert.PanicsWithValue(t, "service.access_log.go: sample function is required", func() {
+		AccessLogStreamInterceptor(log.NewNopLogger(), domain.AccessLogConfig{}, tp, nil)
+	})
+}
+
+func TestAccessLogStreamInterceptor_Fields(t *testing.T) {
+	cfg := domain.AccessLogConfig{Enabled: true, SampleRate: 1, Headers: []string{"x-request-id", "x-missing"}}
+	handler := func(_ any, ss grpc.ServerStream) error {
+		rec := domain.AccessRecordFromContext(ss.Context())
+		rec.SetRoute("/pkg.Svc/", "backend")
+		rec.SetBackend("inst-1", "session-1")
+		rec.AddTransfer()
+		rec.SetBackend("inst-2", "session-1")
+		rec.SetUser("alice")
+		var in wrapperspb.StringValue
+		require.NoError(t, ss.RecvMsg(&in))
+		require.NoError(t, ss.SendMsg(wrapperspb.String("pong")))
+		require.NoError(t, ss.SendMsg(wrapperspb.String("pong")))
+		return nil
+	}
+	entries := runAccessLog(t, cfg, 0.5, metadata.Pairs("x-request-id", "req-7"), handler)
+	require.Len(t, entries, 1)
+	e := entries[0]
+	assert.Equal(t, "2025-01-02T03:04:05Z", e["ts"])
+	assert.Equal(t, "/pkg.Svc/Call", e["method"])
+	assert.Equal(t, "/pkg.Svc/", e["route"])
+	assert.Equal(t, "backend", e["cluster"])
+	assert.Equal(t, "inst-2", e["instance_id"])
+	assert.Equal(t, "session-1", e["sticky_key"])
+	assert.Equal(t, "10.0.0.1:5000", e["peer"])
+	assert.Equal(t, "alice", e["user"])
+	assert.EqualValues(t, 2, e["attempts"])
+	assert.EqualValues(t, 1, e["transfers"])
+	assert.EqualValues(t, 1, e["req_msgs"])
+	assert.EqualValues(t, proto.Size(wrapperspb.String("ping")), e["req_bytes"])
+	assert.EqualValues(t, 2, e["resp_msgs"])
+	assert.EqualValues(t, 2*proto.Size(wrapperspb.String("pong")), e["resp_bytes"])
+	assert.Equal(t, "OK", e["code"])
+	assert.EqualValues(t, 1.5, e["duration_ms"])
+	assert.Equal(t, "req-7", e["header.x-request-id"])
+	assert.NotContains(t, e, "header.x-missing")
+}
+
+func TestAccessLogStreamInterceptor_Sampling(t *testing.T) {
+	cfg := domain.AccessLogConfig{Enabled: true, SampleRate: 0.5, RouteSampleRates: map[string]float64{"/quiet/": 0}}
+	routed := func(prefix string, err error) grpc.StreamHandler {
+		return func(_ any, ss grpc.ServerStream) error {
+			domain.AccessRecordFromContext(ss.Context()).SetRoute(prefix, "c")
+			return err
+		}
+	}
+	t.Run("ok_below_rate_logged", func(t *testing.T) {
+		assert.Len(t, runAccessLog(t, cfg, 0.4, nil, routed("/pkg/", nil)), 1)
+	})
+	t.Run("ok_above_rate_skipped", func(t *testing.T) {
+		assert.Empty(t, runAccessLog(t, cfg, 0.6, nil, routed("/pkg/", nil)))
+	})
+	t.Run("route_override", func(t *testing.T) {
+		assert.Empty(t, runAccessLog(t, cfg, 0, nil, routed("/quiet/", nil)))
+	})
+	t.Run("errors_always_logged", func(t *testing.T) {
+		entries := runAccessLog(t, cfg, 0.99, nil, routed("/quiet/", status.Error(codes.Unavailable, "down")))
+		require.Len(t, entries, 1)
+		assert.Equal(t, "Unavailable", entries[0]["code"])
+	})
+}
+
+func TestAccessLogStreamInterceptor_HashStickyKey(t *testing.T) {
+	cfg := domain.AccessLogConfig{Enabled: true, SampleRate: 1, HashStickyKey: true}
+	entries := runAccessLog(t, cfg, 0, nil, func(_ any, ss grpc.ServerStream) error {
+		domain.AccessRecordFromContext(ss.Context()).SetBackend("inst-1", "session-1")
+		return nil
+	})
+	require.Len(t, entries, 1)
+	key := entries[0]["sticky_key"].(string)
+	assert.Equal(t, hashStickyKey("session-1"), key)
+	assert.True(t, strings.HasPrefix(key, "sha256:"))
+	assert.Len(t, key, len("sha256:")+16)
+	assert.NotContains(t, key, "session-1")
+}
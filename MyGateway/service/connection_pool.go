@@ -3,7 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"io/fs"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"mygateway/domain"
@@ -12,6 +16,7 @@ import (
 
 	"github.com/go-kit/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 )
 
 // ErrConnPoolClosed is returned by GetConnRoundRobin and GetConnForKey when the pool has been closed.
@@ -20,30 +25,80 @@ var ErrConnPoolClosed = errors.New("conn pool is closed")
 // ErrNoAvailableConnInstance is returned when no instance is available: empty list, all factory dials failed, or (for GetConnForKey) no free instance or key empty.
 var ErrNoAvailableConnInstance = errors.New("no available backend instance")
 
+// ErrInstancesSaturated is returned when every candidate instance stays at max_concurrent_streams_per_instance for the whole queue timeout.
+var ErrInstancesSaturated = errors.New("all backend instances are at the concurrent stream limit")
+
+// maxWatchBackoff caps the delay between reconnects of a failing discoverer watch (the delay starts at refreshInterval and doubles).
+const maxWatchBackoff = 30 * time.Second
+
+// refreshJitter spreads every refresh wait over ±10% of refreshInterval, so gateways started together do not poll the
+// discoverer in lockstep.
+const refreshJitter = 0.1
+
+// Unregister queue: OnBackendFailure queues the failed instance (up to unregisterQueueSize pending, more are dropped
+// and logged) and unregisterLoop calls the discoverer, retrying a failed call up to maxUnregisterAttempts times with a
+// delay that starts at defaultUnregisterBackoff and doubles up to maxUnregisterBackoff.
+const (
+	unregisterQueueSize      = 64
+	maxUnregisterAttempts    = 5
+	defaultUnregisterBackoff = time.Second
+	maxUnregisterBackoff     = 30 * time.Second
+)
+
+// unregisterTask is one queued UnregisterInstance call: instanceID and attempt (1 for the first call).
+type unregisterTask struct {
+	instanceID string
+	attempt    int
+}
+
 // connectionPool implements interfaces.ConnectionPool. It maintains a set of backend gRPC connections for a
-// dynamic cluster: a background refresh loop calls Discoverer.GetInstances and updates the instance
-// list; connections for instances that disappeared are closed and sticky bindings removed;
-// GetConnRoundRobin returns the next connection in round-robin order; GetConnForKey binds a key
-// (e.g. session-id) to an instance and reuses that connection; OnBackendFailure unbinds the key,
-// closes the connection to that instance, and calls Discoverer.UnregisterInstance. Fields: discoverer,
-// factory, refreshInterval, logger; under mu: instances, keyToID (sticky key → instanceID), instanceConn (instanceID → conn), rr (round-robin index), closed.
+// dynamic cluster: the instance list comes from the discoverer (refreshLoop, or watchLoop when it is an
+// interfaces.InstanceWatcher) and is kept as a last-known-good snapshot (succeeded, loadSnapshot, expireStale);
+// GetConnectionRoundRobin and GetConnectionForKey pick among the non-draining instances matching the call's label
+// selector, preferring the local zone (localLocked) and, with a stream limit, waiting for a free slot (acquire);
+// OnBackendFailure drops the instance and queues its unregistration (unregisterLoop); ReleaseKeys unbinds revoked
+// sessions.
+// Fields: discoverer, factory, refreshInterval, logger, timeProvider, limit, locality, snapshots (nil — no
+// persistence), maxAge (0 — no limit), watching (last watch call succeeded), ctx (lifetime of the pool — passed to the
+// discoverer and ends the background loops), stop (cancels ctx; called by Close), unregister (queue of unregisterLoop),
+// unregisterBackoff (first retry delay); under mu: instances, updated (time of the last successful answer or SavedAt of
+// the loaded snapshot; zero — no list yet), saved and savedAt (content and time of the last snapshot saved or loaded;
+// zero savedAt — none), keyToID (sticky key → instanceID), instanceConn (instanceID → conn), rr (selector key →
+// round-robin position among its matching instances), active (instanceID → open streams), released (closed and
+// replaced on every ReleaseStream), closed.
 type connectionPool struct {
 	discoverer      interfaces.Discoverer
 	factory         func(ctx context.Context, instance domain.ServiceInstance) (*grpc.ClientConn, error)
 	refreshInterval time.Duration
 	logger          log.Logger
+	timeProvider    interfaces.TimeProvider
+	limit           domain.StreamLimit
+	locality        domain.Locality
+	snapshots       interfaces.InstanceSnapshotStore
+	maxAge          time.Duration
+	watching        atomic.Bool
+	ctx             context.Context
+	stop            context.CancelFunc
+
+	unregister        chan unregisterTask
+	unregisterBackoff time.Duration
 
 	mu           sync.RWMutex
 	instances    []domain.ServiceInstance
+	updated      time.Time
+	saved        []domain.ServiceInstance
+	savedAt      time.Time
 	keyToID      map[string]string
 	instanceConn map[string]*grpc.ClientConn
-	rr           int
+	rr           map[string]*subsetRR
+	active       map[string]int
+	released     chan struct{}
 	closed       bool
 }
 
-// NewConnectionPool creates a connection pool for one dynamic cluster: starts a goroutine that refreshes the instance list every refreshInterval and runs the first refresh. Panics on nil discoverer, factory or logger.
+// NewConnectionPool creates a connection pool for one dynamic cluster: runs the first refresh (falling back to the snapshot in snapshots when it fails), starts a goroutine that refreshes the instance list every refreshInterval (jittered), one that sends the queued UnregisterInstance calls and, when discoverer implements interfaces.InstanceWatcher, one that follows the watch. Panics on nil discoverer, factory, logger or time provider.
 //
-// Parameters: discoverer — source of instance list (e.g. adapters.DiscovererHTTP); factory — (ctx, ServiceInstance) → (*grpc.ClientConn, error) for dialing; refreshInterval — refresh interval (e.g. 5s); logger — logger (GetInstances errors are logged).
+// Parameters: discoverer — source of instance list (e.g. adapters.DiscovererHTTP); factory — (ctx, ServiceInstance) → (*grpc.ClientConn, error) for dialing; refreshInterval — refresh interval (e.g. 5s); logger — logger (GetInstances errors are logged); timeProvider — clock for the age of the list and snapshots; limit — concurrent streams per instance (zero value — unlimited); locality — zone preference (zero value — none); snapshots — last-known-good store (nil — none); maxAge — how long the list may go without a successful discoverer answer before it is dropped, also the oldest snapshot accepted at startup (0 — no limit).
 //
 // Returns: interfaces.ConnectionPool (*connectionPool).
 //
@@ -53,42 +108,269 @@ func NewConnectionPool(
 	factory func(ctx context.Context, instance domain.ServiceInstance) (*grpc.ClientConn, error),
 	refreshInterval time.Duration,
 	logger log.Logger,
+	timeProvider interfaces.TimeProvider,
+	limit domain.StreamLimit,
+	locality domain.Locality,
+	snapshots interfaces.InstanceSnapshotStore,
+	maxAge time.Duration,
 ) interfaces.ConnectionPool {
 	p := &connectionPool{
 		discoverer:      helpers.NilPanic(discoverer, "service.connection_pool.go: discoverer is required"),
 		factory:         helpers.NilPanic(factory, "service.connection_pool.go: factory is required"),
 		refreshInterval: refreshInterval,
 		logger:          log.With(helpers.NilPanic(logger, "service.connection_pool.go: logger is required"), "component", "connection_pool"),
+		timeProvider:    helpers.NilPanic(timeProvider, "service.connection_pool.go: time provider is required"),
+		limit:           limit,
+		locality:        locality,
+		snapshots:       snapshots,
+		maxAge:          maxAge,
 		keyToID:         make(map[string]string),
 		instanceConn:    make(map[string]*grpc.ClientConn),
+		rr:              make(map[string]*subsetRR),
+		active:          make(map[string]int),
+		released:        make(chan struct{}),
+
+		unregister:        make(chan unregisterTask, unregisterQueueSize),
+		unregisterBackoff: defaultUnregisterBackoff,
+	}
+	p.ctx, p.stop = context.WithCancel(context.Background())
+	if !p.refresh() && snapshots != nil {
+		p.loadSnapshot()
+	}
+	if watcher, ok := discoverer.(interfaces.InstanceWatcher); ok {
+		go p.watchLoop(p.ctx, watcher)
 	}
-	p.refresh()
 	go p.refreshLoop()
+	go p.unregisterLoop()
 	return p
 }
 
-// refreshLoop runs refresh every refreshInterval in a ticker loop. Exits when the pool is closed (ticker.Stop on loop exit not required — goroutine lives until process exit).
+// refreshLoop runs refresh every refreshInterval (each wait jittered by refreshJitter), skipping rounds while the watch
+// is healthy. Exits when the pool is closed.
 //
 // Called only from NewConnectionPool in a separate goroutine.
 func (p *connectionPool) refreshLoop() {
-	ticker := time.NewTicker(p.refreshInterval)
-	defer ticker.Stop()
-	for range ticker.C {
-		p.refresh()
+	timer := time.NewTimer(helpers.Jitter(p.refreshInterval, refreshJitter))
+	defer timer.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if !p.watching.Load() {
+			p.refresh()
+		}
+		timer.Reset(helpers.Jitter(p.refreshInterval, refreshJitter))
 	}
 }
 
-// refresh fetches the current instance list from the discoverer; on error logs and returns. On success under lock closes connections for instances not in the new list, unbinds their sticky keys, replaces the instance list and resets rr if needed.
+// unregisterLoop sends the queued UnregisterInstance calls one by one with the pool context. A failed call is queued
+// again after helpers.Backoff(attempt, unregisterBackoff, maxUnregisterBackoff) (a timer, so other tasks are not held
+// up) until maxUnregisterAttempts, then dropped with a log line. Exits when the pool is closed.
 //
-// Parameters and return: none. GetInstances error is not returned, only logged.
+// Called only from NewConnectionPool in a separate goroutine.
+func (p *connectionPool) unregisterLoop() {
+	for {
+		var task unregisterTask
+		select {
+		case <-p.ctx.Done():
+			return
+		case task = <-p.unregister:
+		}
+		err := p.discoverer.UnregisterInstance(p.ctx, task.instanceID)
+		if err == nil || p.ctx.Err() != nil {
+			continue
+		}
+		logger := log.With(p.logger, "err", err, "instance_id", task.instanceID, "attempt", task.attempt)
+		if task.attempt >= maxUnregisterAttempts {
+			_ = logger.Log("msg", "discoverer UnregisterInstance failed, giving up")
+			continue
+		}
+		delay := helpers.Backoff(task.attempt, p.unregisterBackoff, maxUnregisterBackoff)
+		_ = log.With(logger, "retry_in", delay).Log("msg", "discoverer UnregisterInstance failed")
+		task.attempt++
+		time.AfterFunc(delay, func() { p.enqueueUnregister(task) })
+	}
+}
+
+// enqueueUnregister queues task for unregisterLoop without blocking; when the queue is full the task is dropped and
+// logged (the discoverer TTL still expires the instance). Does nothing after Close.
+//
+// Called from OnBackendFailure and from the retry timers of unregisterLoop.
+func (p *connectionPool) enqueueUnregister(task unregisterTask) {
+	if p.ctx.Err() != nil {
+		return
+	}
+	select {
+	case p.unregister <- task:
+	default:
+		_ = log.With(p.logger, "instance_id", task.instanceID).Log("msg", "unregister queue is full, dropped")
+	}
+}
+
+// watchLoop calls watcher.WatchInstances in a loop, passing the version of the previous answer, and applies the list
+// whenever the version changes. On error it logs, clears watching (the refresh ticker takes over) and reconnects after a
+// jittered delay that starts at refreshInterval and doubles up to maxWatchBackoff (helpers.Backoff); the next watch
+// starts from an empty version so the current list is applied at once.
+//
+// Parameters: ctx — cancelled by Close; watcher — the pool discoverer as interfaces.InstanceWatcher.
+//
+// Called only from NewConnectionPool in a separate goroutine.
+func (p *connectionPool) watchLoop(ctx context.Context, watcher interfaces.InstanceWatcher) {
+	version := ""
+	failures := 0
+	for {
+		instances, next, err := watcher.WatchInstances(ctx, version)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			p.watching.Store(false)
+			failures++
+			backoff := helpers.Backoff(failures, p.refreshInterval, maxWatchBackoff)
+			_ = log.With(p.logger, "err", err, "retry_in", backoff).Log("msg", "discoverer watch failed, polling")
+			version = ""
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		if !p.watching.Swap(true) {
+			_ = p.logger.Log("msg", "discoverer watch established")
+		}
+		failures = 0
+		if next != version {
+			p.apply(instances)
+			version = next
+		}
+		p.succeeded(instances)
+	}
+}
+
+// refresh fetches the current instance list from the discoverer and applies it; on error logs (with the age of the
+// kept list) and drops the list once it is older than maxAge.
+//
+// Returns: true when the list was applied; false on GetInstances error (the error is only logged).
 //
 // Called from refreshLoop on timer and once from NewConnectionPool at startup.
-func (p *connectionPool) refresh() {
-	instances, err := p.discoverer.GetInstances()
+func (p *connectionPool) refresh() bool {
+	instances, err := p.discoverer.GetInstances(p.ctx)
 	if err != nil {
-		_ = log.With(p.logger, "err", err).Log("msg", "discoverer GetInstances failed")
+		age, _ := p.InstancesAge()
+		_ = log.With(p.logger, "err", err, "instances_age", age).Log("msg", "discoverer GetInstances failed")
+		p.expireStale()
+		return false
+	}
+	p.apply(instances)
+	p.succeeded(instances)
+	return true
+}
+
+// succeeded records a successful discoverer answer: resets the age of the list and saves a copy of instances as the
+// last-known-good snapshot when it differs from the last saved one, or when maxAge is set and that one is at least
+// maxAge/2 old (so a restored snapshot is not rejected as too old while the list is stable). A failed save is logged
+// and retried on the next answer.
+//
+// Called from refresh and watchLoop.
+func (p *connectionPool) succeeded(instances []domain.ServiceInstance) {
+	now := p.timeProvider.Now()
+	p.mu.Lock()
+	p.updated = now
+	save := p.snapshots != nil && (p.savedAt.IsZero() || !sameInstances(p.saved, instances) ||
+		(p.maxAge > 0 && now.Sub(p.savedAt) >= p.maxAge/2))
+	var snapshot domain.InstanceSnapshot
+	if save {
+		// Copied under mu: instances may be the pool's own list, which OnBackendFailure changes.
+		snapshot = domain.InstanceSnapshot{SavedAt: now, Instances: slices.Clone(instances)}
+		p.saved, p.savedAt = snapshot.Instances, now
+	}
+	p.mu.Unlock()
+	if !save {
+		return
+	}
+	if err := p.snapshots.Save(snapshot); err != nil {
+		_ = log.With(p.logger, "err", err).Log("msg", "instance snapshot not saved")
+		p.mu.Lock()
+		p.savedAt = time.Time{}
+		p.mu.Unlock()
+	}
+}
+
+// sameInstances reports whether a and b hold the same instances in the same order.
+func sameInstances(a, b []domain.ServiceInstance) bool {
+	return slices.EqualFunc(a, b, func(x, y domain.ServiceInstance) bool {
+		return x.InstanceID == y.InstanceID && x.Address == y.Address && x.Port == y.Port &&
+			x.AssignedClientSessionID == y.AssignedClientSessionID && x.Draining == y.Draining && maps.Equal(x.Labels, y.Labels)
+	})
+}
+
+// loadSnapshot applies the stored snapshot unless it is older than maxAge; a missing snapshot is silently ignored,
+// other load errors are logged.
+//
+// Called only from NewConnectionPool when the first refresh fails.
+func (p *connectionPool) loadSnapshot() {
+	snapshot, err := p.snapshots.Load()
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			_ = log.With(p.logger, "err", err).Log("msg", "instance snapshot not loaded")
+		}
+		return
+	}
+	age := p.timeProvider.Now().Sub(snapshot.SavedAt)
+	if p.maxAge > 0 && age > p.maxAge {
+		_ = log.With(p.logger, "age", age, "max_age", p.maxAge).Log("msg", "instance snapshot is too old, ignored")
+		return
+	}
+	p.apply(snapshot.Instances)
+	p.mu.Lock()
+	p.updated = snapshot.SavedAt
+	p.saved, p.savedAt = snapshot.Instances, snapshot.SavedAt
+	p.mu.Unlock()
+	_ = log.With(p.logger, "instances", len(snapshot.Instances), "age", age).Log("msg", "serving last-known-good instance snapshot")
+}
+
+// expireStale drops the instance list (closing its connections) when maxAge is set and the last successful answer is
+// older than that. The list stays dropped until the discoverer answers again.
+//
+// Called from refresh after a GetInstances error.
+func (p *connectionPool) expireStale() {
+	if p.maxAge <= 0 {
+		return
+	}
+	p.mu.RLock()
+	stale := len(p.instances) > 0 && !p.updated.IsZero() && p.timeProvider.Now().Sub(p.updated) > p.maxAge
+	p.mu.RUnlock()
+	if !stale {
 		return
 	}
+	_ = log.With(p.logger, "max_age", p.maxAge).Log("msg", "instance list is stale, dropped")
+	p.apply(nil)
+}
+
+// InstancesAge returns how long ago the current instance list was obtained from the discoverer (for a snapshot loaded
+// at startup — since it was saved).
+//
+// Returns: (age, true); (0, false) when the pool never had a list.
+//
+// Called from refresh (logged on discoverer errors) and from cmd/main after the pools are built.
+func (p *connectionPool) InstancesAge() (time.Duration, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.updated.IsZero() {
+		return 0, false
+	}
+	return p.timeProvider.Now().Sub(p.updated), true
+}
+
+// apply under lock closes connections for instances not in the new list, unbinds their sticky keys, replaces the instance list and drops the round-robin positions of subsets that no longer match any instance.
+//
+// Parameter instances — complete instance list from the discoverer.
+//
+// Called from refresh and watchLoop.
+func (p *connectionPool) apply(instances []domain.ServiceInstance) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -108,76 +390,255 @@ func (p *connectionPool) refresh() {
 		}
 	}
 	p.instances = instances
-	if p.rr >= len(p.instances) {
-		p.rr = 0
+	for key, s := range p.rr {
+		if !slices.ContainsFunc(instances, s.matches) {
+			delete(p.rr, key)
+		}
 	}
 }
 
-// GetConnectionRoundRobin returns a connection to the next instance in round-robin order, creating it via factory if needed. Caller should respect ctx cancellation (timeout/cancel lead to factory error).
-//
-// Parameter ctx — context for dial when creating a new connection; cancel or timeout lead to factory error and move to next instance (or ErrNoAvailableConnInstance if all attempts fail).
-//
-// Returns: (conn, instanceID, nil) on success; (nil, "", ErrConnPoolClosed) if pool is closed; (nil, "", ErrNoAvailableConnInstance) when instance list is empty or dial to all instances fails.
+// subsetRR is the round-robin position of one label selector, limited to zone when the pool keeps calls local: next
+// indexes the instances matching both, in list order.
+type subsetRR struct {
+	selector domain.LabelSelector
+	zone     string
+	next     int
+}
+
+// matches reports whether inst belongs to the subset.
+func (s *subsetRR) matches(inst domain.ServiceInstance) bool {
+	return s.selector.Matches(inst.Labels) && (s.zone == "" || inst.Labels[domain.ZoneLabel] == s.zone)
+}
+
+// localLocked returns the candidates in locality.Zone when at least locality.SpillThreshold of them are available, or
+// nil when the pool has no zone or must spill over to all zones. Caller must hold p.mu.
 //
-// Called from connectionResolverGeneric.GetConnection when route.Balancer.Type != sticky_sessions.
-func (p *connectionPool) GetConnectionRoundRobin(ctx context.Context) (*grpc.ClientConn, string, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	if p.closed {
-		return nil, "", ErrConnPoolClosed
-	}
-	if len(p.instances) == 0 {
-		return nil, "", ErrNoAvailableConnInstance
+// Parameters: candidates — instances matching the selector of the call; available — whether an instance can take the
+// call (the balancer's own skip rules).
+func (p *connectionPool) localLocked(candidates []domain.ServiceInstance, available func(domain.ServiceInstance) bool) []domain.ServiceInstance {
+	if p.locality.Zone == "" {
+		return nil
 	}
-	for i := 0; i < len(p.instances); i++ {
-		idx := (p.rr + i) % len(p.instances)
-		inst := p.instances[idx]
-		conn, err := p.getOrCreateConnLocked(ctx, inst)
-		if err != nil {
+	var local []domain.ServiceInstance
+	n := 0
+	for _, inst := range candidates {
+		if inst.Labels[domain.ZoneLabel] != p.locality.Zone {
 			continue
 		}
-		p.rr = (idx + 1) % len(p.instances)
-		return conn, inst.InstanceID, nil
+		local = append(local, inst)
+		if available(inst) {
+			n++
+		}
+	}
+	if n < p.locality.SpillThreshold {
+		return nil
+	}
+	return local
+}
+
+// matchingLocked returns the instances matching selector (p.instances itself for an empty selector). Caller must hold p.mu.
+func (p *connectionPool) matchingLocked(selector domain.LabelSelector) []domain.ServiceInstance {
+	if len(selector) == 0 {
+		return p.instances
+	}
+	out := make([]domain.ServiceInstance, 0, len(p.instances))
+	for _, inst := range p.instances {
+		if selector.Matches(inst.Labels) {
+			out = append(out, inst)
+		}
 	}
-	return nil, "", ErrNoAvailableConnInstance
+	return out
 }
 
-// GetConnectionForKey returns a connection for the sticky key: if key is already bound to an instance with a live connection returns it; otherwise picks a free instance or one already bound to this key, creates the connection if needed, binds key→instanceID and returns.
+// GetConnectionRoundRobin returns a connection to the next instance matching selector in round-robin order (the position is kept per selector), creating it via factory if needed; saturated and draining instances are skipped. Same-zone instances are preferred while enough of them are available (localLocked). Caller should respect ctx cancellation (timeout/cancel lead to factory error).
 //
-// Parameters: ctx — for dial when creating connection; key — sticky header value (e.g. session-id). Empty key yields (nil, "", ErrNoAvailableConnInstance).
+// Parameters: ctx — context for dial when creating a new connection; cancel or timeout lead to factory error and move to next instance (or ErrNoAvailableConnInstance if all attempts fail); selector — route subset labels (nil — all instances).
 //
-// Returns: (conn, instanceID, nil) on success; (nil, "", ErrConnPoolClosed) if pool is closed; (nil, "", ErrNoAvailableConnInstance) on empty key or no suitable instance (all occupied by other keys or dial error).
+// Returns: (conn, instanceID, nil) on success (with a stream limit the slot is held until ReleaseStream); (nil, "", ErrConnPoolClosed) if pool is closed; (nil, "", ErrNoAvailableConnInstance) when no instance matches selector or dial to all of them fails; (nil, "", ErrInstancesSaturated) when only saturated instances remain after the queue timeout; ctx status error if ctx ends while waiting.
+//
+// Called from connectionResolverGeneric.GetConnection when route.Balancer.Type != sticky_sessions.
+func (p *connectionPool) GetConnectionRoundRobin(ctx context.Context, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
+	return p.acquire(ctx, func() (*grpc.ClientConn, string, bool) {
+		candidates := p.matchingLocked(selector)
+		if len(candidates) == 0 {
+			return nil, "", false
+		}
+		key, zone := selector.Key(), ""
+		if local := p.localLocked(candidates, func(inst domain.ServiceInstance) bool {
+			return !inst.Draining && !p.isSaturatedLocked(inst.InstanceID)
+		}); local != nil {
+			candidates, zone = local, p.locality.Zone
+			key += "\x01" + zone
+		}
+		pos := p.rr[key]
+		if pos == nil {
+			pos = &subsetRR{selector: selector, zone: zone}
+			p.rr[key] = pos
+		}
+		saturated := false
+		for i := 0; i < len(candidates); i++ {
+			idx := (pos.next + i) % len(candidates)
+			inst := candidates[idx]
+			if inst.Draining {
+				continue
+			}
+			if p.isSaturatedLocked(inst.InstanceID) {
+				saturated = true
+				continue
+			}
+			conn, err := p.getOrCreateConnLocked(ctx, inst)
+			if err != nil {
+				continue
+			}
+			pos.next = (idx + 1) % len(candidates)
+			return conn, inst.InstanceID, false
+		}
+		return nil, "", saturated
+	})
+}
+
+// GetConnectionForKey returns a connection for the sticky key: if key is already bound to an instance with a live connection that matches selector returns it (also when the instance is draining); otherwise picks a free, non-draining instance matching selector (in the gateway zone while enough are free there) or one already bound to this key, creates the connection if needed, binds key→instanceID and returns. With a stream limit a saturated bound instance is waited for (the session is not moved); unbound keys skip saturated instances.
+//
+// Parameters: ctx — for dial when creating connection; key — sticky header value (e.g. session-id). Empty key yields (nil, "", ErrNoAvailableConnInstance); selector — route subset labels (nil — all instances). A key bound to an instance outside selector (e.g. the subset header changed) is rebound.
+//
+// Returns: (conn, instanceID, nil) on success (with a stream limit the slot is held until ReleaseStream); (nil, "", ErrConnPoolClosed) if pool is closed; (nil, "", ErrNoAvailableConnInstance) on empty key or no suitable instance (no matching instance, all occupied by other keys or dial error); (nil, "", ErrInstancesSaturated) when the candidates stay saturated for the queue timeout; ctx status error if ctx ends while waiting.
 //
 // Called from connectionResolverGeneric.GetConnection when route.Balancer.Type == sticky_sessions.
-func (p *connectionPool) GetConnectionForKey(ctx context.Context, key string) (*grpc.ClientConn, string, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	if p.closed {
-		return nil, "", ErrConnPoolClosed
-	}
+func (p *connectionPool) GetConnectionForKey(ctx context.Context, key string, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 	if key == "" {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		if p.closed {
+			return nil, "", ErrConnPoolClosed
+		}
 		return nil, "", ErrNoAvailableConnInstance
 	}
-	if id := p.keyToID[key]; id != "" {
-		if conn := p.instanceConn[id]; conn != nil {
-			return conn, id, nil
+	return p.acquire(ctx, func() (*grpc.ClientConn, string, bool) {
+		if id := p.keyToID[key]; id != "" {
+			if conn := p.instanceConn[id]; conn != nil && p.instanceMatchesLocked(id, selector) {
+				if p.isSaturatedLocked(id) {
+					return nil, "", true
+				}
+				return conn, id, false
+			}
+			delete(p.keyToID, key)
+		}
+		candidates := p.matchingLocked(selector)
+		if local := p.localLocked(candidates, func(inst domain.ServiceInstance) bool {
+			return !inst.Draining && !p.isInstanceAssignedToOtherKey(inst.InstanceID, key) && !p.isSaturatedLocked(inst.InstanceID)
+		}); local != nil {
+			candidates = local
+		}
+		saturated := false
+		for _, inst := range candidates {
+			// Skip instance if it's already assigned to a different session (from our keyToID map).
+			// Discoverer does not provide AssignedClientSessionID; we track assignments locally.
+			if inst.Draining || p.isInstanceAssignedToOtherKey(inst.InstanceID, key) {
+				continue
+			}
+			if p.isSaturatedLocked(inst.InstanceID) {
+				saturated = true
+				continue
+			}
+			conn, err := p.getOrCreateConnLocked(ctx, inst)
+			if err != nil {
+				continue
+			}
+			p.keyToID[key] = inst.InstanceID
+			return conn, inst.InstanceID, false
+		}
+		return nil, "", saturated
+	})
+}
+
+// acquire runs pick under p.mu and takes a stream slot of the picked instance. When pick finds nothing but some
+// candidate was skipped only for being saturated, acquire releases the lock and waits for ReleaseStream (then picks
+// again) until limit.QueueTimeout or ctx end.
+//
+// Parameters: ctx — request context (ends the wait); pick — balancer step, called with p.mu held: returns (conn,
+// instanceID, false) on success, or (nil, "", saturated).
+//
+// Returns: (conn, instanceID, nil); (nil, "", ErrConnPoolClosed | ErrNoAvailableConnInstance | ErrInstancesSaturated);
+// (nil, "", ctx status error) when ctx ends while waiting.
+//
+// Called only from GetConnectionRoundRobin and GetConnectionForKey.
+func (p *connectionPool) acquire(ctx context.Context, pick func() (*grpc.ClientConn, string, bool)) (*grpc.ClientConn, string, error) {
+	var deadline <-chan time.Time
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, "", ErrConnPoolClosed
+		}
+		conn, instanceID, saturated := pick()
+		if conn != nil {
+			if p.limit.MaxPerInstance > 0 {
+				p.active[instanceID]++
+			}
+			p.mu.Unlock()
+			return conn, instanceID, nil
+		}
+		released := p.released
+		p.mu.Unlock()
+		if !saturated {
+			return nil, "", ErrNoAvailableConnInstance
+		}
+		if deadline == nil {
+			timer := time.NewTimer(p.limit.QueueTimeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		select {
+		case <-released:
+		case <-deadline:
+			return nil, "", ErrInstancesSaturated
+		case <-ctx.Done():
+			return nil, "", status.FromContextError(ctx.Err()).Err()
 		}
-		delete(p.keyToID, key)
+	}
+}
+
+// instanceMatchesLocked reports whether instanceID is in the instance list and its labels match selector. Caller must hold p.mu.
+func (p *connectionPool) instanceMatchesLocked(instanceID string, selector domain.LabelSelector) bool {
+	if len(selector) == 0 {
+		return true
 	}
 	for _, inst := range p.instances {
-		// Skip instance if it's already assigned to a different session (from our keyToID map).
-		// Discoverer does not provide AssignedClientSessionID; we track assignments locally.
-		if p.isInstanceAssignedToOtherKey(inst.InstanceID, key) {
-			continue
-		}
-		conn, err := p.getOrCreateConnLocked(ctx, inst)
-		if err != nil {
-			continue
+		if inst.InstanceID == instanceID {
+			return selector.Matches(inst.Labels)
 		}
-		p.keyToID[key] = inst.InstanceID
-		return conn, inst.InstanceID, nil
 	}
-	return nil, "", ErrNoAvailableConnInstance
+	return false
+}
+
+// isSaturatedLocked reports whether instanceID already carries limit.MaxPerInstance streams (always false without a limit). Caller must hold p.mu.
+func (p *connectionPool) isSaturatedLocked(instanceID string) bool {
+	return p.limit.MaxPerInstance > 0 && p.active[instanceID] >= p.limit.MaxPerInstance
+}
+
+// ReleaseStream frees one stream slot of instanceID and wakes waiting GetConnection* calls. No-op without a stream limit
+// or when the instance has no open streams (counts never go negative).
+//
+// Parameter instanceID — instance returned by GetConnectionRoundRobin/GetConnectionForKey.
+//
+// Called from connectionResolverGeneric.ReleaseStream when the proxy is done with the backend stream.
+func (p *connectionPool) ReleaseStream(instanceID string) {
+	if p.limit.MaxPerInstance <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := p.active[instanceID]
+	if n == 0 {
+		return
+	}
+	if n == 1 {
+		delete(p.active, instanceID)
+	} else {
+		p.active[instanceID] = n - 1
+	}
+	close(p.released)
+	p.released = make(chan struct{})
 }
 
 // isInstanceAssignedToOtherKey returns true if instanceID is bound in keyToID to any key other than excludeKey. Used when choosing an instance for GetConnectionForKey (do not assign an instance already occupied by another session).
@@ -198,7 +659,7 @@ func (p *connectionPool) isInstanceAssignedToOtherKey(instanceID, excludeKey str
 
 // getOrCreateConnLocked returns the cached connection for the instance or creates it via factory, caches and returns. Caller must hold p.mu.
 //
-// Parameters: ctx — for dial; inst — instance (InstanceID, Ipv4, Port). On factory error the connection is not cached.
+// Parameters: ctx — for dial; inst — instance (InstanceID, Address, Port). On factory error the connection is not cached.
 //
 // Returns: (conn, nil) on success; (nil, error) on factory error.
 //
@@ -215,14 +676,13 @@ func (p *connectionPool) getOrCreateConnLocked(ctx context.Context, inst domain.
 	return conn, nil
 }
 
-// OnBackendFailure unbinds the sticky key (if non-empty), closes and removes the connection for instanceID, removes the instance from the instances list (so retries don't hit the dead instance) and calls discoverer.UnregisterInstance(instanceID).
+// OnBackendFailure unbinds the sticky key (if non-empty), closes and removes the connection for instanceID, removes the instance from the instances list (so retries don't hit the dead instance) and queues discoverer.UnregisterInstance(instanceID) for unregisterLoop, so a slow or unreachable discoverer never holds up the caller or the pool lock.
 //
 // Parameters: key — sticky key of the failed request (empty string allowed — only close and UnregisterInstance will run); instanceID — identifier of the instance that failed.
 //
 // Called from connectionResolverGeneric.OnBackendFailure on stream or dial failure to the backend.
 func (p *connectionPool) OnBackendFailure(key string, instanceID string) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
 	if key != "" {
 		delete(p.keyToID, key)
 	}
@@ -230,20 +690,29 @@ func (p *connectionPool) OnBackendFailure(key string, instanceID string) {
 		_ = conn.Close()
 		delete(p.instanceConn, instanceID)
 	}
-	// Remove from instances so openBackendStream retries don't keep dialing the same dead instance.
-	for i := 0; i < len(p.instances); i++ {
-		if p.instances[i].InstanceID == instanceID {
-			p.instances = append(p.instances[:i], p.instances[i+1:]...)
-			if p.rr >= len(p.instances) {
-				p.rr = 0
-			}
-			break
-		}
+	// Remove from instances so openBackendStream retries don't keep dialing the same dead instance. A new slice, not an
+	// in-place shift: the old one may be the discoverer's answer still being saved as a snapshot.
+	if i := slices.IndexFunc(p.instances, func(inst domain.ServiceInstance) bool { return inst.InstanceID == instanceID }); i >= 0 {
+		p.instances = slices.Concat(p.instances[:i], p.instances[i+1:])
+	}
+	p.mu.Unlock()
+	p.enqueueUnregister(unregisterTask{instanceID: instanceID, attempt: 1})
+}
+
+// ReleaseKeys removes the keyToID bindings of keys (e.g. revoked sessions) so the bound instances can be assigned to other keys. Connections are kept and the discoverer is not notified — the instances are healthy.
+//
+// Parameters: keys — sticky key values; keys without a binding are ignored.
+//
+// Called from connectionResolverGeneric.ReleaseStickyKeys when sessions are revoked.
+func (p *connectionPool) ReleaseKeys(keys []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range keys {
+		delete(p.keyToID, key)
 	}
-	_ = p.discoverer.UnregisterInstance(instanceID)
 }
 
-// Close marks the pool closed, closes all cached connections and clears the maps. Idempotent: repeated call returns nil with no side effects.
+// Close marks the pool closed, stops the watch, closes all cached connections and clears the maps. Idempotent: repeated call returns nil with no side effects.
 //
 // Returns: nil (connection close errors are not returned).
 //
@@ -255,10 +724,14 @@ func (p *connectionPool) Close() error {
 		return nil
 	}
 	p.closed = true
+	p.stop()
 	for _, conn := range p.instanceConn {
 		_ = conn.Close()
 	}
 	p.instanceConn = map[string]*grpc.ClientConn{}
 	p.keyToID = map[string]string{}
+	// Wake calls waiting for a stream slot; they observe closed and return ErrConnPoolClosed.
+	close(p.released)
+	p.released = make(chan struct{})
 	return nil
 }
@@ -3,18 +3,24 @@ package service
 import (
 	"context"
 	"errors"
+	"io/fs"
 	"net"
+	"slices"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"mygateway/domain"
+	"mygateway/interfaces"
 	"mygateway/interfaces/mock"
 
 	"github.com/go-kit/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 func newTestConn(t *testing.T) *grpc.ClientConn {
@@ -31,24 +37,29 @@ func newTestConn(t *testing.T) *grpc.ClientConn {
 }
 
 func TestNewConnPool_Panics(t *testing.T) {
-	disco := &mock.DiscovererMock{GetInstancesFunc: func() ([]domain.ServiceInstance, error) { return nil, nil }}
+	disco := &mock.DiscovererMock{GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) { return nil, nil }}
 	factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) { return nil, nil }
 	logger := log.NewNopLogger()
 	interval := time.Hour
 
 	t.Run("discoverer_nil", func(t *testing.T) {
 		assert.PanicsWithValue(t, "service.connection_pool.go: discoverer is required", func() {
-			NewConnectionPool(nil, factory, interval, logger)
+			NewConnectionPool(nil, factory, interval, logger, NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		})
 	})
 	t.Run("factory_nil", func(t *testing.T) {
 		assert.PanicsWithValue(t, "service.connection_pool.go: factory is required", func() {
-			NewConnectionPool(disco, nil, interval, logger)
+			NewConnectionPool(disco, nil, interval, logger, NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		})
 	})
 	t.Run("logger_nil", func(t *testing.T) {
 		assert.PanicsWithValue(t, "service.connection_pool.go: logger is required", func() {
-			NewConnectionPool(disco, factory, interval, nil)
+			NewConnectionPool(disco, factory, interval, nil, NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
+		})
+	})
+	t.Run("time_provider_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.connection_pool.go: time provider is required", func() {
+			NewConnectionPool(disco, factory, interval, logger, nil, domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		})
 	})
 }
@@ -57,37 +68,37 @@ func TestConnPool_GetConnRoundRobin(t *testing.T) {
 	ctx := context.Background()
 	testConn := newTestConn(t)
 	instances := []domain.ServiceInstance{
-		{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001},
+		{InstanceID: "i1", Address: "127.0.0.1", Port: 9001},
 	}
 
 	t.Run("empty_instances_returns_error", func(t *testing.T) {
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return []domain.ServiceInstance{}, nil
 			},
 		}
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		_, _, err := p.GetConnectionRoundRobin(ctx)
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrNoAvailableConnInstance)
 	})
 
 	t.Run("one_instance_factory_succeeds_returns_conn", func(t *testing.T) {
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return instances, nil
 			},
 		}
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		conn, id, err := p.GetConnectionRoundRobin(ctx)
+		conn, id, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.NoError(t, err)
 		require.NotNil(t, conn)
 		assert.Equal(t, "i1", id)
@@ -95,43 +106,43 @@ func TestConnPool_GetConnRoundRobin(t *testing.T) {
 
 	t.Run("closed_pool_returns_error", func(t *testing.T) {
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return instances, nil
 			},
 		}
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		p.Close()
-		_, _, err := p.GetConnectionRoundRobin(ctx)
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrConnPoolClosed)
 	})
 
 	t.Run("all_factory_calls_fail_returns_error", func(t *testing.T) {
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return instances, nil
 			},
 		}
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return nil, errors.New("dial failed")
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		_, _, err := p.GetConnectionRoundRobin(ctx)
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrNoAvailableConnInstance)
 	})
 
 	t.Run("two_instances_first_factory_fails_second_succeeds", func(t *testing.T) {
 		twoInstances := []domain.ServiceInstance{
-			{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001},
-			{InstanceID: "i2", Ipv4: "127.0.0.1", Port: 9002},
+			{InstanceID: "i1", Address: "127.0.0.1", Port: 9001},
+			{InstanceID: "i2", Address: "127.0.0.1", Port: 9002},
 		}
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return twoInstances, nil
 			},
 		}
@@ -141,9 +152,9 @@ func TestConnPool_GetConnRoundRobin(t *testing.T) {
 			}
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		conn, id, err := p.GetConnectionRoundRobin(ctx)
+		conn, id, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.NoError(t, err)
 		require.NotNil(t, conn)
 		assert.Equal(t, "i2", id)
@@ -152,11 +163,11 @@ func TestConnPool_GetConnRoundRobin(t *testing.T) {
 	t.Run("two_instances_round_robin_order", func(t *testing.T) {
 		conn2 := newTestConn(t)
 		twoInstances := []domain.ServiceInstance{
-			{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001},
-			{InstanceID: "i2", Ipv4: "127.0.0.1", Port: 9002},
+			{InstanceID: "i1", Address: "127.0.0.1", Port: 9001},
+			{InstanceID: "i2", Address: "127.0.0.1", Port: 9002},
 		}
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return twoInstances, nil
 			},
 		}
@@ -166,15 +177,15 @@ func TestConnPool_GetConnRoundRobin(t *testing.T) {
 			}
 			return conn2, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		connA, idA, err := p.GetConnectionRoundRobin(ctx)
+		connA, idA, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.NoError(t, err)
 		require.NotNil(t, connA)
-		connB, idB, err := p.GetConnectionRoundRobin(ctx)
+		connB, idB, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.NoError(t, err)
 		require.NotNil(t, connB)
-		connC, idC, err := p.GetConnectionRoundRobin(ctx)
+		connC, idC, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.NoError(t, err)
 		require.NotNil(t, connC)
 		assert.Equal(t, "i1", idA)
@@ -190,40 +201,40 @@ func TestConnPool_GetConnForKey(t *testing.T) {
 	ctx := context.Background()
 	testConn := newTestConn(t)
 	instances := []domain.ServiceInstance{
-		{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001},
+		{InstanceID: "i1", Address: "127.0.0.1", Port: 9001},
 	}
 
 	t.Run("empty_key_returns_error", func(t *testing.T) {
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return instances, nil
 			},
 		}
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		_, _, err := p.GetConnectionForKey(ctx, "")
+		_, _, err := p.GetConnectionForKey(ctx, "", nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrNoAvailableConnInstance)
 	})
 
 	t.Run("existing_key_returns_cached_conn", func(t *testing.T) {
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return instances, nil
 			},
 		}
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		conn1, id1, err := p.GetConnectionForKey(ctx, "sess-a")
+		conn1, id1, err := p.GetConnectionForKey(ctx, "sess-a", nil)
 		require.NoError(t, err)
 		require.NotNil(t, conn1)
-		conn2, id2, err := p.GetConnectionForKey(ctx, "sess-a")
+		conn2, id2, err := p.GetConnectionForKey(ctx, "sess-a", nil)
 		require.NoError(t, err)
 		assert.Same(t, conn1, conn2)
 		assert.Equal(t, id1, id2)
@@ -232,16 +243,16 @@ func TestConnPool_GetConnForKey(t *testing.T) {
 
 	t.Run("closed_pool_returns_error", func(t *testing.T) {
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return instances, nil
 			},
 		}
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		p.Close()
-		_, _, err := p.GetConnectionForKey(ctx, "sess-a")
+		_, _, err := p.GetConnectionForKey(ctx, "sess-a", nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrConnPoolClosed)
 	})
@@ -249,11 +260,11 @@ func TestConnPool_GetConnForKey(t *testing.T) {
 	t.Run("instance_occupied_by_other_session_uses_free_instance", func(t *testing.T) {
 		conn2 := newTestConn(t)
 		twoInstances := []domain.ServiceInstance{
-			{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001},
-			{InstanceID: "i2", Ipv4: "127.0.0.1", Port: 9002},
+			{InstanceID: "i1", Address: "127.0.0.1", Port: 9001},
+			{InstanceID: "i2", Address: "127.0.0.1", Port: 9002},
 		}
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return twoInstances, nil
 			},
 		}
@@ -263,13 +274,13 @@ func TestConnPool_GetConnForKey(t *testing.T) {
 			}
 			return conn2, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
 		// First bind "other" to i1
-		_, _, err := p.GetConnectionForKey(ctx, "other")
+		_, _, err := p.GetConnectionForKey(ctx, "other", nil)
 		require.NoError(t, err)
 		// sess-a should get i2 (i1 is occupied by "other")
-		conn, id, err := p.GetConnectionForKey(ctx, "sess-a")
+		conn, id, err := p.GetConnectionForKey(ctx, "sess-a", nil)
 		require.NoError(t, err)
 		require.NotNil(t, conn)
 		assert.Equal(t, "i2", id)
@@ -278,11 +289,11 @@ func TestConnPool_GetConnForKey(t *testing.T) {
 	t.Run("all_instances_occupied_returns_error", func(t *testing.T) {
 		conn2 := newTestConn(t)
 		twoInstances := []domain.ServiceInstance{
-			{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001},
-			{InstanceID: "i2", Ipv4: "127.0.0.1", Port: 9002},
+			{InstanceID: "i1", Address: "127.0.0.1", Port: 9001},
+			{InstanceID: "i2", Address: "127.0.0.1", Port: 9002},
 		}
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return twoInstances, nil
 			},
 		}
@@ -292,26 +303,26 @@ func TestConnPool_GetConnForKey(t *testing.T) {
 			}
 			return conn2, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
 		// Bind both instances to other sessions
-		_, _, err := p.GetConnectionForKey(ctx, "other1")
+		_, _, err := p.GetConnectionForKey(ctx, "other1", nil)
 		require.NoError(t, err)
-		_, _, err = p.GetConnectionForKey(ctx, "other2")
+		_, _, err = p.GetConnectionForKey(ctx, "other2", nil)
 		require.NoError(t, err)
 		// sess-a should get ErrNoAvailableConnInstance
-		_, _, err = p.GetConnectionForKey(ctx, "sess-a")
+		_, _, err = p.GetConnectionForKey(ctx, "sess-a", nil)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrNoAvailableConnInstance)
 	})
 
 	t.Run("factory_fails_for_first_matching_instance_succeeds_for_second", func(t *testing.T) {
 		twoInstances := []domain.ServiceInstance{
-			{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001},
-			{InstanceID: "i2", Ipv4: "127.0.0.1", Port: 9002},
+			{InstanceID: "i1", Address: "127.0.0.1", Port: 9001},
+			{InstanceID: "i2", Address: "127.0.0.1", Port: 9002},
 		}
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				return twoInstances, nil
 			},
 		}
@@ -321,9 +332,9 @@ func TestConnPool_GetConnForKey(t *testing.T) {
 			}
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		conn, id, err := p.GetConnectionForKey(ctx, "sess-a")
+		conn, id, err := p.GetConnectionForKey(ctx, "sess-a", nil)
 		require.NoError(t, err)
 		require.NotNil(t, conn)
 		assert.Equal(t, "i2", id)
@@ -334,14 +345,14 @@ func TestConnPool_Refresh(t *testing.T) {
 	ctx := context.Background()
 	testConn := newTestConn(t)
 	instances := []domain.ServiceInstance{
-		{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001},
-		{InstanceID: "i2", Ipv4: "127.0.0.1", Port: 9002},
+		{InstanceID: "i1", Address: "127.0.0.1", Port: 9001},
+		{InstanceID: "i2", Address: "127.0.0.1", Port: 9002},
 	}
 
 	t.Run("refresh_on_GetInstances_error_keeps_previous_instances", func(t *testing.T) {
 		callCount := 0
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				callCount++
 				if callCount == 1 {
 					return instances[:1], nil
@@ -352,19 +363,19 @@ func TestConnPool_Refresh(t *testing.T) {
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 20*time.Millisecond, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 20*time.Millisecond, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		_, _, err := p.GetConnectionRoundRobin(ctx)
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.NoError(t, err)
 		time.Sleep(30 * time.Millisecond)
-		_, _, err = p.GetConnectionRoundRobin(ctx)
+		_, _, err = p.GetConnectionRoundRobin(ctx, nil)
 		require.NoError(t, err)
 	})
 
 	t.Run("refresh_removes_instance_and_unbinds_key", func(t *testing.T) {
 		callCount := 0
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				callCount++
 				if callCount == 1 {
 					return instances, nil
@@ -375,13 +386,13 @@ func TestConnPool_Refresh(t *testing.T) {
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 20*time.Millisecond, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 20*time.Millisecond, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		_, id1, err := p.GetConnectionForKey(ctx, "sess-a")
+		_, id1, err := p.GetConnectionForKey(ctx, "sess-a", nil)
 		require.NoError(t, err)
 		require.Contains(t, []string{"i1", "i2"}, id1)
 		time.Sleep(30 * time.Millisecond)
-		_, id2, err := p.GetConnectionForKey(ctx, "sess-a")
+		_, id2, err := p.GetConnectionForKey(ctx, "sess-a", nil)
 		require.NoError(t, err)
 		assert.Equal(t, "i2", id2)
 	})
@@ -389,7 +400,7 @@ func TestConnPool_Refresh(t *testing.T) {
 	t.Run("refresh_resets_rr_when_out_of_range", func(t *testing.T) {
 		callCount := 0
 		disco := &mock.DiscovererMock{
-			GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
 				callCount++
 				if callCount == 1 {
 					return instances, nil
@@ -400,12 +411,12 @@ func TestConnPool_Refresh(t *testing.T) {
 		factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 			return testConn, nil
 		}
-		p := NewConnectionPool(disco, factory, 20*time.Millisecond, log.NewNopLogger())
+		p := NewConnectionPool(disco, factory, 20*time.Millisecond, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 		defer p.Close()
-		_, _, err := p.GetConnectionRoundRobin(ctx)
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.NoError(t, err)
 		time.Sleep(30 * time.Millisecond)
-		conn, id, err := p.GetConnectionRoundRobin(ctx)
+		conn, id, err := p.GetConnectionRoundRobin(ctx, nil)
 		require.NoError(t, err)
 		require.NotNil(t, conn)
 		assert.Equal(t, "i1", id)
@@ -415,49 +426,634 @@ func TestConnPool_Refresh(t *testing.T) {
 func TestConnPool_OnBackendFailure(t *testing.T) {
 	testConn := newTestConn(t)
 	instances := []domain.ServiceInstance{
-		{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001},
+		{InstanceID: "i1", Address: "127.0.0.1", Port: 9001},
 	}
-	var unregisterCalls []string
+	factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
+		return testConn, nil
+	}
+	ctx := context.Background()
+
+	t.Run("unregisters_in_background", func(t *testing.T) {
+		disco := &mock.DiscovererMock{
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
+				return instances, nil
+			},
+		}
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
+		defer p.Close()
+
+		_, id, err := p.GetConnectionForKey(ctx, "sess-x", nil)
+		require.NoError(t, err)
+		require.Equal(t, "i1", id)
+
+		p.OnBackendFailure("sess-x", "i1")
+		assert.Eventually(t, func() bool { return len(disco.UnregisterInstanceCalls()) == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, "i1", disco.UnregisterInstanceCalls()[0].InstanceID)
+	})
+	t.Run("slow_discoverer_does_not_block", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		disco := &mock.DiscovererMock{
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
+				return instances, nil
+			},
+			UnregisterInstanceFunc: func(ctx context.Context, instanceID string) error {
+				<-release
+				return nil
+			},
+		}
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
+		defer p.Close()
+
+		p.OnBackendFailure("", "i1")
+		p.OnBackendFailure("", "i2")
+		p.(*connectionPool).refresh()
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err, "the pool lock is free while UnregisterInstance hangs")
+	})
+	t.Run("retries_failed_calls", func(t *testing.T) {
+		var failures atomic.Int32
+		failures.Store(2)
+		disco := &mock.DiscovererMock{
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
+				return instances, nil
+			},
+			UnregisterInstanceFunc: func(ctx context.Context, instanceID string) error {
+				if failures.Add(-1) >= 0 {
+					return errors.New("discoverer down")
+				}
+				return nil
+			},
+		}
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
+		defer p.Close()
+		p.(*connectionPool).unregisterBackoff = time.Millisecond
+
+		p.OnBackendFailure("", "i1")
+		assert.Eventually(t, func() bool { return len(disco.UnregisterInstanceCalls()) == 3 }, time.Second, time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		assert.Len(t, disco.UnregisterInstanceCalls(), 3, "no retry after success")
+	})
+	t.Run("gives_up_after_max_attempts", func(t *testing.T) {
+		disco := &mock.DiscovererMock{
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
+				return instances, nil
+			},
+			UnregisterInstanceFunc: func(ctx context.Context, instanceID string) error {
+				return errors.New("discoverer down")
+			},
+		}
+		p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
+		defer p.Close()
+		p.(*connectionPool).unregisterBackoff = time.Millisecond
+
+		p.OnBackendFailure("", "i1")
+		assert.Eventually(t, func() bool { return len(disco.UnregisterInstanceCalls()) == maxUnregisterAttempts }, time.Second, time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		assert.Len(t, disco.UnregisterInstanceCalls(), maxUnregisterAttempts)
+	})
+}
+
+func TestConnPool_ReleaseKeys(t *testing.T) {
+	ctx := context.Background()
+	testConn := newTestConn(t)
 	disco := &mock.DiscovererMock{
-		GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
-			return instances, nil
-		},
-		UnregisterInstanceFunc: func(instanceID string) error {
-			unregisterCalls = append(unregisterCalls, instanceID)
-			return nil
+		GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
+			return []domain.ServiceInstance{{InstanceID: "i1", Address: "127.0.0.1", Port: 9001}}, nil
 		},
 	}
 	factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 		return testConn, nil
 	}
-	p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+	p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 	defer p.Close()
+	_, _, err := p.GetConnectionForKey(ctx, "revoked", nil)
+	require.NoError(t, err)
+	_, _, err = p.GetConnectionForKey(ctx, "sess-b", nil)
+	require.ErrorIs(t, err, ErrNoAvailableConnInstance, "the only instance is bound")
+
+	p.ReleaseKeys([]string{"revoked", "unknown"})
+	conn, id, err := p.GetConnectionForKey(ctx, "sess-b", nil)
+	require.NoError(t, err)
+	assert.Same(t, testConn, conn, "connection is kept")
+	assert.Equal(t, "i1", id)
+	assert.Empty(t, disco.UnregisterInstanceCalls(), "instance is not unregistered")
+}
 
+func TestConnPool_DrainingInstance(t *testing.T) {
 	ctx := context.Background()
-	_, id, err := p.GetConnectionForKey(ctx, "sess-x")
+	testConn := newTestConn(t)
+	draining := false
+	disco := &mock.DiscovererMock{
+		GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
+			return []domain.ServiceInstance{
+				{InstanceID: "i1", Address: "127.0.0.1", Port: 9001, Draining: draining},
+				{InstanceID: "i2", Address: "127.0.0.1", Port: 9002},
+			}, nil
+		},
+	}
+	var dialed []string
+	factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
+		dialed = append(dialed, inst.InstanceID)
+		return testConn, nil
+	}
+	p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
+	defer p.Close()
+	_, id, err := p.GetConnectionForKey(ctx, "sess-a", nil)
 	require.NoError(t, err)
 	require.Equal(t, "i1", id)
 
-	p.OnBackendFailure("sess-x", "i1")
-	assert.Equal(t, []string{"i1"}, unregisterCalls)
+	draining = true
+	p.(*connectionPool).refresh()
+	for i := 0; i < 3; i++ {
+		_, id, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "i2", id, "round robin skips the draining instance")
+	}
+	_, id, err = p.GetConnectionForKey(ctx, "sess-a", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "i1", id, "existing session stays on the draining instance")
+	_, _, err = p.GetConnectionForKey(ctx, "sess-b", nil)
+	require.NoError(t, err)
+	_, _, err = p.GetConnectionForKey(ctx, "sess-c", nil)
+	assert.ErrorIs(t, err, ErrNoAvailableConnInstance, "no new session on the draining instance")
+	assert.Equal(t, []string{"i1", "i2"}, dialed, "the draining connection is kept open")
+	assert.Empty(t, disco.UnregisterInstanceCalls())
+}
+
+func TestConnPool_Subsets(t *testing.T) {
+	ctx := context.Background()
+	testConn := newTestConn(t)
+	instances := []domain.ServiceInstance{
+		{InstanceID: "i1", Address: "127.0.0.1", Port: 9001, Labels: map[string]string{"version": "v1"}},
+		{InstanceID: "i2", Address: "127.0.0.1", Port: 9002, Labels: map[string]string{"version": "v2"}},
+		{InstanceID: "i3", Address: "127.0.0.1", Port: 9003, Labels: map[string]string{"version": "v2"}},
+		{InstanceID: "i4", Address: "127.0.0.1", Port: 9004},
+	}
+	disco := &mock.DiscovererMock{
+		GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) { return instances, nil },
+	}
+	factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
+		return testConn, nil
+	}
+	p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
+	defer p.Close()
+	v1, v2 := domain.LabelSelector{"version": "v1"}, domain.LabelSelector{"version": "v2"}
+
+	t.Run("round_robin_per_subset", func(t *testing.T) {
+		var got []string
+		for _, selector := range []domain.LabelSelector{v2, nil, v2, v1, nil, v2} {
+			_, id, err := p.GetConnectionRoundRobin(ctx, selector)
+			require.NoError(t, err)
+			got = append(got, id)
+		}
+		assert.Equal(t, []string{"i2", "i1", "i3", "i1", "i2", "i2"}, got, "each selector keeps its own position")
+		_, _, err := p.GetConnectionRoundRobin(ctx, domain.LabelSelector{"version": "v3"})
+		assert.ErrorIs(t, err, ErrNoAvailableConnInstance)
+	})
+	t.Run("sticky_capacity_per_subset", func(t *testing.T) {
+		_, id, err := p.GetConnectionForKey(ctx, "sess-a", v1)
+		require.NoError(t, err)
+		assert.Equal(t, "i1", id)
+		_, _, err = p.GetConnectionForKey(ctx, "sess-b", v1)
+		assert.ErrorIs(t, err, ErrNoAvailableConnInstance, "the only v1 instance is bound")
+		_, id, err = p.GetConnectionForKey(ctx, "sess-b", v2)
+		require.NoError(t, err)
+		assert.Equal(t, "i2", id, "v2 instances are still free")
+	})
+	t.Run("key_outside_selector_is_rebound", func(t *testing.T) {
+		_, id, err := p.GetConnectionForKey(ctx, "sess-a", v2)
+		require.NoError(t, err)
+		assert.Equal(t, "i3", id)
+		_, id, err = p.GetConnectionForKey(ctx, "sess-c", v1)
+		require.NoError(t, err)
+		assert.Equal(t, "i1", id, "i1 was freed by the rebind")
+	})
+	t.Run("refresh_drops_unmatched_positions", func(t *testing.T) {
+		instances = instances[1:]
+		p.(*connectionPool).refresh()
+		pool := p.(*connectionPool)
+		pool.mu.RLock()
+		defer pool.mu.RUnlock()
+		assert.NotContains(t, pool.rr, v1.Key())
+		assert.Contains(t, pool.rr, v2.Key())
+	})
+}
+
+func TestConnPool_ZoneAware(t *testing.T) {
+	ctx := context.Background()
+	testConn := newTestConn(t)
+	draining := false
+	disco := &mock.DiscovererMock{
+		GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
+			return []domain.ServiceInstance{
+				{InstanceID: "a1", Address: "127.0.0.1", Port: 9001, Labels: map[string]string{domain.ZoneLabel: "zone-a"}},
+				{InstanceID: "b1", Address: "127.0.0.1", Port: 9002, Labels: map[string]string{domain.ZoneLabel: "zone-b"}},
+				{InstanceID: "a2", Address: "127.0.0.1", Port: 9003, Labels: map[string]string{domain.ZoneLabel: "zone-a"}, Draining: draining},
+				{InstanceID: "n1", Address: "127.0.0.1", Port: 9004},
+			}, nil
+		},
+	}
+	factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
+		return testConn, nil
+	}
+	roundRobin := func(t *testing.T, p interfaces.ConnectionPool, n int) []string {
+		t.Helper()
+		var ids []string
+		for i := 0; i < n; i++ {
+			_, id, err := p.GetConnectionRoundRobin(ctx, nil)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	t.Run("round_robin_stays_local_then_spills", func(t *testing.T) {
+		draining = false
+		p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{Zone: "zone-a", SpillThreshold: 2}, nil, 0)
+		defer p.Close()
+		assert.Equal(t, []string{"a1", "a2", "a1", "a2"}, roundRobin(t, p, 4))
+
+		draining = true
+		p.(*connectionPool).refresh()
+		assert.Equal(t, []string{"a1", "b1", "n1", "a1"}, roundRobin(t, p, 4), "one available local instance is below the threshold")
+	})
+	t.Run("new_sticky_keys_spill_when_local_is_taken", func(t *testing.T) {
+		draining = false
+		p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{Zone: "zone-a", SpillThreshold: 1}, nil, 0)
+		defer p.Close()
+		var ids []string
+		for _, key := range []string{"sess-1", "sess-2", "sess-3"} {
+			_, id, err := p.GetConnectionForKey(ctx, key, nil)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		assert.Equal(t, []string{"a1", "a2", "b1"}, ids)
+	})
+	t.Run("no_zone_uses_all_instances", func(t *testing.T) {
+		draining = false
+		p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{SpillThreshold: 1}, nil, 0)
+		defer p.Close()
+		assert.Equal(t, []string{"a1", "b1", "a2", "n1"}, roundRobin(t, p, 4))
+	})
+}
+
+func TestConnPool_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	testConn := newTestConn(t)
+	factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
+		return testConn, nil
+	}
+	instances := []domain.ServiceInstance{{InstanceID: "i1", Address: "127.0.0.1", Port: 9001}}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var elapsed atomic.Int64 // manual clock: start + elapsed
+	clock := &mock.TimeProviderMock{NowFunc: func() time.Time { return start.Add(time.Duration(elapsed.Load())) }}
+	var discoErr error
+	disco := &mock.DiscovererMock{
+		GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
+			if discoErr != nil {
+				return nil, discoErr
+			}
+			return instances, nil
+		},
+	}
+
+	t.Run("saves_changed_answers", func(t *testing.T) {
+		discoErr = nil
+		var diskFull atomic.Bool
+		diskFull.Store(true)
+		store := &mock.InstanceSnapshotStoreMock{SaveFunc: func(domain.InstanceSnapshot) error {
+			if diskFull.Load() {
+				return errors.New("disk full")
+			}
+			return nil
+		}}
+		p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), clock, domain.StreamLimit{}, domain.Locality{}, store, time.Hour)
+		defer p.Close()
+		pool := p.(*connectionPool)
+		diskFull.Store(false)
+		pool.refresh()
+		require.Len(t, store.SaveCalls(), 2, "a failed save does not stop the pool and is retried")
+		assert.Equal(t, instances, store.SaveCalls()[1].Snapshot.Instances)
+		assert.Equal(t, clock.Now(), store.SaveCalls()[1].Snapshot.SavedAt)
+		assert.Empty(t, store.LoadCalls())
+
+		elapsed.Add(int64(10 * time.Second))
+		pool.refresh()
+		assert.Len(t, store.SaveCalls(), 2, "unchanged list is not written again")
+		age, ok := p.InstancesAge()
+		assert.True(t, ok)
+		assert.Zero(t, age)
+
+		elapsed.Add(int64(30 * time.Minute))
+		pool.refresh()
+		assert.Len(t, store.SaveCalls(), 3, "unchanged list is saved again at max_age/2, so a restart can still use it")
+
+		instances = append(slices.Clone(instances), domain.ServiceInstance{InstanceID: "i2", Address: "127.0.0.1", Port: 9002})
+		defer func() { instances = instances[:1] }()
+		pool.refresh()
+		require.Len(t, store.SaveCalls(), 4, "changed list is saved")
+		discovered := instances
+		p.OnBackendFailure("", "i1")
+		assert.Equal(t, "i1", discovered[0].InstanceID, "the discoverer's answer is not shifted in place")
+		assert.Equal(t, []string{"i1", "i2"}, []string{store.SaveCalls()[3].Snapshot.Instances[0].InstanceID, store.SaveCalls()[3].Snapshot.Instances[1].InstanceID})
+	})
+	t.Run("boots_from_snapshot_when_discovery_fails", func(t *testing.T) {
+		discoErr = errors.New("discoverer down")
+		store := &mock.InstanceSnapshotStoreMock{LoadFunc: func() (domain.InstanceSnapshot, error) {
+			return domain.InstanceSnapshot{SavedAt: clock.Now().Add(-time.Minute), Instances: instances}, nil
+		}}
+		p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), clock, domain.StreamLimit{}, domain.Locality{}, store, time.Hour)
+		defer p.Close()
+		_, id, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "i1", id)
+		age, ok := p.InstancesAge()
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, age, "age counts from when the snapshot was saved")
+		assert.Empty(t, store.SaveCalls(), "a loaded snapshot is not saved back")
+		discoErr = nil
+		p.(*connectionPool).refresh()
+		assert.Empty(t, store.SaveCalls(), "the same list from the discoverer is not saved again")
+	})
+	t.Run("ignores_old_or_missing_snapshot", func(t *testing.T) {
+		discoErr = errors.New("discoverer down")
+		for _, load := range []func() (domain.InstanceSnapshot, error){
+			func() (domain.InstanceSnapshot, error) {
+				return domain.InstanceSnapshot{SavedAt: clock.Now().Add(-time.Hour - time.Millisecond), Instances: instances}, nil
+			},
+			func() (domain.InstanceSnapshot, error) { return domain.InstanceSnapshot{}, fs.ErrNotExist },
+		} {
+			store := &mock.InstanceSnapshotStoreMock{LoadFunc: load}
+			p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), clock, domain.StreamLimit{}, domain.Locality{}, store, time.Hour)
+			_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+			assert.ErrorIs(t, err, ErrNoAvailableConnInstance)
+			_, ok := p.InstancesAge()
+			assert.False(t, ok)
+			p.Close()
+		}
+	})
+	t.Run("drops_stale_list", func(t *testing.T) {
+		discoErr = nil
+		p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), clock, domain.StreamLimit{}, domain.Locality{}, nil, time.Minute)
+		defer p.Close()
+		pool := p.(*connectionPool)
+
+		discoErr = errors.New("discoverer down")
+		elapsed.Add(int64(time.Minute))
+		pool.refresh()
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err, "a list exactly max_age old survives a failed refresh")
+
+		elapsed.Add(int64(time.Millisecond))
+		pool.refresh()
+		_, _, err = p.GetConnectionRoundRobin(ctx, nil)
+		assert.ErrorIs(t, err, ErrNoAvailableConnInstance)
+
+		discoErr = nil
+		pool.refresh()
+		_, _, err = p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err, "the list comes back with the discoverer")
+	})
 }
 
 func TestConnPool_Close(t *testing.T) {
 	testConn := newTestConn(t)
 	disco := &mock.DiscovererMock{
-		GetInstancesFunc: func() ([]domain.ServiceInstance, error) {
-			return []domain.ServiceInstance{{InstanceID: "i1", Ipv4: "127.0.0.1", Port: 9001}}, nil
+		GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) {
+			return []domain.ServiceInstance{{InstanceID: "i1", Address: "127.0.0.1", Port: 9001}}, nil
 		},
 	}
 	factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
 		return testConn, nil
 	}
-	p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger())
+	p := NewConnectionPool(disco, factory, 10*time.Second, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
 	err := p.Close()
 	require.NoError(t, err)
 	err = p.Close()
 	require.NoError(t, err)
-	_, _, err = p.GetConnectionRoundRobin(context.Background())
+	_, _, err = p.GetConnectionRoundRobin(context.Background(), nil)
 	require.Error(t, err)
 	assert.ErrorIs(t, err, ErrConnPoolClosed)
 }
+
+func TestConnPool_StreamLimit(t *testing.T) {
+	ctx := context.Background()
+	testConn := newTestConn(t)
+	newPool := func(t *testing.T, ids []string, limit domain.StreamLimit) interfaces.ConnectionPool {
+		var instances []domain.ServiceInstance
+		for i, id := range ids {
+			instances = append(instances, domain.ServiceInstance{InstanceID: id, Address: "127.0.0.1", Port: 9001 + i})
+		}
+		disco := &mock.DiscovererMock{GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) { return instances, nil }}
+		factory := func(context.Context, domain.ServiceInstance) (*grpc.ClientConn, error) { return testConn, nil }
+		p := NewConnectionPool(disco, factory, time.Hour, log.NewNopLogger(), NewTimeProvider(time.Now), limit, domain.Locality{}, nil, 0)
+		t.Cleanup(func() { _ = p.Close() })
+		return p
+	}
+
+	t.Run("round_robin_skips_saturated_instances", func(t *testing.T) {
+		p := newPool(t, []string{"i1", "i2"}, domain.StreamLimit{MaxPerInstance: 1})
+		ids := make([]string, 0, 2)
+		for i := 0; i < 2; i++ {
+			_, id, err := p.GetConnectionRoundRobin(ctx, nil)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		assert.ElementsMatch(t, []string{"i1", "i2"}, ids)
+
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+		assert.ErrorIs(t, err, ErrInstancesSaturated, "no queue timeout — fails at once")
+
+		p.ReleaseStream("i2")
+		_, id, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "i2", id, "the only instance with a free slot")
+	})
+
+	t.Run("waits_for_released_slot", func(t *testing.T) {
+		p := newPool(t, []string{"i1"}, domain.StreamLimit{MaxPerInstance: 1, QueueTimeout: 5 * time.Second})
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err)
+
+		done := make(chan error, 1)
+		go func() {
+			_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			t.Fatalf("returned before a slot was released: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		p.ReleaseStream("i1")
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("waiter was not woken by ReleaseStream")
+		}
+	})
+
+	t.Run("queue_timeout_returns_saturated", func(t *testing.T) {
+		p := newPool(t, []string{"i1"}, domain.StreamLimit{MaxPerInstance: 2, QueueTimeout: 30 * time.Millisecond})
+		for i := 0; i < 2; i++ {
+			_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+			require.NoError(t, err)
+		}
+		start := time.Now()
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+		assert.ErrorIs(t, err, ErrInstancesSaturated)
+		assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	})
+
+	t.Run("context_end_stops_waiting", func(t *testing.T) {
+		p := newPool(t, []string{"i1"}, domain.StreamLimit{MaxPerInstance: 1, QueueTimeout: time.Minute})
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err)
+		cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, _, err = p.GetConnectionRoundRobin(cctx, nil)
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("close_wakes_waiters", func(t *testing.T) {
+		p := newPool(t, []string{"i1"}, domain.StreamLimit{MaxPerInstance: 1, QueueTimeout: time.Minute})
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err)
+		done := make(chan error, 1)
+		go func() {
+			_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+			done <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+		require.NoError(t, p.Close())
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, ErrConnPoolClosed)
+		case <-time.After(5 * time.Second):
+			t.Fatal("waiter was not woken by Close")
+		}
+	})
+
+	t.Run("sticky_key_waits_for_its_instance", func(t *testing.T) {
+		p := newPool(t, []string{"i1", "i2"}, domain.StreamLimit{MaxPerInstance: 1, QueueTimeout: 20 * time.Millisecond})
+		_, id, err := p.GetConnectionForKey(ctx, "sess-a", nil)
+		require.NoError(t, err)
+		_, _, err = p.GetConnectionForKey(ctx, "sess-a", nil)
+		assert.ErrorIs(t, err, ErrInstancesSaturated, "the session is not moved to another instance")
+
+		p.ReleaseStream(id)
+		_, again, err := p.GetConnectionForKey(ctx, "sess-a", nil)
+		require.NoError(t, err)
+		assert.Equal(t, id, again)
+	})
+
+	t.Run("new_sticky_key_skips_saturated_instance", func(t *testing.T) {
+		p := newPool(t, []string{"i1", "i2"}, domain.StreamLimit{MaxPerInstance: 1})
+		_, id, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err)
+		_, keyID, err := p.GetConnectionForKey(ctx, "sess-b", nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, id, keyID)
+	})
+
+	t.Run("release_without_streams_is_noop", func(t *testing.T) {
+		p := newPool(t, []string{"i1"}, domain.StreamLimit{MaxPerInstance: 1})
+		p.ReleaseStream("i1")
+		_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+		require.NoError(t, err)
+		_, _, err = p.GetConnectionRoundRobin(ctx, nil)
+		assert.ErrorIs(t, err, ErrInstancesSaturated, "count did not go negative")
+	})
+
+	t.Run("no_limit_never_saturates", func(t *testing.T) {
+		p := newPool(t, []string{"i1"}, domain.StreamLimit{})
+		for i := 0; i < 10; i++ {
+			_, _, err := p.GetConnectionRoundRobin(ctx, nil)
+			require.NoError(t, err)
+		}
+	})
+}
+
+// watchingDiscoverer is a Discoverer that also implements interfaces.InstanceWatcher.
+type watchingDiscoverer struct {
+	*mock.DiscovererMock
+	*mock.InstanceWatcherMock
+}
+
+// watchResult is one answer of a scripted WatchInstances call.
+type watchResult struct {
+	instances []domain.ServiceInstance
+	version   string
+	err       error
+}
+
+func TestConnPool_Watch(t *testing.T) {
+	ctx := context.Background()
+	testConn := newTestConn(t)
+	i1 := domain.ServiceInstance{InstanceID: "i1", Address: "127.0.0.1", Port: 9001}
+	i2 := domain.ServiceInstance{InstanceID: "i2", Address: "127.0.0.1", Port: 9002}
+	results := make(chan watchResult)
+	versions := make(chan string, 16)
+	disco := watchingDiscoverer{
+		DiscovererMock: &mock.DiscovererMock{
+			GetInstancesFunc: func(context.Context) ([]domain.ServiceInstance, error) { return []domain.ServiceInstance{i1}, nil },
+		},
+		InstanceWatcherMock: &mock.InstanceWatcherMock{
+			WatchInstancesFunc: func(ctx context.Context, version string) ([]domain.ServiceInstance, string, error) {
+				versions <- version
+				select {
+				case r := <-results:
+					return r.instances, r.version, r.err
+				case <-ctx.Done():
+					return nil, "", ctx.Err()
+				}
+			},
+		},
+	}
+	factory := func(ctx context.Context, inst domain.ServiceInstance) (*grpc.ClientConn, error) {
+		return testConn, nil
+	}
+	p := NewConnectionPool(disco, factory, 20*time.Millisecond, log.NewNopLogger(), NewTimeProvider(time.Now), domain.StreamLimit{}, domain.Locality{}, nil, 0)
+	ids := func() []string {
+		p.(*connectionPool).mu.RLock()
+		defer p.(*connectionPool).mu.RUnlock()
+		var out []string
+		for _, inst := range p.(*connectionPool).instances {
+			out = append(out, inst.InstanceID)
+		}
+		return out
+	}
+
+	assert.Equal(t, "", <-versions, "first watch starts without a version")
+	results <- watchResult{instances: []domain.ServiceInstance{i1, i2}, version: "v1"}
+	assert.Equal(t, "v1", <-versions)
+	assert.Equal(t, []string{"i1", "i2"}, ids(), "watched list applied")
+	polls := len(disco.GetInstancesCalls())
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, polls, len(disco.GetInstancesCalls()), "no polling while the watch is healthy")
+
+	results <- watchResult{instances: []domain.ServiceInstance{i1, i2}, version: "v1"}
+	assert.Equal(t, "v1", <-versions, "unchanged version after the discoverer-side timeout")
+
+	results <- watchResult{instances: []domain.ServiceInstance{i2}, version: "v2"}
+	assert.Equal(t, "v2", <-versions)
+	assert.Equal(t, []string{"i2"}, ids())
+	_, id, err := p.GetConnectionRoundRobin(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "i2", id)
+
+	results <- watchResult{err: errors.New("discoverer down")}
+	assert.Eventually(t, func() bool {
+		return len(disco.GetInstancesCalls()) > polls && assert.ObjectsAreEqual([]string{"i1"}, ids())
+	}, 5*time.Second, 10*time.Millisecond, "polling takes over while the watch fails")
+	assert.Equal(t, "", <-versions, "reconnect starts from an empty version")
+
+	require.NoError(t, p.Close())
+	select {
+	case v := <-versions:
+		t.Fatalf("watch called after Close with version %q", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 
 	"mygateway/domain"
 	"mygateway/helpers"
@@ -21,8 +22,9 @@ var ErrStickyKeyRequired = errors.New("sticky key required for this route")
 
 // connectionResolverGeneric implements interfaces.ConnectionResolver. It resolves (route, headers) to a backend
 // *grpc.ClientConn: for static clusters returns the pre-dialed connection; for dynamic clusters
-// delegates to the corresponding ConnectionPool (GetConnRoundRobin or GetConnForKey using the balancer header).
-// Also implements OnBackendFailure (delegate to pool) and Close (close all static conns and pools).
+// delegates to the corresponding ConnectionPool (GetConnRoundRobin or GetConnForKey using the balancer header), passing
+// the label selector of the route subset (subsetSelector).
+// Also implements OnBackendFailure and ReleaseStream (delegate to pool), ReleaseStickyKeys (unbind revoked sessions in all pools) and Close (close all static conns and pools).
 // Built in cmd/main from staticConns and dynamicPools maps.
 type connectionResolverGeneric struct {
 	staticConns map[domain.ClusterID]*grpc.ClientConn
@@ -46,9 +48,9 @@ func NewConnectionResolverGeneric(
 	}
 }
 
-// GetConnection returns a backend connection for the given route and headers: for static — pre-dialed conn from staticConns; for dynamic — from pool (round-robin or by sticky key from header).
+// GetConnection returns a backend connection for the given route and headers: for static — pre-dialed conn from staticConns (route.Subset is ignored); for dynamic — from pool (round-robin or by sticky key from header) among the instances selected by route.Subset.
 //
-// Parameters: ctx — request context; route — result of RouteMatcher.Match (Cluster, Balancer); headers — metadata after HeaderProcessor (for sticky header when sticky_sessions). Missing required header for sticky_sessions returns ErrStickyKeyRequired.
+// Parameters: ctx — request context; route — result of RouteMatcher.Match (Cluster, Balancer, Subset); headers — metadata after HeaderProcessor (for sticky header when sticky_sessions and subset headers). Missing required header for sticky_sessions returns ErrStickyKeyRequired.
 //
 // Returns: (conn, stickyKey, instanceID, nil) on success (stickyKey empty for round-robin/static; instanceID — instance ID or cluster name for static); (nil, "", "", error) on unknown cluster (ErrGenericUnknownCluster), missing sticky header (ErrStickyKeyRequired) or pool error (ErrNoAvailableConnInstance, etc.).
 //
@@ -65,6 +67,7 @@ func (r *connectionResolverGeneric) GetConnection(
 	if p == nil {
 		return nil, "", "", fmt.Errorf("%w: %s", ErrGenericUnknownCluster, route.Cluster)
 	}
+	selector := subsetSelector(route.Subset, headers)
 	if route.Balancer.Type == domain.BalancerStickySession {
 		header := route.Balancer.Header
 		if header == "" {
@@ -74,19 +77,35 @@ func (r *connectionResolverGeneric) GetConnection(
 		if !ok {
 			return nil, "", "", fmt.Errorf("%w: %s", ErrStickyKeyRequired, header)
 		}
-		conn, instanceID, err := p.GetConnectionForKey(ctx, key)
+		conn, instanceID, err := p.GetConnectionForKey(ctx, key, selector)
 		if err != nil {
 			return nil, "", "", err
 		}
 		return conn, key, instanceID, nil
 	}
-	conn, instanceID, err := p.GetConnectionRoundRobin(ctx)
+	conn, instanceID, err := p.GetConnectionRoundRobin(ctx, selector)
 	if err != nil {
 		return nil, "", "", err
 	}
 	return conn, "", instanceID, nil
 }
 
+// subsetSelector builds the label selector of a request: the fixed subset labels, then for each subset header present in
+// headers with a non-empty value, that value for its label (overriding a fixed one). Returns nil for a route without subset.
+func subsetSelector(subset domain.SubsetConfig, headers metadata.MD) domain.LabelSelector {
+	if subset.IsZero() {
+		return nil
+	}
+	selector := make(domain.LabelSelector, len(subset.Labels)+len(subset.Headers))
+	maps.Copy(selector, subset.Labels)
+	for label, header := range subset.Headers {
+		if v, ok := helpers.GetHeaderValue(headers, header); ok {
+			selector[label] = v
+		}
+	}
+	return selector
+}
+
 // OnBackendFailure delegates to the route's pool to unbind sticky key and close/unregister the instance. No-op for static cluster or when pool is missing.
 //
 // Parameters: route — route of the failed request; stickyKey — sticky key (from GetConnection); instanceID — identifier of the instance that failed.
@@ -100,6 +119,33 @@ func (r *connectionResolverGeneric) OnBackendFailure(route domain.Route, stickyK
 	p.OnBackendFailure(stickyKey, instanceID)
 }
 
+// ReleaseStream delegates to the route's pool to free the stream slot of instanceID. No-op for static cluster or when pool is missing.
+//
+// Parameters: route — route of the finished request; instanceID — identifier returned by GetConnection.
+//
+// Called from service.TransparentProxy.Handler once per successful GetConnection when the backend stream is done.
+func (r *connectionResolverGeneric) ReleaseStream(route domain.Route, instanceID string) {
+	p := r.pools[route.Cluster]
+	if p == nil {
+		return
+	}
+	p.ReleaseStream(instanceID)
+}
+
+// ReleaseStickyKeys drops the sticky bindings of keys in every dynamic pool (a sticky key is not tied to one cluster in config, so all pools are asked).
+//
+// Parameters: keys — sticky key values, e.g. session IDs reported as revoked by the revocation store. Empty slice is a no-op.
+//
+// Called from the revocation cache callback built in cmd/main.
+func (r *connectionResolverGeneric) ReleaseStickyKeys(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	for _, p := range r.pools {
+		p.ReleaseKeys(keys)
+	}
+}
+
 // Close closes all static connections and all pools. Errors from individual connections/pools are not aggregated; returns nil.
 //
 // Called from cmd/main via defer on graceful shutdown.
@@ -79,7 +79,7 @@ func TestConnectionResolverGeneric_GetConnection(t *testing.T) {
 			staticConns: map[domain.ClusterID]*grpc.ClientConn{},
 			pools: map[domain.ClusterID]interfaces.ConnectionPool{
 				"dynamic": &mock.ConnectionPoolMock{
-					GetConnectionForKeyFunc: func(ctx context.Context, key string) (*grpc.ClientConn, string, error) {
+					GetConnectionForKeyFunc: func(ctx context.Context, key string, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 						if key != "sess-1" {
 							return nil, "", errors.New("unexpected key")
 						}
@@ -98,7 +98,7 @@ func TestConnectionResolverGeneric_GetConnection(t *testing.T) {
 			staticConns: map[domain.ClusterID]*grpc.ClientConn{},
 			pools: map[domain.ClusterID]interfaces.ConnectionPool{
 				"dynamic": &mock.ConnectionPoolMock{
-					GetConnectionRoundRobinFunc: func(ctx context.Context) (*grpc.ClientConn, string, error) {
+					GetConnectionRoundRobinFunc: func(ctx context.Context, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 						return testConn, "inst-rr", nil
 					},
 				},
@@ -113,7 +113,7 @@ func TestConnectionResolverGeneric_GetConnection(t *testing.T) {
 			staticConns: map[domain.ClusterID]*grpc.ClientConn{},
 			pools: map[domain.ClusterID]interfaces.ConnectionPool{
 				"dynamic": &mock.ConnectionPoolMock{
-					GetConnectionForKeyFunc: func(ctx context.Context, key string) (*grpc.ClientConn, string, error) {
+					GetConnectionForKeyFunc: func(ctx context.Context, key string, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 						if key != "sess-default" {
 							return nil, "", errors.New("unexpected key")
 						}
@@ -132,7 +132,7 @@ func TestConnectionResolverGeneric_GetConnection(t *testing.T) {
 			staticConns: map[domain.ClusterID]*grpc.ClientConn{},
 			pools: map[domain.ClusterID]interfaces.ConnectionPool{
 				"dynamic": &mock.ConnectionPoolMock{
-					GetConnectionForKeyFunc: func(ctx context.Context, key string) (*grpc.ClientConn, string, error) {
+					GetConnectionForKeyFunc: func(ctx context.Context, key string, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 						return nil, "", errPoolKey
 					},
 				},
@@ -146,7 +146,7 @@ func TestConnectionResolverGeneric_GetConnection(t *testing.T) {
 			staticConns: map[domain.ClusterID]*grpc.ClientConn{},
 			pools: map[domain.ClusterID]interfaces.ConnectionPool{
 				"dynamic": &mock.ConnectionPoolMock{
-					GetConnectionRoundRobinFunc: func(ctx context.Context) (*grpc.ClientConn, string, error) {
+					GetConnectionRoundRobinFunc: func(ctx context.Context, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 						return nil, "", errPoolRR
 					},
 				},
@@ -155,12 +155,33 @@ func TestConnectionResolverGeneric_GetConnection(t *testing.T) {
 			headers: nil,
 			wantErr: errPoolRR,
 		},
+		{
+			name:        "subset_selector_passed_to_pool",
+			staticConns: map[domain.ClusterID]*grpc.ClientConn{},
+			pools: map[domain.ClusterID]interfaces.ConnectionPool{
+				"dynamic": &mock.ConnectionPoolMock{
+					GetConnectionRoundRobinFunc: func(ctx context.Context, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
+						if selector.Key() != (domain.LabelSelector{"version": "v3", "zone": "eu-1a"}).Key() {
+							return nil, "", errors.New("unexpected selector")
+						}
+						return testConn, "inst-v3", nil
+					},
+				},
+			},
+			route: domain.Route{Cluster: "dynamic", Subset: domain.SubsetConfig{
+				Labels:  map[string]string{"version": "v2", "zone": "eu-1a"},
+				Headers: map[string]string{"version": "x-version"},
+			}},
+			headers:    metadata.Pairs("x-version", "v3"),
+			wantErr:    nil,
+			wantInstID: "inst-v3",
+		},
 		{
 			name:        "static_cluster_nil_conn_falls_through_to_pool",
 			staticConns: map[domain.ClusterID]*grpc.ClientConn{domain.ClusterID("c"): nil},
 			pools: map[domain.ClusterID]interfaces.ConnectionPool{
 				"c": &mock.ConnectionPoolMock{
-					GetConnectionRoundRobinFunc: func(ctx context.Context) (*grpc.ClientConn, string, error) {
+					GetConnectionRoundRobinFunc: func(ctx context.Context, selector domain.LabelSelector) (*grpc.ClientConn, string, error) {
 						return testConn, "inst-pool", nil
 					},
 				},
@@ -188,6 +209,30 @@ func TestConnectionResolverGeneric_GetConnection(t *testing.T) {
 	}
 }
 
+func TestSubsetSelector(t *testing.T) {
+	subset := domain.SubsetConfig{
+		Labels:  map[string]string{"version": "v2"},
+		Headers: map[string]string{"version": "x-version", "zone": "x-zone"},
+	}
+	tests := []struct {
+		name    string
+		subset  domain.SubsetConfig
+		headers metadata.MD
+		want    domain.LabelSelector
+	}{
+		{name: "no_subset", subset: domain.SubsetConfig{}, headers: metadata.Pairs("x-version", "v3"), want: nil},
+		{name: "fixed_labels_without_headers", subset: subset, headers: nil, want: domain.LabelSelector{"version": "v2"}},
+		{name: "header_overrides_label", subset: subset, headers: metadata.Pairs("x-version", "v3"), want: domain.LabelSelector{"version": "v3"}},
+		{name: "header_adds_label", subset: subset, headers: metadata.Pairs("x-zone", "eu-1b"), want: domain.LabelSelector{"version": "v2", "zone": "eu-1b"}},
+		{name: "empty_header_ignored", subset: subset, headers: metadata.Pairs("x-version", ""), want: domain.LabelSelector{"version": "v2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, subsetSelector(tt.subset, tt.headers))
+		})
+	}
+}
+
 func TestConnectionResolverGeneric_OnBackendFailure(t *testing.T) {
 	t.Run("no_pool_for_cluster_noop", func(t *testing.T) {
 		r := NewConnectionResolverGeneric(
@@ -216,6 +261,35 @@ func TestConnectionResolverGeneric_OnBackendFailure(t *testing.T) {
 	})
 }
 
+func TestConnectionResolverGeneric_ReleaseStream(t *testing.T) {
+	var got []string
+	pool := &mock.ConnectionPoolMock{ReleaseStreamFunc: func(instanceID string) { got = append(got, instanceID) }}
+	r := NewConnectionResolverGeneric(
+		map[domain.ClusterID]*grpc.ClientConn{"s": newTestConn(t)},
+		map[domain.ClusterID]interfaces.ConnectionPool{"c1": pool},
+	)
+	r.ReleaseStream(domain.Route{Cluster: "s"}, "s")
+	r.ReleaseStream(domain.Route{Cluster: "none"}, "inst-0")
+	r.ReleaseStream(domain.Route{Cluster: "c1"}, "inst-1")
+	assert.Equal(t, []string{"inst-1"}, got)
+}
+
+func TestConnectionResolverGeneric_ReleaseStickyKeys(t *testing.T) {
+	var got1, got2 []string
+	r := NewConnectionResolverGeneric(
+		map[domain.ClusterID]*grpc.ClientConn{},
+		map[domain.ClusterID]interfaces.ConnectionPool{
+			"c1": &mock.ConnectionPoolMock{ReleaseKeysFunc: func(keys []string) { got1 = keys }},
+			"c2": &mock.ConnectionPoolMock{ReleaseKeysFunc: func(keys []string) { got2 = keys }},
+		},
+	)
+	r.ReleaseStickyKeys(nil)
+	assert.Nil(t, got1)
+	r.ReleaseStickyKeys([]string{"s1"})
+	assert.Equal(t, []string{"s1"}, got1)
+	assert.Equal(t, []string{"s1"}, got2)
+}
+
 func TestConnectionResolverGeneric_Close(t *testing.T) {
 	testConn := newTestConn(t)
 	staticClosed := false
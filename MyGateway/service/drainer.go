@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"mygateway/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrGatewayDraining is the cancellation cause of streams ended by StreamDrainer.NotifyStreams; TransparentProxy checks
+// it so a drained stream is not reported as a backend failure.
+var ErrGatewayDraining = errors.New("gateway is draining")
+
+// StreamDrainer tracks the streams open on every frontend so shutdown can end them cleanly. After StartDrain new
+// calls are answered with the configured drain status; NotifyStreams cancels the context of every open stream with
+// ErrGatewayDraining and its handler error is replaced by the same status and trailers. Fields: cfg; under mu:
+// draining, streams (cancel function per open stream).
+type StreamDrainer struct {
+	cfg domain.ShutdownConfig
+
+	mu       sync.Mutex
+	draining bool
+	streams  map[*drainStream]context.CancelCauseFunc
+}
+
+// NewStreamDrainer creates the drainer with no open streams.
+//
+// Parameters: cfg — validated shutdown settings (status, message, trailers).
+//
+// Returns: *StreamDrainer; its StreamInterceptor is installed on every frontend.
+//
+// Called from cmd/main.
+func NewStreamDrainer(cfg domain.ShutdownConfig) *StreamDrainer {
+	return &StreamDrainer{cfg: cfg, streams: make(map[*drainStream]context.CancelCauseFunc)}
+}
+
+// StreamInterceptor returns the stream server interceptor that registers each call for draining. Must run outside
+// GatewayErrorToGRPCStreamInterceptor (which would turn the drain status into "backend service unavailable").
+//
+// Returns: grpc.StreamServerInterceptor; the drain status for calls rejected or failed by the drain, otherwise the
+// handler result unchanged.
+//
+// Called from cmd/main when building the interceptor chain.
+func (d *StreamDrainer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := context.WithCancelCause(ss.Context())
+		defer cancel(nil)
+		stream := &drainStream{ServerStream: ss, ctx: ctx}
+		if !d.register(stream, cancel) {
+			return d.drainError(ss)
+		}
+		err := handler(srv, stream)
+		d.unregister(stream)
+		if err != nil && errors.Is(context.Cause(ctx), ErrGatewayDraining) {
+			return d.drainError(ss)
+		}
+		return err
+	}
+}
+
+// StartDrain makes the interceptor reject new calls with the drain status. Open streams are not touched.
+//
+// Called from cmd/main on SIGINT/SIGTERM, before the servers are stopped.
+func (d *StreamDrainer) StartDrain() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.draining = true
+}
+
+// NotifyStreams ends every open stream: its context is canceled with ErrGatewayDraining and the client gets the
+// drain status and trailers.
+//
+// Returns: number of streams notified.
+//
+// Called from cmd/main shutdown.notify_before_ms before the drain deadline.
+func (d *StreamDrainer) NotifyStreams() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := len(d.streams)
+	for stream, cancel := range d.streams {
+		cancel(ErrGatewayDraining)
+		delete(d.streams, stream)
+	}
+	return n
+}
+
+// register adds an open stream unless the gateway is draining.
+func (d *StreamDrainer) register(stream *drainStream, cancel context.CancelCauseFunc) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.streams[stream] = cancel
+	return true
+}
+
+// unregister removes a finished stream.
+func (d *StreamDrainer) unregister(stream *drainStream) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.streams, stream)
+}
+
+// drainError sets the configured trailers on ss and returns the drain status.
+func (d *StreamDrainer) drainError(ss grpc.ServerStream) error {
+	if len(d.cfg.Trailers) > 0 {
+		ss.SetTrailer(metadata.New(d.cfg.Trailers))
+	}
+	return status.Error(codes.Code(d.cfg.StatusCode), d.cfg.StatusMessage)
+}
+
+// drainStream is a grpc.ServerStream whose context is canceled by NotifyStreams.
+type drainStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the cancelable stream context.
+func (s *drainStream) Context() context.Context { return s.ctx }
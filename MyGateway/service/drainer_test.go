@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testDrainConfig() domain.ShutdownConfig {
+	return domain.ShutdownConfig{
+		DrainTimeout:  time.Second,
+		StatusCode:    uint32(codes.Unavailable),
+		StatusMessage: "gateway is shutting down, reconnect",
+		Trailers:      map[string]string{"x-gateway-draining": "true"},
+	}
+}
+
+func TestStreamDrainer_PassesCallsThrough(t *testing.T) {
+	d := NewStreamDrainer(testDrainConfig())
+	info := &grpc.StreamServerInfo{FullMethod: "/svc/Call"}
+	handlerErr := status.Error(codes.NotFound, "missing")
+	ss := &trailerStream{fakeServerStream: fakeServerStream{ctx: context.Background()}}
+	err := d.StreamInterceptor()(nil, ss, info, func(_ any, stream grpc.ServerStream) error {
+		assert.NoError(t, stream.Context().Err())
+		return handlerErr
+	})
+	assert.Equal(t, handlerErr, err)
+	assert.Empty(t, ss.trailer)
+	assert.Empty(t, d.streams, "finished stream is unregistered")
+}
+
+func TestStreamDrainer_StartDrainRejectsNewCalls(t *testing.T) {
+	d := NewStreamDrainer(testDrainConfig())
+	d.StartDrain()
+	ss := &trailerStream{fakeServerStream: fakeServerStream{ctx: context.Background()}}
+	called := false
+	err := d.StreamInterceptor()(nil, ss, &grpc.StreamServerInfo{FullMethod: "/svc/Call"}, func(any, grpc.ServerStream) error {
+		called = true
+		return nil
+	})
+	assert.False(t, called)
+	st := status.Convert(err)
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "gateway is shutting down, reconnect", st.Message())
+	assert.Equal(t, []string{"true"}, ss.trailer.Get("x-gateway-draining"))
+}
+
+func TestStreamDrainer_NotifyStreams(t *testing.T) {
+	cfg := testDrainConfig()
+	cfg.StatusCode = uint32(codes.Aborted)
+	d := NewStreamDrainer(cfg)
+	info := &grpc.StreamServerInfo{FullMethod: "/svc/Subscribe"}
+
+	started := make(chan struct{}, 2)
+	subscribe := func(_ any, stream grpc.ServerStream) error {
+		started <- struct{}{}
+		<-stream.Context().Done()
+		assert.ErrorIs(t, context.Cause(stream.Context()), ErrGatewayDraining)
+		return status.Error(codes.Canceled, "context canceled")
+	}
+	results := make(chan error, 2)
+	streams := make(chan *trailerStream, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			ss := &trailerStream{fakeServerStream: fakeServerStream{ctx: context.Background()}}
+			err := d.StreamInterceptor()(nil, ss, info, subscribe)
+			streams <- ss
+			results <- err
+		}()
+	}
+	<-started
+	<-started
+
+	assert.Equal(t, 2, d.NotifyStreams())
+	for i := 0; i < 2; i++ {
+		err := <-results
+		ss := <-streams
+		st := status.Convert(err)
+		assert.Equal(t, codes.Aborted, st.Code())
+		assert.Equal(t, "gateway is shutting down, reconnect", st.Message())
+		assert.Equal(t, []string{"true"}, ss.trailer.Get("x-gateway-draining"))
+	}
+	assert.Equal(t, 0, d.NotifyStreams())
+}
+
+func TestStreamDrainer_NotifiedStreamFinishingCleanly(t *testing.T) {
+	d := NewStreamDrainer(testDrainConfig())
+	ss := &trailerStream{fakeServerStream: fakeServerStream{ctx: context.Background()}}
+	err := d.StreamInterceptor()(nil, ss, &grpc.StreamServerInfo{FullMethod: "/svc/Call"}, func(any, grpc.ServerStream) error {
+		require.Equal(t, 1, d.NotifyStreams())
+		return nil
+	})
+	assert.NoError(t, err, "a call that completed keeps its OK status")
+}
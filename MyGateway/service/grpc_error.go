@@ -11,6 +11,7 @@ import (
 )
 
 const msgAllInstancesBusy = "all instances are busy"
+const msgStreamLimitReached = "all instances are at the concurrent stream limit"
 const msgBackendUnavailable = "backend service unavailable"
 const msgMissingOrInvalidToken = "missing or invalid token"
 
@@ -36,7 +37,7 @@ func GatewayErrorToGRPCStreamInterceptor(logger log.Logger) grpc.StreamServerInt
 	}
 }
 
-// gatewayErrorToGRPC maps handler errors to gRPC status per FR-MGW-5 (table 4.1.4): nil → nil; ErrNoAvailableConnInstance → ResourceExhausted "all instances are busy"; ErrStickyKeyRequired → Unauthenticated "missing or invalid token"; ErrConnPoolClosed/ErrGenericUnknownCluster and any Unavailable → Unavailable "backend service unavailable"; other gRPC status with code != Unknown returned as-is; rest → Unavailable "backend service unavailable".
+// gatewayErrorToGRPC maps handler errors to gRPC status per FR-MGW-5 (table 4.1.4): nil → nil; ErrNoAvailableConnInstance → ResourceExhausted "all instances are busy"; ErrInstancesSaturated → ResourceExhausted "all instances are at the concurrent stream limit"; ErrStickyKeyRequired → Unauthenticated "missing or invalid token"; ErrConnPoolClosed/ErrGenericUnknownCluster and any Unavailable → Unavailable "backend service unavailable"; other gRPC status with code != Unknown returned as-is; rest → Unavailable "backend service unavailable".
 //
 // Parameter err — error returned by handler; nil is allowed.
 //
@@ -57,6 +58,8 @@ func gatewayErrorToGRPC(err error) error {
 	switch {
 	case errors.Is(err, ErrNoAvailableConnInstance):
 		return status.Error(codes.ResourceExhausted, msgAllInstancesBusy)
+	case errors.Is(err, ErrInstancesSaturated):
+		return status.Error(codes.ResourceExhausted, msgStreamLimitReached)
 	case errors.Is(err, ErrStickyKeyRequired):
 		return status.Error(codes.Unauthenticated, msgMissingOrInvalidToken)
 	case errors.Is(err, ErrConnPoolClosed), errors.Is(err, ErrGenericUnknownCluster):
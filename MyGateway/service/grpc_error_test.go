@@ -38,6 +38,14 @@ func TestGatewayErrorToGRPC_ErrNoAvailableConnInstanceWrapped(t *testing.T) {
 	assert.Equal(t, msgAllInstancesBusy, s.Message())
 }
 
+func TestGatewayErrorToGRPC_ErrInstancesSaturated(t *testing.T) {
+	err := gatewayErrorToGRPC(ErrInstancesSaturated)
+	s, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, s.Code())
+	assert.Equal(t, msgStreamLimitReached, s.Message())
+}
+
 func TestGatewayErrorToGRPC_ErrStickyKeyRequired(t *testing.T) {
 	err := gatewayErrorToGRPC(ErrStickyKeyRequired)
 	assert.Error(t, err)
@@ -0,0 +1,594 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/helpers"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	grpcContentType        = "application/grpc"
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+
+	// grpcWebFrameTrailer marks the trailer frame; data frames have flag 0 (bit 0 — compressed, not supported).
+	grpcWebFrameTrailer byte = 0x80
+	grpcWebFrameHeader       = 5
+)
+
+// grpcWebAllowedHeaders are always allowed in CORS preflight: the gRPC-Web client headers plus the gateway auth and sticky headers.
+var grpcWebAllowedHeaders = []string{"content-type", "x-grpc-web", "x-user-agent", "grpc-timeout", "authorization", domain.StickySessionHeader}
+
+// grpcWebExposedHeaders are always exposed to browser code (status of trailers-only responses).
+var grpcWebExposedHeaders = []string{"grpc-status", "grpc-message"}
+
+// httpSkippedHeaders are HTTP headers not copied into incoming gRPC metadata (hop-by-hop, framing and gRPC-Web transport headers).
+var httpSkippedHeaders = map[string]bool{
+	"connection": true, "keep-alive": true, "proxy-connection": true, "transfer-encoding": true, "upgrade": true, "te": true,
+	"host": true, "content-length": true, "grpc-timeout": true, "grpc-encoding": true, "grpc-accept-encoding": true, "x-grpc-web": true,
+}
+
+// GRPCWebHandler is an http.Handler that accepts gRPC-Web requests from browsers (binary application/grpc-web[+proto] and
+// text application/grpc-web-text[+proto]) and runs them through the same stream handler as native gRPC
+// (TransparentProxy.Handler behind the gateway interceptors): the request body frames become RecvMsg messages, HTTP headers
+// become incoming metadata (with grpc-timeout as the deadline and the client address as peer), and SendMsg/trailers are
+// written back as gRPC-Web frames, flushed per message so server streaming works. Answers CORS preflight per CORSConfig.
+// Fields: handler (stream handler wrapped by interceptors), cors, maxMessage (largest request message, as
+// server.max_recv_msg_size_bytes for native gRPC), maxBody (request body limit: one such message with its frame header,
+// base64-encoded in text mode), logger.
+type GRPCWebHandler struct {
+	handler    grpc.StreamHandler
+	cors       domain.CORSConfig
+	maxMessage int
+	maxBody    int64
+	logger     log.Logger
+}
+
+// NewGRPCWebHandler creates the gRPC-Web frontend. Panics on nil handler, non-positive maxMessage, nil interceptor or
+// nil logger.
+//
+// Parameters: handler — stream handler for every method (TransparentProxy.Handler); cors — cross-origin policy;
+// maxMessage — largest request message in bytes (server.max_recv_msg_size_bytes, so gRPC-Web accepts what native gRPC
+// does); logger — logs failed writes to the browser; interceptors — stream interceptors applied in order as in
+// grpc.ChainStreamInterceptor (cmd/main passes GatewayErrorToGRPCStreamInterceptor so errors map to the same codes as
+// for native gRPC).
+//
+// Returns: *GRPCWebHandler (implements http.Handler).
+//
+// Called from cmd/main when grpc_web.enabled is set.
+func NewGRPCWebHandler(handler grpc.StreamHandler, cors domain.CORSConfig, maxMessage int, logger log.Logger, interceptors ...grpc.StreamServerInterceptor) *GRPCWebHandler {
+	handler = helpers.NilPanic(handler, "service.grpc_web.go: handler is required")
+	if maxMessage <= 0 {
+		panic("service.grpc_web.go: max message size must be positive")
+	}
+	for _, interceptor := range interceptors {
+		helpers.NilPanic(interceptor, "service.grpc_web.go: interceptor is required")
+	}
+	return &GRPCWebHandler{
+		handler:    chainStreamHandler(handler, interceptors),
+		cors:       cors,
+		maxMessage: maxMessage,
+		maxBody:    int64(base64.StdEncoding.EncodedLen(grpcWebFrameHeader + maxMessage)),
+		logger:     log.With(helpers.NilPanic(logger, "service.grpc_web.go: logger is required"), "component", "grpc_web"),
+	}
+}
+
+// chainStreamHandler wraps handler with interceptors applied in order, as grpc.ChainStreamInterceptor does for a
+// grpc.Server (the method is taken from the stream's ServerTransportStream).
+//
+// Called from NewGRPCWebHandler and NewHTTPTranscoder (interceptors already checked for nil).
+func chainStreamHandler(handler grpc.StreamHandler, interceptors []grpc.StreamServerInterceptor) grpc.StreamHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i]
+		next := handler
+		handler = func(srv any, ss grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(ss)
+			return interceptor(srv, ss, &grpc.StreamServerInfo{FullMethod: method, IsClientStream: true, IsServerStream: true}, next)
+		}
+	}
+	return handler
+}
+
+// NewGRPCWebMux serves native gRPC and gRPC-Web on one port: HTTP/2 requests with content-type application/grpc go to
+// grpcServer (grpc.Server.ServeHTTP), everything else (gRPC-Web, CORS preflight) to web.
+//
+// Parameters: grpcServer — the gateway *grpc.Server; web — the gRPC-Web handler.
+//
+// Returns: http.Handler for an http.Server with HTTP/1 and unencrypted HTTP/2 enabled.
+//
+// Called from cmd/main when grpc_web.enabled is set without a separate port.
+func NewGRPCWebMux(grpcServer http.Handler, web http.Handler) http.Handler {
+	helpers.NilPanic(grpcServer, "service.grpc_web.go: grpc server is required")
+	helpers.NilPanic(web, "service.grpc_web.go: gRPC-Web handler is required")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ct := r.Header.Get("Content-Type")
+		if r.ProtoMajor == 2 && strings.HasPrefix(ct, grpcContentType) && !strings.HasPrefix(ct, grpcWebContentType) {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		web.ServeHTTP(w, r)
+	})
+}
+
+// ServeHTTP handles one gRPC-Web call or CORS preflight.
+//
+// Parameters: w — response writer (must support flushing for server streaming); r — POST with a gRPC-Web content type and
+// the full method as path, or OPTIONS preflight.
+//
+// Responses: preflight — 204 with CORS headers (403 when the origin is not allowed); wrong method — 405; unsupported content
+// type — 415; unreadable or malformed body — gRPC status in a trailers-only response (Internal/ResourceExhausted/Unimplemented);
+// otherwise 200 with data frames and a trailer frame (or trailers-only when the handler failed before any message).
+//
+// Called by net/http for requests on the gRPC-Web listener (or via NewGRPCWebMux).
+func (h *GRPCWebHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if r.Method == http.MethodOptions {
+		h.preflight(w, origin)
+		return
+	}
+	if h.cors.AllowsOrigin(origin) {
+		h.setCORSHeaders(w.Header(), origin)
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(append(append([]string{}, grpcWebExposedHeaders...), h.cors.ExposedHeaders...), ", "))
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "gRPC-Web requires POST", http.StatusMethodNotAllowed)
+		return
+	}
+	contentType := r.Header.Get("Content-Type")
+	text := strings.HasPrefix(contentType, grpcWebTextContentType)
+	if !text && !strings.HasPrefix(contentType, grpcWebContentType) {
+		http.Error(w, "unsupported content type "+contentType, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	stream := &grpcWebStream{w: w, text: text, contentType: contentType, header: metadata.MD{}, trailer: metadata.MD{}, logger: h.logger}
+	maxBody := h.maxBody
+	if !text {
+		maxBody = int64(grpcWebFrameHeader + h.maxMessage)
+	}
+	messages, err := readGRPCWebMessages(http.MaxBytesReader(w, r.Body, maxBody), text, h.maxMessage)
+	if err != nil {
+		stream.finish(err)
+		return
+	}
+	stream.messages = messages
+
+	ctx, cancel, err := httpCallContext(r)
+	if err != nil {
+		stream.finish(err)
+		return
+	}
+	defer cancel()
+	stream.ctx = grpc.NewContextWithServerTransportStream(ctx, &httpTransportStream{method: r.URL.Path, stream: stream})
+	stream.finish(h.handler(nil, stream))
+}
+
+// preflight answers a CORS preflight: allowed origin — 204 with Allow-Origin/Methods/Headers (and Max-Age, Allow-Credentials when configured); otherwise 403.
+//
+// Called only from ServeHTTP for OPTIONS.
+func (h *GRPCWebHandler) preflight(w http.ResponseWriter, origin string) {
+	if !h.cors.AllowsOrigin(origin) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	hdr := w.Header()
+	h.setCORSHeaders(hdr, origin)
+	hdr.Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	hdr.Set("Access-Control-Allow-Headers", strings.Join(append(append([]string{}, grpcWebAllowedHeaders...), h.cors.AllowedHeaders...), ", "))
+	if h.cors.MaxAge > 0 {
+		hdr.Set("Access-Control-Max-Age", strconv.Itoa(int(h.cors.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setCORSHeaders sets Allow-Origin ("*" for AnyOrigin without credentials, otherwise the request origin with Vary: Origin) and Allow-Credentials.
+//
+// Called from ServeHTTP and preflight for allowed origins.
+func (h *GRPCWebHandler) setCORSHeaders(hdr http.Header, origin string) {
+	if !h.cors.AllowCredentials && len(h.cors.AllowedOrigins) == 1 && h.cors.AllowedOrigins[0] == domain.AnyOrigin {
+		hdr.Set("Access-Control-Allow-Origin", domain.AnyOrigin)
+	} else {
+		hdr.Set("Access-Control-Allow-Origin", origin)
+		hdr.Add("Vary", "Origin")
+	}
+	if h.cors.AllowCredentials {
+		hdr.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// readGRPCWebMessages reads the request body and splits it into message payloads (text mode is base64 first, possibly several padded chunks).
+//
+// Parameters: body — request body (size-limited by the caller); text — application/grpc-web-text; maxMessage — largest
+// message payload in bytes.
+//
+// Returns: (payloads, nil) for data frames in order (trailer frames from the client are ignored); (nil, status error) on read
+// error (Internal), invalid base64 or truncated frame (Internal), compressed frame (Unimplemented), oversized message or
+// body (ResourceExhausted).
+//
+// Called only from ServeHTTP.
+func readGRPCWebMessages(body io.Reader, text bool, maxMessage int) ([][]byte, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, status.Errorf(codes.ResourceExhausted, "gRPC-Web request body larger than max (%d bytes)", tooLarge.Limit)
+		}
+		return nil, status.Errorf(codes.Internal, "read gRPC-Web request: %v", err)
+	}
+	if text {
+		if data, err = decodeGRPCWebText(data); err != nil {
+			return nil, status.Errorf(codes.Internal, "decode gRPC-Web text request: %v", err)
+		}
+	}
+	var messages [][]byte
+	for len(data) > 0 {
+		if len(data) < grpcWebFrameHeader {
+			return nil, status.Error(codes.Internal, "truncated gRPC-Web frame")
+		}
+		flag := data[0]
+		n := binary.BigEndian.Uint32(data[1:grpcWebFrameHeader])
+		if uint64(n) > uint64(maxMessage) {
+			return nil, status.Errorf(codes.ResourceExhausted, "gRPC-Web message larger than max (%d vs. %d)", n, maxMessage)
+		}
+		if uint32(len(data)-grpcWebFrameHeader) < n {
+			return nil, status.Error(codes.Internal, "truncated gRPC-Web frame")
+		}
+		payload := data[grpcWebFrameHeader : grpcWebFrameHeader+int(n)]
+		data = data[grpcWebFrameHeader+int(n):]
+		if flag&grpcWebFrameTrailer != 0 {
+			continue
+		}
+		if flag&0x01 != 0 {
+			return nil, status.Error(codes.Unimplemented, "compressed gRPC-Web messages are not supported")
+		}
+		messages = append(messages, payload)
+	}
+	return messages, nil
+}
+
+// decodeGRPCWebText decodes a grpc-web-text body: base64 that may consist of several padded chunks (one per frame).
+//
+// Returns: (decoded bytes, nil); (nil, error) on invalid base64.
+//
+// Called only from readGRPCWebMessages.
+func decodeGRPCWebText(data []byte) ([]byte, error) {
+	data = bytes.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == ' ' {
+			return -1
+		}
+		return r
+	}, data)
+	var out []byte
+	for len(data) > 0 {
+		end := len(data)
+		if i := bytes.IndexByte(data, '='); i >= 0 {
+			end = min((i/4+1)*4, len(data))
+		}
+		enc := base64.StdEncoding
+		if end%4 != 0 {
+			enc = base64.RawStdEncoding
+		}
+		chunk := make([]byte, enc.DecodedLen(end))
+		n, err := enc.Decode(chunk, data[:end])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk[:n]...)
+		data = data[end:]
+	}
+	return out, nil
+}
+
+// httpCallContext builds the handler context from the HTTP request: incoming metadata from headers (lowercase keys, -bin
+// values base64-decoded, :authority from Host), peer from RemoteAddr and the grpc-timeout deadline.
+//
+// Returns: (ctx, cancel, nil); (nil, nil, status error) on an invalid grpc-timeout (Internal) or -bin value (Internal).
+//
+// Called from GRPCWebHandler.ServeHTTP and HTTPTranscoder.ServeHTTP.
+func httpCallContext(r *http.Request) (context.Context, context.CancelFunc, error) {
+	md := metadata.MD{}
+	for k, vs := range r.Header {
+		key := strings.ToLower(k)
+		if httpSkippedHeaders[key] {
+			continue
+		}
+		for _, v := range vs {
+			if strings.HasSuffix(key, "-bin") {
+				decoded, err := decodeBinaryHeader(v)
+				if err != nil {
+					return nil, nil, status.Errorf(codes.Internal, "malformed binary metadata %q: %v", key, err)
+				}
+				v = string(decoded)
+			}
+			md.Append(key, v)
+		}
+	}
+	md.Set(":authority", r.Host)
+	md.Set("content-type", grpcContentType)
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	if ap, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: net.TCPAddrFromAddrPort(ap)})
+	}
+	if v := r.Header.Get("Grpc-Timeout"); v != "" {
+		timeout, err := parseGRPCTimeout(v)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.Internal, "malformed grpc-timeout: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, cancel, nil
+}
+
+// decodeBinaryHeader decodes a -bin metadata value (padded or unpadded base64).
+func decodeBinaryHeader(v string) ([]byte, error) {
+	if len(v)%4 == 0 {
+		return base64.StdEncoding.DecodeString(v)
+	}
+	return base64.RawStdEncoding.DecodeString(v)
+}
+
+// parseGRPCTimeout parses a grpc-timeout value: up to 8 digits followed by a unit H, M, S, m, u or n.
+//
+// Returns: (duration, nil); (0, error) on a malformed value.
+//
+// Called only from httpCallContext.
+func parseGRPCTimeout(v string) (time.Duration, error) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, fmt.Errorf("invalid length %q", v)
+	}
+	units := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second, 'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond}
+	unit, ok := units[v[len(v)-1]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit in %q", v)
+	}
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid value %q", v)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// grpcWebStream implements grpc.ServerStream over one gRPC-Web HTTP exchange. Request messages are fully read up front;
+// responses are written as frames and flushed per message. Under mu: header, trailer, headerSent, finished (writes to w
+// happen from the proxy forwarding goroutine and from finish).
+type grpcWebStream struct {
+	ctx         context.Context
+	w           http.ResponseWriter
+	text        bool
+	contentType string
+	logger      log.Logger
+	messages    [][]byte
+	next        int
+
+	mu         sync.Mutex
+	header     metadata.MD
+	trailer    metadata.MD
+	headerSent bool
+	finished   bool
+}
+
+// SetHeader merges md into the response headers (sent with the first message or the trailers-only response).
+func (s *grpcWebStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.headerSent {
+		return status.Error(codes.Internal, "headers already sent")
+	}
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+// SendHeader merges md and writes the response headers immediately.
+func (s *grpcWebStream) SendHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.headerSent {
+		return status.Error(codes.Internal, "headers already sent")
+	}
+	s.header = metadata.Join(s.header, md)
+	s.writeHeaderLocked(nil)
+	s.flushLocked()
+	return nil
+}
+
+// SetTrailer merges md into the trailer frame written by finish.
+func (s *grpcWebStream) SetTrailer(md metadata.MD) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+// Context returns the request context (incoming metadata, peer, deadline, server transport stream with the method).
+func (s *grpcWebStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg marshals m and writes it as a data frame, flushing so streamed responses reach the browser immediately.
+//
+// Returns: nil; status error when m is not a proto message or the call already finished; write error when the client went away.
+func (s *grpcWebStream) SendMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "gRPC-Web: unsupported message type %T", m)
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return status.Errorf(codes.Internal, "gRPC-Web: marshal response: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return status.Error(codes.Internal, "gRPC-Web: stream already finished")
+	}
+	s.writeHeaderLocked(nil)
+	if err := s.writeFrameLocked(0, payload); err != nil {
+		return err
+	}
+	s.flushLocked()
+	return nil
+}
+
+// RecvMsg unmarshals the next request message into m (unknown fields are kept, so emptypb.Empty forwards the raw bytes).
+//
+// Returns: nil; io.EOF after the last message; context error when the call was cancelled; status error on unmarshal failure.
+func (s *grpcWebStream) RecvMsg(m any) error {
+	if err := s.ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	if s.next >= len(s.messages) {
+		return io.EOF
+	}
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "gRPC-Web: unsupported message type %T", m)
+	}
+	payload := s.messages[s.next]
+	s.next++
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return status.Errorf(codes.Internal, "gRPC-Web: unmarshal request: %v", err)
+	}
+	return nil
+}
+
+// finish writes the final status: a trailer frame when headers were already sent, otherwise a trailers-only response
+// (grpc-status/grpc-message and trailer metadata as HTTP headers). Later SendMsg calls fail.
+//
+// Parameter err — handler result (nil — OK).
+//
+// Called from GRPCWebHandler.ServeHTTP once the handler returned (or the request could not be decoded).
+func (s *grpcWebStream) finish(err error) {
+	st := status.Convert(err)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.finished = true
+	trailer := metadata.Join(s.trailer, metadata.Pairs("grpc-status", strconv.Itoa(int(st.Code()))))
+	if st.Message() != "" {
+		trailer.Set("grpc-message", encodeGRPCMessage(st.Message()))
+	}
+	if !s.headerSent {
+		s.writeHeaderLocked(trailer)
+		return
+	}
+	var block bytes.Buffer
+	for k, vs := range trailer {
+		for _, v := range vs {
+			if strings.HasSuffix(k, "-bin") {
+				v = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			block.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	if werr := s.writeFrameLocked(grpcWebFrameTrailer, block.Bytes()); werr != nil {
+		level.Debug(s.logger).Log("msg", "write gRPC-Web trailer", "err", werr)
+		return
+	}
+	s.flushLocked()
+}
+
+// writeHeaderLocked writes the HTTP status and headers once: content type of the request and header metadata (plus
+// extra — the status for a trailers-only response). Caller must hold mu.
+func (s *grpcWebStream) writeHeaderLocked(extra metadata.MD) {
+	if s.headerSent {
+		return
+	}
+	s.headerSent = true
+	hdr := s.w.Header()
+	hdr.Set("Content-Type", s.contentType)
+	for _, md := range []metadata.MD{s.header, extra} {
+		for k, vs := range md {
+			for _, v := range vs {
+				if strings.HasSuffix(k, "-bin") {
+					v = base64.StdEncoding.EncodeToString([]byte(v))
+				}
+				hdr.Add(k, v)
+			}
+		}
+	}
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// writeFrameLocked writes one frame (flag, length, payload); in text mode the frame is base64-encoded on its own. Caller must hold mu.
+func (s *grpcWebStream) writeFrameLocked(flag byte, payload []byte) error {
+	frame := make([]byte, grpcWebFrameHeader+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:grpcWebFrameHeader], uint32(len(payload)))
+	copy(frame[grpcWebFrameHeader:], payload)
+	if s.text {
+		frame = []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+	if _, err := s.w.Write(frame); err != nil {
+		return status.Errorf(codes.Canceled, "gRPC-Web: write response: %v", err)
+	}
+	return nil
+}
+
+// flushLocked flushes buffered output to the client when the writer supports it. Caller must hold mu.
+func (s *grpcWebStream) flushLocked() {
+	if err := http.NewResponseController(s.w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		level.Debug(s.logger).Log("msg", "flush gRPC-Web response", "err", err)
+	}
+}
+
+// encodeGRPCMessage percent-encodes a status message as required for the grpc-message header (bytes outside printable ASCII and '%').
+func encodeGRPCMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// httpTransportStream implements grpc.ServerTransportStream so grpc.MethodFromServerStream, grpc.SetHeader and
+// grpc.SetTrailer work for calls arriving over HTTP (gRPC-Web, HTTP/JSON) as for native ones.
+type httpTransportStream struct {
+	method string
+	stream grpc.ServerStream
+}
+
+// Method returns the full gRPC method name.
+func (t *httpTransportStream) Method() string { return t.method }
+
+// SetHeader delegates to the HTTP stream.
+func (t *httpTransportStream) SetHeader(md metadata.MD) error { return t.stream.SetHeader(md) }
+
+// SendHeader delegates to the HTTP stream.
+func (t *httpTransportStream) SendHeader(md metadata.MD) error { return t.stream.SendHeader(md) }
+
+// SetTrailer delegates to the HTTP stream.
+func (t *httpTransportStream) SetTrailer(md metadata.MD) error {
+	t.stream.SetTrailer(md)
+	return nil
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/interfaces/mock"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testMaxMessage is the message size limit of the frontends under test: the server.max_recv_msg_size_bytes default.
+const testMaxMessage = 16 << 20
+
+// grpcWebFrame builds one gRPC-Web frame.
+func grpcWebFrame(flag byte, payload []byte) []byte {
+	frame := make([]byte, 5+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(payload)))
+	copy(frame[5:], payload)
+	return frame
+}
+
+// parseGRPCWebResponse splits a (binary) response body into data payloads and the trailer block.
+func parseGRPCWebResponse(t *testing.T, body []byte) ([][]byte, string) {
+	t.Helper()
+	var data [][]byte
+	var trailer string
+	for len(body) > 0 {
+		require.GreaterOrEqual(t, len(body), 5)
+		n := int(binary.BigEndian.Uint32(body[1:5]))
+		require.GreaterOrEqual(t, len(body)-5, n)
+		if body[0]&0x80 != 0 {
+			trailer = string(body[5 : 5+n])
+		} else {
+			data = append(data, body[5:5+n])
+		}
+		body = body[5+n:]
+	}
+	return data, trailer
+}
+
+func mustMarshal(t *testing.T, m proto.Message) []byte {
+	t.Helper()
+	b, err := proto.Marshal(m)
+	require.NoError(t, err)
+	return b
+}
+
+func TestNewGRPCWebHandler_Panics(t *testing.T) {
+	handler := func(any, grpc.ServerStream) error { return nil }
+	t.Run("handler_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.grpc_web.go: handler is required", func() {
+			NewGRPCWebHandler(nil, domain.CORSConfig{}, testMaxMessage, log.NewNopLogger())
+		})
+	})
+	t.Run("logger_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.grpc_web.go: logger is required", func() {
+			NewGRPCWebHandler(handler, domain.CORSConfig{}, testMaxMessage, nil)
+		})
+	})
+	t.Run("interceptor_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.grpc_web.go: interceptor is required", func() {
+			NewGRPCWebHandler(handler, domain.CORSConfig{}, testMaxMessage, log.NewNopLogger(), nil)
+		})
+	})
+	t.Run("max_message_zero", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.grpc_web.go: max message size must be positive", func() {
+			NewGRPCWebHandler(handler, domain.CORSConfig{}, 0, log.NewNopLogger())
+		})
+	})
+}
+
+func TestGRPCWebHandler_MessageSize(t *testing.T) {
+	handler := func(_ any, ss grpc.ServerStream) error {
+		var m emptypb.Empty
+		if err := ss.RecvMsg(&m); err != nil {
+			return err
+		}
+		return ss.SendMsg(&m)
+	}
+	post := func(h *GRPCWebHandler, text bool, size int) *httptest.ResponseRecorder {
+		body := grpcWebFrame(0, mustMarshal(t, wrapperspb.Bytes(make([]byte, size))))
+		contentType := "application/grpc-web"
+		if text {
+			body = []byte(base64.StdEncoding.EncodeToString(body))
+			contentType = "application/grpc-web-text"
+		}
+		r := httptest.NewRequest(http.MethodPost, "/svc/Method", bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	h := NewGRPCWebHandler(handler, domain.CORSConfig{}, testMaxMessage, log.NewNopLogger())
+	for _, text := range []bool{false, true} {
+		w := post(h, text, 5<<20)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Grpc-Status"), "5 MiB message accepted as over native gRPC (text=%v)", text)
+	}
+
+	small := NewGRPCWebHandler(handler, domain.CORSConfig{}, 1<<10, log.NewNopLogger())
+	for _, tc := range []struct {
+		name string
+		text bool
+		size int
+	}{
+		{name: "binary_over_max", size: 1 << 10},
+		{name: "text_over_max", text: true, size: 1 << 10},
+		{name: "body_over_max", size: 4 << 10},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := post(small, tc.text, tc.size)
+			assert.Equal(t, "8", w.Header().Get("Grpc-Status"), "ResourceExhausted as for native gRPC")
+		})
+	}
+	t.Run("at_max", func(t *testing.T) {
+		w := post(small, true, 1<<10-3)
+		assert.Empty(t, w.Header().Get("Grpc-Status"), "tag and length of the bytes field fill the limit exactly")
+	})
+}
+
+func TestGRPCWebHandler_Unary(t *testing.T) {
+	req := mustMarshal(t, wrapperspb.String("hello"))
+	var gotMethod, gotSession, gotPeer string
+	var gotBin []string
+	handler := func(_ any, ss grpc.ServerStream) error {
+		gotMethod, _ = grpc.MethodFromServerStream(ss)
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		gotSession = firstMDValue(md, "session-id")
+		gotBin = md.Get("trace-bin")
+		if p, ok := peer.FromContext(ss.Context()); ok {
+			gotPeer = p.Addr.String()
+		}
+		var m emptypb.Empty
+		require.NoError(t, ss.RecvMsg(&m))
+		assert.Equal(t, io.EOF, ss.RecvMsg(&m))
+		require.NoError(t, ss.SetHeader(metadata.Pairs("x-header", "h")))
+		ss.SetTrailer(metadata.Pairs("x-trailer", "t"))
+		return ss.SendMsg(&m)
+	}
+	h := NewGRPCWebHandler(handler, domain.CORSConfig{}, testMaxMessage, log.NewNopLogger())
+
+	r := httptest.NewRequest(http.MethodPost, "/svc/Method", bytes.NewReader(grpcWebFrame(0, req)))
+	r.Header.Set("Content-Type", "application/grpc-web+proto")
+	r.Header.Set("Session-Id", "sess-1")
+	r.Header.Set("Trace-Bin", base64.StdEncoding.EncodeToString([]byte{1, 2}))
+	r.RemoteAddr = "10.0.0.1:5555"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/grpc-web+proto", w.Header().Get("Content-Type"))
+	assert.Equal(t, "h", w.Header().Get("X-Header"))
+	assert.Equal(t, "/svc/Method", gotMethod)
+	assert.Equal(t, "sess-1", gotSession)
+	assert.Equal(t, []string{"\x01\x02"}, gotBin)
+	assert.Equal(t, "10.0.0.1:5555", gotPeer)
+	data, trailer := parseGRPCWebResponse(t, w.Body.Bytes())
+	require.Len(t, data, 1)
+	assert.Equal(t, req, data[0], "message bytes survive emptypb.Empty (unknown fields)")
+	assert.Contains(t, trailer, "grpc-status: 0\r\n")
+	assert.Contains(t, trailer, "x-trailer: t\r\n")
+}
+
+func TestGRPCWebHandler_TextServerStreaming(t *testing.T) {
+	handler := func(_ any, ss grpc.ServerStream) error {
+		var m wrapperspb.StringValue
+		require.NoError(t, ss.RecvMsg(&m))
+		for i := 0; i < 3; i++ {
+			if err := ss.SendMsg(wrapperspb.String(m.GetValue() + strings.Repeat("!", i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	srv := httptest.NewServer(NewGRPCWebHandler(handler, domain.CORSConfig{}, testMaxMessage, log.NewNopLogger()))
+	defer srv.Close()
+
+	body := base64.StdEncoding.EncodeToString(grpcWebFrame(0, mustMarshal(t, wrapperspb.String("hi"))))
+	resp, err := http.Post(srv.URL+"/svc/Stream", "application/grpc-web-text", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/grpc-web-text", resp.Header.Get("Content-Type"))
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	decoded, err := decodeGRPCWebText(raw)
+	require.NoError(t, err, "each frame is a separately padded base64 chunk")
+	data, trailer := parseGRPCWebResponse(t, decoded)
+	require.Len(t, data, 3)
+	for i, want := range []string{"hi", "hi!", "hi!!"} {
+		var got wrapperspb.StringValue
+		require.NoError(t, proto.Unmarshal(data[i], &got))
+		assert.Equal(t, want, got.GetValue())
+	}
+	assert.Contains(t, trailer, "grpc-status: 0\r\n")
+}
+
+func TestGRPCWebHandler_Errors(t *testing.T) {
+	failing := func(any, grpc.ServerStream) error { return ErrNoAvailableConnInstance }
+	h := NewGRPCWebHandler(failing, domain.CORSConfig{}, testMaxMessage, log.NewNopLogger(), GatewayErrorToGRPCStreamInterceptor(log.NewNopLogger()))
+
+	post := func(contentType string, body []byte) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/svc/Method", bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("trailers_only_with_interceptor_mapping", func(t *testing.T) {
+		w := post("application/grpc-web", grpcWebFrame(0, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "8", w.Header().Get("Grpc-Status"), "ResourceExhausted as for native gRPC")
+		assert.Equal(t, "all instances are busy", w.Header().Get("Grpc-Message"))
+		assert.Empty(t, w.Body.Bytes())
+	})
+	t.Run("compressed_frame", func(t *testing.T) {
+		w := post("application/grpc-web", grpcWebFrame(1, []byte{1}))
+		assert.Equal(t, "12", w.Header().Get("Grpc-Status"))
+	})
+	t.Run("truncated_frame", func(t *testing.T) {
+		w := post("application/grpc-web", []byte{0, 0, 0, 0, 9, 1})
+		assert.Equal(t, "13", w.Header().Get("Grpc-Status"))
+	})
+	t.Run("bad_grpc_timeout", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/svc/Method", nil)
+		r.Header.Set("Content-Type", "application/grpc-web")
+		r.Header.Set("Grpc-Timeout", "soon")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, "13", w.Header().Get("Grpc-Status"))
+	})
+	t.Run("method_not_allowed", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/svc/Method", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+	t.Run("unsupported_content_type", func(t *testing.T) {
+		w := post("application/json", []byte("{}"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+}
+
+func TestGRPCWebHandler_Deadline(t *testing.T) {
+	var deadline time.Time
+	handler := func(_ any, ss grpc.ServerStream) error {
+		deadline, _ = ss.Context().Deadline()
+		return status.Error(codes.NotFound, "no such thing: 100%")
+	}
+	h := NewGRPCWebHandler(handler, domain.CORSConfig{}, testMaxMessage, log.NewNopLogger())
+	r := httptest.NewRequest(http.MethodPost, "/svc/Method", nil)
+	r.Header.Set("Content-Type", "application/grpc-web")
+	r.Header.Set("Grpc-Timeout", "2S")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), deadline, time.Second)
+	assert.Equal(t, "5", w.Header().Get("Grpc-Status"))
+	assert.Equal(t, "no such thing: 100%25", w.Header().Get("Grpc-Message"))
+}
+
+func TestGRPCWebHandler_CORS(t *testing.T) {
+	handler := func(any, grpc.ServerStream) error { return nil }
+	cors := domain.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example"},
+		AllowedHeaders:   []string{"x-custom"},
+		ExposedHeaders:   []string{"x-request-id"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	h := NewGRPCWebHandler(handler, cors, testMaxMessage, log.NewNopLogger())
+
+	t.Run("preflight_allowed", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodOptions, "/svc/Method", nil)
+		r.Header.Set("Origin", "https://app.example")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "session-id")
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "x-custom")
+	})
+	t.Run("preflight_denied", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodOptions, "/svc/Method", nil)
+		r.Header.Set("Origin", "https://evil.example")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+	t.Run("actual_request_exposes_headers", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/svc/Method", nil)
+		r.Header.Set("Content-Type", "application/grpc-web")
+		r.Header.Set("Origin", "https://app.example")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, "https://app.example", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "grpc-status, grpc-message, x-request-id", w.Header().Get("Access-Control-Expose-Headers"))
+	})
+	t.Run("any_origin", func(t *testing.T) {
+		anyOrigin := NewGRPCWebHandler(handler, domain.CORSConfig{AllowedOrigins: []string{domain.AnyOrigin}}, testMaxMessage, log.NewNopLogger())
+		r := httptest.NewRequest(http.MethodOptions, "/svc/Method", nil)
+		r.Header.Set("Origin", "https://other.example")
+		w := httptest.NewRecorder()
+		anyOrigin.ServeHTTP(w, r)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestGRPCWebHandler_ThroughTransparentProxy(t *testing.T) {
+	backendLis, backendSrv := startBidiBackend(t, func(stream grpc.ServerStream) error {
+		var m wrapperspb.StringValue
+		if err := stream.RecvMsg(&m); err != nil {
+			return err
+		}
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		for i := 0; i < 2; i++ {
+			if err := stream.SendMsg(wrapperspb.String(m.GetValue() + "/" + firstMDValue(md, "session-id"))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	defer backendSrv.Stop()
+	defer backendLis.Close()
+	backendConn, err := grpc.NewClient(backendLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer backendConn.Close()
+
+	route := domain.Route{Prefix: "/svc/", Cluster: "test", Balancer: domain.BalancerConfig{Type: domain.BalancerStickySession, Header: "session-id"}}
+	router := &mock.RouteMatcherMock{MatchFunc: func(string) (domain.Route, bool) { return route, true }}
+	var gotKey string
+	resolver := &mock.ConnectionResolverMock{
+		GetConnectionFunc: func(ctx context.Context, r domain.Route, headers metadata.MD) (*grpc.ClientConn, string, string, error) {
+			gotKey = firstMDValue(headers, "session-id")
+			return backendConn, gotKey, "i1", nil
+		},
+	}
+	headers := &mock.HeaderProcessorMock{ProcessFunc: func(ctx context.Context, md metadata.MD, method string) (metadata.MD, error) {
+		return md, nil
+	}}
+	proxy := newProxyForTest(router, resolver, headers, log.NewNopLogger(), nil)
+	srv := httptest.NewServer(NewGRPCWebHandler(proxy.Handler, domain.CORSConfig{}, testMaxMessage, log.NewNopLogger(), GatewayErrorToGRPCStreamInterceptor(log.NewNopLogger())))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/svc/Method", bytes.NewReader(grpcWebFrame(0, mustMarshal(t, wrapperspb.String("ping")))))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	req.Header.Set("Session-Id", "sess-42")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	data, trailer := parseGRPCWebResponse(t, body)
+	require.Len(t, data, 2, "server streaming")
+	var got wrapperspb.StringValue
+	require.NoError(t, proto.Unmarshal(data[1], &got))
+	assert.Equal(t, "ping/sess-42", got.GetValue())
+	assert.Equal(t, "sess-42", gotKey, "sticky key from the gRPC-Web header")
+	assert.Contains(t, trailer, "grpc-status: 0\r\n")
+}
+
+func TestNewGRPCWebMux(t *testing.T) {
+	var hit string
+	grpcSrv := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hit = "grpc" })
+	web := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hit = "web" })
+	mux := NewGRPCWebMux(grpcSrv, web)
+
+	r := httptest.NewRequest(http.MethodPost, "/svc/Method", nil)
+	r.ProtoMajor = 2
+	r.Header.Set("Content-Type", "application/grpc")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "grpc", hit)
+
+	r.Header.Set("Content-Type", "application/grpc-web+proto")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "web", hit)
+}
+
+func TestParseGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "1H", want: time.Hour},
+		{in: "100m", want: 100 * time.Millisecond},
+		{in: "5S", want: 5 * time.Second},
+		{in: "10u", want: 10 * time.Microsecond},
+		{in: "S", wantErr: true},
+		{in: "10x", wantErr: true},
+		{in: "123456789S", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseGRPCTimeout(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const msgOverloaded = "gateway is overloaded, retry later"
+
+// retryPushbackTrailer is the gRPC retry pushback trailer (milliseconds) honoured by clients with a retry policy.
+const retryPushbackTrailer = "grpc-retry-pushback-ms"
+
+// LoadShedder is gateway-wide admission control with an adaptive concurrency limit (AIMD on latency): every call
+// holds one in-flight slot until it sends its first response message (or its handler returns without one), so only
+// calls still waiting for an answer count — long-lived subscriptions that already answered do not fill the limit. A
+// call is admitted while the in-flight count is below the limit
+// times the share of its priority (domain.LimitShare), so low priority calls are shed first and critical routes (e.g.
+// Login) are never shed. A call ending with UNAVAILABLE / RESOURCE_EXHAUSTED / DEADLINE_EXCEEDED multiplies the limit
+// by Backoff, unless it was ended by the gateway drain (ErrGatewayDraining; neither an overload nor a sample). Only calls ending OK after sending a response are latency samples (fast rejections such as
+// PERMISSION_DENIED and streams that never answered would skew the baseline): their latency to the first response
+// message is compared with the baseline — the lowest sampled latency of the previous BaselineWindow; a sample slower
+// than baseline × LatencyTolerance multiplies the limit by Backoff too (decreases at most once per baseline latency,
+// so one burst counts once); a sample within tolerance, while the limit is at least half used, adds 1/limit. Shed calls get UNAVAILABLE with a google.rpc.RetryInfo detail and the
+// grpc-retry-pushback-ms trailer. Fields: cfg, router, timeProvider; under mu: limit, inFlight, baseline, windowMin,
+// windowStart, lastDecrease.
+type LoadShedder struct {
+	cfg          domain.LoadSheddingConfig
+	router       interfaces.RouteMatcher
+	timeProvider interfaces.TimeProvider
+
+	mu           sync.Mutex
+	limit        float64
+	inFlight     int
+	baseline     time.Duration
+	windowMin    time.Duration
+	windowStart  time.Time
+	lastDecrease time.Time
+}
+
+// NewLoadShedder creates the admission controller with the limit at cfg.InitialLimit. Panics on nil router or time provider.
+//
+// Parameters: cfg — validated load_shedding settings; router — route matcher (route priority); timeProvider — clock for latencies.
+//
+// Returns: *LoadShedder; its StreamInterceptor is installed on every frontend.
+//
+// Called from cmd/main when load_shedding.enabled is set.
+func NewLoadShedder(cfg domain.LoadSheddingConfig, router interfaces.RouteMatcher, timeProvider interfaces.TimeProvider) *LoadShedder {
+	s := &LoadShedder{
+		cfg:          cfg,
+		router:       helpers.NilPanic(router, "service.load_shedder.go: router is required"),
+		timeProvider: helpers.NilPanic(timeProvider, "service.load_shedder.go: time provider is required"),
+		limit:        float64(cfg.InitialLimit),
+	}
+	s.windowStart = s.timeProvider.Now()
+	return s
+}
+
+// StreamInterceptor returns the stream server interceptor doing admission and latency sampling. Must run outside
+// GatewayErrorToGRPCStreamInterceptor (which would drop the retry details of UNAVAILABLE) and inside the access log.
+//
+// Returns: grpc.StreamServerInterceptor; the shed error or the handler error unchanged.
+//
+// Called from cmd/main when building the interceptor chain.
+func (s *LoadShedder) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !s.admit(s.priority(ss.Context(), info.FullMethod)) {
+			ss.SetTrailer(metadata.Pairs(retryPushbackTrailer, strconv.FormatInt(s.cfg.RetryAfter.Milliseconds(), 10)))
+			return s.shedError()
+		}
+		start := s.timeProvider.Now()
+		release := sync.OnceValue(s.release)
+		stream := &firstResponseStream{ServerStream: ss, timeProvider: s.timeProvider, onFirst: func() { release() }}
+		err := handler(srv, stream)
+		used := release()
+		if errors.Is(context.Cause(ss.Context()), ErrGatewayDraining) {
+			return err
+		}
+		var latency time.Duration
+		first := stream.firstResponse.Load()
+		if first != 0 {
+			latency = time.Unix(0, first).Sub(start)
+		}
+		code := status.Code(err)
+		s.done(latency, used, code == codes.OK && first != 0, code == codes.Unavailable || code == codes.ResourceExhausted || code == codes.DeadlineExceeded)
+		return err
+	}
+}
+
+// priority returns the effective priority of a call: critical routes stay critical; otherwise a valid client header
+// value (low|normal|high — clients cannot claim critical) wins over the route priority; default normal.
+func (s *LoadShedder) priority(ctx context.Context, fullMethod string) domain.Priority {
+	route, routed := s.router.Match(fullMethod)
+	if routed && route.Priority == domain.PriorityCritical {
+		return domain.PriorityCritical
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if value, ok := helpers.GetHeaderValue(md, s.cfg.PriorityHeader); ok {
+		switch p := domain.Priority(strings.ToLower(value)); p {
+		case domain.PriorityLow, domain.PriorityNormal, domain.PriorityHigh:
+			return p
+		}
+	}
+	if routed && route.Priority != "" {
+		return route.Priority
+	}
+	return domain.PriorityNormal
+}
+
+// admit takes an in-flight slot unless the priority's share of the limit is already used.
+func (s *LoadShedder) admit(p domain.Priority) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if share := domain.LimitShare(p); share > 0 && float64(s.inFlight) >= s.limit*share {
+		return false
+	}
+	s.inFlight++
+	return true
+}
+
+// release frees the in-flight slot of a call.
+//
+// Returns: the in-flight count, this call included, before the release.
+//
+// Called once per admitted call: at its first response message or when its handler returns.
+func (s *LoadShedder) release() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inFlight := s.inFlight
+	s.inFlight--
+	return inFlight
+}
+
+// done adjusts the limit after a call; a sampled call also updates the latency baseline.
+//
+// Parameters: latency — time to the first response message; inFlight — in-flight count when the call released its
+// slot (release); sampled — the call ended OK after a response (latency is meaningful); overloaded — the call ended with
+// an overload code.
+func (s *LoadShedder) done(latency time.Duration, inFlight int, sampled, overloaded bool) {
+	now := s.timeProvider.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if overloaded {
+		s.decrease(now)
+		return
+	}
+	if !sampled {
+		return
+	}
+
+	if s.windowMin == 0 || latency < s.windowMin {
+		s.windowMin = latency
+	}
+	if s.baseline == 0 || latency < s.baseline {
+		s.baseline = latency
+	}
+	if now.Sub(s.windowStart) >= s.cfg.BaselineWindow {
+		// The baseline follows the fastest call of the last window, so it recovers after backends change.
+		s.baseline, s.windowMin, s.windowStart = s.windowMin, 0, now
+	}
+
+	if float64(latency) > float64(s.baseline)*s.cfg.LatencyTolerance {
+		s.decrease(now)
+		return
+	}
+	if float64(inFlight) >= s.limit/2 {
+		s.limit = math.Min(float64(s.cfg.MaxLimit), s.limit+1/s.limit)
+	}
+}
+
+// decrease multiplies the limit by Backoff (not below MinLimit) unless it was decreased within the last baseline
+// latency. Caller holds mu.
+func (s *LoadShedder) decrease(now time.Time) {
+	if now.Sub(s.lastDecrease) < s.baseline {
+		return
+	}
+	s.limit = math.Max(float64(s.cfg.MinLimit), s.limit*s.cfg.Backoff)
+	s.lastDecrease = now
+}
+
+// shedError returns UNAVAILABLE with a RetryInfo detail carrying cfg.RetryAfter.
+func (s *LoadShedder) shedError() error {
+	st := status.New(codes.Unavailable, msgOverloaded)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(s.cfg.RetryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// firstResponseStream records when the first response message was sent to the client (UnixNano, 0 — none yet) and
+// then calls onFirst.
+type firstResponseStream struct {
+	grpc.ServerStream
+	timeProvider  interfaces.TimeProvider
+	onFirst       func()
+	firstResponse atomic.Int64
+}
+
+// SendMsg sends to the client and records the time of the first message.
+func (s *firstResponseStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil && s.firstResponse.Load() == 0 && s.firstResponse.CompareAndSwap(0, s.timeProvider.Now().UnixNano()) {
+		s.onFirst()
+	}
+	return err
+}
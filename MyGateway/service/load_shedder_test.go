@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mygateway/domain"
+	"mygateway/interfaces/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// trailerStream is a fakeServerStream that keeps the trailer.
+type trailerStream struct {
+	fakeServerStream
+	trailer metadata.MD
+}
+
+func (s *trailerStream) SetTrailer(md metadata.MD) { s.trailer = metadata.Join(s.trailer, md) }
+
+// testShedder returns a LoadShedder over routes /login/ (critical) and /batch/ (low), a manual clock and the clock setter.
+func testShedder(t *testing.T, cfg domain.LoadSheddingConfig) (*LoadShedder, func(time.Duration)) {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
+	router := &mock.RouteMatcherMock{MatchFunc: func(method string) (domain.Route, bool) {
+		switch {
+		case method == "/login/Login":
+			return domain.Route{Prefix: "/login/", Cluster: "auth", Priority: domain.PriorityCritical}, true
+		case method == "/batch/Run":
+			return domain.Route{Prefix: "/batch/", Cluster: "svc", Priority: domain.PriorityLow}, true
+		case method == "/svc/Call":
+			return domain.Route{Prefix: "/svc/", Cluster: "svc"}, true
+		}
+		return domain.Route{}, false
+	}}
+	return NewLoadShedder(cfg, router, tp), func(d time.Duration) { now = now.Add(d) }
+}
+
+func testShedConfig() domain.LoadSheddingConfig {
+	return domain.LoadSheddingConfig{
+		Enabled: true, InitialLimit: 10, MinLimit: 2, MaxLimit: 20, LatencyTolerance: 2, Backoff: 0.5,
+		BaselineWindow: time.Minute, RetryAfter: 1500 * time.Millisecond, PriorityHeader: domain.DefaultPriorityHeader,
+	}
+}
+
+func TestNewLoadShedder_Panics(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: time.Now}
+	assert.PanicsWithValue(t, "service.load_shedder.go: router is required", func() {
+		NewLoadShedder(testShedConfig(), nil, tp)
+	})
+	assert.PanicsWithValue(t, "service.load_shedder.go: time provider is required", func() {
+		NewLoadShedder(testShedConfig(), &mock.RouteMatcherMock{}, nil)
+	})
+}
+
+func TestLoadShedder_Priority(t *testing.T) {
+	s, _ := testShedder(t, testShedConfig())
+	cases := []struct {
+		name   string
+		method string
+		header string
+		want   domain.Priority
+	}{
+		{name: "route_default_normal", method: "/svc/Call", want: domain.PriorityNormal},
+		{name: "route_low", method: "/batch/Run", want: domain.PriorityLow},
+		{name: "unrouted_normal", method: "/unknown/X", want: domain.PriorityNormal},
+		{name: "header_overrides_route", method: "/batch/Run", header: "HIGH", want: domain.PriorityHigh},
+		{name: "header_lowers_priority", method: "/svc/Call", header: "low", want: domain.PriorityLow},
+		{name: "client_cannot_claim_critical", method: "/svc/Call", header: "critical", want: domain.PriorityNormal},
+		{name: "invalid_header_ignored", method: "/batch/Run", header: "urgent", want: domain.PriorityLow},
+		{name: "critical_route_keeps_priority", method: "/login/Login", header: "low", want: domain.PriorityCritical},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.header != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-priority", tc.header))
+			}
+			assert.Equal(t, tc.want, s.priority(ctx, tc.method))
+		})
+	}
+}
+
+func TestLoadShedder_AdmissionByPriority(t *testing.T) {
+	s, _ := testShedder(t, testShedConfig())
+	fill := func(p domain.Priority) int {
+		n := 0
+		for s.admit(p) {
+			n++
+			require.Less(t, n, 100)
+		}
+		return n
+	}
+	assert.Equal(t, 5, fill(domain.PriorityLow), "low fills half the limit")
+	assert.Equal(t, 3, fill(domain.PriorityNormal), "normal fills up to 80%")
+	assert.Equal(t, 2, fill(domain.PriorityHigh), "high fills the whole limit")
+	for i := 0; i < 5; i++ {
+		assert.True(t, s.admit(domain.PriorityCritical), "critical is never shed")
+	}
+	assert.Equal(t, 15, s.inFlight)
+}
+
+func TestLoadShedder_StreamInterceptor(t *testing.T) {
+	info := func(method string) *grpc.StreamServerInfo { return &grpc.StreamServerInfo{FullMethod: method} }
+
+	t.Run("shed_call_gets_unavailable_with_retry_hint", func(t *testing.T) {
+		cfg := testShedConfig()
+		cfg.InitialLimit = 2
+		s, _ := testShedder(t, cfg)
+		require.True(t, s.admit(domain.PriorityNormal))
+		require.True(t, s.admit(domain.PriorityNormal))
+
+		ss := &trailerStream{fakeServerStream: fakeServerStream{ctx: context.Background()}}
+		called := false
+		err := s.StreamInterceptor()(nil, ss, info("/svc/Call"), func(any, grpc.ServerStream) error { called = true; return nil })
+		assert.False(t, called)
+		st := status.Convert(err)
+		assert.Equal(t, codes.Unavailable, st.Code())
+		require.Len(t, st.Details(), 1)
+		retry, ok := st.Details()[0].(*errdetails.RetryInfo)
+		require.True(t, ok)
+		assert.Equal(t, 1500*time.Millisecond, retry.RetryDelay.AsDuration())
+		assert.Equal(t, []string{"1500"}, ss.trailer.Get("grpc-retry-pushback-ms"))
+
+		ss = &trailerStream{fakeServerStream: fakeServerStream{ctx: context.Background()}}
+		err = s.StreamInterceptor()(nil, ss, info("/login/Login"), func(any, grpc.ServerStream) error { return nil })
+		assert.NoError(t, err, "Login passes while other calls are shed")
+	})
+
+	t.Run("slow_calls_shrink_limit_once_per_baseline", func(t *testing.T) {
+		s, advance := testShedder(t, testShedConfig())
+		call := func(latency time.Duration, err error) {
+			ss := &fakeServerStream{ctx: context.Background()}
+			_ = s.StreamInterceptor()(nil, ss, info("/svc/Call"), func(_ any, stream grpc.ServerStream) error {
+				advance(latency)
+				require.NoError(t, stream.SendMsg(&emptypb.Empty{}))
+				advance(time.Second) // time after the first response does not count
+				return err
+			})
+		}
+		call(10*time.Millisecond, nil)
+		assert.Equal(t, 10*time.Millisecond, s.baseline)
+		assert.Equal(t, 10.0, s.limit, "limit not grown while mostly idle")
+
+		call(50*time.Millisecond, nil)
+		assert.Equal(t, 5.0, s.limit, "latency above baseline × tolerance halves the limit")
+		s.lastDecrease = time.Time{}
+		call(5*time.Millisecond, status.Error(codes.ResourceExhausted, "busy"))
+		assert.Equal(t, 2.5, s.limit, "overload code shrinks the limit")
+		assert.Equal(t, 10*time.Millisecond, s.baseline, "failed calls are not latency samples")
+		s.lastDecrease = time.Time{}
+		call(50*time.Millisecond, nil)
+		assert.Equal(t, 2.0, s.limit, "limit never goes below min_limit")
+		assert.Equal(t, 0, s.inFlight)
+	})
+
+	t.Run("fast_calls_under_load_grow_limit", func(t *testing.T) {
+		s, advance := testShedder(t, testShedConfig())
+		for i := 0; i < 5; i++ {
+			require.True(t, s.admit(domain.PriorityHigh))
+		}
+		ss := &fakeServerStream{ctx: context.Background()}
+		err := s.StreamInterceptor()(nil, ss, info("/svc/Call"), func(_ any, stream grpc.ServerStream) error {
+			advance(10 * time.Millisecond)
+			return stream.SendMsg(&emptypb.Empty{})
+		})
+		require.NoError(t, err)
+		assert.InDelta(t, 10.1, s.limit, 1e-9, "additive increase of 1/limit")
+	})
+
+	t.Run("fast_errors_and_silent_calls_are_not_sampled", func(t *testing.T) {
+		s, advance := testShedder(t, testShedConfig())
+		call := func(latency time.Duration, send bool, err error) {
+			require.True(t, s.admit(domain.PriorityHigh)) // keep the limit half used so samples may grow it
+			defer s.release()
+			ss := &fakeServerStream{ctx: context.Background()}
+			_ = s.StreamInterceptor()(nil, ss, info("/svc/Call"), func(_ any, stream grpc.ServerStream) error {
+				advance(latency)
+				if send {
+					require.NoError(t, stream.SendMsg(&emptypb.Empty{}))
+				}
+				return err
+			})
+		}
+		for i := 0; i < 4; i++ {
+			require.True(t, s.admit(domain.PriorityHigh))
+		}
+
+		call(time.Microsecond, false, status.Error(codes.PermissionDenied, "denied"))
+		call(time.Microsecond, true, status.Error(codes.Unauthenticated, "no token"))
+		call(10*time.Minute, false, nil) // subscription ended before its first message
+		assert.Zero(t, s.baseline, "no sample yet")
+		assert.Equal(t, 10.0, s.limit)
+
+		for i := 0; i < 20; i++ {
+			call(20*time.Millisecond, true, nil)
+			advance(time.Microsecond)
+		}
+		assert.Equal(t, 20*time.Millisecond, s.baseline)
+		assert.Greater(t, s.limit, 10.0, "normal calls after a fast PERMISSION_DENIED grow the limit instead of collapsing it")
+	})
+
+	t.Run("answered_streams_release_their_slot", func(t *testing.T) {
+		cfg := testShedConfig()
+		cfg.InitialLimit = 4
+		s, advance := testShedder(t, cfg)
+		answered := make(chan struct{})
+		end := make(chan struct{})
+		ended := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			go func() {
+				ss := &fakeServerStream{ctx: context.Background()}
+				ended <- s.StreamInterceptor()(nil, ss, info("/batch/Run"), func(_ any, stream grpc.ServerStream) error {
+					if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+						return err
+					}
+					answered <- struct{}{}
+					<-end // subscription stays open
+					return nil
+				})
+			}()
+			select {
+			case <-answered:
+			case err := <-ended:
+				t.Fatalf("subscription %d shed: %v", i, err)
+			}
+		}
+		s.mu.Lock()
+		assert.Zero(t, s.inFlight, "open subscriptions that answered hold no slot")
+		s.mu.Unlock()
+
+		ss := &fakeServerStream{ctx: context.Background()}
+		err := s.StreamInterceptor()(nil, ss, info("/batch/Run"), func(_ any, stream grpc.ServerStream) error {
+			advance(time.Millisecond)
+			return stream.SendMsg(&emptypb.Empty{})
+		})
+		assert.NoError(t, err, "low priority call admitted next to 10 open subscriptions with limit 4")
+
+		close(end)
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, <-ended)
+		}
+		assert.Zero(t, s.inFlight)
+	})
+
+	t.Run("unanswered_streams_keep_their_slot", func(t *testing.T) {
+		cfg := testShedConfig()
+		cfg.InitialLimit = 2
+		s, _ := testShedder(t, cfg)
+		ss := &fakeServerStream{ctx: context.Background()}
+		err := s.StreamInterceptor()(nil, ss, info("/svc/Call"), func(any, grpc.ServerStream) error {
+			assert.Equal(t, 1, s.inFlight, "slot held until the first response")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Zero(t, s.inFlight, "released when the handler returns without a response")
+	})
+
+	t.Run("drained_calls_are_not_overload", func(t *testing.T) {
+		s, advance := testShedder(t, testShedConfig())
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(ErrGatewayDraining)
+		ss := &fakeServerStream{ctx: ctx}
+		err := s.StreamInterceptor()(nil, ss, info("/svc/Call"), func(_ any, stream grpc.ServerStream) error {
+			advance(time.Millisecond)
+			require.NoError(t, stream.SendMsg(&emptypb.Empty{}))
+			return status.Error(codes.Unavailable, "gateway is shutting down")
+		})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, 10.0, s.limit, "drain status does not shrink the limit")
+		assert.Zero(t, s.baseline, "nor is it a latency sample")
+		assert.Zero(t, s.inFlight)
+
+		ss = &fakeServerStream{ctx: context.Background()}
+		_ = s.StreamInterceptor()(nil, ss, info("/svc/Call"), func(any, grpc.ServerStream) error {
+			return status.Error(codes.Unavailable, "backend gone")
+		})
+		assert.Equal(t, 5.0, s.limit, "other UNAVAILABLE still shrinks it")
+	})
+
+	t.Run("baseline_follows_last_window", func(t *testing.T) {
+		s, advance := testShedder(t, testShedConfig())
+		s.done(10*time.Millisecond, 0, true, false)
+		advance(30 * time.Second)
+		s.done(40*time.Millisecond, 0, true, false)
+		assert.Equal(t, 10*time.Millisecond, s.baseline)
+		advance(31 * time.Second)
+		s.done(30*time.Millisecond, 0, true, false)
+		assert.Equal(t, 10*time.Millisecond, s.baseline, "window closes with the minimum of the window")
+		advance(61 * time.Second)
+		s.done(30*time.Millisecond, 0, true, false)
+		assert.Equal(t, 30*time.Millisecond, s.baseline, "old fast samples age out")
+	})
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mygateway/auth"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// revocationCache implements interfaces.RevocationChecker. It keeps the last snapshot loaded from a RevocationSource
+// (Redis or a file) and refreshes it every refreshInterval in a background loop, so request-time checks never hit the
+// store. When the store cannot be read the previous snapshot is kept. Sessions that appear in a new snapshot are
+// passed to onRevoked (cmd/main drops their sticky bindings in the connection pools).
+// Fields: source, refreshInterval, onRevoked, logger; under mu: current (last loaded snapshot).
+type revocationCache struct {
+	source          interfaces.RevocationSource
+	refreshInterval time.Duration
+	onRevoked       func(sessionIDs []string)
+	logger          log.Logger
+
+	mu      sync.RWMutex
+	current auth.Revocations
+}
+
+// NewRevocationCache creates a revocation checker: loads the first snapshot synchronously, then starts a goroutine that
+// reloads it every refreshInterval. Panics on nil source, onRevoked or logger.
+//
+// Parameters: source — revocation store (adapters.RevocationRedis or adapters.RevocationFile); refreshInterval — reload
+// interval (also the deadline of one load); onRevoked — called outside the lock with session IDs revoked since the
+// previous snapshot; logger — logs load failures.
+//
+// Returns: interfaces.RevocationChecker (*revocationCache).
+//
+// Called from cmd/main when auth.revocation is configured.
+func NewRevocationCache(
+	source interfaces.RevocationSource,
+	refreshInterval time.Duration,
+	onRevoked func(sessionIDs []string),
+	logger log.Logger,
+) interfaces.RevocationChecker {
+	c := &revocationCache{
+		source:          helpers.NilPanic(source, "service.revocation_cache.go: revocation source is required"),
+		refreshInterval: refreshInterval,
+		onRevoked:       helpers.NilPanic(onRevoked, "service.revocation_cache.go: onRevoked is required"),
+		logger:          log.With(helpers.NilPanic(logger, "service.revocation_cache.go: logger is required"), "component", "revocation_cache"),
+	}
+	c.refresh()
+	go c.refreshLoop()
+	return c
+}
+
+// refreshLoop runs refresh every refreshInterval (goroutine lives until process exit, like connectionPool.refreshLoop).
+//
+// Called only from NewRevocationCache in a separate goroutine.
+func (c *revocationCache) refreshLoop() {
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		c.refresh()
+	}
+}
+
+// refresh loads a new snapshot; on error logs and keeps the previous one. On success replaces the snapshot and reports
+// the newly revoked sessions to onRevoked.
+//
+// Called from refreshLoop on timer and once from NewRevocationCache at startup.
+func (c *revocationCache) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.refreshInterval)
+	defer cancel()
+	next, err := c.source.Load(ctx)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "revocation store load failed, keeping previous snapshot", "err", err)
+		return
+	}
+	c.mu.Lock()
+	var added []string
+	for id := range next.Sessions {
+		if _, ok := c.current.Sessions[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	c.current = next
+	c.mu.Unlock()
+	if len(added) > 0 {
+		c.onRevoked(added)
+	}
+}
+
+// IsRevoked checks claims against the cached snapshot (auth.Revocations.Revoked).
+//
+// Parameters: claims — claims of a verified token.
+//
+// Returns: true when the token is revoked.
+//
+// Called from revocationValidator.ValidateToken.
+func (c *revocationCache) IsRevoked(claims auth.TokenClaims) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current.Revoked(claims)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"mygateway/auth"
+	"mygateway/interfaces/mock"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRevocationCache_Panics(t *testing.T) {
+	source := &mock.RevocationSourceMock{}
+	onRevoked := func([]string) {}
+	t.Run("source_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.revocation_cache.go: revocation source is required", func() {
+			NewRevocationCache(nil, time.Minute, onRevoked, log.NewNopLogger())
+		})
+	})
+	t.Run("on_revoked_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.revocation_cache.go: onRevoked is required", func() {
+			NewRevocationCache(source, time.Minute, nil, log.NewNopLogger())
+		})
+	})
+	t.Run("logger_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.revocation_cache.go: logger is required", func() {
+			NewRevocationCache(source, time.Minute, onRevoked, nil)
+		})
+	})
+}
+
+func TestRevocationCache_Refresh(t *testing.T) {
+	snapshot := auth.Revocations{Sessions: map[string]struct{}{"s1": {}}}
+	var loadErr error
+	source := &mock.RevocationSourceMock{LoadFunc: func(context.Context) (auth.Revocations, error) {
+		return snapshot, loadErr
+	}}
+	var released [][]string
+	onRevoked := func(ids []string) {
+		sort.Strings(ids)
+		released = append(released, ids)
+	}
+	c := NewRevocationCache(source, time.Hour, onRevoked, log.NewNopLogger()).(*revocationCache)
+
+	assert.True(t, c.IsRevoked(auth.TokenClaims{SessionID: "s1"}))
+	assert.False(t, c.IsRevoked(auth.TokenClaims{SessionID: "s2"}))
+	assert.Equal(t, [][]string{{"s1"}}, released)
+
+	snapshot = auth.Revocations{Sessions: map[string]struct{}{"s1": {}, "s2": {}, "s3": {}}}
+	c.refresh()
+	assert.True(t, c.IsRevoked(auth.TokenClaims{SessionID: "s2"}))
+	assert.Equal(t, [][]string{{"s1"}, {"s2", "s3"}}, released, "only newly revoked sessions are released")
+
+	loadErr = errors.New("redis down")
+	snapshot = auth.Revocations{}
+	c.refresh()
+	assert.True(t, c.IsRevoked(auth.TokenClaims{SessionID: "s3"}), "previous snapshot is kept on load error")
+	require.Len(t, released, 2)
+
+	loadErr = nil
+	c.refresh()
+	assert.False(t, c.IsRevoked(auth.TokenClaims{SessionID: "s3"}), "un-revoked session")
+	require.Len(t, released, 2)
+}
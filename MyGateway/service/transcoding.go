@@ -0,0 +1,626 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mygateway/helpers"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	jsonContentType   = "application/json"
+	ndjsonContentType = "application/x-ndjson"
+
+	// httpMetadataHeaderPrefix / httpTrailerHeaderPrefix prefix response header and trailer metadata in HTTP headers, so
+	// backend metadata cannot overwrite HTTP headers such as Content-Type.
+	httpMetadataHeaderPrefix = "Grpc-Metadata-"
+	httpTrailerHeaderPrefix  = "Grpc-Trailer-"
+)
+
+// HTTPTranscoder is an http.Handler that exposes gRPC methods annotated with google.api.http as HTTP/JSON endpoints:
+// the request path and HTTP method select an HTTPRule, path variables, query parameters and the JSON body are mapped to
+// the request message (described by the loaded FileDescriptorSet), which is sent as protobuf through the same stream
+// handler as native gRPC (TransparentProxy.Handler behind the gateway interceptors — route matching on the gRPC method,
+// auth and sticky sessions from HTTP headers). Unary responses are returned as JSON; server-streaming responses as
+// newline-delimited JSON ({"result": ...} per message, {"error": ...} when the stream fails after the first message).
+// Fields: rules (matched in order, rules with a verb first), types (resolver for Any), handler, maxMessage (largest
+// protobuf request, as server.max_recv_msg_size_bytes for native gRPC), maxBody (JSON body limit: 4/3 of maxMessage,
+// the size of a bytes field in base64), logger.
+type HTTPTranscoder struct {
+	rules      []HTTPRule
+	types      *dynamicpb.Types
+	handler    grpc.StreamHandler
+	maxMessage int
+	maxBody    int64
+	logger     log.Logger
+}
+
+// NewHTTPTranscoder creates the HTTP/JSON frontend. Panics on empty rules, nil handler, non-positive maxMessage, nil
+// interceptor or nil logger.
+//
+// Parameters: rules — result of ParseHTTPRules; handler — stream handler for every method (TransparentProxy.Handler);
+// maxMessage — largest protobuf request in bytes (server.max_recv_msg_size_bytes, so HTTP/JSON accepts what native gRPC
+// does); logger — logs failed writes to the client; interceptors — stream interceptors applied in order (cmd/main
+// passes GatewayErrorToGRPCStreamInterceptor so errors map to the same codes as for native gRPC).
+//
+// Returns: *HTTPTranscoder (implements http.Handler).
+//
+// Called from cmd/main when http_json.enabled is set.
+func NewHTTPTranscoder(rules HTTPRules, handler grpc.StreamHandler, maxMessage int, logger log.Logger, interceptors ...grpc.StreamServerInterceptor) *HTTPTranscoder {
+	if len(rules.Rules) == 0 || rules.Types == nil {
+		panic("service.transcoding.go: HTTP rules are required")
+	}
+	handler = helpers.NilPanic(handler, "service.transcoding.go: handler is required")
+	if maxMessage <= 0 {
+		panic("service.transcoding.go: max message size must be positive")
+	}
+	for _, interceptor := range interceptors {
+		helpers.NilPanic(interceptor, "service.transcoding.go: interceptor is required")
+	}
+	// Templates with a verb go first, so "/v1/{name}:watch" wins over "/v1/{name}" (whose variable would take "x:watch").
+	ordered := slices.Clone(rules.Rules)
+	slices.SortStableFunc(ordered, func(a, b HTTPRule) int {
+		return cmp.Compare(boolRank(b.Template.verb != ""), boolRank(a.Template.verb != ""))
+	})
+	return &HTTPTranscoder{
+		rules:      ordered,
+		types:      rules.Types,
+		handler:    chainStreamHandler(handler, interceptors),
+		maxMessage: maxMessage,
+		maxBody:    int64(base64.StdEncoding.EncodedLen(maxMessage)),
+		logger:     log.With(helpers.NilPanic(logger, "service.transcoding.go: logger is required"), "component", "http_json"),
+	}
+}
+
+// boolRank orders true after false.
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ServeHTTP handles one HTTP/JSON call.
+//
+// Parameters: w — response writer (must support flushing for server streaming); r — request matching one of the rules.
+//
+// Responses: no rule for the path — 404; path matches but not the HTTP method — 405; invalid JSON, path or query value —
+// 400; handler error — HTTP status mapped from the gRPC code with a google.rpc.Status JSON body; otherwise 200 with the
+// response JSON (unary) or NDJSON stream (server streaming). Header metadata is returned as Grpc-Metadata-* headers,
+// trailer metadata of unary calls as Grpc-Trailer-* headers.
+//
+// Called by net/http for requests on the HTTP/JSON listener.
+func (h *HTTPTranscoder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rule, vars, err := h.match(r)
+	if err != nil {
+		writeHTTPError(w, h.types, metadata.MD{}, err)
+		return
+	}
+	stream := &httpJSONStream{
+		w:         w,
+		rule:      rule,
+		types:     h.types,
+		streaming: rule.Method.IsStreamingServer(),
+		header:    metadata.MD{},
+		trailer:   metadata.MD{},
+		logger:    h.logger,
+	}
+	request, err := h.decodeRequest(w, r, rule, vars)
+	if err != nil {
+		stream.finish(err)
+		return
+	}
+	stream.request = request
+
+	ctx, cancel, err := httpCallContext(r)
+	if err != nil {
+		stream.finish(err)
+		return
+	}
+	defer cancel()
+	stream.ctx = grpc.NewContextWithServerTransportStream(ctx, &httpTransportStream{method: rule.FullMethod, stream: stream})
+	stream.finish(h.handler(nil, stream))
+}
+
+// match finds the first rule whose template matches the request path and whose verb is the request method.
+//
+// Returns: (rule, path variables, nil); (nil, nil, status error) — NotFound when no template matches, Unimplemented
+// (HTTP 405) when a template matches with another method.
+//
+// Called only from ServeHTTP.
+func (h *HTTPTranscoder) match(r *http.Request) (*HTTPRule, map[string]string, error) {
+	pathMatched := false
+	for i := range h.rules {
+		vars, ok := h.rules[i].Template.Match(r.URL.EscapedPath())
+		if !ok {
+			continue
+		}
+		if h.rules[i].Verb != r.Method {
+			pathMatched = true
+			continue
+		}
+		return &h.rules[i], vars, nil
+	}
+	if pathMatched {
+		return nil, nil, errMethodNotAllowed
+	}
+	return nil, nil, status.Errorf(codes.NotFound, "no HTTP rule for %s %s", r.Method, r.URL.Path)
+}
+
+// errMethodNotAllowed is returned by match when the path is known but not for the request method (written as HTTP 405).
+var errMethodNotAllowed = status.Error(codes.Unimplemented, "HTTP method not allowed for this path")
+
+// decodeRequest builds the protobuf request: the JSON body (per rule.Body), then query parameters (fields not bound by
+// the path or body; unknown names are ignored), then path variables.
+//
+// Returns: (serialized request, nil); (nil, status error) — InvalidArgument on invalid JSON or parameter value,
+// ResourceExhausted when the body or the protobuf request is larger than allowed, Internal on read or marshal failure.
+//
+// Called only from ServeHTTP.
+func (h *HTTPTranscoder) decodeRequest(w http.ResponseWriter, r *http.Request, rule *HTTPRule, vars map[string]string) ([]byte, error) {
+	req := dynamicpb.NewMessage(rule.Method.Input())
+	unmarshal := protojson.UnmarshalOptions{Resolver: h.types}
+	if rule.Body != "" {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, status.Errorf(codes.ResourceExhausted, "request body larger than max (%d bytes)", tooLarge.Limit)
+			}
+			return nil, status.Errorf(codes.Internal, "read request body: %v", err)
+		}
+		if len(strings.TrimSpace(string(body))) > 0 {
+			if err := decodeRequestBody(unmarshal, req, rule.Body, body); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+			}
+		}
+	}
+	if rule.Body != "*" {
+		for key, values := range r.URL.Query() {
+			path := strings.Split(key, ".")
+			if _, bound := vars[key]; bound || (rule.Body != "" && path[0] == rule.Body) {
+				continue
+			}
+			if _, err := lookupFieldPath(req.Descriptor(), path); err != nil {
+				continue
+			}
+			if err := setFieldPath(req, path, values, h.types); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "query parameter %q: %v", key, err)
+			}
+		}
+	}
+	for key, value := range vars {
+		if err := setFieldPath(req, strings.Split(key, "."), []string{value}, h.types); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "path parameter %q: %v", key, err)
+		}
+	}
+	payload, err := proto.Marshal(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshal request: %v", err)
+	}
+	if len(payload) > h.maxMessage {
+		return nil, status.Errorf(codes.ResourceExhausted, "request larger than max (%d vs. %d)", len(payload), h.maxMessage)
+	}
+	return payload, nil
+}
+
+// decodeRequestBody unmarshals the JSON body into the whole request (bodyField "*") or into one top-level field.
+//
+// Called only from decodeRequest.
+func decodeRequestBody(unmarshal protojson.UnmarshalOptions, req *dynamicpb.Message, bodyField string, body []byte) error {
+	if bodyField == "*" {
+		return unmarshal.Unmarshal(body, req)
+	}
+	fd := req.Descriptor().Fields().ByName(protoreflect.Name(bodyField))
+	wrapped, err := json.Marshal(map[string]json.RawMessage{fd.JSONName(): body})
+	if err != nil {
+		return err
+	}
+	tmp := dynamicpb.NewMessage(req.Descriptor())
+	if err := unmarshal.Unmarshal(wrapped, tmp); err != nil {
+		return err
+	}
+	req.Set(fd, tmp.Get(fd))
+	return nil
+}
+
+// setFieldPath sets a (possibly nested) field from string values: a repeated field gets all values, a singular one the last.
+//
+// Returns: nil; error when the path is unknown, a map field, or a value cannot be parsed for the field kind.
+//
+// Called from decodeRequest for query parameters and path variables.
+func setFieldPath(msg protoreflect.Message, path []string, values []string, types *dynamicpb.Types) error {
+	fd, err := lookupFieldPath(msg.Descriptor(), path)
+	if err != nil {
+		return err
+	}
+	for _, name := range path[:len(path)-1] {
+		parent := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if parent == nil {
+			parent = msg.Descriptor().Fields().ByJSONName(name)
+		}
+		msg = msg.Mutable(parent).Message()
+	}
+	if fd.IsMap() {
+		return fmt.Errorf("map fields cannot be set from parameters")
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if fd.IsList() {
+		list := msg.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseFieldValue(fd, s, types)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+	v, err := parseFieldValue(fd, values[len(values)-1], types)
+	if err != nil {
+		return err
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+// parseFieldValue converts a path or query string to a field value: numbers and bools as in Go, bytes as (URL-safe)
+// base64, enums by name or number, well-known message types (Timestamp, Duration, FieldMask, wrappers) via their JSON form.
+//
+// Called only from setFieldPath.
+func parseFieldValue(fd protoreflect.FieldDescriptor, s string, types *dynamicpb.Types) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			b, err = base64.URLEncoding.DecodeString(s)
+		}
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown %s value %q", fd.Enum().FullName(), s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if !isWellKnownScalar(fd.Message()) {
+			return protoreflect.Value{}, fmt.Errorf("message field %s cannot be set from a parameter", fd.FullName())
+		}
+		m := dynamicpb.NewMessage(fd.Message())
+		quoted, _ := json.Marshal(s)
+		unmarshal := protojson.UnmarshalOptions{Resolver: types}
+		if err := unmarshal.Unmarshal(quoted, m); err != nil {
+			if err2 := unmarshal.Unmarshal([]byte(s), m); err2 != nil {
+				return protoreflect.Value{}, err
+			}
+		}
+		return protoreflect.ValueOfMessage(m), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+}
+
+// httpStatusFromCode maps a gRPC code to the HTTP status of an HTTP/JSON error response (as in google.rpc.Code docs).
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// statusJSON encodes a status as google.rpc.Status JSON; details whose types are not in the descriptor set are dropped.
+func statusJSON(st *status.Status, types *dynamicpb.Types) []byte {
+	b, err := protojson.MarshalOptions{Resolver: types}.Marshal(st.Proto())
+	if err != nil {
+		b, _ = protojson.Marshal(status.New(st.Code(), st.Message()).Proto())
+	}
+	return b
+}
+
+// writeHTTPError writes a complete error response: header metadata as Grpc-Metadata-*, HTTP status from the gRPC code
+// (405 for errMethodNotAllowed) and the google.rpc.Status JSON body.
+//
+// Called from ServeHTTP when no rule matches and from httpJSONStream.finish.
+func writeHTTPError(w http.ResponseWriter, types *dynamicpb.Types, header metadata.MD, err error) {
+	st := status.Convert(err)
+	code := httpStatusFromCode(st.Code())
+	if errors.Is(err, errMethodNotAllowed) {
+		code = http.StatusMethodNotAllowed
+	}
+	setMetadataHeaders(w.Header(), httpMetadataHeaderPrefix, header)
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(code)
+	_, _ = w.Write(statusJSON(st, types))
+}
+
+// setMetadataHeaders copies metadata into HTTP headers under prefix (-bin values base64-encoded).
+func setMetadataHeaders(hdr http.Header, prefix string, md metadata.MD) {
+	for k, vs := range md {
+		for _, v := range vs {
+			if strings.HasSuffix(k, "-bin") {
+				v = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			hdr.Add(prefix+k, v)
+		}
+	}
+}
+
+// httpJSONStream implements grpc.ServerStream over one HTTP/JSON exchange. The single request message is decoded up
+// front; a unary response is kept until finish (so the HTTP status reflects the final gRPC status), server-streamed
+// responses are written as NDJSON lines and flushed per message. Under mu: header, trailer, pending, headerSent, finished.
+type httpJSONStream struct {
+	ctx       context.Context
+	w         http.ResponseWriter
+	rule      *HTTPRule
+	types     *dynamicpb.Types
+	streaming bool
+	request   []byte
+	received  bool
+	logger    log.Logger
+
+	mu         sync.Mutex
+	header     metadata.MD
+	trailer    metadata.MD
+	pending    []byte
+	headerSent bool
+	finished   bool
+}
+
+// SetHeader merges md into the response header metadata.
+func (s *httpJSONStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.headerSent {
+		return status.Error(codes.Internal, "headers already sent")
+	}
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+// SendHeader merges md; headers are written with the first streamed message or the final response.
+func (s *httpJSONStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+// SetTrailer merges md into the trailer metadata (returned as Grpc-Trailer-* headers for unary calls).
+func (s *httpJSONStream) SetTrailer(md metadata.MD) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+// Context returns the request context (incoming metadata, peer, deadline, server transport stream with the method).
+func (s *httpJSONStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg converts a response to JSON (response_body field only, when set): unary — kept for finish; server streaming —
+// written as {"result": ...} line and flushed.
+//
+// Returns: nil; status error when m is not a proto message, does not decode as the output type, a unary method sends a
+// second response, or the call already finished; write error when the client went away.
+func (s *httpJSONStream) SendMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "HTTP/JSON: unsupported message type %T", m)
+	}
+	body, err := s.responseJSON(msg)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return status.Error(codes.Internal, "HTTP/JSON: stream already finished")
+	}
+	if !s.streaming {
+		if s.pending != nil {
+			return status.Error(codes.Internal, "HTTP/JSON: unary method sent more than one response")
+		}
+		s.pending = body
+		return nil
+	}
+	s.writeHeaderLocked(http.StatusOK, ndjsonContentType, nil)
+	line := append(append([]byte(`{"result":`), body...), '}', '\n')
+	if _, err := s.w.Write(line); err != nil {
+		return status.Errorf(codes.Canceled, "HTTP/JSON: write response: %v", err)
+	}
+	s.flushLocked()
+	return nil
+}
+
+// responseJSON decodes the forwarded response bytes as the method's output type and marshals it (or its response_body field) to JSON.
+func (s *httpJSONStream) responseJSON(msg proto.Message) ([]byte, error) {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "HTTP/JSON: marshal response: %v", err)
+	}
+	out := dynamicpb.NewMessage(s.rule.Method.Output())
+	if err := (proto.UnmarshalOptions{Resolver: s.types}).Unmarshal(payload, out); err != nil {
+		return nil, status.Errorf(codes.Internal, "HTTP/JSON: decode response as %s: %v", s.rule.Method.Output().FullName(), err)
+	}
+	marshal := protojson.MarshalOptions{Resolver: s.types}
+	if s.rule.ResponseBody == "" {
+		return marshalJSON(marshal, out)
+	}
+	fd := out.Descriptor().Fields().ByName(protoreflect.Name(s.rule.ResponseBody))
+	if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+		return marshalJSON(marshal, out.Get(fd).Message().Interface())
+	}
+	tmp := dynamicpb.NewMessage(out.Descriptor())
+	tmp.Set(fd, out.Get(fd))
+	marshal.EmitUnpopulated = true
+	full, err := marshalJSON(marshal, tmp)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(full, &fields); err != nil {
+		return nil, status.Errorf(codes.Internal, "HTTP/JSON: select response_body: %v", err)
+	}
+	return fields[fd.JSONName()], nil
+}
+
+// marshalJSON marshals m with opts, mapping failures to Internal.
+func marshalJSON(opts protojson.MarshalOptions, m proto.Message) ([]byte, error) {
+	b, err := opts.Marshal(m)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "HTTP/JSON: encode response: %v", err)
+	}
+	return b, nil
+}
+
+// RecvMsg unmarshals the decoded request into m on the first call (unknown fields are kept, so emptypb.Empty forwards the raw bytes).
+//
+// Returns: nil; io.EOF after the request; context error when the call was cancelled; status error on unmarshal failure.
+func (s *httpJSONStream) RecvMsg(m any) error {
+	if err := s.ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	if s.received {
+		return io.EOF
+	}
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "HTTP/JSON: unsupported message type %T", m)
+	}
+	s.received = true
+	if err := proto.Unmarshal(s.request, msg); err != nil {
+		return status.Errorf(codes.Internal, "HTTP/JSON: unmarshal request: %v", err)
+	}
+	return nil
+}
+
+// finish writes the outcome: a started NDJSON stream gets an {"error": ...} line on failure; otherwise an error response
+// (HTTP status from the gRPC code), the unary JSON response, or an empty 200 for a stream without messages. Later SendMsg calls fail.
+//
+// Parameter err — handler result (nil — OK).
+//
+// Called from HTTPTranscoder.ServeHTTP once the handler returned (or the request could not be decoded).
+func (s *httpJSONStream) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.finished = true
+	if s.headerSent {
+		if err != nil {
+			line := append(append([]byte(`{"error":`), statusJSON(status.Convert(err), s.types)...), '}', '\n')
+			if _, werr := s.w.Write(line); werr != nil {
+				level.Debug(s.logger).Log("msg", "write HTTP/JSON stream error", "err", werr)
+				return
+			}
+		}
+		s.flushLocked()
+		return
+	}
+	if err != nil {
+		s.headerSent = true
+		setMetadataHeaders(s.w.Header(), httpTrailerHeaderPrefix, s.trailer)
+		writeHTTPError(s.w, s.types, s.header, err)
+		return
+	}
+	if s.streaming {
+		s.writeHeaderLocked(http.StatusOK, ndjsonContentType, s.trailer)
+		return
+	}
+	body := s.pending
+	if body == nil {
+		body = []byte("{}")
+	}
+	s.writeHeaderLocked(http.StatusOK, jsonContentType, s.trailer)
+	if _, werr := s.w.Write(body); werr != nil {
+		level.Debug(s.logger).Log("msg", "write HTTP/JSON response", "err", werr)
+	}
+}
+
+// writeHeaderLocked writes the HTTP status and headers once: content type, header metadata and trailer (final
+// responses only). Caller must hold mu.
+func (s *httpJSONStream) writeHeaderLocked(code int, contentType string, trailer metadata.MD) {
+	if s.headerSent {
+		return
+	}
+	s.headerSent = true
+	hdr := s.w.Header()
+	hdr.Set("Content-Type", contentType)
+	setMetadataHeaders(hdr, httpMetadataHeaderPrefix, s.header)
+	setMetadataHeaders(hdr, httpTrailerHeaderPrefix, trailer)
+	s.w.WriteHeader(code)
+}
+
+// flushLocked flushes buffered output to the client when the writer supports it. Caller must hold mu.
+func (s *httpJSONStream) flushLocked() {
+	if err := http.NewResponseController(s.w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		level.Debug(s.logger).Log("msg", "flush HTTP/JSON response", "err", err)
+	}
+}
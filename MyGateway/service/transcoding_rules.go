@@ -0,0 +1,395 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// HTTPRules is the set of HTTP/JSON bindings loaded from a FileDescriptorSet: one HTTPRule per google.api.http
+// annotation (including additional_bindings), in file order, plus the type registry used to encode Any fields.
+type HTTPRules struct {
+	Rules []HTTPRule
+	Types *dynamicpb.Types
+}
+
+// HTTPRule binds an HTTP method and path template to a gRPC method: Verb (GET, POST, ... or a custom kind), Template,
+// Method (descriptor), FullMethod ("/pkg.Service/Method" — the name routes are matched against), Body ("" — no body,
+// "*" — whole request, otherwise a top-level field) and ResponseBody ("" — whole response, otherwise a top-level field).
+type HTTPRule struct {
+	Verb         string
+	Template     PathTemplate
+	Method       protoreflect.MethodDescriptor
+	FullMethod   string
+	Body         string
+	ResponseBody string
+}
+
+// PathTemplate is a compiled google.api.http path template: "/" Segments [":" Verb], where a segment is a literal, "*",
+// "**" (rest of the path, last only) or a variable "{field.path}" / "{field.path=Segments}".
+type PathTemplate struct {
+	raw       string
+	segments  []templateSegment
+	variables []templateVariable
+	verb      string
+}
+
+// templateSegment is one path segment: a literal (wildcard false) or "*"/"**" (wildcard true, multi for "**").
+type templateSegment struct {
+	literal  string
+	wildcard bool
+	multi    bool
+}
+
+// templateVariable binds segments [start, end) to a request field path; end is -1 when the variable ends with "**".
+type templateVariable struct {
+	fieldPath []string
+	start     int
+	end       int
+}
+
+// ParseHTTPRules decodes a FileDescriptorSet (protoc --include_imports --descriptor_set_out) and collects the
+// google.api.http rules of all its methods, checking that path variables and body fields exist in the request message.
+//
+// Parameter data — serialized google.protobuf.FileDescriptorSet.
+//
+// Returns: (rules, nil); (zero, error) on decode error, unresolved imports, an invalid template or field, a
+// client-streaming method with an HTTP rule, or when no method has a rule.
+//
+// Called from cmd.LoadConfig to validate http_json.descriptor_set and from cmd/main to build the HTTPTranscoder.
+func ParseHTTPRules(data []byte) (HTTPRules, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return HTTPRules{}, fmt.Errorf("unmarshal descriptor set: %w", err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return HTTPRules{}, fmt.Errorf("build descriptors (was the set built with --include_imports?): %w", err)
+	}
+	var out HTTPRules
+	var rangeErr error
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				rules, err := methodHTTPRules(methods.Get(j))
+				if err != nil {
+					rangeErr = err
+					return false
+				}
+				out.Rules = append(out.Rules, rules...)
+			}
+		}
+		return true
+	})
+	if rangeErr != nil {
+		return HTTPRules{}, rangeErr
+	}
+	if len(out.Rules) == 0 {
+		return HTTPRules{}, fmt.Errorf("descriptor set has no methods with google.api.http rules")
+	}
+	out.Types = dynamicpb.NewTypes(files)
+	return out, nil
+}
+
+// methodHTTPRules returns the rules of one method: the google.api.http option and its additional_bindings.
+//
+// Returns: (nil, nil) when the method has no option; (nil, error) on an invalid binding.
+//
+// Called only from ParseHTTPRules.
+func methodHTTPRules(md protoreflect.MethodDescriptor) ([]HTTPRule, error) {
+	opts, ok := md.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil || !proto.HasExtension(opts, annotations.E_Http) {
+		return nil, nil
+	}
+	httpRule, ok := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+	if !ok || httpRule == nil {
+		return nil, nil
+	}
+	fullMethod := "/" + string(md.Parent().FullName()) + "/" + string(md.Name())
+	if md.IsStreamingClient() {
+		return nil, fmt.Errorf("%s: client streaming methods cannot have google.api.http rules", fullMethod)
+	}
+	bindings := append([]*annotations.HttpRule{httpRule}, httpRule.GetAdditionalBindings()...)
+	out := make([]HTTPRule, 0, len(bindings))
+	for _, b := range bindings {
+		rule, err := newHTTPRule(md, fullMethod, b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fullMethod, err)
+		}
+		out = append(out, rule)
+	}
+	return out, nil
+}
+
+// newHTTPRule validates one binding against the method's request and response messages.
+//
+// Called only from methodHTTPRules.
+func newHTTPRule(md protoreflect.MethodDescriptor, fullMethod string, b *annotations.HttpRule) (HTTPRule, error) {
+	var verb, path string
+	switch p := b.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		verb, path = "GET", p.Get
+	case *annotations.HttpRule_Put:
+		verb, path = "PUT", p.Put
+	case *annotations.HttpRule_Post:
+		verb, path = "POST", p.Post
+	case *annotations.HttpRule_Delete:
+		verb, path = "DELETE", p.Delete
+	case *annotations.HttpRule_Patch:
+		verb, path = "PATCH", p.Patch
+	case *annotations.HttpRule_Custom:
+		verb, path = strings.ToUpper(p.Custom.GetKind()), p.Custom.GetPath()
+	default:
+		return HTTPRule{}, fmt.Errorf("http rule has no pattern")
+	}
+	if verb == "" {
+		return HTTPRule{}, fmt.Errorf("custom http rule has no kind")
+	}
+	tmpl, err := ParsePathTemplate(path)
+	if err != nil {
+		return HTTPRule{}, err
+	}
+	for _, v := range tmpl.variables {
+		fd, err := lookupFieldPath(md.Input(), v.fieldPath)
+		if err != nil {
+			return HTTPRule{}, fmt.Errorf("path %q: %w", path, err)
+		}
+		if fd.IsList() || fd.IsMap() || (fd.Message() != nil && !isWellKnownScalar(fd.Message())) {
+			return HTTPRule{}, fmt.Errorf("path %q: field %s must be a scalar", path, strings.Join(v.fieldPath, "."))
+		}
+	}
+	if body := b.GetBody(); body != "" && body != "*" {
+		if md.Input().Fields().ByName(protoreflect.Name(body)) == nil {
+			return HTTPRule{}, fmt.Errorf("body field %q not found in %s", body, md.Input().FullName())
+		}
+	}
+	if rb := b.GetResponseBody(); rb != "" && md.Output().Fields().ByName(protoreflect.Name(rb)) == nil {
+		return HTTPRule{}, fmt.Errorf("response_body field %q not found in %s", rb, md.Output().FullName())
+	}
+	return HTTPRule{Verb: verb, Template: tmpl, Method: md, FullMethod: fullMethod, Body: b.GetBody(), ResponseBody: b.GetResponseBody()}, nil
+}
+
+// lookupFieldPath resolves a dotted field path ("a.b.c") in msg; every element but the last must be a singular message.
+//
+// Returns: (leaf field, nil); (nil, error) when an element is missing or not a message.
+//
+// Called from newHTTPRule and from HTTPTranscoder when setting path and query parameters.
+func lookupFieldPath(msg protoreflect.MessageDescriptor, path []string) (protoreflect.FieldDescriptor, error) {
+	var fd protoreflect.FieldDescriptor
+	for i, name := range path {
+		if msg == nil {
+			return nil, fmt.Errorf("field %s is not a message", strings.Join(path[:i], "."))
+		}
+		fd = msg.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = msg.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, fmt.Errorf("field %q not found in %s", name, msg.FullName())
+		}
+		msg = nil
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+			msg = fd.Message()
+		}
+	}
+	if fd == nil {
+		return nil, fmt.Errorf("empty field path")
+	}
+	return fd, nil
+}
+
+// isWellKnownScalar reports whether a message type has a JSON string/number form usable in a path or query parameter
+// (wrappers, Timestamp, Duration, FieldMask).
+func isWellKnownScalar(md protoreflect.MessageDescriptor) bool {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask",
+		"google.protobuf.StringValue", "google.protobuf.BytesValue", "google.protobuf.BoolValue",
+		"google.protobuf.Int32Value", "google.protobuf.Int64Value", "google.protobuf.UInt32Value",
+		"google.protobuf.UInt64Value", "google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return true
+	}
+	return false
+}
+
+// ParsePathTemplate compiles a google.api.http path template.
+//
+// Parameter path — template, e.g. "/v1/{name=shelves/*/books/*}:publish".
+//
+// Returns: (template, nil); (zero, error) when the path does not start with "/", has an empty segment, unbalanced or
+// nested braces, a duplicate variable, or "**" that is not the last segment.
+//
+// Called from newHTTPRule.
+func ParsePathTemplate(path string) (PathTemplate, error) {
+	tmpl := PathTemplate{raw: path}
+	if !strings.HasPrefix(path, "/") {
+		return PathTemplate{}, fmt.Errorf("path template %q must start with /", path)
+	}
+	rest := path[1:]
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndexAny(rest, "/}") {
+		tmpl.verb = rest[i+1:]
+		rest = rest[:i]
+		if tmpl.verb == "" {
+			return PathTemplate{}, fmt.Errorf("path template %q has an empty verb", path)
+		}
+	}
+	tokens, err := splitTemplate(path, rest)
+	if err != nil {
+		return PathTemplate{}, err
+	}
+	seen := map[string]bool{}
+	for _, tok := range tokens {
+		if !strings.HasPrefix(tok, "{") {
+			if strings.ContainsAny(tok, "{}") {
+				return PathTemplate{}, fmt.Errorf("path template %q has a variable inside a segment", path)
+			}
+			s, err := parseTemplateSegment(path, tok)
+			if err != nil {
+				return PathTemplate{}, err
+			}
+			tmpl.segments = append(tmpl.segments, s)
+			continue
+		}
+		if !strings.HasSuffix(tok, "}") {
+			return PathTemplate{}, fmt.Errorf("path template %q: variable must be a whole segment", path)
+		}
+		field, pattern, hasPattern := strings.Cut(tok[1:len(tok)-1], "=")
+		if !hasPattern {
+			pattern = "*"
+		}
+		if field == "" || seen[field] {
+			return PathTemplate{}, fmt.Errorf("path template %q has an empty or duplicate variable %q", path, field)
+		}
+		seen[field] = true
+		v := templateVariable{fieldPath: strings.Split(field, "."), start: len(tmpl.segments)}
+		for _, seg := range strings.Split(pattern, "/") {
+			s, err := parseTemplateSegment(path, seg)
+			if err != nil {
+				return PathTemplate{}, err
+			}
+			tmpl.segments = append(tmpl.segments, s)
+		}
+		v.end = len(tmpl.segments)
+		tmpl.variables = append(tmpl.variables, v)
+	}
+	for i, s := range tmpl.segments {
+		if s.multi && i != len(tmpl.segments)-1 {
+			return PathTemplate{}, fmt.Errorf("path template %q: ** must be the last segment", path)
+		}
+	}
+	for i := range tmpl.variables {
+		if tmpl.segments[tmpl.variables[i].end-1].multi {
+			tmpl.variables[i].end = -1
+		}
+	}
+	return tmpl, nil
+}
+
+// splitTemplate splits the template (without the leading "/" and the verb) at "/" outside variables.
+//
+// Returns: (tokens, nil); (nil, error) on unbalanced or nested braces.
+func splitTemplate(path, rest string) ([]string, error) {
+	var tokens []string
+	depth, start := 0, 0
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '{':
+			if depth++; depth > 1 {
+				return nil, fmt.Errorf("path template %q has nested variables", path)
+			}
+		case '}':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("path template %q has an unbalanced }", path)
+			}
+		case '/':
+			if depth == 0 {
+				tokens = append(tokens, rest[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("path template %q has an unclosed variable", path)
+	}
+	return append(tokens, rest[start:]), nil
+}
+
+// parseTemplateSegment parses one literal, "*" or "**" segment.
+func parseTemplateSegment(path, seg string) (templateSegment, error) {
+	switch seg {
+	case "":
+		return templateSegment{}, fmt.Errorf("path template %q has an empty segment", path)
+	case "*":
+		return templateSegment{wildcard: true}, nil
+	case "**":
+		return templateSegment{wildcard: true, multi: true}, nil
+	}
+	return templateSegment{literal: seg}, nil
+}
+
+// String returns the template as written in the annotation.
+func (t PathTemplate) String() string {
+	return t.raw
+}
+
+// Match matches a request path (as received, percent-encoded) against the template.
+//
+// Returns: (variable values by dotted field path, true) on match — single-segment values are fully unescaped,
+// multi-segment values keep "/" between unescaped segments; (nil, false) otherwise.
+//
+// Called from HTTPTranscoder.ServeHTTP for every rule until one matches.
+func (t PathTemplate) Match(escapedPath string) (map[string]string, bool) {
+	if !strings.HasPrefix(escapedPath, "/") {
+		return nil, false
+	}
+	rest := escapedPath[1:]
+	if t.verb != "" {
+		if !strings.HasSuffix(rest, ":"+t.verb) {
+			return nil, false
+		}
+		rest = strings.TrimSuffix(rest, ":"+t.verb)
+	}
+	parts := strings.Split(rest, "/")
+	n := len(t.segments)
+	multi := n > 0 && t.segments[n-1].multi
+	if len(parts) < n || (!multi && len(parts) != n) {
+		return nil, false
+	}
+	for i, s := range t.segments {
+		if s.multi {
+			break
+		}
+		if parts[i] == "" || (!s.wildcard && parts[i] != s.literal) {
+			return nil, false
+		}
+	}
+	values := make(map[string]string, len(t.variables))
+	for _, v := range t.variables {
+		end := v.end
+		if end < 0 {
+			end = len(parts)
+		}
+		seg := parts[v.start:end]
+		decoded := make([]string, len(seg))
+		for i, p := range seg {
+			u, err := url.PathUnescape(p)
+			if err != nil {
+				return nil, false
+			}
+			if len(seg) > 1 && strings.Contains(u, "/") {
+				u = p
+			}
+			decoded[i] = u
+		}
+		values[strings.Join(v.fieldPath, ".")] = strings.Join(decoded, "/")
+	}
+	return values, true
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testEchoMethod describes one method of the test.echo.Echo service and its optional HTTP rule.
+type testEchoMethod struct {
+	name            string
+	rule            *annotations.HttpRule
+	clientStreaming bool
+	serverStreaming bool
+}
+
+// testDescriptorSet builds a serialized FileDescriptorSet with package test.echo: messages Inner{note}, EchoRequest{name,
+// count, inner, tags, kind, at, id} (used as input and output of every method) and the Echo service with methods.
+func testDescriptorSet(t *testing.T, methods ...testEchoMethod) []byte {
+	t.Helper()
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(num), Type: typ.Enum(), Label: label.Enum(), JsonName: proto.String(name)}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	opt := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/echo.proto"),
+		Package:    proto.String("test.echo"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/api/annotations.proto", "google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Kind"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("KIND_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("KIND_A"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Inner"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("note", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt, ""),
+			}},
+			{Name: proto.String("EchoRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt, ""),
+				field("count", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, opt, ""),
+				field("inner", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, opt, ".test.echo.Inner"),
+				field("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED, ""),
+				field("kind", 5, descriptorpb.FieldDescriptorProto_TYPE_ENUM, opt, ".test.echo.Kind"),
+				field("at", 6, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, opt, ".google.protobuf.Timestamp"),
+				field("id", 7, descriptorpb.FieldDescriptorProto_TYPE_INT64, opt, ""),
+			}},
+		},
+	}
+	svc := &descriptorpb.ServiceDescriptorProto{Name: proto.String("Echo")}
+	for _, m := range methods {
+		md := &descriptorpb.MethodDescriptorProto{
+			Name:            proto.String(m.name),
+			InputType:       proto.String(".test.echo.EchoRequest"),
+			OutputType:      proto.String(".test.echo.EchoRequest"),
+			ClientStreaming: proto.Bool(m.clientStreaming),
+			ServerStreaming: proto.Bool(m.serverStreaming),
+		}
+		if m.rule != nil {
+			md.Options = &descriptorpb.MethodOptions{}
+			proto.SetExtension(md.Options, annotations.E_Http, m.rule)
+		}
+		svc.Method = append(svc.Method, md)
+	}
+	file.Service = []*descriptorpb.ServiceDescriptorProto{svc}
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+		protodesc.ToFileDescriptorProto(annotations.File_google_api_http_proto),
+		protodesc.ToFileDescriptorProto(annotations.File_google_api_annotations_proto),
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		file,
+	}}
+	data, err := proto.Marshal(set)
+	require.NoError(t, err)
+	return data
+}
+
+func getRule(path string) *annotations.HttpRule {
+	return &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: path}}
+}
+
+func TestParseHTTPRules(t *testing.T) {
+	t.Run("collects_rules_and_additional_bindings", func(t *testing.T) {
+		rule := getRule("/v1/echo/{name}")
+		rule.AdditionalBindings = []*annotations.HttpRule{{Pattern: &annotations.HttpRule_Post{Post: "/v1/echo"}, Body: "*"}}
+		rules, err := ParseHTTPRules(testDescriptorSet(t,
+			testEchoMethod{name: "Get", rule: rule},
+			testEchoMethod{name: "Plain"},
+			testEchoMethod{name: "Watch", rule: getRule("/v1/echo/{name}:watch"), serverStreaming: true},
+		))
+		require.NoError(t, err)
+		require.Len(t, rules.Rules, 3)
+		assert.Equal(t, "GET", rules.Rules[0].Verb)
+		assert.Equal(t, "/test.echo.Echo/Get", rules.Rules[0].FullMethod)
+		assert.Equal(t, "POST", rules.Rules[1].Verb)
+		assert.Equal(t, "*", rules.Rules[1].Body)
+		assert.Equal(t, "/test.echo.Echo/Watch", rules.Rules[2].FullMethod)
+		assert.NotNil(t, rules.Types)
+	})
+
+	errorCases := []struct {
+		name        string
+		data        func(t *testing.T) []byte
+		wantContain string
+	}{
+		{name: "not_a_descriptor_set", data: func(*testing.T) []byte { return []byte("garbage") }, wantContain: "unmarshal descriptor set"},
+		{name: "missing_imports", data: func(t *testing.T) []byte {
+			var set descriptorpb.FileDescriptorSet
+			require.NoError(t, proto.Unmarshal(testDescriptorSet(t, testEchoMethod{name: "Get", rule: getRule("/v1/echo")}), &set))
+			set.File = set.File[len(set.File)-1:]
+			b, _ := proto.Marshal(&set)
+			return b
+		}, wantContain: "--include_imports"},
+		{name: "no_rules", data: func(t *testing.T) []byte { return testDescriptorSet(t, testEchoMethod{name: "Plain"}) }, wantContain: "no methods with google.api.http rules"},
+		{name: "client_streaming", data: func(t *testing.T) []byte {
+			return testDescriptorSet(t, testEchoMethod{name: "Up", rule: getRule("/v1/up"), clientStreaming: true})
+		}, wantContain: "client streaming"},
+		{name: "unknown_path_field", data: func(t *testing.T) []byte {
+			return testDescriptorSet(t, testEchoMethod{name: "Get", rule: getRule("/v1/{missing}")})
+		}, wantContain: `field "missing" not found`},
+		{name: "message_path_field", data: func(t *testing.T) []byte {
+			return testDescriptorSet(t, testEchoMethod{name: "Get", rule: getRule("/v1/{inner}")})
+		}, wantContain: "must be a scalar"},
+		{name: "repeated_path_field", data: func(t *testing.T) []byte {
+			return testDescriptorSet(t, testEchoMethod{name: "Get", rule: getRule("/v1/{tags}")})
+		}, wantContain: "must be a scalar"},
+		{name: "unknown_body_field", data: func(t *testing.T) []byte {
+			return testDescriptorSet(t, testEchoMethod{name: "Post", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1"}, Body: "nope"}})
+		}, wantContain: `body field "nope" not found`},
+		{name: "unknown_response_body_field", data: func(t *testing.T) []byte {
+			return testDescriptorSet(t, testEchoMethod{name: "Get", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1"}, ResponseBody: "nope"}})
+		}, wantContain: `response_body field "nope" not found`},
+		{name: "custom_without_kind", data: func(t *testing.T) []byte {
+			return testDescriptorSet(t, testEchoMethod{name: "Get", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Path: "/v1"}}}})
+		}, wantContain: "custom http rule has no kind"},
+		{name: "bad_template", data: func(t *testing.T) []byte {
+			return testDescriptorSet(t, testEchoMethod{name: "Get", rule: getRule("v1/echo")})
+		}, wantContain: "must start with /"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseHTTPRules(tc.data(t))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantContain)
+		})
+	}
+}
+
+func TestPathTemplate_Match(t *testing.T) {
+	cases := []struct {
+		template string
+		path     string
+		wantOK   bool
+		wantVars map[string]string
+	}{
+		{template: "/v1/echo", path: "/v1/echo", wantOK: true, wantVars: map[string]string{}},
+		{template: "/v1/echo", path: "/v1/echo/x", wantOK: false},
+		{template: "/v1/echo/{name}", path: "/v1/echo/a%20b", wantOK: true, wantVars: map[string]string{"name": "a b"}},
+		{template: "/v1/echo/{name}", path: "/v1/echo/", wantOK: false},
+		{template: "/v1/{name=shelves/*/books/*}", path: "/v1/shelves/1/books/2", wantOK: true, wantVars: map[string]string{"name": "shelves/1/books/2"}},
+		{template: "/v1/{name=shelves/*/books/*}", path: "/v1/shelves/1/cds/2", wantOK: false},
+		{template: "/v1/{inner.note}/*", path: "/v1/n/x", wantOK: true, wantVars: map[string]string{"inner.note": "n"}},
+		{template: "/files/{name=**}", path: "/files/a/b%2Fc/d", wantOK: true, wantVars: map[string]string{"name": "a/b%2Fc/d"}},
+		{template: "/v1/echo/{name}:watch", path: "/v1/echo/x:watch", wantOK: true, wantVars: map[string]string{"name": "x"}},
+		{template: "/v1/echo/{name}:watch", path: "/v1/echo/x", wantOK: false},
+		{template: "/v1/echo/{name}", path: "/v1/echo/x:watch", wantOK: true, wantVars: map[string]string{"name": "x:watch"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.template+" "+tc.path, func(t *testing.T) {
+			tmpl, err := ParsePathTemplate(tc.template)
+			require.NoError(t, err)
+			assert.Equal(t, tc.template, tmpl.String())
+			vars, ok := tmpl.Match(tc.path)
+			assert.Equal(t, tc.wantOK, ok)
+			if tc.wantOK {
+				assert.Equal(t, tc.wantVars, vars)
+			}
+		})
+	}
+}
+
+func TestParsePathTemplate_Errors(t *testing.T) {
+	cases := map[string]string{
+		"v1/echo":           "must start with /",
+		"/v1//echo":         "empty segment",
+		"/v1/{name":         "unclosed variable",
+		"/v1/{a={b}}":       "nested variables",
+		"/v1/x{name}":       "variable inside a segment",
+		"/v1/{name}/{name}": "duplicate variable",
+		"/v1/**/x":          "** must be the last segment",
+		"/v1/echo:":         "empty verb",
+	}
+	for template, wantContain := range cases {
+		t.Run(template, func(t *testing.T) {
+			_, err := ParsePathTemplate(template)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), wantContain)
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mygateway/domain"
+	"mygateway/interfaces/mock"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// testHTTPRules parses the descriptor set used by the transcoder tests: Get (GET /v1/echo/{name}), Create (POST /v1/echo,
+// body *), Update (PATCH /v1/echo/{name=items/*}:update, body and response_body inner), Tags (GET /v1/tags, response_body
+// tags) and Watch (server streaming, GET /v1/echo/{name}:watch).
+func testHTTPRules(t *testing.T) HTTPRules {
+	t.Helper()
+	rules, err := ParseHTTPRules(testDescriptorSet(t,
+		testEchoMethod{name: "Get", rule: getRule("/v1/echo/{name}")},
+		testEchoMethod{name: "Create", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1/echo"}, Body: "*"}},
+		testEchoMethod{name: "Update", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Patch{Patch: "/v1/echo/{name=items/*}:update"}, Body: "inner", ResponseBody: "inner"}},
+		testEchoMethod{name: "Tags", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/tags"}, ResponseBody: "tags"}},
+		testEchoMethod{name: "Watch", rule: getRule("/v1/echo/{name}:watch"), serverStreaming: true},
+	))
+	require.NoError(t, err)
+	return rules
+}
+
+// echoStreamHandler echoes the request (as raw bytes) times times and records the method and incoming metadata.
+func echoStreamHandler(times int, gotMethod *string, gotMD *metadata.MD) grpc.StreamHandler {
+	return func(_ any, stream grpc.ServerStream) error {
+		*gotMethod, _ = grpc.MethodFromServerStream(stream)
+		*gotMD, _ = metadata.FromIncomingContext(stream.Context())
+		var m emptypb.Empty
+		if err := stream.RecvMsg(&m); err != nil {
+			return err
+		}
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != io.EOF {
+			return status.Errorf(codes.Internal, "expected EOF, got %v", err)
+		}
+		for i := 0; i < times; i++ {
+			if err := stream.SendMsg(&m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestNewHTTPTranscoder_Panics(t *testing.T) {
+	rules := testHTTPRules(t)
+	handler := func(any, grpc.ServerStream) error { return nil }
+	logger := log.NewNopLogger()
+	tests := []struct {
+		name string
+		fn   func()
+		msg  string
+	}{
+		{"rules", func() { NewHTTPTranscoder(HTTPRules{}, handler, testMaxMessage, logger) }, "service.transcoding.go: HTTP rules are required"},
+		{"handler", func() { NewHTTPTranscoder(rules, nil, testMaxMessage, logger) }, "service.transcoding.go: handler is required"},
+		{"logger", func() { NewHTTPTranscoder(rules, handler, testMaxMessage, nil) }, "service.transcoding.go: logger is required"},
+		{"interceptor", func() { NewHTTPTranscoder(rules, handler, testMaxMessage, logger, nil) }, "service.transcoding.go: interceptor is required"},
+		{"max_message", func() { NewHTTPTranscoder(rules, handler, 0, logger) }, "service.transcoding.go: max message size must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PanicsWithValue(t, tt.msg, tt.fn)
+		})
+	}
+}
+
+func TestHTTPTranscoder_Unary(t *testing.T) {
+	rules := testHTTPRules(t)
+	var gotMethod string
+	var gotMD metadata.MD
+	transcoder := NewHTTPTranscoder(rules, echoStreamHandler(1, &gotMethod, &gotMD), testMaxMessage, log.NewNopLogger())
+
+	t.Run("path_and_query_parameters", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/v1/echo/a%20b?count=3&tags=x&tags=y&kind=KIND_A&inner.note=n&at=2026-01-02T03:04:05Z&id=9007199254740993&unknown=1", nil)
+		r.Header.Set("Authorization", "Bearer t")
+		r.Header.Set("Session-Id", "s1")
+		w := httptest.NewRecorder()
+		transcoder.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"name":"a b","count":3,"tags":["x","y"],"kind":"KIND_A","inner":{"note":"n"},"at":"2026-01-02T03:04:05Z","id":"9007199254740993"}`, w.Body.String())
+		assert.Equal(t, "/test.echo.Echo/Get", gotMethod)
+		assert.Equal(t, []string{"Bearer t"}, gotMD.Get("authorization"))
+		assert.Equal(t, []string{"s1"}, gotMD.Get("session-id"))
+	})
+	t.Run("body_star", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/v1/echo?count=5", strings.NewReader(`{"name":"n","count":2,"inner":{"note":"x"}}`))
+		w := httptest.NewRecorder()
+		transcoder.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.JSONEq(t, `{"name":"n","count":2,"inner":{"note":"x"}}`, w.Body.String(), "query is ignored with body *")
+		assert.Equal(t, "/test.echo.Echo/Create", gotMethod)
+	})
+	t.Run("empty_body", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/v1/echo", nil)
+		w := httptest.NewRecorder()
+		transcoder.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.JSONEq(t, `{}`, w.Body.String())
+	})
+	t.Run("body_field_and_response_body", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPatch, "/v1/echo/items/7:update?count=4", strings.NewReader(`{"note":"patched"}`))
+		w := httptest.NewRecorder()
+		transcoder.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.JSONEq(t, `{"note":"patched"}`, w.Body.String())
+		assert.Equal(t, "/test.echo.Echo/Update", gotMethod)
+	})
+	t.Run("scalar_response_body", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/v1/tags?tags=a&tags=b", nil)
+		w := httptest.NewRecorder()
+		transcoder.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.JSONEq(t, `["a","b"]`, w.Body.String())
+	})
+}
+
+func TestHTTPTranscoder_ServerStreaming(t *testing.T) {
+	var gotMethod string
+	var gotMD metadata.MD
+	srv := httptest.NewServer(NewHTTPTranscoder(testHTTPRules(t), echoStreamHandler(3, &gotMethod, &gotMD), testMaxMessage, log.NewNopLogger()))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/echo/w:watch?count=1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.JSONEq(t, `{"result":{"name":"w","count":1}}`, line)
+	}
+	assert.Equal(t, "/test.echo.Echo/Watch", gotMethod)
+}
+
+func TestHTTPTranscoder_Errors(t *testing.T) {
+	rules := testHTTPRules(t)
+
+	t.Run("routing_and_decoding", func(t *testing.T) {
+		var gotMethod string
+		var gotMD metadata.MD
+		transcoder := NewHTTPTranscoder(rules, echoStreamHandler(1, &gotMethod, &gotMD), testMaxMessage, log.NewNopLogger())
+		cases := []struct {
+			name       string
+			method     string
+			target     string
+			body       string
+			wantStatus int
+			wantCode   codes.Code
+		}{
+			{name: "no_rule", method: http.MethodGet, target: "/v2/nothing", wantStatus: http.StatusNotFound, wantCode: codes.NotFound},
+			{name: "wrong_method", method: http.MethodDelete, target: "/v1/echo/x", wantStatus: http.StatusMethodNotAllowed, wantCode: codes.Unimplemented},
+			{name: "invalid_json", method: http.MethodPost, target: "/v1/echo", body: `{"name":`, wantStatus: http.StatusBadRequest, wantCode: codes.InvalidArgument},
+			{name: "unknown_json_field", method: http.MethodPost, target: "/v1/echo", body: `{"nope":1}`, wantStatus: http.StatusBadRequest, wantCode: codes.InvalidArgument},
+			{name: "bad_query_number", method: http.MethodGet, target: "/v1/echo/x?count=abc", wantStatus: http.StatusBadRequest, wantCode: codes.InvalidArgument},
+			{name: "bad_query_enum", method: http.MethodGet, target: "/v1/echo/x?kind=KIND_Z", wantStatus: http.StatusBadRequest, wantCode: codes.InvalidArgument},
+			{name: "message_query", method: http.MethodGet, target: "/v1/echo/x?inner=x", wantStatus: http.StatusBadRequest, wantCode: codes.InvalidArgument},
+			{name: "too_large", method: http.MethodPost, target: "/v1/echo", body: `{"name":"` + strings.Repeat("x", testMaxMessage*4/3) + `"}`, wantStatus: http.StatusTooManyRequests, wantCode: codes.ResourceExhausted},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				transcoder.ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+				assert.Equal(t, tc.wantStatus, w.Code)
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+				var st struct {
+					Code    int    `json:"code"`
+					Message string `json:"message"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &st), w.Body.String())
+				assert.Equal(t, int(tc.wantCode), st.Code)
+				assert.NotEmpty(t, st.Message)
+			})
+		}
+	})
+
+	t.Run("message_size", func(t *testing.T) {
+		var gotMethod string
+		var gotMD metadata.MD
+		transcoder := NewHTTPTranscoder(rules, echoStreamHandler(1, &gotMethod, &gotMD), 1<<10, log.NewNopLogger())
+		post := func(nameLen int) int {
+			w := httptest.NewRecorder()
+			body := `{"name":"` + strings.Repeat("x", nameLen) + `"}`
+			transcoder.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/echo", strings.NewReader(body)))
+			return w.Code
+		}
+		assert.Equal(t, http.StatusOK, post(1000))
+		assert.Equal(t, http.StatusTooManyRequests, post(1100), "JSON body within 4/3 of the limit, protobuf request over it")
+		assert.Equal(t, http.StatusTooManyRequests, post(1500), "JSON body over 4/3 of the limit")
+	})
+
+	t.Run("handler_error_through_interceptor", func(t *testing.T) {
+		handler := func(_ any, stream grpc.ServerStream) error {
+			_ = stream.SetHeader(metadata.Pairs("x-h", "1"))
+			stream.SetTrailer(metadata.Pairs("x-t", "2"))
+			return ErrNoAvailableConnInstance
+		}
+		transcoder := NewHTTPTranscoder(rules, handler, testMaxMessage, log.NewNopLogger(), GatewayErrorToGRPCStreamInterceptor(log.NewNopLogger()))
+		w := httptest.NewRecorder()
+		transcoder.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/echo/x", nil))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Grpc-Metadata-X-H"))
+		assert.Equal(t, "2", w.Header().Get("Grpc-Trailer-X-T"))
+		assert.Contains(t, w.Body.String(), `"code":8`)
+	})
+
+	t.Run("stream_error_after_first_message", func(t *testing.T) {
+		handler := func(_ any, stream grpc.ServerStream) error {
+			var m emptypb.Empty
+			if err := stream.RecvMsg(&m); err != nil {
+				return err
+			}
+			if err := stream.SendMsg(&m); err != nil {
+				return err
+			}
+			return status.Error(codes.Unavailable, "backend gone")
+		}
+		transcoder := NewHTTPTranscoder(rules, handler, testMaxMessage, log.NewNopLogger())
+		w := httptest.NewRecorder()
+		transcoder.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/echo/x:watch", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.JSONEq(t, `{"result":{"name":"x"}}`, lines[0])
+		assert.JSONEq(t, `{"error":{"code":14,"message":"backend gone"}}`, lines[1])
+	})
+
+	t.Run("unary_second_response", func(t *testing.T) {
+		var gotMethod string
+		var gotMD metadata.MD
+		transcoder := NewHTTPTranscoder(rules, echoStreamHandler(2, &gotMethod, &gotMD), testMaxMessage, log.NewNopLogger())
+		w := httptest.NewRecorder()
+		transcoder.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/echo/x", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "more than one response")
+	})
+}
+
+func TestHTTPTranscoder_ThroughTransparentProxy(t *testing.T) {
+	backendLis, backendSrv := startUnknownBackend(t, func(_ any, stream grpc.ServerStream) error {
+		var m emptypb.Empty
+		if err := stream.RecvMsg(&m); err != nil {
+			return err
+		}
+		return stream.SendMsg(&m)
+	})
+	defer backendSrv.Stop()
+	defer backendLis.Close()
+	backendConn, err := grpc.NewClient(backendLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer backendConn.Close()
+
+	route := domain.Route{Prefix: "/test.echo.Echo/", Cluster: "test", Balancer: domain.BalancerConfig{Type: domain.BalancerStickySession, Header: "session-id"}}
+	var gotMethod string
+	router := &mock.RouteMatcherMock{MatchFunc: func(method string) (domain.Route, bool) {
+		gotMethod = method
+		return route, true
+	}}
+	var gotKey string
+	resolver := &mock.ConnectionResolverMock{
+		GetConnectionFunc: func(ctx context.Context, r domain.Route, headers metadata.MD) (*grpc.ClientConn, string, string, error) {
+			gotKey = firstMDValue(headers, "session-id")
+			return backendConn, gotKey, "i1", nil
+		},
+	}
+	headers := &mock.HeaderProcessorMock{ProcessFunc: func(ctx context.Context, md metadata.MD, method string) (metadata.MD, error) {
+		return md, nil
+	}}
+	proxy := newProxyForTest(router, resolver, headers, log.NewNopLogger(), nil)
+	srv := httptest.NewServer(NewHTTPTranscoder(testHTTPRules(t), proxy.Handler, testMaxMessage, log.NewNopLogger(), GatewayErrorToGRPCStreamInterceptor(log.NewNopLogger())))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/echo", strings.NewReader(`{"name":"ping","tags":["a"]}`))
+	require.NoError(t, err)
+	req.Header.Set("Session-Id", "sess-7")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	assert.JSONEq(t, `{"name":"ping","tags":["a"]}`, string(body))
+	assert.Equal(t, "/test.echo.Echo/Create", gotMethod, "routes match the gRPC method")
+	assert.Equal(t, "sess-7", gotKey, "sticky key from the HTTP header")
+}
+
+// startUnknownBackend starts a gRPC server that handles every method with handler.
+func startUnknownBackend(t *testing.T, handler grpc.StreamHandler) (net.Listener, *grpc.Server) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(handler))
+	go func() { _ = srv.Serve(lis) }()
+	return lis, srv
+}
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	assert.Equal(t, http.StatusOK, httpStatusFromCode(codes.OK))
+	assert.Equal(t, http.StatusUnauthorized, httpStatusFromCode(codes.Unauthenticated))
+	assert.Equal(t, http.StatusForbidden, httpStatusFromCode(codes.PermissionDenied))
+	assert.Equal(t, http.StatusServiceUnavailable, httpStatusFromCode(codes.Unavailable))
+	assert.Equal(t, http.StatusGatewayTimeout, httpStatusFromCode(codes.DeadlineExceeded))
+	assert.Equal(t, http.StatusInternalServerError, httpStatusFromCode(codes.DataLoss))
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"io"
+	"sync"
 	"time"
 
 	"mygateway/domain"
@@ -24,7 +26,8 @@ import (
 // via ConnectionResolver, (5) open a client stream to the backend and bidirectionally forward messages
 // using emptypb.Empty (no application-level protobuf parsing). On any backend or stream error it
 // calls OnBackendFailure. For dynamic clusters, retries NewStream up to retryCount times with
-// retryTimeout per attempt (FR-MGW-4). Fields: router, resolver, headers, logger, retryCount,
+// retryTimeout per attempt (FR-MGW-4). Every connection taken from the resolver is handed back with
+// ReleaseStream exactly once when its backend stream ends (per-instance stream limits). Fields: router, resolver, headers, logger, retryCount,
 // retryTimeout, dynamicClusters.
 type TransparentProxy struct {
 	router          interfaces.RouteMatcher
@@ -63,7 +66,7 @@ func NewTransparentProxy(
 	}
 }
 
-// Handler implements the handler signature for grpc.UnknownServiceHandler: extracts method from context, matches route, processes headers (auth), gets backend connection, opens stream and forwards messages both ways via emptypb.Empty. On backend/stream error calls OnBackendFailure; for dynamic clusters retries within retryCount.
+// Handler implements the handler signature for grpc.UnknownServiceHandler: extracts method from context, matches route, processes headers (auth), gets backend connection, opens stream and forwards messages both ways via emptypb.Empty. On backend/stream error calls OnBackendFailure (not when the stream context was canceled with ErrGatewayDraining); for dynamic clusters retries within retryCount. When the context carries a domain.AccessRecord, the matched route, each backend attempt (instance, sticky key) and each transfer are recorded in it.
 //
 // Parameters: _ — unused (gRPC signature); serverStream — incoming stream from client (RecvMsg/SendMsg to client).
 //
@@ -79,6 +82,8 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 	if !ok {
 		return status.Error(codes.Unimplemented, "method not routed")
 	}
+	accessRecord := domain.AccessRecordFromContext(serverStream.Context())
+	accessRecord.SetRoute(route.Prefix, route.Cluster)
 
 	inMD, _ := metadata.FromIncomingContext(serverStream.Context())
 	outMD, err := p.headers.Process(serverStream.Context(), inMD, fullMethodName)
@@ -87,6 +92,13 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 	}
 	outCtx := metadata.NewOutgoingContext(serverStream.Context(), outMD)
 	_, retryable := p.dynamicClusters[route.Cluster]
+	// backendFailed reports a failed backend stream unless the stream was ended by the gateway drain (StreamDrainer).
+	backendFailed := func(stickyKey, instanceID string) {
+		if errors.Is(context.Cause(serverStream.Context()), ErrGatewayDraining) {
+			return
+		}
+		p.resolver.OnBackendFailure(route, stickyKey, instanceID)
+	}
 
 	type streamState struct {
 		clientStream grpc.ClientStream
@@ -102,6 +114,8 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 				if getConnErr != nil {
 					return nil, getConnErr
 				}
+				accessRecord.SetBackend(instanceID, stickyKey)
+				release := p.releaseOnce(route, instanceID)
 				streamCtx, cancel := context.WithCancel(outCtx)
 				timer := time.AfterFunc(p.retryTimeout, cancel)
 				desc := &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}
@@ -110,14 +124,15 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 					timer.Stop()
 					return &streamState{
 						clientStream: clientStream,
-						streamCancel: cancel,
+						streamCancel: func() { cancel(); release() },
 						stickyKey:    stickyKey,
 						instanceID:   instanceID,
 					}, nil
 				}
 				timer.Stop()
 				cancel()
-				p.resolver.OnBackendFailure(route, stickyKey, instanceID)
+				backendFailed(stickyKey, instanceID)
+				release()
 				if attempt == p.retryCount-1 {
 					return nil, newStreamErr
 				}
@@ -129,17 +144,20 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 		if getConnErr != nil {
 			return nil, getConnErr
 		}
+		accessRecord.SetBackend(instanceID, stickyKey)
+		release := p.releaseOnce(route, instanceID)
 		clientCtx, cancel := context.WithCancel(outCtx)
 		desc := &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}
 		clientStream, newStreamErr := backendConn.NewStream(clientCtx, desc, fullMethodName)
 		if newStreamErr != nil {
 			cancel()
-			p.resolver.OnBackendFailure(route, stickyKey, instanceID)
+			backendFailed(stickyKey, instanceID)
+			release()
 			return nil, newStreamErr
 		}
 		return &streamState{
 			clientStream: clientStream,
-			streamCancel: cancel,
+			streamCancel: func() { cancel(); release() },
 			stickyKey:    stickyKey,
 			instanceID:   instanceID,
 		}, nil
@@ -170,7 +188,7 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 				return status.Error(codes.Internal, "failed to clone first client message")
 			}
 			if sendErr := state.clientStream.SendMsg(msg); sendErr != nil {
-				p.resolver.OnBackendFailure(route, state.stickyKey, state.instanceID)
+				backendFailed(state.stickyKey, state.instanceID)
 				state.streamCancel()
 				return sendErr
 			}
@@ -182,7 +200,7 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 				return nil
 			}
 
-			p.resolver.OnBackendFailure(route, state.stickyKey, state.instanceID)
+			backendFailed(state.stickyKey, state.instanceID)
 			state.streamCancel()
 			if !retryable || transferAttempt >= p.retryCount-1 || serverStream.Context().Err() != nil {
 				return c2sErr
@@ -192,6 +210,7 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 				return openErr
 			}
 			state = nextState
+			accessRecord.AddTransfer()
 			continue
 		}
 
@@ -209,7 +228,7 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 				case firstClientMsg = <-firstClientMsgCh:
 				default:
 				}
-				p.resolver.OnBackendFailure(route, state.stickyKey, state.instanceID)
+				backendFailed(state.stickyKey, state.instanceID)
 				state.streamCancel()
 				if !retryable || firstClientMsg == nil || transferAttempt >= p.retryCount-1 || serverStream.Context().Err() != nil {
 					return s2cErr
@@ -219,6 +238,7 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 					return openErr
 				}
 				state = nextState
+				accessRecord.AddTransfer()
 				goto retryNextBackend
 			case c2sErr := <-c2sErrChan:
 				serverStream.SetTrailer(state.clientStream.Trailer())
@@ -229,7 +249,7 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 				case firstClientMsg = <-firstClientMsgCh:
 				default:
 				}
-				p.resolver.OnBackendFailure(route, state.stickyKey, state.instanceID)
+				backendFailed(state.stickyKey, state.instanceID)
 				state.streamCancel()
 				if !retryable || firstClientMsg == nil || transferAttempt >= p.retryCount-1 || serverStream.Context().Err() != nil {
 					return c2sErr
@@ -239,6 +259,7 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 					return openErr
 				}
 				state = nextState
+				accessRecord.AddTransfer()
 				goto retryNextBackend
 			}
 		}
@@ -250,6 +271,18 @@ func (p *TransparentProxy) Handler(_ any, serverStream grpc.ServerStream) error
 	}
 }
 
+// releaseOnce returns a function that reports the end of a backend stream to resolver.ReleaseStream; calls after the first are no-ops (streamCancel runs on failure paths and again in the deferred cleanup).
+//
+// Parameters: route — request route; instanceID — instance returned by GetConnection.
+//
+// Called only from TransparentProxy.Handler after each successful GetConnection.
+func (p *TransparentProxy) releaseOnce(route domain.Route, instanceID string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { p.resolver.ReleaseStream(route, instanceID) })
+	}
+}
+
 // forwardClientToServer in a goroutine forwards messages from backend (src) to client (dst) using emptypb.Empty without protobuf parsing. When sendHeader=true the first response sends backend response headers to client via dst.SendHeader.
 //
 // Parameters: src — client stream to backend (RecvMsg); dst — server stream to client (SendMsg); sendHeader — when true first response is accompanied by SendHeader(md) from backend.
@@ -520,9 +520,9 @@ func TestTransparentProxy_Handler(t *testing.T) {
 			GetConnectionFunc: func(ctx context.Context, r domain.Route, headers metadata.MD) (*grpc.ClientConn, string, string, error) {
 				n := atomic.AddInt32(&getConnCalls, 1)
 				if n == 1 {
-					return badConn, "", "", nil
+					return badConn, "", "bad-instance", nil
 				}
-				return goodConn, "", "", nil
+				return goodConn, "", "good-instance", nil
 			},
 			OnBackendFailureFunc: func(domain.Route, string, string) {
 				atomic.AddInt32(&onFailureCalls, 1)
@@ -535,9 +535,18 @@ func TestTransparentProxy_Handler(t *testing.T) {
 		}
 		dynamicClusters := map[domain.ClusterID]struct{}{"test": {}}
 		proxy := NewTransparentProxy(router, resolver, headers, log.NewNopLogger(), 3, 5*time.Second, dynamicClusters)
-		proxyLis, proxySrv := startProxyServer(t, proxy)
-		defer proxySrv.Stop()
+		record := &domain.AccessRecord{}
+		proxyLis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
 		defer proxyLis.Close()
+		proxySrv := grpc.NewServer(
+			grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				return handler(srv, &countingServerStream{ServerStream: ss, ctx: domain.WithAccessRecord(ss.Context(), record)})
+			}),
+			grpc.UnknownServiceHandler(proxy.Handler),
+		)
+		go func() { _ = proxySrv.Serve(proxyLis) }()
+		defer proxySrv.Stop()
 
 		clientConn, err := grpc.NewClient(proxyLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
 		require.NoError(t, err)
@@ -564,6 +573,15 @@ func TestTransparentProxy_Handler(t *testing.T) {
 		}
 		assert.Equal(t, int32(2), atomic.LoadInt32(&getConnCalls), "GetConnection should be called twice (first failed, second succeeded)")
 		assert.Equal(t, int32(1), atomic.LoadInt32(&onFailureCalls), "OnBackendFailure should be called once for the failed first attempt")
+		snap := record.Snapshot()
+		assert.Equal(t, "/svc/", snap.Route)
+		assert.Equal(t, "test", snap.Cluster)
+		assert.Equal(t, "good-instance", snap.InstanceID)
+		assert.Equal(t, int64(2), snap.Attempts)
+		require.Eventually(t, func() bool { return len(resolver.ReleaseStreamCalls()) == 2 }, time.Second, 5*time.Millisecond)
+		released := resolver.ReleaseStreamCalls()
+		assert.ElementsMatch(t, []string{"bad-instance", "good-instance"}, []string{released[0].InstanceID, released[1].InstanceID},
+			"every GetConnection is released once: the failed attempt and the finished stream")
 		_ = badConn.Close()
 	})
 
@@ -713,6 +731,9 @@ func TestTransparentProxy_Handler(t *testing.T) {
 		assert.GreaterOrEqual(t, atomic.LoadInt32(&onFailureCalls), int32(1), "OnBackendFailure should be called when first backend fails")
 		assert.Equal(t, "Bearer test-token", backend2Auth, "authorization metadata must be preserved on transferred stream")
 		assert.Equal(t, "session-123", backend2Session, "session-id metadata must be preserved on transferred stream")
+		require.Eventually(t, func() bool { return len(resolver.ReleaseStreamCalls()) == 2 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, "instance-1", resolver.ReleaseStreamCalls()[0].InstanceID, "the first stream is released on transfer")
+		assert.Equal(t, "instance-2", resolver.ReleaseStreamCalls()[1].InstanceID)
 	})
 }
 
@@ -723,3 +744,63 @@ func firstMDValue(md metadata.MD, key string) string {
 	}
 	return values[0]
 }
+
+func TestTransparentProxy_DrainedStreamIsNotBackendFailure(t *testing.T) {
+	route := domain.Route{Prefix: "/svc/", Cluster: "test", Authorization: domain.AuthorizationNone}
+	router := &mock.RouteMatcherMock{MatchFunc: func(string) (domain.Route, bool) { return route, true }}
+	received := make(chan struct{}, 1)
+	backendLis, backendSrv := startBidiBackend(t, func(stream grpc.ServerStream) error {
+		var m emptypb.Empty
+		for {
+			if err := stream.RecvMsg(&m); err != nil {
+				return err
+			}
+			received <- struct{}{}
+		}
+	})
+	defer backendSrv.Stop()
+	defer backendLis.Close()
+	backendConn, err := grpc.NewClient(backendLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer backendConn.Close()
+
+	resolver := &mock.ConnectionResolverMock{
+		GetConnectionFunc: func(context.Context, domain.Route, metadata.MD) (*grpc.ClientConn, string, string, error) {
+			return backendConn, "session-1", "instance-1", nil
+		},
+	}
+	headers := &mock.HeaderProcessorMock{
+		ProcessFunc: func(context.Context, metadata.MD, string) (metadata.MD, error) { return metadata.New(nil), nil },
+	}
+	proxy := newProxyForTest(router, resolver, headers, log.NewNopLogger(), map[domain.ClusterID]struct{}{"test": {}})
+	drainer := NewStreamDrainer(domain.ShutdownConfig{StatusCode: uint32(codes.Unavailable), StatusMessage: "draining"})
+	proxyLis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	proxySrv := grpc.NewServer(
+		grpc.ChainStreamInterceptor(drainer.StreamInterceptor(), GatewayErrorToGRPCStreamInterceptor(log.NewNopLogger())),
+		grpc.UnknownServiceHandler(proxy.Handler),
+	)
+	go func() { _ = proxySrv.Serve(proxyLis) }()
+	defer proxySrv.Stop()
+
+	clientConn, err := grpc.NewClient(proxyLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer clientConn.Close()
+	stream, err := clientConn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, "/svc/Method")
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(&emptypb.Empty{}))
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("backend did not receive the message")
+	}
+
+	require.Equal(t, 1, drainer.NotifyStreams())
+	var recv emptypb.Empty
+	err = stream.RecvMsg(&recv)
+	st := status.Convert(err)
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "draining", st.Message())
+	assert.Empty(t, resolver.OnBackendFailureCalls(), "a drained stream must not mark its backend as failed")
+	assert.Len(t, resolver.ReleaseStreamCalls(), 1, "the backend stream slot is released")
+}
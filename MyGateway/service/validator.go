@@ -1,57 +1,114 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"mygateway/auth"
 	"mygateway/helpers"
 	"mygateway/interfaces"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 )
 
-// jwtValidator implements interfaces.JwtService. It validates tokens using ParseAndVerify (signature)
-// and then checks expiry (using the injected TimeProvider.Now()) and that the token's session_id claim
-// matches the sessionID argument (from the session-id metadata). Used for routes with authorization=required.
+// jwtValidator implements interfaces.JwtService. It validates tokens using ParseAndVerify (signature) against every
+// active HMAC key from the KeySources (current and previous keys during rotation), then checks expiry (using the injected
+// TimeProvider.Now()) and that the token's session_id claim matches the sessionID argument (from the session-id metadata).
+// Used for routes with authorization=required.
 type jwtValidator struct {
-	secret       []byte
+	sources      []interfaces.KeySource
 	timeProvider interfaces.TimeProvider
+	logger       log.Logger
 }
 
-// NewJWTValidator creates a JwtService that validates tokens with the given secret (HMAC-SHA256); time for expiry check comes from timeProvider.
+// NewJWTValidator creates a JwtService that validates legacy tokens (HMAC-SHA256) with the keys from sources; time for expiry and key not_after checks comes from timeProvider.
 //
-// Parameters: secret — shared HMAC key (must match MyAuth); timeProvider — source of current time (prod — time.Now().UTC(), tests — fixed). Panics on nil secret or timeProvider.
+// Parameters: timeProvider — source of current time (prod — time.Now().UTC(), tests — fixed); logger — logs the ID of the key that verified a token (debug);
+// sources — key sources (JWT_SECRET/JWT_SECRETS as auth.StaticKeys, JWT_KEYS_FILE), consulted in order. Panics on nil timeProvider or logger, empty sources or a nil source.
 //
 // Returns: interfaces.JwtService (*jwtValidator).
 //
 // Called from cmd/main when building the header chain.
-func NewJWTValidator(secret []byte, timeProvider interfaces.TimeProvider) interfaces.JwtService {
+func NewJWTValidator(timeProvider interfaces.TimeProvider, logger log.Logger, sources ...interfaces.KeySource) interfaces.JwtService {
+	if len(sources) == 0 {
+		panic("service.validator.go: key source is required")
+	}
+	for _, s := range sources {
+		helpers.NilPanic(s, "service.validator.go: key source is required")
+	}
 	return &jwtValidator{
-		secret:       helpers.NilPanic(secret, "service.validator.go: secret is required"),
+		sources:      sources,
 		timeProvider: helpers.NilPanic(timeProvider, "service.validator.go: time provider is required"),
+		logger:       helpers.NilPanic(logger, "service.validator.go: logger is required"),
 	}
 }
 
-// ValidateToken verifies token signature (ParseAndVerify), parses ExpiresAt (RFC3339), compares "now" with expiry and claims.SessionID with the given sessionID.
+// ValidateToken verifies token signature (ParseAndVerify with each active key), parses ExpiresAt (RFC3339), compares "now" with expiry and claims.SessionID with the given sessionID.
 //
 // Parameters: sessionID — session-id metadata value; token — authorization metadata value. Empty/invalid token or session_id mismatch yield (false, nil).
 //
-// Returns: (true, nil) when token is valid and session_id matches; (false, nil) when invalid, expired or session_id mismatch; (false, err) on internal error (e.g. time parse).
+// Returns: (claims, true, nil) when token is valid and session_id matches; (zero, false, nil) when invalid, expired or session_id mismatch; (zero, false, err) when no key source could supply keys.
 //
 // Called from helpers.ConfigurableAuthProcessor.Process when authorization=required for the matched route.
-func (v *jwtValidator) ValidateToken(sessionID string, token string) (bool, error) {
-	claims, err := auth.ParseAndVerify(token, v.secret)
+func (v *jwtValidator) ValidateToken(sessionID string, token string) (auth.TokenClaims, bool, error) {
+	var zero auth.TokenClaims
+	now := v.timeProvider.Now()
+	claims, key, err := v.verify(token, now)
 	if err != nil {
-		return false, nil
+		return zero, false, err
+	}
+	if key == nil {
+		return zero, false, nil
 	}
 	t, err := time.Parse(time.RFC3339, claims.ExpiresAt)
 	if err != nil {
-		return false, nil
+		return zero, false, nil
 	}
-	now := v.timeProvider.Now()
 	if now.After(t) {
-		return false, nil
+		return zero, false, nil
 	}
 	if claims.SessionID != sessionID {
-		return false, nil
+		return zero, false, nil
+	}
+	level.Debug(v.logger).Log("msg", "token verified", "key_id", key.ID)
+	return claims, true, nil
+}
+
+// verify tries every active HMAC key from the sources in order until one verifies the signature.
+//
+// Parameters: token — raw token; now — current time for key not_after.
+//
+// Returns: (claims, key, nil) for the first key that verifies; (zero, nil, nil) when none does; (zero, nil, err) when all sources failed to supply keys.
+//
+// Called only from ValidateToken.
+func (v *jwtValidator) verify(token string, now time.Time) (auth.TokenClaims, *auth.VerificationKey, error) {
+	var zero auth.TokenClaims
+	var lastErr error
+	loaded := false
+	for _, source := range v.sources {
+		keys, err := source.Keys()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		loaded = true
+		for i := range keys {
+			if !keys[i].Active(now) || !keys[i].Accepts(auth.AlgHS256) {
+				continue
+			}
+			claims, err := auth.ParseAndVerify(token, keys[i].Key.([]byte))
+			if err == nil {
+				return claims, &keys[i], nil
+			}
+			if !errors.Is(err, auth.ErrInvalidSignature) {
+				return zero, nil, nil
+			}
+		}
+	}
+	if !loaded {
+		return zero, nil, fmt.Errorf("load verification keys: %w", lastErr)
 	}
-	return true, nil
+	return zero, nil, nil
 }
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"mygateway/auth"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// revocationValidator implements interfaces.JwtService on top of another JwtService (jwtValidator or
+// standardJWTValidator): a token that the inner validator accepts is additionally checked against the revocation
+// store, so a logged-out session or a token issued before a cutoff is rejected before its expiry.
+type revocationValidator struct {
+	inner       interfaces.JwtService
+	revocations interfaces.RevocationChecker
+	logger      log.Logger
+}
+
+// NewRevocationValidator wraps inner with a revocation check. Panics on nil inner, revocations or logger.
+//
+// Parameters: inner — token validator (legacy or standard); revocations — cached revocation store (NewRevocationCache);
+// logger — logs rejected revoked tokens (debug).
+//
+// Returns: interfaces.JwtService (*revocationValidator).
+//
+// Called from cmd/main when auth.revocation is configured.
+func NewRevocationValidator(inner interfaces.JwtService, revocations interfaces.RevocationChecker, logger log.Logger) interfaces.JwtService {
+	return &revocationValidator{
+		inner:       helpers.NilPanic(inner, "service.validator_revocation.go: JwtService is required"),
+		revocations: helpers.NilPanic(revocations, "service.validator_revocation.go: revocation checker is required"),
+		logger:      helpers.NilPanic(logger, "service.validator_revocation.go: logger is required"),
+	}
+}
+
+// ValidateToken validates the token with the inner validator and rejects it when its session or issued_at is revoked.
+//
+// Parameters: sessionID — session-id metadata value; token — authorization metadata value.
+//
+// Returns: the inner result when the token is invalid or an error occurred; (zero, false, nil) when the token is revoked;
+// otherwise (claims, true, nil).
+//
+// Called from helpers.ConfigurableAuthProcessor.Process when authorization=required for the matched route.
+func (v *revocationValidator) ValidateToken(sessionID string, token string) (auth.TokenClaims, bool, error) {
+	claims, ok, err := v.inner.ValidateToken(sessionID, token)
+	if err != nil || !ok {
+		return claims, ok, err
+	}
+	if v.revocations.IsRevoked(claims) {
+		level.Debug(v.logger).Log("msg", "token revoked", "session_id", claims.SessionID, "login", claims.Login)
+		return auth.TokenClaims{}, false, nil
+	}
+	return claims, true, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"mygateway/auth"
+	"mygateway/interfaces/mock"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRevocationValidator_Panics(t *testing.T) {
+	inner := &mock.JwtServiceMock{}
+	checker := &mock.RevocationCheckerMock{}
+	t.Run("inner_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator_revocation.go: JwtService is required", func() {
+			NewRevocationValidator(nil, checker, log.NewNopLogger())
+		})
+	})
+	t.Run("checker_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator_revocation.go: revocation checker is required", func() {
+			NewRevocationValidator(inner, nil, log.NewNopLogger())
+		})
+	})
+	t.Run("logger_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator_revocation.go: logger is required", func() {
+			NewRevocationValidator(inner, checker, nil)
+		})
+	})
+}
+
+func TestRevocationValidator_ValidateToken(t *testing.T) {
+	inner := &mock.JwtServiceMock{ValidateTokenFunc: func(sessionID, token string) (auth.TokenClaims, bool, error) {
+		switch token {
+		case "valid":
+			return auth.TokenClaims{Login: "u", SessionID: sessionID}, true, nil
+		case "broken":
+			return auth.TokenClaims{}, false, errors.New("keys unavailable")
+		}
+		return auth.TokenClaims{}, false, nil
+	}}
+	checker := &mock.RevocationCheckerMock{IsRevokedFunc: func(claims auth.TokenClaims) bool {
+		return claims.SessionID == "revoked"
+	}}
+	v := NewRevocationValidator(inner, checker, log.NewNopLogger())
+
+	claims, ok, err := v.ValidateToken("s1", "valid")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "u", claims.Login)
+
+	claims, ok, err = v.ValidateToken("revoked", "valid")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, claims.Login)
+
+	_, ok, err = v.ValidateToken("s1", "invalid")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = v.ValidateToken("s1", "broken")
+	require.Error(t, err)
+	assert.Len(t, checker.IsRevokedCalls(), 2, "only accepted tokens are checked")
+}
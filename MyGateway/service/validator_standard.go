@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"mygateway/auth"
+	"mygateway/domain"
+	"mygateway/helpers"
+	"mygateway/interfaces"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// standardJWTValidator implements interfaces.JwtService for RFC 7519 tokens. It parses header.payload.signature,
+// checks that "alg" is allowed, selects active keys from the KeySources by "kid" and key type, verifies the signature and then
+// checks exp (required), nbf, iat, iss, aud (with leeway) and that the session_id claim matches the session-id metadata.
+type standardJWTValidator struct {
+	sources      []interfaces.KeySource
+	algorithms   map[string]bool
+	issuer       string
+	audience     string
+	leeway       time.Duration
+	timeProvider interfaces.TimeProvider
+	logger       log.Logger
+}
+
+// NewStandardJWTValidator creates a JwtService for standard JWTs. Panics on nil timeProvider or logger, empty sources or a nil source.
+//
+// Parameters: cfg — verification settings (Algorithms empty — auth.SupportedAlgorithms; Issuer/Audience empty — not checked; Leeway — clock skew);
+// timeProvider — source of current time; logger — logs the ID of the key that verified a token (debug);
+// sources — key sources (JWT_SECRET/JWT_SECRETS as auth.StaticKeys, JWT_KEYS_FILE, JWKS file/URL), consulted in order.
+//
+// Returns: interfaces.JwtService (*standardJWTValidator).
+//
+// Called from cmd/main when auth.jwt.format=standard.
+func NewStandardJWTValidator(cfg domain.JWTConfig, timeProvider interfaces.TimeProvider, logger log.Logger, sources ...interfaces.KeySource) interfaces.JwtService {
+	if len(sources) == 0 {
+		panic("service.validator_standard.go: key source is required")
+	}
+	for _, s := range sources {
+		helpers.NilPanic(s, "service.validator_standard.go: key source is required")
+	}
+	algs := cfg.Algorithms
+	if len(algs) == 0 {
+		algs = auth.SupportedAlgorithms
+	}
+	allowed := make(map[string]bool, len(algs))
+	for _, a := range algs {
+		allowed[a] = true
+	}
+	return &standardJWTValidator{
+		sources:      sources,
+		algorithms:   allowed,
+		issuer:       cfg.Issuer,
+		audience:     cfg.Audience,
+		leeway:       cfg.Leeway,
+		timeProvider: helpers.NilPanic(timeProvider, "service.validator_standard.go: time provider is required"),
+		logger:       helpers.NilPanic(logger, "service.validator_standard.go: logger is required"),
+	}
+}
+
+// ValidateToken parses and verifies a standard JWT and checks its claims against now (TimeProvider) and sessionID.
+//
+// Parameters: sessionID — session-id metadata value; token — authorization metadata value.
+//
+// Returns: (claims, true, nil) when signature, time claims, iss/aud and session_id are valid; (zero, false, nil) on any
+// token problem (format, alg, unknown kid, signature, expired, not yet valid, issuer/audience/session mismatch);
+// (zero, false, err) when no key source could supply keys.
+//
+// Called from helpers.ConfigurableAuthProcessor.Process when authorization=required for the matched route.
+func (v *standardJWTValidator) ValidateToken(sessionID string, token string) (auth.TokenClaims, bool, error) {
+	var zero auth.TokenClaims
+	parsed, err := auth.ParseJWT(token)
+	if err != nil {
+		return zero, false, nil
+	}
+	if !v.algorithms[parsed.Header.Algorithm] {
+		return zero, false, nil
+	}
+	now := v.timeProvider.Now()
+	key, err := v.verify(parsed, now)
+	if err != nil {
+		return zero, false, err
+	}
+	if key == nil {
+		return zero, false, nil
+	}
+	claims := parsed.Claims
+	if claims.ExpiresAt == nil || !now.Before(claims.ExpiresAt.Time().Add(v.leeway)) {
+		return zero, false, nil
+	}
+	if claims.NotBefore != nil && now.Add(v.leeway).Before(claims.NotBefore.Time()) {
+		return zero, false, nil
+	}
+	if claims.IssuedAt != nil && now.Add(v.leeway).Before(claims.IssuedAt.Time()) {
+		return zero, false, nil
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return zero, false, nil
+	}
+	if v.audience != "" && !claims.Audience.Contains(v.audience) {
+		return zero, false, nil
+	}
+	out := claims.TokenClaims()
+	if out.SessionID != sessionID {
+		return zero, false, nil
+	}
+	level.Debug(v.logger).Log("msg", "token verified", "key_id", key.ID, "alg", parsed.Header.Algorithm)
+	return out, true, nil
+}
+
+// verify tries every active key that fits the token: when the header has a kid only keys with that ID (or without an ID,
+// such as JWT_SECRET) are tried; the key type and pinned alg must match the header alg; keys past not_after are skipped.
+//
+// Parameters: parsed — decoded token; now — current time for key not_after.
+//
+// Returns: (key, nil) for the first key that verifies the signature; (nil, nil) when none does; (nil, err) when all sources failed to supply keys.
+//
+// Called only from ValidateToken.
+func (v *standardJWTValidator) verify(parsed *auth.ParsedJWT, now time.Time) (*auth.VerificationKey, error) {
+	var lastErr error
+	loaded := false
+	for _, source := range v.sources {
+		keys, err := source.Keys()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		loaded = true
+		for i := range keys {
+			key := &keys[i]
+			if parsed.Header.KeyID != "" && key.ID != "" && key.ID != parsed.Header.KeyID {
+				continue
+			}
+			if !key.Active(now) || !key.Accepts(parsed.Header.Algorithm) {
+				continue
+			}
+			if auth.VerifySignature(parsed.Header.Algorithm, key.Key, parsed.SigningInput, parsed.Signature) == nil {
+				return key, nil
+			}
+		}
+	}
+	if !loaded {
+		return nil, fmt.Errorf("load verification keys: %w", lastErr)
+	}
+	return nil, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"mygateway/auth"
+	"mygateway/domain"
+	"mygateway/interfaces/mock"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStandardJWTValidator_Panics(t *testing.T) {
+	tp := &mock.TimeProviderMock{NowFunc: testNow}
+
+	t.Run("no_sources", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator_standard.go: key source is required", func() {
+			NewStandardJWTValidator(domain.JWTConfig{}, tp, log.NewNopLogger())
+		})
+	})
+	t.Run("nil_source", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator_standard.go: key source is required", func() {
+			NewStandardJWTValidator(domain.JWTConfig{}, tp, log.NewNopLogger(), nil)
+		})
+	})
+	t.Run("time_provider_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator_standard.go: time provider is required", func() {
+			NewStandardJWTValidator(domain.JWTConfig{}, nil, log.NewNopLogger(), auth.StaticKeys{})
+		})
+	})
+	t.Run("logger_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator_standard.go: logger is required", func() {
+			NewStandardJWTValidator(domain.JWTConfig{}, tp, nil, auth.StaticKeys{})
+		})
+	})
+}
+
+func TestStandardJWTValidator_ValidateToken(t *testing.T) {
+	now := testNow()
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keys := auth.StaticKeys{
+		{Key: secret},
+		{ID: "rsa-1", Key: &rsaKey.PublicKey},
+		{ID: "ec-1", Key: &ecKey.PublicKey},
+		{ID: "hs-prev", Key: []byte("previous"), NotAfter: now.Add(time.Hour)},
+		{ID: "hs-old", Key: []byte("retired"), NotAfter: now.Add(-time.Hour)},
+	}
+	baseCfg := domain.JWTConfig{Format: domain.JWTFormatStandard, Issuer: "myauth", Audience: "mygateway"}
+	claims := func(mut func(c *auth.JWTClaims)) auth.JWTClaims {
+		c := auth.JWTClaims{
+			Issuer:    "myauth",
+			Audience:  auth.Audience{"other", "mygateway"},
+			ExpiresAt: auth.NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  auth.NewNumericDate(now),
+			Login:     "u1",
+			Role:      "admin",
+			SessionID: "sess1",
+		}
+		if mut != nil {
+			mut(&c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		cfg       domain.JWTConfig
+		alg       string
+		kid       string
+		claims    auth.JWTClaims
+		signKey   any
+		sessionID string
+		wantOK    bool
+	}{
+		{name: "hs256_jwt_secret", cfg: baseCfg, alg: auth.AlgHS256, claims: claims(nil), signKey: secret, sessionID: "sess1", wantOK: true},
+		{name: "rs256_by_kid", cfg: baseCfg, alg: auth.AlgRS256, kid: "rsa-1", claims: claims(nil), signKey: rsaKey, sessionID: "sess1", wantOK: true},
+		{name: "rs256_without_kid", cfg: baseCfg, alg: auth.AlgRS256, claims: claims(nil), signKey: rsaKey, sessionID: "sess1", wantOK: true},
+		{name: "es256_by_kid", cfg: baseCfg, alg: auth.AlgES256, kid: "ec-1", claims: claims(nil), signKey: ecKey, sessionID: "sess1", wantOK: true},
+		{name: "unknown_kid", cfg: baseCfg, alg: auth.AlgRS256, kid: "rsa-2", claims: claims(nil), signKey: rsaKey, sessionID: "sess1"},
+		{name: "wrong_rsa_key", cfg: baseCfg, alg: auth.AlgRS256, kid: "rsa-1", claims: claims(nil), signKey: otherRSA, sessionID: "sess1"},
+		{name: "retired_secret", cfg: baseCfg, alg: auth.AlgHS256, kid: "hs-old", claims: claims(nil), signKey: []byte("retired"), sessionID: "sess1"},
+		{name: "previous_secret_by_kid", cfg: baseCfg, alg: auth.AlgHS256, kid: "hs-prev", claims: claims(nil), signKey: []byte("previous"), sessionID: "sess1", wantOK: true},
+		{name: "wrong_secret", cfg: baseCfg, alg: auth.AlgHS256, claims: claims(nil), signKey: []byte("other"), sessionID: "sess1"},
+		{
+			name: "alg_not_allowed", alg: auth.AlgHS256, claims: claims(nil), signKey: secret, sessionID: "sess1",
+			cfg: domain.JWTConfig{Algorithms: []string{auth.AlgRS256, auth.AlgES256}},
+		},
+		{name: "expired", cfg: baseCfg, alg: auth.AlgHS256, signKey: secret, sessionID: "sess1", claims: claims(func(c *auth.JWTClaims) {
+			c.ExpiresAt = auth.NewNumericDate(now.Add(-time.Second))
+		})},
+		{name: "expired_within_leeway", alg: auth.AlgHS256, signKey: secret, sessionID: "sess1", wantOK: true,
+			cfg: domain.JWTConfig{Leeway: time.Minute},
+			claims: claims(func(c *auth.JWTClaims) {
+				c.ExpiresAt = auth.NewNumericDate(now.Add(-30 * time.Second))
+			})},
+		{name: "missing_exp", cfg: baseCfg, alg: auth.AlgHS256, signKey: secret, sessionID: "sess1", claims: claims(func(c *auth.JWTClaims) {
+			c.ExpiresAt = nil
+		})},
+		{name: "not_yet_valid", cfg: baseCfg, alg: auth.AlgHS256, signKey: secret, sessionID: "sess1", claims: claims(func(c *auth.JWTClaims) {
+			c.NotBefore = auth.NewNumericDate(now.Add(time.Minute))
+		})},
+		{name: "issued_in_future", cfg: baseCfg, alg: auth.AlgHS256, signKey: secret, sessionID: "sess1", claims: claims(func(c *auth.JWTClaims) {
+			c.IssuedAt = auth.NewNumericDate(now.Add(time.Minute))
+		})},
+		{name: "wrong_issuer", cfg: baseCfg, alg: auth.AlgHS256, signKey: secret, sessionID: "sess1", claims: claims(func(c *auth.JWTClaims) {
+			c.Issuer = "evil"
+		})},
+		{name: "wrong_audience", cfg: baseCfg, alg: auth.AlgHS256, signKey: secret, sessionID: "sess1", claims: claims(func(c *auth.JWTClaims) {
+			c.Audience = auth.Audience{"other"}
+		})},
+		{name: "issuer_audience_not_configured", cfg: domain.JWTConfig{}, alg: auth.AlgHS256, signKey: secret, sessionID: "sess1", wantOK: true,
+			claims: claims(func(c *auth.JWTClaims) {
+				c.Issuer = ""
+				c.Audience = nil
+			})},
+		{name: "session_mismatch", cfg: baseCfg, alg: auth.AlgHS256, claims: claims(nil), signKey: secret, sessionID: "other"},
+		{name: "sid_alias", cfg: baseCfg, alg: auth.AlgHS256, signKey: secret, sessionID: "sid-9", wantOK: true, claims: claims(func(c *auth.JWTClaims) {
+			c.SessionID = ""
+			c.SID = "sid-9"
+		})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := auth.CreateJWT(tt.alg, tt.kid, tt.claims, tt.signKey)
+			require.NoError(t, err)
+			v := NewStandardJWTValidator(tt.cfg, &mock.TimeProviderMock{NowFunc: testNow}, log.NewNopLogger(), keys)
+			got, ok, err := v.ValidateToken(tt.sessionID, token)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, "u1", got.Login)
+				assert.Equal(t, "admin", got.Role)
+				assert.Equal(t, tt.sessionID, got.SessionID)
+			}
+		})
+	}
+}
+
+func TestStandardJWTValidator_RejectsLegacyAndNone(t *testing.T) {
+	now := testNow()
+	secret := []byte("secret")
+	v := NewStandardJWTValidator(domain.JWTConfig{}, &mock.TimeProviderMock{NowFunc: testNow}, log.NewNopLogger(), auth.StaticKeys{{Key: secret}})
+
+	legacy, err := auth.CreateToken("u", "r", "s", now.Add(time.Hour), now, secret)
+	require.NoError(t, err)
+	_, ok, err := v.ValidateToken("s", legacy)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// alg=none with an empty signature must never be accepted.
+	_, ok, err = v.ValidateToken("s", "eyJhbGciOiJub25lIn0.eyJzZXNzaW9uX2lkIjoicyIsImV4cCI6OTk5OTk5OTk5OX0.")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestStandardJWTValidator_KeySources(t *testing.T) {
+	now := testNow()
+	secret := []byte("secret")
+	token, err := auth.CreateJWT(auth.AlgHS256, "k2", auth.JWTClaims{ExpiresAt: auth.NewNumericDate(now.Add(time.Hour)), SessionID: "s"}, secret)
+	require.NoError(t, err)
+	tp := &mock.TimeProviderMock{NowFunc: testNow}
+
+	t.Run("all_sources_fail_is_internal_error", func(t *testing.T) {
+		failing := &mock.KeySourceMock{KeysFunc: func() ([]auth.VerificationKey, error) { return nil, errors.New("jwks down") }}
+		v := NewStandardJWTValidator(domain.JWTConfig{}, tp, log.NewNopLogger(), failing)
+		_, ok, err := v.ValidateToken("s", token)
+		require.Error(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("failing_source_skipped_when_another_loads", func(t *testing.T) {
+		failing := &mock.KeySourceMock{KeysFunc: func() ([]auth.VerificationKey, error) { return nil, errors.New("jwks down") }}
+		v := NewStandardJWTValidator(domain.JWTConfig{}, tp, log.NewNopLogger(), failing, auth.StaticKeys{{ID: "k2", Key: secret}})
+		_, ok, err := v.ValidateToken("s", token)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
@@ -1,12 +1,14 @@
 package service
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"mygateway/auth"
 	"mygateway/interfaces/mock"
 
+	"github.com/go-kit/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -19,14 +21,24 @@ func TestNewJWTValidator_Panics(t *testing.T) {
 	now := testNow()
 	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
 
-	t.Run("secret_nil", func(t *testing.T) {
-		assert.PanicsWithValue(t, "service.validator.go: secret is required", func() {
-			NewJWTValidator(nil, tp)
+	t.Run("no_sources", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator.go: key source is required", func() {
+			NewJWTValidator(tp, log.NewNopLogger())
+		})
+	})
+	t.Run("nil_source", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator.go: key source is required", func() {
+			NewJWTValidator(tp, log.NewNopLogger(), nil)
 		})
 	})
 	t.Run("time_provider_nil", func(t *testing.T) {
 		assert.PanicsWithValue(t, "service.validator.go: time provider is required", func() {
-			NewJWTValidator([]byte("x"), nil)
+			NewJWTValidator(nil, log.NewNopLogger(), auth.StaticKeys{})
+		})
+	})
+	t.Run("logger_nil", func(t *testing.T) {
+		assert.PanicsWithValue(t, "service.validator.go: logger is required", func() {
+			NewJWTValidator(tp, nil, auth.StaticKeys{})
 		})
 	})
 }
@@ -39,10 +51,13 @@ func TestJWTValidator_ValidToken(t *testing.T) {
 	require.NoError(t, err)
 
 	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
-	v := NewJWTValidator(secret, tp)
-	ok, err := v.ValidateToken("sess1", token)
+	v := NewJWTValidator(tp, log.NewNopLogger(), auth.StaticKeys{{Key: secret}})
+	claims, ok, err := v.ValidateToken("sess1", token)
 	require.NoError(t, err)
 	assert.True(t, ok)
+	assert.Equal(t, "u", claims.Login)
+	assert.Equal(t, "admin", claims.Role)
+	assert.Equal(t, "sess1", claims.SessionID)
 }
 
 func TestJWTValidator_ExpiredToken(t *testing.T) {
@@ -53,8 +68,8 @@ func TestJWTValidator_ExpiredToken(t *testing.T) {
 	require.NoError(t, err)
 
 	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
-	v := NewJWTValidator(secret, tp)
-	ok, err := v.ValidateToken("sess1", token)
+	v := NewJWTValidator(tp, log.NewNopLogger(), auth.StaticKeys{{Key: secret}})
+	_, ok, err := v.ValidateToken("sess1", token)
 	require.NoError(t, err)
 	assert.False(t, ok)
 }
@@ -67,8 +82,8 @@ func TestJWTValidator_WrongSessionID(t *testing.T) {
 	require.NoError(t, err)
 
 	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
-	v := NewJWTValidator(secret, tp)
-	ok, err := v.ValidateToken("other-session", token)
+	v := NewJWTValidator(tp, log.NewNopLogger(), auth.StaticKeys{{Key: secret}})
+	_, ok, err := v.ValidateToken("other-session", token)
 	require.NoError(t, err)
 	assert.False(t, ok)
 }
@@ -80,8 +95,58 @@ func TestJWTValidator_InvalidSignature(t *testing.T) {
 	require.NoError(t, err)
 
 	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
-	v := NewJWTValidator([]byte("wrong-secret"), tp)
-	ok, err := v.ValidateToken("sid", token)
+	v := NewJWTValidator(tp, log.NewNopLogger(), auth.StaticKeys{{Key: []byte("wrong-secret")}})
+	_, ok, err := v.ValidateToken("sid", token)
 	require.NoError(t, err)
 	assert.False(t, ok)
 }
+
+func TestJWTValidator_KeyRotation(t *testing.T) {
+	now := testNow()
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
+	keys := auth.StaticKeys{
+		{ID: "k2", Algorithm: auth.AlgHS256, Key: []byte("new")},
+		{ID: "k1", Algorithm: auth.AlgHS256, Key: []byte("old"), NotAfter: now.Add(time.Minute)},
+		{ID: "k0", Algorithm: auth.AlgHS256, Key: []byte("retired"), NotAfter: now.Add(-time.Minute)},
+	}
+	v := NewJWTValidator(tp, log.NewNopLogger(), keys)
+
+	tests := []struct {
+		name   string
+		secret string
+		wantOK bool
+	}{
+		{name: "current_key", secret: "new", wantOK: true},
+		{name: "previous_key_before_not_after", secret: "old", wantOK: true},
+		{name: "key_past_not_after", secret: "retired"},
+		{name: "unknown_key", secret: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := auth.CreateToken("u", "r", "sid", now.Add(time.Hour), now, []byte(tt.secret))
+			require.NoError(t, err)
+			_, ok, err := v.ValidateToken("sid", token)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestJWTValidator_KeySources(t *testing.T) {
+	now := testNow()
+	tp := &mock.TimeProviderMock{NowFunc: func() time.Time { return now }}
+	token, err := auth.CreateToken("u", "r", "sid", now.Add(time.Hour), now, []byte("secret"))
+	require.NoError(t, err)
+	failing := &mock.KeySourceMock{KeysFunc: func() ([]auth.VerificationKey, error) { return nil, errors.New("key file missing") }}
+
+	t.Run("all_sources_fail_is_internal_error", func(t *testing.T) {
+		_, ok, err := NewJWTValidator(tp, log.NewNopLogger(), failing).ValidateToken("sid", token)
+		require.Error(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("failing_source_skipped_when_another_loads", func(t *testing.T) {
+		_, ok, err := NewJWTValidator(tp, log.NewNopLogger(), failing, auth.StaticKeys{{Key: []byte("secret")}}).ValidateToken("sid", token)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+}